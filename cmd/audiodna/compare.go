@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pforret/videodna/internal/audiodna"
+)
+
+// runCompare implements the "compare" subcommand.
+func runCompare(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	method := fs.String("method", audiodna.CompareChroma, "Comparison method: chroma")
+	threshold := fs.Float64("threshold", 0.85, "Similarity at which tracks count as the same composition")
+	jsonFile := fs.String("json", "", "Write JSON result")
+	timeout := fs.Int("timeout", 600, "Timeout in seconds")
+	silent := fs.Bool("silent", false, "Suppress stdout output")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: audiodna compare [options] <track-a> <track-b>\n\n")
+		fmt.Fprintf(os.Stderr, "Scores whether two tracks are versions of the same composition\n")
+		fmt.Fprintf(os.Stderr, "(covers, remixes, live takes) despite different key and tempo.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Exit code is 0 when the tracks match, 3 when they do not.
+
+Example:
+  audiodna compare -json match.json original.mp3 cover.mp3
+`)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Error: compare needs exactly two input files")
+		fs.Usage()
+		os.Exit(1)
+	}
+	for _, path := range fs.Args() {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: input file does not exist: %s\n", path)
+			os.Exit(1)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout)*time.Second)
+	defer cancel()
+
+	result, err := audiodna.Compare(ctx, fs.Arg(0), fs.Arg(1), *method, *threshold)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *jsonFile != "" {
+		if err := result.WriteJSON(*jsonFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !*silent {
+		verdict := "different compositions"
+		if result.SameSource {
+			verdict = "same composition"
+		}
+		fmt.Printf("Similarity: %.3f (transpose %+d semitones): %s\n",
+			result.Match.Similarity, result.Match.Transpose, verdict)
+	}
+
+	if !result.SameSource {
+		os.Exit(3)
+	}
+}
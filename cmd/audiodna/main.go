@@ -2,45 +2,182 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/pforret/videodna/internal/archive"
 	"github.com/pforret/videodna/internal/audio"
 	"github.com/pforret/videodna/internal/audiodna"
+	"github.com/pforret/videodna/internal/catalog"
+	"github.com/pforret/videodna/internal/checksum"
+	"github.com/pforret/videodna/internal/fingerprint"
+	"github.com/pforret/videodna/internal/font"
+	"github.com/pforret/videodna/internal/hooks"
+	"github.com/pforret/videodna/internal/lanespec"
+	"github.com/pforret/videodna/internal/locale"
+	"github.com/pforret/videodna/internal/notify"
+	"github.com/pforret/videodna/internal/pipe"
+	"github.com/pforret/videodna/internal/progress"
+	"github.com/pforret/videodna/internal/publish"
+	"github.com/pforret/videodna/internal/resizespec"
+	"github.com/pforret/videodna/internal/signature"
+	"github.com/pforret/videodna/internal/sink"
+	"github.com/pforret/videodna/internal/tiles"
+	"github.com/pforret/videodna/internal/toolexec"
+	"github.com/pforret/videodna/internal/transform"
+	"github.com/pforret/videodna/internal/workdir"
+	"github.com/pforret/videodna/internal/xmp"
 )
 
+var version = "1.0.0"
+
+// stringList collects a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string     { return fmt.Sprint(*l) }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// cleanups run when main returns or exits (see exit).
+var cleanups []func()
+
+// atExit registers f to run when main returns or exits, e.g. to remove
+// temp files of failed runs.
+func atExit(f func()) {
+	cleanups = append(cleanups, f)
+}
+
+// runCleanups runs the registered cleanups, latest first.
+func runCleanups() {
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+	cleanups = nil
+}
+
+// exit runs the cleanups and exits with code. main exits through it instead
+// of os.Exit, which skips deferred calls.
+func exit(code int) {
+	runCleanups()
+	os.Exit(code)
+}
+
 func main() {
+	defer runCleanups()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "compare":
+			runCompare(os.Args[2:])
+			return
+		case "playlist":
+			runPlaylist(os.Args[2:])
+			return
+		case "models":
+			runModels(os.Args[2:])
+			return
+		}
+	}
+
 	// Define flags
 	input := flag.String("input", "", "Input audio file (required)")
-	output := flag.String("output", "audiodna.png", "Output PNG file")
-	resize := flag.String("resize", "", "Resize output to WxH (e.g., 1920x200)")
+	output := flag.String("output", "audiodna.png", "Output PNG file (.dzi = Deep Zoom tile pyramid, - = stdout)")
+	resize := flag.String("resize", "", "Resize output to WxH, or one side: Wx or xH (e.g., 1920x200)")
 	stemHeight := flag.Int("stem-height", 50, "Height per stem in pixels")
-	stems := flag.Int("stems", 4, "Number of stems: 2, 4, or 6")
+	laneSpec := flag.String("lanes", "", "Order, height and visibility of stems: name[:height],... (-name hides) or a .json file")
+	stems := flag.Int("stems", 4, "Number of stems: 2, 4, 5 (spleeter) or 6 (demucs)")
 	separator := flag.String("separator", "demucs", "Stem separator: demucs or spleeter")
 	model := flag.String("model", "", "Model name (e.g., htdemucs, htdemucs_6s)")
 	device := flag.String("device", "cpu", "Device: cpu or cuda")
 	noStems := flag.Bool("no-stems", false, "Skip stem separation, use original audio only")
+	resume := flag.Bool("resume", false, "Reuse complete stems of a previous run of the same input instead of separating again")
+	oomFallback := flag.String("oom-fallback", "auto", "When demucs runs out of GPU memory: auto (smaller segments, then cpu), segment, cpu, or off")
+	retries := flag.Int("retries", 3, "Attempts for a crashing stem separator, the last one on cpu (1 = no retry)")
+	workDir := flag.String("workdir", "", "Directory for temp files such as stems, removed after the run (default $TMPDIR)")
+	modelCache := flag.String("model-cache", "", "Demucs model cache directory (default $TORCH_HOME or ~/.cache/torch; see audiodna models)")
+	strictStems := flag.Bool("strict-stems", false, "Fail when the separator output lacks expected stems (default: warn and continue)")
 	noLabels := flag.Bool("no-labels", false, "Hide stem labels")
+	lang := flag.String("locale", "", "Language of the labels and settings errors: en, fr, de, es (default $VIDEODNA_LOCALE or en)")
+	fontPath := flag.String("font", "", "Font file for labels in scripts the bitmap font lacks: Arabic, Hebrew, CJK, ... (default $VIDEODNA_FONT or a system font)")
+	checksumInput := flag.Bool("checksum", false, "Record the SHA-256 of the input in the JSON report and XMP sidecar")
+	md5Input := flag.Bool("md5", false, "Also record the MD5 of the input, for legacy systems")
+	verifyChecksum := flag.String("verify-checksum", "", "Refuse to process an input whose checksum differs: sha256:HEX, md5:HEX or bare hex")
+	archiveFile := flag.String("archive", "", "Bundle the image, JSON report, sidecars and a manifest (checksums, version, command line, environment) into a .zip or .tar")
+	signKey := flag.String("sign-key", "", "Sign the -fingerprint file with this Ed25519 private key (PEM), writing <fingerprint>.sig")
+	xmpSidecar := flag.Bool("xmp", false, "Write an XMP sidecar (output name with .xmp) with the analysis metadata, for DAM systems")
+	iccProfile := flag.String("icc", "srgb", "Color profile embedded in the image: srgb, rec709 (gamma 2.4 grading monitor), none, or an .icc file")
+	maxDimension := flag.Int("max-dimension", audiodna.DefaultMaxDimension, "Cap of the output width in pixels; longer inputs use longer segments (0 = no limit)")
+	showTimings := flag.Bool("timings", false, "Show the run time and its slowest stage in the label bar (always in the JSON report)")
 	noNormalize := flag.Bool("no-normalize", false, "Don't normalize volume levels")
 	timeout := flag.Int("timeout", 600, "Timeout in seconds (default 10 minutes)")
 	silent := flag.Bool("silent", false, "Suppress stdout output")
+	progressJSON := flag.Bool("progress-json", false, "Print stem separation progress with throughput and ETA as JSON lines on stderr")
+	loudness := flag.String("loudness", "", "Check loudness compliance: ebu (-23 LUFS) or atsc (-24 LKFS)")
+	targetLUFS := flag.Float64("target-lufs", 0, "Override target integrated loudness (LUFS)")
+	targetTP := flag.Float64("target-tp", 0, "Override maximum true peak (dBTP)")
+	jsonFile := flag.String("json", "", "Write JSON report (stems, loudness, compliance)")
+	csvFile := flag.String("csv", "", "Write per-segment RMS/peak CSV")
+	fingerprintFile := flag.String("fingerprint", "", "Write a multi-resolution fingerprint (stem RMS/peak) for similarity search: JSON, or binary .vdna")
+	fingerprintLevels := flag.String("fingerprint-levels", "64,256,1024,4096", "Fingerprint resolutions in columns")
+	var hookSpecs stringList
+	dockerImage := flag.String("docker-image", "", "Run ffmpeg/demucs from this Docker image when not installed (default $VIDEODNA_DOCKER_IMAGE)")
+	notifyURL := flag.String("notify-url", "", "Post a summary (and thumbnail) to a Slack, Discord or other webhook when done or failed")
+	publishURL := flag.String("publish", "", "Publish a completion event to pubsub://PROJECT/TOPIC, sns://TOPIC_ARN or nats://HOST/SUBJECT")
+	uploadURL := flag.String("upload", "", "Also deliver the image as PNG to s3://BUCKET/KEY (AWS_* credentials) or a file path")
+	uploadJSON := flag.String("upload-json", "", "Also deliver the JSON report to s3://BUCKET/KEY or a file path")
+	flag.Var(&hookSpecs, "hook", "Run a command at a pipeline point: POINT=COMMAND (repeatable; after-probe, after-generate, before-upload, on-error)")
+	catalogFile := flag.String("catalog", "", "Record the generated DNA in a SQLite catalog (needs sqlite3; list with videodna catalog)")
+	bitDepth := flag.Int("bit-depth", 16, "PCM extraction depth: 16, 24, or 32 (float)")
+	maxProcs := flag.Int("max-procs", 0, "Maximum ffmpeg/demucs processes at once (0 = no limit)")
+	maxMemory := flag.String("max-memory", "", "Memory budget for ffmpeg/demucs processes, e.g. 8G (default 75% of RAM)")
+	stemJobs := flag.Int("stem-jobs", 0, "Decode at most N stem waveforms at once, in one ffmpeg (0 = all; lower on network storage)")
+	overlap := flag.Float64("overlap", 0, "Volume window overlap 0.0-0.9 (e.g. 0.5 = 50%, smoother envelope)")
+	peakOutline := flag.Bool("peak-outline", false, "Draw true peak as a thin outline over the RMS body")
+	envelope := flag.Bool("envelope", false, "Draw the min-max span of the samples in a translucent color behind the RMS body")
+	dynamicRange := flag.Bool("dr", false, "Compute dynamic range (DR/PLR) per stem and for the mix")
+	beatGrid := flag.Bool("grid", false, "Detect tempo and draw faint bar/beat grid lines behind the waveforms")
+	structure := flag.Bool("structure", false, "Detect song sections (A/B/C) and show them above the stems")
+	mfcc := flag.Int("mfcc", 0, "Export N MFCCs per segment of the mix to -json/-csv (e.g. 13)")
+	diarize := flag.String("diarize", "", "Color the vocals lane by speaker: cluster (built-in) or pyannote")
+	speakers := flag.Int("speakers", 0, "Number of speakers for -diarize (0 = 2 for cluster, auto for pyannote)")
+	noise := flag.Bool("noise", false, "Detect 50/60 Hz hum and noise floor, mark affected spans")
+	eventsFile := flag.String("events", "", "Export sections, speakers, silences and QC spans: .edl, .ffmeta (FFmpeg chapters) or .txt (YouTube chapters)")
+	altText := flag.String("alt-text", "", "Write a text alternative describing loud and quiet passages, for screen readers: .html (figure) or .json")
+	reverse := flag.Bool("reverse", false, "Reverse the time axis (end of the track first)")
+	flip := flag.Bool("flip", false, "Flip the waveform image vertically")
+	logTime := flag.Bool("log-time", false, "Map time logarithmically to expand the beginning")
+	compressTail := flag.Bool("compress-tail", false, "Compress a quiet tail (outro silence) logarithmically, with tick marks showing the warp")
+	palette := flag.String("palette", "default", "Stem colors: default, colorblind (Okabe-Ito), tol, or monochrome")
+	patterns := flag.Bool("patterns", false, "Texture each stem with a fill pattern (for grayscale print)")
+	tracks := flag.Bool("tracks", false, "One lane per audio track (languages, M&E) labeled from metadata, instead of stems")
+	channels := flag.Bool("channels", false, "One lane per channel of a surround track (L, R, C, LFE, Ls, Rs); with -tracks, per track")
+	classify := flag.Bool("classify", false, "Classify speech, music and silence per half second (strip above the stems, -json, -events)")
+	podcast := flag.Bool("podcast", false, "With -stems 2: flag likely intro music, outro and mid-roll ads (strip, -json, -events)")
+	karaoke := flag.Bool("karaoke", false, "With -stems 2: show the vocals/accompaniment balance, marking a cappella and instrumental-only spans")
+	scale := flag.Int("scale", 1, "HiDPI scale factor (1-3): labels and strips drawn larger, same time resolution")
+	segmentsPerSecond := flag.Float64("segments-per-second", 0, "Analysis segments per second (default: one per pixel column)")
+	adaptiveColumns := flag.Bool("adaptive-columns", false, "Share the width by loudness: more columns for loud passages, fewer for silence (-json lists the time of each column)")
 
 	// Custom usage
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Audio DNA Generator - Create visual DNA from audio with stem separation\n\n")
-		fmt.Fprintf(os.Stderr, "Usage: audiodna -input <audio> [options]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: audiodna -input <audio> [options]\n")
+		fmt.Fprintf(os.Stderr, "       audiodna compare [options] <track-a> <track-b>\n")
+		fmt.Fprintf(os.Stderr, "       audiodna playlist [options] <track> <track> [track...]\n")
+		fmt.Fprintf(os.Stderr, "       audiodna models [options] list|download|path [model...]\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nStem Separation:\n  Uses Demucs (default) or Spleeter to separate audio into (-separator, -stems):\n")
+		for _, sep := range audio.Separators {
+			for _, n := range audio.SeparatorStems[sep] {
+				fmt.Fprintf(os.Stderr, "    %-8s %d stems: %s\n", sep, n, strings.Join(audio.StemNames(sep, n), " + "))
+			}
+		}
 		fmt.Fprintf(os.Stderr, `
-Stem Separation:
-  Uses Demucs (default) or Spleeter to separate audio into:
-    2 stems: vocals + accompaniment
-    4 stems: vocals + drums + bass + other (default)
-    6 stems: vocals + drums + bass + other + piano + guitar (demucs only)
-
 Output:
   X-axis = time, Y-axis = volume per stem
   Each stem is shown as a waveform with distinct color:
@@ -57,16 +194,90 @@ Examples:
   # 6-stem separation with GPU acceleration
   audiodna -input song.mp3 -stems 6 -device cuda
 
+  # Retry after a failure, reusing stems that were already separated
+  audiodna -input song.mp3 -stems 6 -resume
+
+  # Serverless: keep temp stems off a small shared /tmp
+  audiodna -input song.mp3 -workdir /mnt/scratch
+
   # Use Spleeter instead of Demucs
   audiodna -input song.mp3 -separator spleeter
 
+  # Archive indexing: where is speech, where is music?
+  audiodna -input broadcast.wav -no-stems -classify -json content.json
+
+  # Multi-language delivery: one lane per audio track, silent tracks stay flat
+  audiodna -input master.mkv -tracks
+
+  # 5.1 delivery QC: a silent center (dialogue) channel stands out
+  audiodna -input film.mkv -channels
+
+  # Podcast triage: likely intro, outro and ad breaks as chapters
+  audiodna -input episode.mp3 -stems 2 -podcast -events breaks.txt
+
+  # Karaoke production: where are the vocals alone, where is the track instrumental?
+  audiodna -input song.mp3 -stems 2 -karaoke -json balance.json
+
+  # Min-max sample envelope and true peak outline around the RMS body
+  audiodna -input song.mp3 -envelope -peak-outline
+
+  # Color-blind safe colors plus fill patterns for grayscale print
+  audiodna -input song.mp3 -palette colorblind -patterns
+
   # Custom dimensions
   audiodna -input song.mp3 -width 3840 -stem-height 80
+  audiodna -input song.mp3 -lanes vocals:120,drums,-other
+
+  # Retina display: 2x labels and text, same number of segments
+  audiodna -input song.mp3 -scale 2
+
+  # Stem labels and loudness status in German
+  audiodna -input song.mp3 -loudness ebu -locale de
+
+  # Fingerprint at 10 segments/second, whatever the image size
+  audiodna -input song.mp3 -segments-per-second 10 -json dna.json -csv dna.csv
+  audiodna -input interview.wav -adaptive-columns -json dna.json
+
+  # Coarse-to-fine fingerprint pyramid for a similarity index
+  audiodna -input song.mp3 -no-stems -fingerprint song.fp.json
+
+  # Timbre features for similarity search (13 MFCCs per segment)
+  audiodna -input song.mp3 -no-stems -segments-per-second 4 -mfcc 13 -csv features.csv
+
+Loudness:
+  -loudness ebu   EBU R128: -23 LUFS ±0.5 LU, true peak -1 dBTP, short-term -18 LUFS
+  -loudness atsc  ATSC A/85: -24 LKFS ±2 LU, true peak -2 dBTP
+  Violating spans are tinted red on the DNA; -json writes a pass/fail report.
+
+  # Delivery QC against EBU R128
+  audiodna -input mix.wav -no-stems -loudness ebu -json qc.json
+
+  # Bar/beat grid to reveal arrangement structure
+  audiodna -input song.mp3 -grid
+
+  # Podcast chapters for YouTube from song form / speakers, silences and QC flags
+  audiodna -input episode.mp3 -no-stems -structure -loudness ebu -events chapters.txt
+
+  # Field recording QC: hum (orange) and noisy quiet passages (yellow) along the top
+  audiodna -input interview.wav -no-stems -noise -json qc.json
+
+  # Podcast: color the vocals by speaker and export speaker turns
+  audiodna -input episode.mp3 -stems 2 -diarize cluster -speakers 3 -json turns.json
+
+  # Song form: repeated sections share a letter (e.g. A B A B C B)
+  audiodna -input song.mp3 -structure -json form.json
+
+  # Loudness-war check: DR score per stem and for the mix (DR < 7 = heavily limited)
+  audiodna -input song.mp3 -dr -json dr.json
+
+  # Text alternative for a catalog: loud and quiet passages, loudest stem
+  audiodna -input song.mp3 -output dna.png -alt-text dna.alt.json
 
 Dependencies:
   - ffmpeg/ffprobe (required)
   - demucs: pip install demucs
   - spleeter: pip install spleeter
+  - pyannote (optional, -diarize pyannote): pip install pyannote.audio, HF_TOKEN set
 
 Docker:
   docker run -v $(pwd):/data audiodna -input /data/song.mp3 -output /data/dna.png
@@ -75,76 +286,307 @@ Docker:
 
 	flag.Parse()
 
+	// "-output -" writes the image to stdout, so progress goes to stderr
+	if pipe.IsStdout(*output) {
+		pipe.LogsToStderr()
+		if *catalogFile != "" {
+			fmt.Fprintln(os.Stderr, "Error: -catalog needs an output file, not stdout")
+			exit(1)
+		}
+		if *xmpSidecar {
+			fmt.Fprintln(os.Stderr, "Error: -xmp needs an output file, not stdout")
+			exit(1)
+		}
+		if *archiveFile != "" {
+			fmt.Fprintln(os.Stderr, "Error: -archive needs an output file, not stdout")
+			exit(1)
+		}
+	}
+	toolexec.SetDockerImage(*dockerImage)
+	font.SetFile(*fontPath)
+	budget := toolexec.SystemMemory() * 3 / 4
+	if *maxMemory != "" {
+		var err error
+		if budget, err = toolexec.ParseMemory(*maxMemory); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+	toolexec.SetLimits(*maxProcs, budget)
+
 	// Validate input
 	if *input == "" {
 		fmt.Fprintln(os.Stderr, "Error: -input is required")
 		flag.Usage()
-		os.Exit(1)
+		exit(1)
 	}
 
 	// Check if input file exists
 	if _, err := os.Stat(*input); os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Error: input file does not exist: %s\n", *input)
-		os.Exit(1)
+		exit(1)
 	}
 
-	// Validate stems count
-	if *stems != 2 && *stems != 4 && *stems != 6 {
-		fmt.Fprintln(os.Stderr, "Error: -stems must be 2, 4, or 6")
-		os.Exit(1)
+	sep := audio.SeparatorType(strings.ToLower(*separator))
+
+	loc := locale.Default()
+	if *lang != "" {
+		var err error
+		if loc, err = locale.Parse(*lang); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
 	}
 
-	// Validate separator
-	sep := audio.SeparatorType(strings.ToLower(*separator))
-	if sep != audio.SeparatorDemucs && sep != audio.SeparatorSpleeter {
-		fmt.Fprintln(os.Stderr, "Error: -separator must be 'demucs' or 'spleeter'")
-		os.Exit(1)
+	// Validate fingerprint levels
+	levels, err := fingerprint.ParseLevels(*fingerprintLevels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
 	}
 
-	// 6 stems only supported by demucs
-	if *stems == 6 && sep == audio.SeparatorSpleeter {
-		fmt.Fprintln(os.Stderr, "Error: 6-stem separation requires demucs (spleeter only supports 2, 4, or 5 stems)")
-		os.Exit(1)
+	if _, _, err := checksum.Parse(*verifyChecksum); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
 	}
 
-	// Parse resize option
-	var resizeWidth, resizeHeight int
-	if *resize != "" {
-		if _, err := fmt.Sscanf(*resize, "%dx%d", &resizeWidth, &resizeHeight); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: invalid resize format '%s', use WxH (e.g., 1920x200)\n", *resize)
-			os.Exit(1)
+	var signingKey ed25519.PrivateKey
+	if *signKey != "" {
+		if *fingerprintFile == "" {
+			fmt.Fprintln(os.Stderr, "Error: -sign-key needs -fingerprint")
+			exit(1)
+		}
+		if signingKey, err = signature.LoadPrivateKey(*signKey); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
 		}
 	}
 
+	// Temp files of the run (stems, the archived report, shaped text) live
+	// in one directory, removed on every exit
+	work, err := workdir.New(*workDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	atExit(func() {
+		size, err := work.Cleanup()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else if size > 0 && !*silent {
+			fmt.Printf("Removed %s of temp files\n", workdir.FormatSize(size))
+		}
+	})
+	font.SetWorkdir(work)
+
+	reportFile := *jsonFile
+	if *archiveFile != "" {
+		if tiles.IsDZIPath(*output) {
+			fmt.Fprintln(os.Stderr, "Error: -archive needs a PNG output, not a tile pyramid")
+			exit(1)
+		}
+		if err := archive.CheckPath(*archiveFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		var cleanup func()
+		if reportFile, cleanup, err = archive.ReportPath(work, *jsonFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		atExit(cleanup)
+	}
+
+	// Validate loudness target
+	var target *audio.LoudnessTarget
+	switch strings.ToLower(*loudness) {
+	case "":
+	case "ebu":
+		t := audio.TargetEBU
+		target = &t
+	case "atsc":
+		t := audio.TargetATSC
+		target = &t
+	default:
+		fmt.Fprintln(os.Stderr, "Error: -loudness must be 'ebu' or 'atsc'")
+		exit(1)
+	}
+	if target != nil && *targetLUFS != 0 {
+		target.Integrated = *targetLUFS
+	}
+	if target != nil && *targetTP != 0 {
+		target.MaxTruePeak = *targetTP
+	}
+
+	// Parse resize option
+	resizeSpec, err := resizespec.Parse(*resize)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	if resizeSpec.Input || resizeSpec.Percent() {
+		fmt.Fprintf(os.Stderr, "Error: -resize takes pixels in audiodna: WxH, Wx or xH (e.g., 1920x200)\n")
+		exit(1)
+	}
+
+	lanes, err := lanespec.Parse(*laneSpec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+
 	// Build config
 	config := audiodna.DefaultConfig()
 	config.Width = 0 // Auto-calculate based on duration
 	config.StemHeight = *stemHeight
+	config.Lanes = lanes
 	config.StemConfig.NumStems = *stems
 	config.StemConfig.Separator = sep
 	config.StemConfig.Device = *device
+	config.StemConfig.Resume = *resume
+	config.StemConfig.Strict = *strictStems
+	config.StemConfig.TorchHome = *modelCache
+	config.Workdir = *workDir
+	config.Retry.Attempts = *retries
+	config.StemConfig.OOMFallback = audio.OOMFallback(strings.ToLower(*oomFallback))
 	if *model != "" {
 		config.StemConfig.Model = *model
 	}
 	config.SkipStems = *noStems
 	config.ShowLabels = !*noLabels
+	config.Locale = loc
+	config.ShowTimings = *showTimings
+	config.MaxDimension = *maxDimension
+	config.ICCProfile = *iccProfile
+	config.XMP = *xmpSidecar
+	config.Checksum = checksum.Options{Enabled: *checksumInput, MD5: *md5Input, Verify: *verifyChecksum}
 	config.Normalize = !*noNormalize
 	config.Timeout = *timeout
 	config.Silent = *silent
-	config.ResizeWidth = resizeWidth
-	config.ResizeHeight = resizeHeight
+	if *progressJSON {
+		config.Progress = progress.JSONLines(os.Stderr)
+	}
+	config.ResizeWidth = resizeSpec.Width.Pixels
+	config.ResizeHeight = resizeSpec.Height.Pixels
+	config.LoudnessTarget = target
+	config.ReportPath = reportFile
+	config.CSVPath = *csvFile
+	config.FingerprintPath = *fingerprintFile
+	config.FingerprintLevels = levels
+	config.SegmentsPerSecond = *segmentsPerSecond
+	config.AdaptiveColumns = *adaptiveColumns
+	config.BitDepth = *bitDepth
+	config.StemJobs = *stemJobs
+	config.Overlap = *overlap
+	config.PeakOutline = *peakOutline
+	config.Envelope = *envelope
+	config.DynamicRange = *dynamicRange
+	config.BeatGrid = *beatGrid
+	config.Structure = *structure
+	config.MFCC = *mfcc
+	config.Noise = *noise
+	config.EventsPath = *eventsFile
+	config.AltTextPath = *altText
+	config.ColorScheme = audiodna.ColorScheme(strings.ToLower(*palette))
+	config.Patterns = *patterns
+	config.Karaoke = *karaoke
+	config.Podcast = *podcast
+	config.Classify = *classify
+	config.Tracks = *tracks
+	config.Channels = *channels
+	config.Scale = *scale
+	config.Transform = transform.Options{Reverse: *reverse, Flip: *flip, LogTime: *logTime, CompressTail: *compressTail}
+	config.Hooks = hooks.NewRunner("audiodna")
+	for _, spec := range hookSpecs {
+		if err := config.Hooks.AddSpec(spec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+	if *notifyURL != "" {
+		config.Hooks.Add(hooks.AfterGenerate, notify.Webhook(*notifyURL))
+		config.Hooks.Add(hooks.OnError, notify.Webhook(*notifyURL))
+	}
+	if *publishURL != "" {
+		publisher, err := publish.Open(*publishURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		config.Hooks.Add(hooks.AfterGenerate, publish.Hook{Publisher: publisher})
+	}
+	if *uploadURL != "" {
+		s, err := sink.Open(*uploadURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		config.ImageSink = s
+	}
+	if *uploadJSON != "" {
+		s, err := sink.Open(*uploadJSON)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		config.ReportSink = s
+	}
+	config.Diarize = audio.DiarizeConfig{Diarizer: audio.DiarizerType(strings.ToLower(*diarize)), Speakers: *speakers}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout)*time.Second)
+	// Validate the settings and their combinations, reporting all violations
+	if err := config.Validate(); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", loc.T("Error"), line)
+		}
+		exit(1)
+	}
+
+	// Create context with timeout; an interrupt cancels it so the temp
+	// files of the run are removed before exiting
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(sigCtx, time.Duration(*timeout)*time.Second)
 	defer cancel()
+	ctx = workdir.NewContext(ctx, work)
 
 	// Generate DNA
 	startTime := time.Now()
 
 	result, err := audiodna.Generate(ctx, *input, *output, config)
+	if err == nil && signingKey != nil {
+		err = signature.SignFingerprint(*fingerprintFile, signingKey, *silent)
+	}
+	if err == nil && *archiveFile != "" {
+		b := &archive.Bundle{Tool: "audiodna", ToolVersion: version, CommandLine: os.Args, Input: *input}
+		b.Add(*output, "image")
+		b.Add(reportFile, "report")
+		if *xmpSidecar {
+			b.Add(xmp.SidecarPath(*output), "xmp")
+		}
+		b.Add(*csvFile, "csv")
+		b.Add(*fingerprintFile, "fingerprint")
+		if signingKey != nil {
+			b.Add(*fingerprintFile+signature.Ext, "signature")
+		}
+		b.Add(*eventsFile, "events")
+		b.Add(*altText, "alt-text")
+		var m *archive.Manifest
+		if m, err = b.Write(*archiveFile); err == nil && !*silent {
+			fmt.Printf("Archive: %s (%d files, %s)\n", *archiveFile, len(m.Files), m.Created)
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		c := hooks.Context{Point: hooks.OnError, Input: *input, Output: *output, Error: err.Error(), Elapsed: time.Since(startTime).Seconds()}
+		if err := config.Hooks.Run(context.Background(), c); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		exit(1)
+	}
+	c := hooks.Context{Point: hooks.AfterGenerate, Input: *input, Output: *output, Elapsed: time.Since(startTime).Seconds()}
+	if err := config.Hooks.Run(context.Background(), c); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
 	}
 
 	if !*silent {
@@ -153,4 +595,28 @@ Docker:
 		fmt.Printf("Output: %s (%dx%d, %d stems, %.1fs in %.1fs)\n",
 			*output, bounds.Dx(), bounds.Dy(), len(result.Stems), result.Duration, elapsed.Seconds())
 	}
+
+	if *catalogFile != "" {
+		bounds := result.Image.Bounds()
+		stats := map[string]any{
+			"duration": result.Duration,
+			"stems":    len(result.Stems),
+			"width":    bounds.Dx(),
+			"height":   bounds.Dy(),
+		}
+		if result.Loudness != nil {
+			stats["integrated_lufs"] = result.Loudness.Integrated
+		}
+		if result.Tempo != nil {
+			stats["bpm"] = result.Tempo.BPM
+		}
+		if err := catalog.Record(*catalogFile, "audiodna", *input, *output, os.Args[1:], stats); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record in catalog: %v\n", err)
+		}
+	}
+
+	// Non-zero exit on failed compliance so delivery QC scripts can gate on it
+	if result.Compliance != nil && !result.Compliance.Pass {
+		exit(2)
+	}
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pforret/videodna/internal/audio"
+)
+
+// runModels implements the "models" subcommand.
+func runModels(args []string) {
+	fs := flag.NewFlagSet("models", flag.ExitOnError)
+	cache := fs.String("cache", "", "Model cache directory (default $TORCH_HOME or ~/.cache/torch)")
+	verify := fs.Bool("verify", false, "list: hash every checkpoint against its checksum")
+	timeout := fs.Int("timeout", 3600, "download: timeout in seconds")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: audiodna models [options] list|download|path [model...]\n\n")
+		fmt.Fprintf(os.Stderr, "Manages the demucs models, so separation never stalls downloading a model\n")
+		fmt.Fprintf(os.Stderr, "mid-job (cold starts, air-gapped machines).\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Commands:
+  list       Known models, whether they are cached, and their size
+  download   Download and verify models (default htdemucs); needs the demucs Python package
+  path       Print the checkpoint directory
+
+Use the same cache when separating with -model-cache or TORCH_HOME.
+
+Examples:
+  audiodna models list -verify
+  audiodna models download -cache /opt/models htdemucs htdemucs_6s
+`)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	// Options may also follow the command
+	command := fs.Arg(0)
+	fs.Parse(fs.Args()[1:])
+
+	switch command {
+	case "path":
+		fmt.Println(audio.ModelDir(*cache))
+	case "list":
+		fmt.Printf("%-12s %5s %9s  %-9s %s\n", "MODEL", "STEMS", "SIZE", "STATUS", "DESCRIPTION")
+		for _, m := range audio.DemucsModels {
+			status, err := audio.CheckModel(*cache, m, *verify)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%-12s %5d %8.0fM  %-9s %s\n", m.Name, m.Stems, float64(status.Size)/(1<<20), modelState(status), m.Description)
+		}
+		fmt.Printf("\nCache: %s\n", audio.ModelDir(*cache))
+	case "download":
+		names := fs.Args()
+		if len(names) == 0 {
+			names = []string{"htdemucs"}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout)*time.Second)
+		defer cancel()
+		failed := false
+		for _, name := range names {
+			m, known := audio.FindDemucsModel(name)
+			if known {
+				if status, err := audio.CheckModel(*cache, m, true); err == nil && status.Complete() {
+					fmt.Printf("%s: already cached (%.0f MB, verified)\n", name, float64(status.Size)/(1<<20))
+					continue
+				}
+			}
+			fmt.Printf("%s: downloading to %s\n", name, audio.ModelDir(*cache))
+			if err := audio.DownloadModel(ctx, *cache, name); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				failed = true
+				continue
+			}
+			if !known {
+				fmt.Printf("%s: downloaded (unknown model, not verified)\n", name)
+				continue
+			}
+			status, err := audio.CheckModel(*cache, m, true)
+			if err != nil || !status.Complete() {
+				fmt.Fprintf(os.Stderr, "Error: %s: %s after download\n", name, modelState(status))
+				failed = true
+				continue
+			}
+			fmt.Printf("%s: ok (%.0f MB, verified)\n", name, float64(status.Size)/(1<<20))
+		}
+		if failed {
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown models command %q\n", command)
+		fs.Usage()
+		os.Exit(1)
+	}
+}
+
+// modelState summarizes the cache state of a model.
+func modelState(s audio.ModelStatus) string {
+	switch {
+	case len(s.Corrupt) > 0:
+		return "corrupt"
+	case s.Present == 0:
+		return "missing"
+	case s.Present < len(s.Model.Files):
+		return fmt.Sprintf("partial %d/%d", s.Present, len(s.Model.Files))
+	}
+	return "cached"
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pforret/videodna/internal/audiodna"
+)
+
+// runPlaylist implements the "playlist" subcommand.
+func runPlaylist(args []string) {
+	fs := flag.NewFlagSet("playlist", flag.ExitOnError)
+	output := fs.String("output", "playlist.png", "Output PNG of the joined DNA")
+	jsonFile := fs.String("json", "", "Write JSON transition report")
+	timeout := fs.Int("timeout", 600, "Timeout in seconds")
+	silent := fs.Bool("silent", false, "Suppress stdout output")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: audiodna playlist [options] <track> <track> [track...]\n\n")
+		fmt.Fprintf(os.Stderr, "Analyzes gaps and transitions between tracks in playlist order, renders\n")
+		fmt.Fprintf(os.Stderr, "a joined DNA with transition scores and suggests crossfade durations.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Transition markers: green = smooth, yellow = noticeable, red = abrupt.
+
+Example:
+  audiodna playlist -output album.png -json album.json 01.flac 02.flac 03.flac
+`)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, "Error: playlist needs at least two tracks")
+		fs.Usage()
+		os.Exit(1)
+	}
+	for _, path := range fs.Args() {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: input file does not exist: %s\n", path)
+			os.Exit(1)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout)*time.Second)
+	defer cancel()
+
+	result, err := audiodna.AnalyzePlaylist(ctx, fs.Args(), *output, *silent)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *jsonFile != "" {
+		if err := result.WriteJSON(*jsonFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !*silent {
+		bounds := result.Image.Bounds()
+		fmt.Printf("Output: %s (%dx%d, %d tracks, %.1fs)\n",
+			*output, bounds.Dx(), bounds.Dy(), len(result.Tracks), result.Duration)
+	}
+}
@@ -0,0 +1,264 @@
+// Command libvideodna is the C shared library of the video and audio DNA
+// engine, so Python, Node or asset management plugins can call it in-process
+// instead of running the binaries:
+//
+//	go build -buildmode=c-shared -o bin/libvideodna.so ./cmd/libvideodna
+//
+// writes bin/libvideodna.so and its header bin/libvideodna.h with:
+//
+//	char* generate_video_dna(char* input, char* output, char* options);
+//	char* generate_audio_dna(char* input, char* output, char* options);
+//	void videodna_free(char* p);
+//
+// options is a JSON object named like the command line flags (NULL or ""
+// = defaults), e.g. {"mode": "average", "resize": "1200x100", "cuts": true}.
+// The functions return NULL on success, else an error message the caller
+// releases with videodna_free. Progress output is off unless "verbose" is
+// set, as the host process owns stdout.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/pforret/videodna/internal/adjust"
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/audiodna"
+	"github.com/pforret/videodna/internal/checksum"
+	"github.com/pforret/videodna/internal/dna"
+	"github.com/pforret/videodna/internal/lanespec"
+	"github.com/pforret/videodna/internal/locale"
+)
+
+// videoOptions are the options of generate_video_dna.
+type videoOptions struct {
+	Mode             string  `json:"mode"`
+	Vertical         bool    `json:"vertical"`
+	Resize           string  `json:"resize"`
+	Width            string  `json:"width"` // Column count, or "auto"
+	ColumnsPerSecond float64 `json:"columns_per_second"`
+	AdaptiveColumns  bool    `json:"adaptive_columns"`
+	Timeout          int     `json:"timeout"`
+	Name             string  `json:"name"`
+	NoLegend         bool    `json:"no_legend"`
+	Letterbox        bool    `json:"letterbox"`
+	Text             bool    `json:"text"`
+	Cuts             bool    `json:"cuts"`
+	Scale            int     `json:"scale"`
+	Depth            int     `json:"depth"`
+	ICC              string  `json:"icc"`
+	ColorConvert     bool    `json:"color_convert"`
+	Colors           int     `json:"colors"`
+	XMP              bool    `json:"xmp"`
+	Checksum         bool    `json:"checksum"`
+	MD5              bool    `json:"md5"`
+	VerifyChecksum   string  `json:"verify_checksum"`
+	Locale           string  `json:"locale"`      // en, fr, de or es
+	JSON             string  `json:"json"`        // Report path
+	AltText          string  `json:"alt_text"`    // Text alternative path, .html or .json
+	AutoLevels       string  `json:"auto_levels"` // "global" or "row"
+	Linear           bool    `json:"linear"`      // Average in linear light
+	Saturation       float64 `json:"saturation"`  // Presentation adjustments, 0 = unchanged
+	Vibrance         float64 `json:"vibrance"`
+	Contrast         float64 `json:"contrast"`
+	Brightness       float64 `json:"brightness"`
+	Verbose          bool    `json:"verbose"`
+
+	// Lanes sets the lane order, heights and visibility, as an array of
+	// {"name", "height", "hidden"} objects ("dna" = the DNA height).
+	Lanes lanespec.Spec `json:"lanes"`
+}
+
+// audioOptions are the options of generate_audio_dna.
+type audioOptions struct {
+	Stems          int     `json:"stems"` // 0 = default, -1 = no separation
+	Separator      string  `json:"separator"`
+	Device         string  `json:"device"`
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	Timeout        int     `json:"timeout"`
+	NoLabels       bool    `json:"no_labels"`
+	Scale          int     `json:"scale"`
+	Overlap        float64 `json:"overlap"`
+	ICC            string  `json:"icc"`
+	XMP            bool    `json:"xmp"`
+	Checksum       bool    `json:"checksum"`
+	MD5            bool    `json:"md5"`
+	VerifyChecksum string  `json:"verify_checksum"`
+	Locale         string  `json:"locale"`   // en, fr, de or es
+	JSON           string  `json:"json"`     // Report path
+	AltText        string  `json:"alt_text"` // Text alternative path, .html or .json
+	Workdir        string  `json:"workdir"`
+	Verbose        bool    `json:"verbose"`
+
+	// Lanes sets the stem order, heights and visibility, as an array of
+	// {"name", "height", "hidden"} objects.
+	Lanes lanespec.Spec `json:"lanes"`
+
+	// AdaptiveColumns shares the width by loudness; the report then lists
+	// the time of each column.
+	AdaptiveColumns bool `json:"adaptive_columns"`
+}
+
+//export generate_video_dna
+func generate_video_dna(input, output, options *C.char) *C.char {
+	return cError(run(func() error {
+		var o videoOptions
+		if err := decodeOptions(options, &o); err != nil {
+			return err
+		}
+		opts := dna.DefaultOptions(C.GoString(input), C.GoString(output))
+		opts.Silent = !o.Verbose
+		opts.Vertical = o.Vertical
+		opts.Resize = o.Resize
+		if o.Mode != "" {
+			opts.Mode = o.Mode
+		}
+		if o.Timeout > 0 {
+			opts.Timeout = o.Timeout
+		}
+		if o.Name != "" {
+			opts.Legend.Name = o.Name
+		}
+		opts.Legend.Enabled = !o.NoLegend
+		switch o.Width {
+		case "":
+		case "auto":
+			opts.Analysis.Width = dna.WidthAuto
+		default:
+			if _, err := fmt.Sscan(o.Width, &opts.Analysis.Width); err != nil {
+				return fmt.Errorf("invalid width %q, use a column count or auto", o.Width)
+			}
+		}
+		opts.Analysis.ColumnsPerSecond = o.ColumnsPerSecond
+		opts.Analysis.AdaptiveColumns = o.AdaptiveColumns
+		opts.Analysis.Letterbox = o.Letterbox
+		opts.Analysis.Text = o.Text
+		opts.Analysis.Cuts = o.Cuts
+		opts.Analysis.Scale = o.Scale
+		opts.Analysis.BitDepth = o.Depth
+		opts.Analysis.ICCProfile = o.ICC
+		opts.Analysis.ColorConvert = o.ColorConvert
+		opts.Analysis.Colors = o.Colors
+		opts.Analysis.XMP = o.XMP
+		opts.Analysis.Checksum = checksum.Options{Enabled: o.Checksum, MD5: o.MD5, Verify: o.VerifyChecksum}
+		opts.Analysis.ReportPath = o.JSON
+		opts.Analysis.AltTextPath = o.AltText
+		opts.Analysis.AutoLevels = o.AutoLevels
+		opts.Analysis.Linear = o.Linear
+		opts.Analysis.Lanes = o.Lanes
+		opts.Analysis.Adjust = adjust.Options{Saturation: o.Saturation, Vibrance: o.Vibrance, Contrast: o.Contrast, Brightness: o.Brightness}
+		loc, err := locale.Parse(o.Locale)
+		if err != nil {
+			return err
+		}
+		opts.Legend.Locale = loc
+		if err := opts.Validate(); err != nil {
+			return err
+		}
+		_, err = dna.GenerateOptions(context.Background(), opts)
+		return err
+	}))
+}
+
+//export generate_audio_dna
+func generate_audio_dna(input, output, options *C.char) *C.char {
+	return cError(run(func() error {
+		var o audioOptions
+		if err := decodeOptions(options, &o); err != nil {
+			return err
+		}
+		config := audiodna.DefaultConfig()
+		config.Silent = !o.Verbose
+		switch {
+		case o.Stems < 0:
+			config.SkipStems = true
+		case o.Stems > 0:
+			config.StemConfig.NumStems = o.Stems
+		}
+		if o.Separator != "" {
+			config.StemConfig.Separator = audio.SeparatorType(o.Separator)
+		}
+		if o.Device != "" {
+			config.StemConfig.Device = o.Device
+		}
+		if o.Timeout > 0 {
+			config.Timeout = o.Timeout
+		}
+		config.Width, config.Height = o.Width, o.Height
+		config.ShowLabels = !o.NoLabels
+		config.Scale = o.Scale
+		config.Overlap = o.Overlap
+		config.Lanes = o.Lanes
+		config.AdaptiveColumns = o.AdaptiveColumns
+		config.ICCProfile = o.ICC
+		config.XMP = o.XMP
+		config.Checksum = checksum.Options{Enabled: o.Checksum, MD5: o.MD5, Verify: o.VerifyChecksum}
+		config.ReportPath = o.JSON
+		config.AltTextPath = o.AltText
+		loc, err := locale.Parse(o.Locale)
+		if err != nil {
+			return err
+		}
+		config.Locale = loc
+		config.Workdir = o.Workdir
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Second)
+		defer cancel()
+		_, err = audiodna.Generate(ctx, C.GoString(input), C.GoString(output), config)
+		return err
+	}))
+}
+
+//export videodna_free
+func videodna_free(p *C.char) {
+	C.free(unsafe.Pointer(p))
+}
+
+// decodeOptions decodes the JSON options into v; NULL or "" keeps the
+// defaults.
+func decodeOptions(options *C.char, v any) error {
+	if options == nil {
+		return nil
+	}
+	text := strings.TrimSpace(C.GoString(options))
+	if text == "" {
+		return nil
+	}
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+	return nil
+}
+
+// run calls fn, turning a panic into an error so it does not take down the
+// host process.
+func run(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// cError returns err as a C string owned by the caller, or NULL.
+func cError(err error) *C.char {
+	if err == nil {
+		return nil
+	}
+	return C.CString(err.Error())
+}
+
+func main() {}
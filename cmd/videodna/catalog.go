@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pforret/videodna/internal/catalog"
+	"github.com/pforret/videodna/internal/video"
+)
+
+// runCatalog implements the "catalog" subcommand.
+func runCatalog(args []string) {
+	fs := flag.NewFlagSet("catalog", flag.ExitOnError)
+	input := fs.String("input", "", "Only entries whose input path contains this text")
+	tool := fs.String("tool", "", "Only entries of this tool: videodna, audiodna")
+	hash := fs.String("hash", "", "Only entries whose input hash starts with this")
+	since := fs.String("since", "", "Only entries created on or after this date (YYYY-MM-DD)")
+	limit := fs.Int("limit", 50, "Maximum entries, newest first (0 = all)")
+	asJSON := fs.Bool("json", false, "Print entries as JSON")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: videodna catalog [options] <catalog.sqlite>\n\n")
+		fmt.Fprintf(os.Stderr, "Lists the DNA images recorded with -catalog by videodna and audiodna.\n")
+		fmt.Fprintf(os.Stderr, "Needs the sqlite3 command-line tool.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Example:
+  videodna catalog -input interviews/ -since 2026-01-01 dna.sqlite
+`)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: catalog needs exactly one database file")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	filter := catalog.Filter{Input: *input, Tool: *tool, Hash: *hash, Limit: *limit}
+	if *since != "" {
+		t, err := time.Parse("2006-01-02", *since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid -since date %q, use YYYY-MM-DD\n", *since)
+			os.Exit(1)
+		}
+		filter.Since = t
+	}
+
+	entries, err := catalog.Query(fs.Arg(0), filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		data, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	for _, e := range entries {
+		fmt.Printf("%s  %-8s  %.12s  %s -> %s\n", e.Created, e.Tool, e.Hash, e.Input, e.Output)
+		fmt.Printf("    options: %s\n    stats:   %s\n", e.Options, e.Stats)
+	}
+	fmt.Printf("%d entries\n", len(entries))
+}
+
+// recordCatalog adds a generated DNA to the catalog. Failures are reported
+// as warnings: the DNA itself was written.
+func recordCatalog(dbPath, input, output string) {
+	stats := map[string]any{}
+	if info, err := video.GetFullInfo(input); err == nil {
+		stats["width"], stats["height"] = info.Width, info.Height
+		stats["frames"], stats["fps"] = info.FrameCount, info.FPS
+		stats["duration"], stats["codec"] = info.Duration, info.Codec
+	}
+	if err := catalog.Record(dbPath, "videodna", input, output, os.Args[1:], stats); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record in catalog: %v\n", err)
+	}
+}
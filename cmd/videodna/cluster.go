@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/pforret/videodna/internal/dna"
+	"github.com/pforret/videodna/internal/notify"
+	"github.com/pforret/videodna/internal/progress"
+	"github.com/pforret/videodna/internal/toolexec"
+)
+
+// runCluster implements the "cluster" subcommand.
+func runCluster(args []string) {
+	config := dna.DefaultClusterConfig()
+	fs := flag.NewFlagSet("cluster", flag.ExitOnError)
+	threshold := fs.Float64("threshold", config.Threshold, "Similarity (0-1) at which files count as near-duplicates")
+	columns := fs.Int("columns", config.Columns, "Fingerprint resolution compared, in columns")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "Files fingerprinted in parallel")
+	maxProcs := fs.Int("max-procs", max(1, runtime.NumCPU()/4), "Maximum ffmpeg processes at once, across all jobs (0 = no limit)")
+	maxMemory := fs.String("max-memory", "", "Memory budget for ffmpeg processes, e.g. 8G (default 75% of RAM)")
+	jsonFile := fs.String("json", "", "Write JSON cluster report")
+	timeout := fs.Int("timeout", 3600, "Timeout in seconds for the whole directory")
+	silent := fs.Bool("silent", false, "Suppress stdout output")
+	tui := fs.Bool("tui", false, "Show a live dashboard of the files being fingerprinted, throughput, ETA and failures")
+	notifyURL := fs.String("notify-url", "", "Post a summary to a Slack, Discord or other webhook when done or failed")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: videodna cluster [options] <directory>\n\n")
+		fmt.Fprintf(os.Stderr, "Fingerprints all videos below a directory and groups near-duplicates\n")
+		fmt.Fprintf(os.Stderr, "(re-encodes, other resolutions) with the best copy to keep:\n")
+		fmt.Fprintf(os.Stderr, "highest resolution, then bit rate, then file size.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Example:
+  videodna cluster -threshold 0.97 -json duplicates.json /archive/videos
+  videodna cluster -jobs 8 -tui /archive/videos
+`)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: cluster needs exactly one directory")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if st, err := os.Stat(fs.Arg(0)); err != nil || !st.IsDir() {
+		fmt.Fprintf(os.Stderr, "Error: not a directory: %s\n", fs.Arg(0))
+		os.Exit(1)
+	}
+	if *threshold <= 0 || *threshold > 1 {
+		fmt.Fprintln(os.Stderr, "Error: -threshold must be between 0 and 1")
+		os.Exit(1)
+	}
+	if *columns < 1 {
+		fmt.Fprintln(os.Stderr, "Error: -columns must be at least 1")
+		os.Exit(1)
+	}
+	budget := toolexec.SystemMemory() * 3 / 4
+	if *maxMemory != "" {
+		var err error
+		if budget, err = toolexec.ParseMemory(*maxMemory); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	toolexec.SetLimits(*maxProcs, budget)
+
+	config.Threshold = *threshold
+	config.Columns = *columns
+	config.Jobs = *jobs
+	config.Silent = *silent
+	if *tui && !*silent {
+		if progress.IsTerminal(os.Stderr) {
+			config.Progress = progress.New(os.Stderr, "videodna cluster", 0)
+		} else {
+			fmt.Fprintln(os.Stderr, "Warning: -tui needs a terminal, printing a line per file")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*timeout)*time.Second)
+	defer cancel()
+
+	startTime := time.Now()
+	config.Progress.Start()
+	report, err := dna.Cluster(ctx, fs.Arg(0), config)
+	config.Progress.Stop()
+	if *notifyURL != "" {
+		m := notify.Message{Title: "videodna cluster finished"}
+		if err != nil {
+			m.Title, m.Failed, m.Text = "videodna cluster failed", true, fmt.Sprintf("Directory: %s\nError: %v", fs.Arg(0), err)
+		} else {
+			m.Text = fmt.Sprintf("Directory: %s\n%d files, %d near-duplicate groups, %d skipped\nTook %s",
+				fs.Arg(0), report.Files, len(report.Groups), len(report.Skipped), time.Since(startTime).Round(time.Second))
+		}
+		if err := notify.Send(context.Background(), *notifyURL, m); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *jsonFile != "" {
+		if err := report.WriteJSON(*jsonFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !*silent {
+		for path, reason := range report.Skipped {
+			fmt.Printf("Skipped %s: %s\n", path, reason)
+		}
+		for i, group := range report.Groups {
+			fmt.Printf("\nGroup %d (similarity %.3f):\n", i+1, group.Similarity)
+			for j, f := range group.Files {
+				mark := "  "
+				if j == 0 {
+					mark = "* "
+				}
+				fmt.Printf("  %s%s (%dx%d, %d kb/s)\n", mark, f.Path, f.Width, f.Height, f.BitRate/1000)
+			}
+		}
+		fmt.Printf("\n%d files, %d near-duplicate groups (* = best copy)\n", report.Files, len(report.Groups))
+	}
+}
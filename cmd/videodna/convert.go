@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pforret/videodna/internal/dna"
+	"github.com/pforret/videodna/internal/fingerprint"
+)
+
+// runConvert implements the "convert" subcommand.
+func runConvert(args []string) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	codec := fs.String("codec", "uint8", "Value encoding for .vdna output: uint8, uint16, float32")
+	levels := fs.String("fingerprint-levels", "64,256,1024,4096", "Fingerprint resolutions in columns (PNG input)")
+	silent := fs.Bool("silent", false, "Suppress stdout output")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: videodna convert [options] <input> <output>\n\n")
+		fmt.Fprintf(os.Stderr, "Converts a fingerprint between JSON and the binary .vdna format, or\n")
+		fmt.Fprintf(os.Stderr, "re-extracts one from a DNA PNG made by videodna.\n")
+		fmt.Fprintf(os.Stderr, "The output format follows the output extension.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Example:
+  videodna convert -codec uint16 video.fp.json video.vdna
+  videodna convert video.vdna video.fp.json
+  videodna convert old_dna.png video.fp.json
+`)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Error: convert needs an input and an output file")
+		fs.Usage()
+		os.Exit(1)
+	}
+	c, err := fingerprint.ParseCodec(*codec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fpLevels, err := fingerprint.ParseLevels(*levels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var fp *fingerprint.Fingerprint
+	if dna.IsPNGPath(fs.Arg(0)) {
+		fp, err = dna.ReadFingerprintPNG(fs.Arg(0), fpLevels)
+	} else {
+		fp, err = fingerprint.Read(fs.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := fingerprint.WriteCodec(fs.Arg(1), fp, c); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !*silent {
+		fmt.Printf("Fingerprint written to %s (%d levels)\n", fs.Arg(1), len(fp.Levels))
+	}
+}
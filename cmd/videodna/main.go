@@ -1,25 +1,167 @@
 package main
 
 import (
+	"context"
+	"crypto/ed25519"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/pforret/videodna/internal/adjust"
+	"github.com/pforret/videodna/internal/archive"
+	"github.com/pforret/videodna/internal/checksum"
 	"github.com/pforret/videodna/internal/dna"
+	"github.com/pforret/videodna/internal/fingerprint"
+	"github.com/pforret/videodna/internal/font"
+	"github.com/pforret/videodna/internal/hooks"
+	"github.com/pforret/videodna/internal/lanespec"
+	"github.com/pforret/videodna/internal/locale"
+	"github.com/pforret/videodna/internal/notify"
+	"github.com/pforret/videodna/internal/pipe"
+	"github.com/pforret/videodna/internal/plot"
+	"github.com/pforret/videodna/internal/progress"
+	"github.com/pforret/videodna/internal/publish"
+	"github.com/pforret/videodna/internal/signature"
+	"github.com/pforret/videodna/internal/sink"
+	"github.com/pforret/videodna/internal/tiles"
+	"github.com/pforret/videodna/internal/toolexec"
+	"github.com/pforret/videodna/internal/transform"
+	"github.com/pforret/videodna/internal/workdir"
+	"github.com/pforret/videodna/internal/xmp"
 )
 
 var version = "1.0.0"
 
+// stringList collects a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string     { return fmt.Sprint(*l) }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// cleanups run when main returns or exits (see exit).
+var cleanups []func()
+
+// atExit registers f to run when main returns or exits, e.g. to remove
+// temp files of failed runs.
+func atExit(f func()) {
+	cleanups = append(cleanups, f)
+}
+
+// runCleanups runs the registered cleanups, latest first.
+func runCleanups() {
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+	cleanups = nil
+}
+
+// exit runs the cleanups and exits with code. main exits through it instead
+// of os.Exit, which skips deferred calls.
+func exit(code int) {
+	runCleanups()
+	os.Exit(code)
+}
+
 func main() {
-	inputFile := flag.String("input", "", "Input video file (required)")
-	outputFile := flag.String("output", "output.png", "Output PNG file")
-	mode := flag.String("mode", "average", "Color mode: average, min, max, common")
+	defer runCleanups()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "convert":
+			runConvert(os.Args[2:])
+			return
+		case "cluster":
+			runCluster(os.Args[2:])
+			return
+		case "catalog":
+			runCatalog(os.Args[2:])
+			return
+		case "verify":
+			runVerify(os.Args[2:])
+			return
+		case "worker":
+			runWorker(os.Args[2:])
+			return
+		}
+	}
+
+	inputFile := flag.String("input", "", "Input video file, or .otio/.fcpxml timeline of an edited sequence (required)")
+	outputFile := flag.String("output", "output.png", "Output PNG file (.tif/.tiff = TIFF, .dzi = Deep Zoom tile pyramid, - = stdout)")
+	mode := flag.String("mode", "average", "Color mode: average, min, max, common; or a heatmap of the pixels per bin: hue, luma")
 	vertical := flag.Bool("vertical", false, "Vertical output (width=video width, height=frames)")
-	resize := flag.String("resize", "", "Resize output: 'WxH' or 'input' for video dimensions")
+	resize := flag.String("resize", "", "Resize output: 'WxH', '50%', one side ('1920x', 'x200') or 'input' for video dimensions")
+	width := flag.String("width", "", "DNA columns, averaging adjacent frames: N, or auto (one per frame, at most 8192; default: one per frame)")
+	colors := flag.Int("colors", 0, "Quantize to an indexed PNG of at most N colors (2-256), for small catalog thumbnails")
+	checksumInput := flag.Bool("checksum", false, "Record the SHA-256 of the input in the PNG metadata and JSON report")
+	md5Input := flag.Bool("md5", false, "Also record the MD5 of the input, for legacy systems")
+	verifyChecksum := flag.String("verify-checksum", "", "Refuse to process an input whose checksum differs: sha256:HEX, md5:HEX or bare hex")
+	signKey := flag.String("sign-key", "", "Sign the -fingerprint file with this Ed25519 private key (PEM), writing <fingerprint>.sig")
+	archiveFile := flag.String("archive", "", "Bundle the image, JSON report, sidecars and a manifest (checksums, version, command line, environment) into a .zip or .tar")
+	xmpSidecar := flag.Bool("xmp", false, "Write an XMP sidecar (output name with .xmp) with the analysis metadata, for DAM systems")
+	iccProfile := flag.String("icc", "srgb", "Color profile embedded in the image: srgb, rec709 (gamma 2.4 grading monitor), none, or an .icc file")
+	colorConvert := flag.Bool("color-convert", false, "Convert frames to BT.709/sRGB from the color properties of the source: HD matrix, BT.2020, HDR tone mapping (needs an ffmpeg built with zimg)")
+	maxDimension := flag.Int("max-dimension", dna.DefaultMaxDimension, "Cap of the DNA length in pixels; longer DNAs average adjacent frames (0 = no limit)")
+	depth := flag.Int("depth", 8, "Bits per color channel of the PNG or TIFF: 8, or 16 to keep the fraction of averaged colors")
+	columnsPerSecond := flag.Float64("columns-per-second", 0, "DNA columns per second of video, averaging adjacent frames")
+	adaptiveColumns := flag.Bool("adaptive-columns", false, "Share -width columns by content: more around scene changes, fewer for static shots")
 	silent := flag.Bool("silent", false, "Suppress stdout output")
+	progressJSON := flag.Bool("progress-json", false, "Print decode progress with throughput and ETA as JSON lines on stderr")
 	timeout := flag.Int("timeout", 60, "Timeout in seconds")
 	name := flag.String("name", "", "Display name in legend (default: input filename)")
 	noLegend := flag.Bool("no-legend", false, "Hide top legend bar")
+	lang := flag.String("locale", "", "Language of the legend, lane labels and settings errors: en, fr, de, es (default $VIDEODNA_LOCALE or en)")
+	fontPath := flag.String("font", "", "Font file for names and labels in scripts the bitmap font lacks: Arabic, Hebrew, CJK, ... (default $VIDEODNA_FONT or a system font)")
+	showTimings := flag.Bool("timings", false, "Show the run time and its slowest stage in the legend (always in the JSON report)")
+	reference := flag.String("reference", "", "Reference (master) video: render color difference DNA against it")
+	offset := flag.Int("offset", 0, "Frame alignment for -reference: >0 skips input frames, <0 skips reference frames")
+	noLanes := flag.Bool("no-lanes", false, "Hide metric lanes (PSNR/SSIM in -reference mode)")
+	jsonFile := flag.String("json", "", "Write JSON report (analysis results, or PSNR/SSIM/deltaE in -reference mode)")
+	fingerprintFile := flag.String("fingerprint", "", "Write a multi-resolution fingerprint (16 RGB bands per column) for similarity search: JSON, or binary .vdna")
+	fingerprintLevels := flag.String("fingerprint-levels", "64,256,1024,4096", "Fingerprint resolutions in columns")
+	catalogFile := flag.String("catalog", "", "Record the generated DNA in a SQLite catalog (needs sqlite3)")
+	var hookSpecs stringList
+	hwaccel := flag.String("hwaccel", "", "Decode on the GPU: cuda or vaapi (scaling and conversion stay on the GPU)")
+	workDir := flag.String("workdir", "", "Directory for temp files such as the archived report, removed after the run (default $TMPDIR)")
+	dockerImage := flag.String("docker-image", "", "Run ffmpeg from this Docker image when not installed (default $VIDEODNA_DOCKER_IMAGE)")
+	notifyURL := flag.String("notify-url", "", "Post a summary (and thumbnail) to a Slack, Discord or other webhook when done or failed")
+	publishURL := flag.String("publish", "", "Publish a completion event to pubsub://PROJECT/TOPIC, sns://TOPIC_ARN or nats://HOST/SUBJECT")
+	uploadURL := flag.String("upload", "", "Also deliver the image, encoded as -output (PNG or TIFF), to s3://BUCKET/KEY (AWS_* credentials) or a file path")
+	uploadJSON := flag.String("upload-json", "", "Also deliver the JSON report to s3://BUCKET/KEY or a file path")
+	flag.Var(&hookSpecs, "hook", "Run a command at a pipeline point: POINT=COMMAND (repeatable; after-probe, after-generate, before-upload, on-error)")
+	letterbox := flag.Bool("letterbox", false, "Add lane showing active picture area and aspect ratio changes")
+	logo := flag.String("logo", "", "Logo/watermark image (PNG/JPEG at video scale): add presence lane")
+	logoThreshold := flag.Float64("logo-threshold", 0.6, "Match score (0-1) above which the logo counts as present")
+	text := flag.Bool("text", false, "Add lane marking credits and burned-in subtitles")
+	skin := flag.Bool("skin", false, "Add skin-tone ratio lane (rough people-on-screen density)")
+	cuts := flag.Bool("cuts", false, "Add scene cut lane and list hard cuts in -json/-events")
+	eventsFile := flag.String("events", "", "Export cuts and QC spans: .edl (markers), .ffmeta (FFmpeg chapters) or .txt (YouTube chapters)")
+	reverse := flag.Bool("reverse", false, "Reverse the time axis (last frame first)")
+	flip := flag.Bool("flip", false, "Flip the DNA perpendicular to the time axis")
+	logTime := flag.Bool("log-time", false, "Map time logarithmically to expand the beginning")
+	compressTail := flag.Bool("compress-tail", false, "Compress a static tail (credits, end card) logarithmically, with tick marks showing the warp")
+	scale := flag.Int("scale", 1, "HiDPI scale factor (1-3): legend, lanes and separators drawn larger, same time resolution")
+	var annotate stringList
+	flag.Var(&annotate, "annotate", "Mark a labeled point in time: TIME=LABEL (repeatable, e.g. 00:05:00=\"sponsor read\")")
+	var seriesFiles stringList
+	flag.Var(&seriesFiles, "series", "Draw time series (telemetry, bitrate, QoE scores) as lanes aligned with the DNA: .csv (time column, then one column per series) or .json (repeatable)")
+	seriesStyle := flag.String("series-style", "bars", "Style of -series lanes without their own: bars or waveform")
+	laneSpec := flag.String("lanes", "", "Order, height and visibility of lanes: name[:height],... (-name hides, \"dna\" sets the DNA height) or a .json file")
+	annotationsFile := flag.String("annotations", "", "Annotations file: JSON, EDL (CMX3600) or NLE marker CSV (Premiere, Resolve)")
+	seekMap := flag.String("seek-map", "", "Write a click-to-seek map from image pixels to video time: .html (image map) or .json")
+	altText := flag.String("alt-text", "", "Write a text alternative describing the colors over time, for screen readers: .html (figure) or .json")
+	linear := flag.Bool("linear", false, "Average colors in linear light (decode sRGB, average, re-encode) instead of darkening gamma-encoded averages")
+	saturation := flag.Float64("saturation", 0, "Presentation: change DNA saturation, -1 (gray) to 3 (e.g. 0.4 = +40%); exported data is unaffected")
+	vibrance := flag.Float64("vibrance", 0, "Presentation: like -saturation, boosting muted colors most (-1 to 3)")
+	contrast := flag.Float64("contrast", 0, "Presentation: change DNA contrast around mid gray, -1 to 3 (e.g. 0.2 = +20%)")
+	brightness := flag.Float64("brightness", 0, "Presentation: add to DNA brightness, -1 to 1 (e.g. 0.05)")
+	autoLevels := flag.String("auto-levels", "", "Stretch the DNA contrast for dark films: global or row (per row, column with -vertical); fingerprints are unaffected")
+	timecodeBase := flag.String("timecode-base", "", "Start timecode HH:MM:SS:FF (default: from the file; 00:00:00:00 = zero-based)")
+	timecodeFormat := flag.String("timecode-format", "auto", "Timecode notation: auto (drop-frame if the start timecode is HH:MM:SS;FF), ndf, or df (29.97/59.94)")
+	zoomRegion := flag.String("zoom", "", "Render this region expanded below the DNA: START-END (e.g. 00:10:00-00:12:30)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "videodna v%s - Generate DNA fingerprint images from video files\n\n", version)
@@ -31,37 +173,431 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  min      Darkest color per row/column\n")
 		fmt.Fprintf(os.Stderr, "  max      Brightest color per row/column\n")
 		fmt.Fprintf(os.Stderr, "  common   Most frequent color per row/column (slowest)\n")
+		fmt.Fprintf(os.Stderr, "\nAnalysis lanes (rendered below the DNA):\n")
+		fmt.Fprintf(os.Stderr, "  -letterbox  Active picture area; bar color = aspect (1.33 orange, 1.78 green, 2.39 purple)\n")
+		fmt.Fprintf(os.Stderr, "  -logo       Watermark presence in frame corners (green = present, red = absent)\n")
+		fmt.Fprintf(os.Stderr, "  -text       Sharp edge density; yellow = credits, blue = subtitles\n")
+		fmt.Fprintf(os.Stderr, "  -skin       Fraction of skin-tone pixels (full bar = 30%% of frame)\n")
+		fmt.Fprintf(os.Stderr, "  -cuts       Frame-to-frame change; hard cuts are listed in the report\n")
+		fmt.Fprintf(os.Stderr, "\nTime axis (applied when rendering, lanes follow):\n")
+		fmt.Fprintf(os.Stderr, "  -reverse   End of the video first\n")
+		fmt.Fprintf(os.Stderr, "  -flip      Mirror rows (or columns with -vertical)\n")
+		fmt.Fprintf(os.Stderr, "  -log-time  First 10%% of the video takes about a third of the image\n")
+		fmt.Fprintf(os.Stderr, "  -compress-tail  Squeeze static end credits into the last 10%%, ticks mark round times\n")
+		fmt.Fprintf(os.Stderr, "\nAnnotations:\n")
+		fmt.Fprintf(os.Stderr, "  -annotate and -annotations draw labeled markers on the DNA, with the labels\n")
+		fmt.Fprintf(os.Stderr, "  in a band below it, turning the image into an annotated timeline for reviews\n")
+		fmt.Fprintf(os.Stderr, "  -annotations reads .json, .edl (markers or cuts) and .csv/.txt marker exports;\n")
+		fmt.Fprintf(os.Stderr, "  NLE timecodes count from the start timecode of the file, or without one\n")
+		fmt.Fprintf(os.Stderr, "  from 01:00:00:00 when every marker is past it\n")
+		fmt.Fprintf(os.Stderr, "\nTimecode:\n")
+		fmt.Fprintf(os.Stderr, "  The start timecode of the file (QuickTime tmcd, MXF) is shown in the legend,\n")
+		fmt.Fprintf(os.Stderr, "  added to -json times and used as the -events EDL start; -timecode-base overrides it\n")
+		fmt.Fprintf(os.Stderr, "  -timecode-format df labels 29.97/59.94 video in SMPTE drop-frame timecode (HH:MM:SS;FF)\n")
+		fmt.Fprintf(os.Stderr, "\nDifference:\n")
+		fmt.Fprintf(os.Stderr, "  -reference decodes both videos in lockstep and renders per-row deltaE\n")
+		fmt.Fprintf(os.Stderr, "  (black = identical, red/yellow/white = increasing deviation)\n")
+		fmt.Fprintf(os.Stderr, "  with PSNR and SSIM quality lanes below; -json writes a QC report\n")
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png\n")
 		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -mode max\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input graded.mp4 -output hue.png -mode hue\n")
 		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -vertical -resize input\n")
 		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -resize 1920x1080\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input movie.mkv -output dna.png -width auto\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input movie.mkv -output dna.png -columns-per-second 1\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input movie.mkv -output dna.png -width 1920 -adaptive-columns\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input movie.mkv -output dna.tif -depth 16\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input movie.mkv -output thumb.png -resize 600x40 -no-legend -colors 64\n")
 		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -name \"My Video\"\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -scale 2\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input reportage.mp4 -output dna.png -cuts -locale fr\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input movie.mp4 -output dna.png -zoom 00:10:00-00:12:30\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input show.mp4 -output dna.png -annotate 00:05:00=\"sponsor read\" -annotate 00:41:30=outro\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -cuts -events chapters.ffmeta\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input master.mxf -output dna.png -cuts -events cuts.edl -timecode-base 10:00:00:00\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -resize 1200x100 -seek-map dna.html\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -alt-text dna.alt.html\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input dark.mp4 -output dna.png -auto-levels row\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -linear\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input stream.mp4 -output dna.png -series player-stats.csv\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input stream.mp4 -output dna.png -cuts -series player-stats.csv -lanes dna:200,bitrate:64,cuts\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input trailer.mp4 -output poster.png -saturation 0.4 -contrast 0.2 -fingerprint fp.json\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -fingerprint video.fp.json\n")
+		fmt.Fprintf(os.Stderr, "  videodna convert -codec uint16 video.fp.json video.vdna\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -fingerprint video.fp.json -sign-key signing.pem\n")
+		fmt.Fprintf(os.Stderr, "  videodna verify -key signing.pub.pem video.fp.json\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input film.mkv -output film.png -fingerprint film.fp.json -archive film.zip\n")
+		fmt.Fprintf(os.Stderr, "  videodna cluster -json duplicates.json /archive/videos\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input video.mp4 -output dna.png -catalog dna.sqlite\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input evidence.mp4 -output dna.png -json report.json -verify-checksum sha256:<hex>\n")
+		fmt.Fprintf(os.Stderr, "  videodna catalog -input interviews/ dna.sqlite\n")
+		fmt.Fprintf(os.Stderr, "  videodna worker -key < jobs.jsonl > fingerprints.jsonl\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input final_cut.fcpxml -output cut.png\n")
+		fmt.Fprintf(os.Stderr, "  videodna -input encode.mp4 -reference master.mov -output diff.png -json qc.json\n")
 	}
 
 	flag.Parse()
 
+	// "-output -" writes the image to stdout, so progress goes to stderr
+	if pipe.IsStdout(*outputFile) {
+		pipe.LogsToStderr()
+		if *catalogFile != "" {
+			fmt.Fprintln(os.Stderr, "Error: -catalog needs an output file, not stdout")
+			exit(1)
+		}
+		if *archiveFile != "" {
+			fmt.Fprintln(os.Stderr, "Error: -archive needs an output file, not stdout")
+			exit(1)
+		}
+	}
+
 	if *inputFile == "" {
 		flag.Usage()
-		os.Exit(1)
+		exit(1)
+	}
+	// Library callers may leave the output empty to keep the image in memory
+	if *outputFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: -output is required")
+		exit(1)
+	}
+
+	loc := locale.Default()
+	if *lang != "" {
+		var err error
+		if loc, err = locale.Parse(*lang); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
 	}
 
-	validModes := map[string]bool{"average": true, "min": true, "max": true, "common": true}
-	if !validModes[*mode] {
-		fmt.Fprintf(os.Stderr, "Error: Invalid mode '%s'. Use: average, min, max, common\n", *mode)
-		os.Exit(1)
+	timeTransform := transform.Options{Reverse: *reverse, Flip: *flip, LogTime: *logTime, CompressTail: *compressTail}
+	adjustments := adjust.Options{Saturation: *saturation, Vibrance: *vibrance, Contrast: *contrast, Brightness: *brightness}
+
+	var zoom dna.Zoom
+	if *zoomRegion != "" {
+		var err error
+		if zoom, err = dna.ParseZoom(*zoomRegion); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+
+	levels, err := fingerprint.ParseLevels(*fingerprintLevels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+
+	columns := 0
+	switch *width {
+	case "":
+	case "auto":
+		columns = dna.WidthAuto
+	default:
+		if columns, err = strconv.Atoi(*width); err != nil || columns <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: invalid width %q, use a column count or auto\n", *width)
+			exit(1)
+		}
+	}
+
+	var signingKey ed25519.PrivateKey
+	if *signKey != "" {
+		if *fingerprintFile == "" {
+			fmt.Fprintln(os.Stderr, "Error: -sign-key needs -fingerprint")
+			exit(1)
+		}
+		if signingKey, err = signature.LoadPrivateKey(*signKey); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Temp files of the run live in one directory, removed on every exit
+	work, err := workdir.New(*workDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	atExit(func() {
+		if _, err := work.Cleanup(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	})
+	font.SetWorkdir(work)
+
+	reportFile := *jsonFile
+	if *archiveFile != "" {
+		if tiles.IsDZIPath(*outputFile) {
+			fmt.Fprintln(os.Stderr, "Error: -archive needs a PNG or TIFF output, not a tile pyramid")
+			exit(1)
+		}
+		if err := archive.CheckPath(*archiveFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		var cleanup func()
+		if reportFile, cleanup, err = archive.ReportPath(work, *jsonFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		atExit(cleanup)
+	}
+
+	toolexec.SetDockerImage(*dockerImage)
+	font.SetFile(*fontPath)
+
+	runner := hooks.NewRunner("videodna")
+	for _, spec := range hookSpecs {
+		if err := runner.AddSpec(spec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+	if *notifyURL != "" {
+		runner.Add(hooks.AfterGenerate, notify.Webhook(*notifyURL))
+		runner.Add(hooks.OnError, notify.Webhook(*notifyURL))
+	}
+	if *publishURL != "" {
+		publisher, err := publish.Open(*publishURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		runner.Add(hooks.AfterGenerate, publish.Hook{Publisher: publisher})
 	}
 
-	legend := dna.DefaultLegendConfig()
-	legend.Enabled = !*noLegend
-	legend.Name = *name
+	var annotations []dna.Annotation
+	if *annotationsFile != "" {
+		var err error
+		if annotations, err = dna.LoadAnnotations(*annotationsFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+	for _, a := range annotate {
+		annotation, err := dna.ParseAnnotation(a)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		annotations = append(annotations, annotation)
+	}
 
-	if err := dna.GenerateWithLegend(*inputFile, *outputFile, *mode, *vertical, *resize, *silent, *timeout, legend); err != nil {
+	style, err := plot.ParseStyle(*seriesStyle)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		exit(1)
+	}
+	var series []dna.TimeSeries
+	for _, path := range seriesFiles {
+		loaded, err := dna.LoadTimeSeries(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		for i := range loaded {
+			if loaded[i].Style == "" {
+				loaded[i].Style = style
+			}
+		}
+		series = append(series, loaded...)
+	}
+	lanes, err := lanespec.Parse(*laneSpec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+
+	opts := dna.DefaultOptions(*inputFile, *outputFile)
+	opts.Reference = *reference
+	opts.Mode = *mode
+	opts.Vertical = *vertical
+	opts.Resize = *resize
+	opts.Silent = *silent
+	opts.Timeout = *timeout
+	opts.Legend.Enabled = !*noLegend
+	opts.Legend.Name = *name
+	opts.Legend.Timings = *showTimings
+	opts.Legend.Locale = loc
+	opts.Analysis = dna.AnalysisConfig{
+		Letterbox:     *letterbox,
+		LogoPath:      *logo,
+		LogoThreshold: *logoThreshold,
+		Text:          *text,
+		Skin:          *skin,
+		Cuts:          *cuts,
+		EventsPath:    *eventsFile,
+		SeekMapPath:   *seekMap,
+		AltTextPath:   *altText,
+		AutoLevels:    *autoLevels,
+		Linear:        *linear,
+		Adjust:        adjustments,
+		Series:        series,
+		Lanes:         lanes,
+		ReportPath:    reportFile,
+		Transform:     timeTransform,
+		Zoom:          zoom,
+		Scale:         *scale,
+		Annotations:   annotations,
+	}
+	opts.Analysis.FingerprintPath = *fingerprintFile
+	opts.Analysis.FingerprintLevels = levels
+	opts.Analysis.Hooks = runner
+	if *progressJSON {
+		opts.Progress = progress.JSONLines(os.Stderr)
+	}
+	opts.Analysis.HWAccel = *hwaccel
+	opts.Analysis.TimecodeBase = *timecodeBase
+	opts.Analysis.TimecodeFormat = *timecodeFormat
+	opts.Analysis.Width = columns
+	opts.Analysis.ColumnsPerSecond = *columnsPerSecond
+	opts.Analysis.AdaptiveColumns = *adaptiveColumns
+	opts.Analysis.MaxDimension = *maxDimension
+	opts.Analysis.ICCProfile = *iccProfile
+	opts.Analysis.ColorConvert = *colorConvert
+	opts.Analysis.XMP = *xmpSidecar
+	opts.Analysis.Colors = *colors
+	opts.Analysis.BitDepth = *depth
+	opts.Analysis.Checksum = checksum.Options{Enabled: *checksumInput, MD5: *md5Input, Verify: *verifyChecksum}
+	if *uploadURL != "" {
+		s, err := sink.Open(*uploadURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		opts.ImageSink = s
+	}
+	if *uploadJSON != "" {
+		s, err := sink.Open(*uploadJSON)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		opts.ReportSink = s
+	}
+
+	// Validate the settings and their combinations, reporting all violations
+	if err := opts.Validate(); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", loc.T("Error"), line)
+		}
+		exit(1)
+	}
+
+	if *reference != "" {
+		config := dna.DefaultDiffConfig()
+		config.ReferencePath = *reference
+		config.Offset = *offset
+		config.Vertical = *vertical
+		config.Resize = *resize
+		config.Silent = *silent
+		config.Timeout = *timeout
+		config.Legend = opts.Legend
+		config.QualityLanes = !*noLanes
+		config.ReportPath = reportFile
+		config.SeekMapPath = *seekMap
+		config.Transform = timeTransform
+		config.Zoom = zoom
+		config.Scale = *scale
+		config.Annotations = annotations
+		config.TimecodeBase = *timecodeBase
+		config.TimecodeFormat = *timecodeFormat
+		config.Hooks = runner
+		config.ICCProfile = *iccProfile
+		config.ColorConvert = *colorConvert
+		config.XMP = *xmpSidecar
+		config.Colors = *colors
+
+		startTime := time.Now()
+		if _, err := dna.GenerateDiff(*inputFile, *outputFile, config); err != nil {
+			failWithHooks(runner, *inputFile, *outputFile, startTime, err)
+		}
+		if *archiveFile != "" {
+			b := &archive.Bundle{Tool: "videodna", ToolVersion: version, CommandLine: os.Args, Input: *inputFile}
+			b.Add(*outputFile, "image")
+			b.Add(reportFile, "report")
+			if *xmpSidecar {
+				b.Add(xmp.SidecarPath(*outputFile), "xmp")
+			}
+			b.Add(*seekMap, "seek-map")
+			writeArchive(runner, b, *archiveFile, *outputFile, startTime, *silent)
+		}
+		finishWithHooks(runner, *inputFile, *outputFile, startTime)
+
+		if *catalogFile != "" {
+			recordCatalog(*catalogFile, *inputFile, *outputFile)
+		}
+		if !*silent {
+			fmt.Printf("Difference DNA generated: %s\n", *outputFile)
+		}
+		return
 	}
 
+	// An interrupt cancels the run, stopping ffmpeg and uploads
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	startTime := time.Now()
+	if _, err := dna.GenerateOptions(ctx, opts); err != nil {
+		failWithHooks(runner, *inputFile, *outputFile, startTime, err)
+	}
+	if signingKey != nil {
+		if err := signature.SignFingerprint(*fingerprintFile, signingKey, *silent); err != nil {
+			failWithHooks(runner, *inputFile, *outputFile, startTime, err)
+		}
+	}
+	if *archiveFile != "" {
+		b := &archive.Bundle{Tool: "videodna", ToolVersion: version, CommandLine: os.Args, Input: *inputFile}
+		b.Add(*outputFile, "image")
+		b.Add(reportFile, "report")
+		if *xmpSidecar {
+			b.Add(xmp.SidecarPath(*outputFile), "xmp")
+		}
+		b.Add(*fingerprintFile, "fingerprint")
+		if signingKey != nil {
+			b.Add(*fingerprintFile+signature.Ext, "signature")
+		}
+		b.Add(*eventsFile, "events")
+		b.Add(*seekMap, "seek-map")
+		b.Add(*altText, "alt-text")
+		writeArchive(runner, b, *archiveFile, *outputFile, startTime, *silent)
+	}
+	finishWithHooks(runner, *inputFile, *outputFile, startTime)
+
+	if *catalogFile != "" {
+		recordCatalog(*catalogFile, *inputFile, *outputFile)
+	}
 	if !*silent {
 		fmt.Printf("Video DNA generated: %s\n", *outputFile)
 	}
 }
+
+// failWithHooks runs the on-error hooks and exits.
+func failWithHooks(runner *hooks.Runner, input, output string, start time.Time, err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	c := hooks.Context{Point: hooks.OnError, Input: input, Output: output, Error: err.Error(), Elapsed: time.Since(start).Seconds()}
+	if err := runner.Run(context.Background(), c); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	exit(1)
+}
+
+// writeArchive writes the archival bundle of the run; a failure exits.
+func writeArchive(runner *hooks.Runner, b *archive.Bundle, path, output string, start time.Time, silent bool) {
+	m, err := b.Write(path)
+	if err != nil {
+		failWithHooks(runner, b.Input, output, start, err)
+	}
+	if !silent {
+		fmt.Printf("Archive: %s (%d files, %s)\n", path, len(m.Files), m.Created)
+	}
+}
+
+// finishWithHooks runs the after-generate hooks; a failing hook exits.
+func finishWithHooks(runner *hooks.Runner, input, output string, start time.Time) {
+	c := hooks.Context{Point: hooks.AfterGenerate, Input: input, Output: output, Elapsed: time.Since(start).Seconds()}
+	if err := runner.Run(context.Background(), c); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pforret/videodna/internal/signature"
+)
+
+// runVerify implements the "verify" subcommand.
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	keyFile := fs.String("key", "", "Trusted Ed25519 public key (PEM); without it the signer is not checked (exit status 2)")
+	sigFile := fs.String("sig", "", "Signature sidecar (default: <file>.sig)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: videodna verify [options] <fingerprint>\n\n")
+		fmt.Fprintf(os.Stderr, "Checks a fingerprint signed with -sign-key by videodna or audiodna:\n")
+		fmt.Fprintf(os.Stderr, "the file is unchanged since signing and, with -key, was signed by\n")
+		fmt.Fprintf(os.Stderr, "that key. Exits with status 1 when verification fails, and with status 2\n")
+		fmt.Fprintf(os.Stderr, "when the file is intact but no -key was given: anyone can re-sign an\n")
+		fmt.Fprintf(os.Stderr, "edited fingerprint with their own key.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Example:
+  videodna verify -key signing.pub.pem video.fp.json
+`)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: verify needs exactly one fingerprint file")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var trusted ed25519.PublicKey
+	if *keyFile != "" {
+		var err error
+		if trusted, err = signature.LoadPublicKey(*keyFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	s, err := signature.Verify(fs.Arg(0), *sigFile, trusted)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: verification failed: %v\n", err)
+		os.Exit(1)
+	}
+	public, _ := s.Key()
+	if trusted == nil {
+		fmt.Printf("UNTRUSTED: %s (SHA-256 %s) matches its signature at %s by key %s, but no -key was given to check the signer\n",
+			fs.Arg(0), s.SHA256, s.SignedAt, signature.KeyID(public))
+		os.Exit(2)
+	}
+	fmt.Printf("OK: %s (SHA-256 %s) signed at %s by key %s\n", fs.Arg(0), s.SHA256, s.SignedAt, signature.KeyID(public))
+}
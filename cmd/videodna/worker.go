@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pforret/videodna/internal/dna"
+	"github.com/pforret/videodna/internal/fingerprint"
+	"github.com/pforret/videodna/internal/progress"
+	"github.com/pforret/videodna/internal/toolexec"
+)
+
+// workerJob is a job message: a JSON object, or a bare input path or URL.
+type workerJob struct {
+	ID     string `json:"id,omitempty"` // Echoed in the record (default: the input)
+	Input  string `json:"input"`
+	Levels []int  `json:"levels,omitempty"` // Fingerprint resolutions (default: -levels)
+}
+
+// workerRecord is the fingerprint record written for each job.
+type workerRecord struct {
+	ID          string                   `json:"id"`
+	Input       string                   `json:"input"`
+	Width       int                      `json:"width,omitempty"`
+	Height      int                      `json:"height,omitempty"`
+	Fingerprint *fingerprint.Fingerprint `json:"fingerprint,omitempty"`
+	Error       string                   `json:"error,omitempty"`
+	Elapsed     float64                  `json:"elapsed"` // Seconds
+}
+
+// runWorker implements the "worker" subcommand.
+func runWorker(args []string) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	levelsFlag := fs.String("levels", "64,256,1024,4096", "Default fingerprint resolutions in columns")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "Jobs fingerprinted in parallel")
+	maxProcs := fs.Int("max-procs", max(1, runtime.NumCPU()/4), "Maximum ffmpeg processes at once, across all jobs (0 = no limit)")
+	maxMemory := fs.String("max-memory", "", "Memory budget for ffmpeg processes, e.g. 8G (default 75% of RAM)")
+	keyed := fs.Bool("key", false, "Prefix each record with its job ID and a tab (kcat -P -K '\\t')")
+	timeout := fs.Int("timeout", 3600, "Timeout in seconds per job")
+	silent := fs.Bool("silent", false, "Suppress progress on stderr")
+	tui := fs.Bool("tui", false, "Show a live dashboard of running jobs, throughput and failures on stderr instead of a line per job")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: videodna worker [options] < jobs.jsonl > records.jsonl\n\n")
+		fmt.Fprintf(os.Stderr, "Reads job messages from stdin, one per line: {\"id\", \"input\", \"levels\"}\n")
+		fmt.Fprintf(os.Stderr, "or a bare input path or URL. Writes one fingerprint record per job to\n")
+		fmt.Fprintf(os.Stderr, "stdout as it finishes; failed jobs get a record with \"error\". Pipe it\n")
+		fmt.Fprintf(os.Stderr, "between Kafka topics with kcat.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Example:
+  kcat -C -b kafka:9092 -G videodna jobs -f '%%s\n' -u -q |
+    videodna worker -key | kcat -P -b kafka:9092 -t fingerprints -K '\t'
+`)
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Error: worker reads jobs from stdin and takes no arguments")
+		fs.Usage()
+		os.Exit(1)
+	}
+	levels, err := fingerprint.ParseLevels(*levelsFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	budget := toolexec.SystemMemory() * 3 / 4
+	if *maxMemory != "" {
+		if budget, err = toolexec.ParseMemory(*maxMemory); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	toolexec.SetLimits(*maxProcs, budget)
+
+	var dashboard *progress.Dashboard
+	if *tui && !*silent {
+		if progress.IsTerminal(os.Stderr) {
+			dashboard = progress.New(os.Stderr, "videodna worker", 0)
+		} else {
+			fmt.Fprintln(os.Stderr, "Warning: -tui needs a terminal, printing a line per job")
+		}
+	}
+	dashboard.Start()
+
+	queue := make(chan workerJob)
+	var outMu sync.Mutex
+	out := bufio.NewWriter(os.Stdout)
+	var wg sync.WaitGroup
+	for w := 0; w < max(*jobs, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				record := runWorkerJob(job, levels, time.Duration(*timeout)*time.Second, dashboard)
+				data, _ := json.Marshal(record)
+				outMu.Lock()
+				if *keyed {
+					out.WriteString(strings.NewReplacer("\t", " ", "\n", " ").Replace(record.ID) + "\t")
+				}
+				out.Write(data)
+				out.WriteByte('\n')
+				out.Flush() // One message per line as soon as it is done
+				if !*silent && dashboard == nil {
+					if record.Error != "" {
+						fmt.Fprintf(os.Stderr, "Failed %s: %s\n", record.ID, record.Error)
+					} else {
+						fmt.Fprintf(os.Stderr, "Fingerprinted %s in %.1fs\n", record.ID, record.Elapsed)
+					}
+				}
+				outMu.Unlock()
+			}
+		}()
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		job := workerJob{Input: line}
+		if strings.HasPrefix(line, "{") {
+			job = workerJob{}
+			if err := json.Unmarshal([]byte(line), &job); err != nil || job.Input == "" {
+				fmt.Fprintf(os.Stderr, "Warning: skipping invalid job message: %.200s\n", line)
+				continue
+			}
+		}
+		if job.ID == "" {
+			job.ID = job.Input
+		}
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+	dashboard.Stop()
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read jobs: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runWorkerJob fingerprints the input of one job, shown on dashboard (nil =
+// none).
+func runWorkerJob(job workerJob, levels []int, timeout time.Duration, dashboard *progress.Dashboard) workerRecord {
+	start := time.Now()
+	record := workerRecord{ID: job.ID, Input: job.Input}
+	if len(job.Levels) > 0 {
+		levels = job.Levels
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	task := dashboard.Begin(job.ID)
+	fp, info, err := dna.ComputeFingerprint(progress.NewContext(ctx, task), job.Input, levels)
+	task.Done(err)
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.Fingerprint, record.Width, record.Height = fp, info.Width, info.Height
+	}
+	record.Elapsed = time.Since(start).Seconds()
+	return record
+}
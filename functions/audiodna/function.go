@@ -10,25 +10,69 @@
 // 1. Using -no-stems mode for lightweight waveform only
 // 2. Running stem separation as a separate container service
 // 3. Pre-separating stems and passing them to this function
+//
+// Teams sharing a deployment authenticate with their API key in the
+// Authorization header (Bearer KEY), which names their tenant in
+// VIDEODNA_TENANT_KEYS. Each tenant works in its own directory, and
+// VIDEODNA_TENANT_MAX_JOBS and VIDEODNA_TENANT_MINUTES_PER_DAY limit its
+// concurrent jobs and processed audio minutes per UTC day (answered with
+// 429 Too Many Requests).
+// VIDEODNA_PUBLISH_URL publishes a completion event per job (see package
+// publish). VIDEODNA_MAX_PROCS and VIDEODNA_MAX_MEMORY bound the ffmpeg and
+// demucs processes of all jobs together.
 package audiodna
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image/png"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pforret/videodna/internal/audio"
-	"github.com/pforret/github.com/pforret/videodna/internal/audiodna"
+	"github.com/pforret/videodna/internal/audiodna"
+	"github.com/pforret/videodna/internal/publish"
+	"github.com/pforret/videodna/internal/retry"
+	"github.com/pforret/videodna/internal/tenant"
+	"github.com/pforret/videodna/internal/toolexec"
+	"github.com/pforret/videodna/internal/workdir"
 )
 
+// keys authenticates the tenant of a request by its API key.
+var keys = sync.OnceValues(tenant.DefaultKeys)
+
+// limiter enforces the tenant quotas of the environment.
+var limiter = sync.OnceValues(func() (*tenant.Limiter, error) {
+	quota, err := tenant.DefaultQuota()
+	if err != nil {
+		return nil, err
+	}
+	return tenant.NewLimiter(quota), nil
+})
+
+// limits bounds the ffmpeg and demucs processes of concurrent requests by
+// VIDEODNA_MAX_PROCS and VIDEODNA_MAX_MEMORY.
+var limits = sync.OnceValue(toolexec.SetLimitsFromEnv)
+
+// publisher sends completion events to VIDEODNA_PUBLISH_URL (nil = unset).
+var publisher = sync.OnceValues(func() (publish.Publisher, error) {
+	url := os.Getenv(publish.EnvURL)
+	if url == "" {
+		return nil, nil
+	}
+	return publish.Open(url)
+})
+
 // Request is the Cloud Function request format.
 type Request struct {
 	// AudioURL is a URL to fetch the audio file from
@@ -40,6 +84,9 @@ type Request struct {
 	// Filename is the original filename (used for temp file extension)
 	Filename string `json:"filename,omitempty"`
 
+	// Tenant is the team the job runs for, authenticated by its API key
+	Tenant string `json:"-"`
+
 	// Options
 	Width      int  `json:"width,omitempty"`       // Output width (default: 1920)
 	StemHeight int  `json:"stem_height,omitempty"` // Height per stem (default: 50)
@@ -57,17 +104,34 @@ type Response struct {
 	ImageURL string `json:"image_url,omitempty"`
 
 	// Metadata
-	Duration   float64  `json:"duration"`
-	Stems      []string `json:"stems"`
-	Width      int      `json:"width"`
-	Height     int      `json:"height"`
+	Tenant   string   `json:"tenant,omitempty"`
+	Duration float64  `json:"duration"`
+	Stems    []string `json:"stems"`
+	Width    int      `json:"width"`
+	Height   int      `json:"height"`
 
 	// Error info
 	Error string `json:"error,omitempty"`
 }
 
-// HandleHTTP is the HTTP Cloud Function entry point.
+// HandleHTTP is the HTTP Cloud Function entry point. GET /openapi.json
+// returns its OpenAPI document (see Spec), GET /healthz and /readyz answer
+// liveness and readiness probes.
 func HandleHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		switch {
+		case strings.HasSuffix(r.URL.Path, SpecPath):
+			spec.ServeHTTP(w, r)
+			return
+		case strings.HasSuffix(r.URL.Path, HealthPath):
+			handleHealth(w, r)
+			return
+		case strings.HasSuffix(r.URL.Path, ReadyPath):
+			handleReady(w, r)
+			return
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
 	defer cancel()
 
@@ -85,10 +149,33 @@ func HandleHTTP(w http.ResponseWriter, r *http.Request) {
 		sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	k, err := keys()
+	if err != nil {
+		sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	id, err := k.Authenticate(r.Header.Get("Authorization"))
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		sendError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	req.Tenant = id
 
 	// Process
 	resp, err := Process(ctx, req)
-	if err != nil {
+	switch {
+	case errors.Is(err, tenant.ErrTooManyJobs):
+		w.Header().Set("Retry-After", "30")
+		sendError(w, err.Error(), http.StatusTooManyRequests)
+		return
+	case errors.Is(err, tenant.ErrDailyQuota):
+		if l, _ := limiter(); l != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(l.ResetIn().Seconds())+1))
+		}
+		sendError(w, err.Error(), http.StatusTooManyRequests)
+		return
+	case err != nil:
 		sendError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -97,8 +184,36 @@ func HandleHTTP(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
-// Process generates the audio DNA and returns the result.
+// Process generates the audio DNA and returns the result. The job counts
+// against the quotas of req.Tenant: it reserves the minutes of the audio
+// before rendering and is charged them when it fails. Its temp files live in
+// the tenant's work directory.
 func Process(ctx context.Context, req Request) (*Response, error) {
+	if err := limits(); err != nil {
+		return nil, err
+	}
+	id, err := tenant.ID(req.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	l, err := limiter()
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenant quota: %w", err)
+	}
+	job, err := l.Acquire(id)
+	if err != nil {
+		return nil, err
+	}
+	minutes := 0.0
+	defer func() { job.Done(minutes) }()
+
+	work, err := workdir.New(tenant.Dir("", id))
+	if err != nil {
+		return nil, err
+	}
+	defer work.Cleanup()
+	ctx = workdir.NewContext(ctx, work)
+
 	// Get audio data
 	audioPath, cleanup, err := getAudioFile(ctx, req)
 	if err != nil {
@@ -106,8 +221,23 @@ func Process(ctx context.Context, req Request) (*Response, error) {
 	}
 	defer cleanup()
 
+	// Book the minutes before the work, so concurrent jobs cannot overrun
+	// the quota together
+	info, err := audio.GetInfo(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+	if err := job.Reserve(info.Duration / 60); err != nil {
+		return nil, err
+	}
+	minutes = job.Reserved()
+
 	// Configure
 	config := audiodna.DefaultConfig()
+	if os.Getenv("TORCH_HOME") == "" {
+		// Shared by all tenants; the home directory may be read-only
+		config.StemConfig.TorchHome = filepath.Join(os.TempDir(), "torch")
+	}
 	if req.Width > 0 {
 		config.Width = req.Width
 	}
@@ -134,18 +264,18 @@ func Process(ctx context.Context, req Request) (*Response, error) {
 	if err != nil {
 		return nil, fmt.Errorf("generation failed: %w", err)
 	}
+	minutes = result.Duration / 60
 
 	// Encode image to base64
-	var imgBuf strings.Builder
-	b64Writer := base64.NewEncoder(base64.StdEncoding, &imgBuf)
-	if err := png.Encode(b64Writer, result.Image); err != nil {
+	var imgBuf bytes.Buffer
+	if err := png.Encode(&imgBuf, result.Image); err != nil {
 		return nil, fmt.Errorf("failed to encode image: %w", err)
 	}
-	b64Writer.Close()
 
 	// Build response
 	resp := &Response{
-		ImageBase64: imgBuf.String(),
+		ImageBase64: base64.StdEncoding.EncodeToString(imgBuf.Bytes()),
+		Tenant:      id,
 		Duration:    result.Duration,
 		Width:       result.Image.Bounds().Dx(),
 		Height:      result.Image.Bounds().Dy(),
@@ -155,6 +285,22 @@ func Process(ctx context.Context, req Request) (*Response, error) {
 		resp.Stems = append(resp.Stems, stem.Label)
 	}
 
+	if p, err := publisher(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else if p != nil {
+		input := req.AudioURL
+		if input == "" {
+			input = req.Filename
+		}
+		e := publish.NewEvent("audiodna", input)
+		e.Tenant = id
+		e.Fingerprint, _ = publish.Fingerprint(&imgBuf)
+		e.Stats = publish.Stats{Width: resp.Width, Height: resp.Height, Bytes: int64(imgBuf.Len()), Duration: resp.Duration}
+		if err := publish.Send(ctx, p, e); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
 	return resp, nil
 }
 
@@ -166,7 +312,7 @@ func getAudioFile(ctx context.Context, req Request) (string, func(), error) {
 	}
 
 	// Create temp file
-	tmpFile, err := os.CreateTemp("", "audiodna-*"+ext)
+	tmpFile, err := workdir.FromContext(ctx).CreateTemp("audiodna-*" + ext)
 	if err != nil {
 		return "", nil, err
 	}
@@ -189,25 +335,32 @@ func getAudioFile(ctx context.Context, req Request) (string, func(), error) {
 			return "", nil, err
 		}
 	} else if req.AudioURL != "" {
-		// Fetch from URL
-		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.AudioURL, nil)
-		if err != nil {
-			cleanup()
-			return "", nil, err
-		}
-		resp, err := http.DefaultClient.Do(httpReq)
-		if err != nil {
-			cleanup()
-			return "", nil, err
-		}
-		defer resp.Body.Close()
+		// Fetch from URL, retrying network and server errors
+		err := retry.DefaultPolicy().Do(ctx, "audio download", func(int) error {
+			if err := tmpFile.Truncate(0); err != nil {
+				return retry.Permanent(err)
+			}
+			if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+				return retry.Permanent(err)
+			}
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.AudioURL, nil)
+			if err != nil {
+				return retry.Permanent(err)
+			}
+			resp, err := http.DefaultClient.Do(httpReq)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			cleanup()
-			return "", nil, fmt.Errorf("failed to fetch audio: %s", resp.Status)
-		}
+			if resp.StatusCode != http.StatusOK {
+				return retry.HTTPStatus(resp, fmt.Errorf("failed to fetch audio: %s", resp.Status))
+			}
 
-		if _, err := io.Copy(tmpFile, resp.Body); err != nil {
+			_, err = io.Copy(tmpFile, resp.Body)
+			return err
+		})
+		if err != nil {
 			cleanup()
 			return "", nil, err
 		}
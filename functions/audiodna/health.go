@@ -0,0 +1,86 @@
+package audiodna
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/toolexec"
+	"github.com/pforret/videodna/internal/workdir"
+)
+
+// Probe paths served by HandleHTTP, e.g. for Kubernetes liveness and
+// readiness probes.
+const (
+	HealthPath = "/healthz"
+	ReadyPath  = "/readyz"
+)
+
+// Health is the response of the probe endpoints.
+type Health struct {
+	Status string            `json:"status"`           // "ok" or "unavailable"
+	Checks map[string]string `json:"checks,omitempty"` // Check name to "ok" or the failure
+}
+
+// check is one readiness check. Optional checks are reported but don't
+// make the function unready.
+type check struct {
+	name     string
+	optional bool
+	run      func() error
+}
+
+// readyChecks are what a request needs: the tools, a writable temp
+// directory, valid tenant quota, API keys and publish URL. Demucs is
+// optional since Process falls back to no stems without it.
+var readyChecks = []check{
+	{name: "ffmpeg", run: func() error { return toolexec.Available("ffmpeg") }},
+	{name: "ffprobe", run: func() error { return toolexec.Available("ffprobe") }},
+	{name: "tempdir", run: checkTempDir},
+	{name: "quota", run: func() error { _, err := limiter(); return err }},
+	{name: "keys", run: func() error { _, err := keys(); return err }},
+	{name: "publish", run: func() error { _, err := publisher(); return err }},
+	{name: "demucs", optional: true, run: func() error { return audio.CheckSeparatorAvailable(audio.SeparatorDemucs) }},
+}
+
+// checkTempDir creates and removes a work directory in the temp directory,
+// where the tenant work directories live.
+func checkTempDir() error {
+	work, err := workdir.New("")
+	if err != nil {
+		return err
+	}
+	_, err = work.Cleanup()
+	return err
+}
+
+// handleHealth answers HealthPath: the process is up and serving.
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	sendHealth(w, Health{Status: "ok"}, http.StatusOK)
+}
+
+// handleReady answers ReadyPath with the result of every readiness check,
+// 503 Service Unavailable when a required one fails.
+func handleReady(w http.ResponseWriter, r *http.Request) {
+	health := Health{Status: "ok", Checks: map[string]string{}}
+	code := http.StatusOK
+	for _, c := range readyChecks {
+		if err := c.run(); err != nil {
+			health.Checks[c.name] = err.Error()
+			if !c.optional {
+				health.Status = "unavailable"
+				code = http.StatusServiceUnavailable
+			}
+			continue
+		}
+		health.Checks[c.name] = "ok"
+	}
+	sendHealth(w, health, code)
+}
+
+func sendHealth(w http.ResponseWriter, health Health, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(health)
+}
@@ -0,0 +1,65 @@
+package audiodna
+
+import (
+	"net/http"
+
+	"github.com/pforret/videodna/internal/openapi"
+)
+
+// SpecPath is where HandleHTTP serves its OpenAPI document.
+const SpecPath = "/openapi.json"
+
+// spec is served at SpecPath.
+var spec = Spec()
+
+// Spec returns the OpenAPI 3 document of HandleHTTP. The schemas come from
+// Request and Response, so clients generated from it match the handler.
+func Spec() *openapi.Document {
+	doc := openapi.New("audiodna", "1.0.0",
+		"Renders the audio DNA of an audio file: one waveform lane per separated stem, returned as a base64 PNG.")
+	responses := map[string]openapi.Response{
+		"200": {Description: "The rendered audio DNA", Content: doc.JSON(Response{})},
+		"400": {Description: "Invalid JSON request", Content: doc.JSON(Response{})},
+		"401": {Description: "Missing or unknown API key", Content: doc.JSON(Response{})},
+		"429": {Description: "The tenant has too many jobs running or used up its daily minutes; see Retry-After", Content: doc.JSON(Response{})},
+		"500": {Description: "Fetching or rendering the audio failed", Content: doc.JSON(Response{})},
+	}
+	tenantParam := openapi.Parameter{Name: "Authorization", In: "header", Description: "Bearer API key of the tenant the job runs for (required when VIDEODNA_TENANT_KEYS is set)", Schema: &openapi.Schema{Type: "string"}}
+
+	doc.Add(http.MethodPost, "/", &openapi.Operation{
+		OperationID: "generate",
+		Summary:     "Render the audio DNA of an audio URL or base64 upload",
+		Parameters:  []openapi.Parameter{tenantParam},
+		RequestBody: &openapi.RequestBody{Required: true, Content: doc.JSON(Request{})},
+		Responses:   responses,
+	})
+	doc.Add(http.MethodGet, "/", &openapi.Operation{
+		OperationID: "generateFromURL",
+		Summary:     "Render the audio DNA of an audio URL",
+		Parameters: []openapi.Parameter{
+			{Name: "url", In: "query", Required: true, Description: "Audio file to fetch", Schema: &openapi.Schema{Type: "string", Format: "uri"}},
+			{Name: "no_stems", In: "query", Description: "true skips stem separation", Schema: &openapi.Schema{Type: "boolean"}},
+			tenantParam,
+		},
+		Responses: responses,
+	})
+	doc.Add(http.MethodGet, HealthPath, &openapi.Operation{
+		OperationID: "health",
+		Summary:     "Liveness probe",
+		Responses:   map[string]openapi.Response{"200": {Description: "The function is up", Content: doc.JSON(Health{})}},
+	})
+	doc.Add(http.MethodGet, ReadyPath, &openapi.Operation{
+		OperationID: "ready",
+		Summary:     "Readiness probe: ffmpeg, ffprobe, a writable temp directory, valid tenant quota, API keys and publish settings",
+		Responses: map[string]openapi.Response{
+			"200": {Description: "The function can serve requests", Content: doc.JSON(Health{})},
+			"503": {Description: "A required check failed", Content: doc.JSON(Health{})},
+		},
+	})
+	doc.Add(http.MethodGet, SpecPath, &openapi.Operation{
+		OperationID: "openapi",
+		Summary:     "This OpenAPI document",
+		Responses:   map[string]openapi.Response{"200": {Description: "OpenAPI 3 document"}},
+	})
+	return doc
+}
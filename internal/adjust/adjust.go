@@ -0,0 +1,78 @@
+// Package adjust provides presentation adjustments of rendered DNA:
+// saturation, vibrance, contrast and brightness. They change the picture
+// only; fingerprints, reports and other exported data are computed before
+// them.
+package adjust
+
+import (
+	"image"
+)
+
+// Options selects adjustments. Each is a change, so the zero value is the
+// identity.
+type Options struct {
+	Saturation float64 // Chroma change: -1 = gray, 0.5 = +50% (-1 to 3)
+	Vibrance   float64 // Like Saturation, weighted to muted colors (-1 to 3)
+	Contrast   float64 // Spread around mid gray: -1 = flat gray, 0.5 = +50% (-1 to 3)
+	Brightness float64 // Added to every channel, as a share of full scale (-1 to 1)
+}
+
+// IsZero reports whether no adjustment is selected.
+func (o Options) IsZero() bool {
+	return o == Options{}
+}
+
+// Valid reports whether every adjustment is in range.
+func (o Options) Valid() bool {
+	in := func(v, lo, hi float64) bool { return v >= lo && v <= hi }
+	return in(o.Saturation, -1, 3) && in(o.Vibrance, -1, 3) && in(o.Contrast, -1, 3) && in(o.Brightness, -1, 1)
+}
+
+// Apply adjusts rect of img in place: 8-bit *image.RGBA or 16-bit
+// *image.RGBA64; other images are left as they are.
+func (o Options) Apply(img image.Image, rect image.Rectangle) {
+	if o.IsZero() {
+		return
+	}
+	rect = rect.Intersect(img.Bounds())
+	switch img := img.(type) {
+	case *image.RGBA:
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				p := img.Pix[img.PixOffset(x, y):]
+				r, g, b := o.color(float64(p[0])/255, float64(p[1])/255, float64(p[2])/255)
+				p[0], p[1], p[2] = uint8(r*255+0.5), uint8(g*255+0.5), uint8(b*255+0.5)
+			}
+		}
+	case *image.RGBA64:
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				p := img.Pix[img.PixOffset(x, y):]
+				get := func(i int) float64 { return float64(uint16(p[i])<<8|uint16(p[i+1])) / 0xffff }
+				set := func(i int, v float64) {
+					u := uint16(v*0xffff + 0.5)
+					p[i], p[i+1] = uint8(u>>8), uint8(u)
+				}
+				r, g, b := o.color(get(0), get(2), get(4))
+				set(0, r)
+				set(2, g)
+				set(4, b)
+			}
+		}
+	}
+}
+
+// color adjusts one color (channels 0-1): saturation and vibrance around
+// its Rec. 709 luma, then contrast and brightness. Results are clipped to
+// 0-1.
+func (o Options) color(r, g, b float64) (float64, float64, float64) {
+	luma := 0.2126*r + 0.7152*g + 0.0722*b
+	chroma := max(r, g, b) - min(r, g, b)
+	k := (1 + o.Saturation) * (1 + o.Vibrance*(1-chroma))
+	adjust := func(v float64) float64 {
+		v = luma + (v-luma)*k
+		v = 0.5 + (v-0.5)*(1+o.Contrast)
+		return min(max(v+o.Brightness, 0), 1)
+	}
+	return adjust(r), adjust(g), adjust(b)
+}
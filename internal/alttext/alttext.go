@@ -0,0 +1,283 @@
+// Package alttext writes a text alternative of a DNA image: a short alt
+// text and a description of its structure over time (dominant colors of a
+// video, loud and quiet passages of audio), as JSON for catalogs or as an
+// HTML figure for screen readers.
+package alttext
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Spans is the number of equal time spans a DNA is described in before
+// neighbours that read the same are merged.
+const Spans = 12
+
+// Description is the text alternative of a DNA image.
+type Description struct {
+	Image    string  `json:"image"` // Described image file name
+	Kind     string  `json:"kind"`  // "video" or "audio"
+	Title    string  `json:"title"`
+	Duration float64 `json:"duration"` // Seconds
+	Alt      string  `json:"alt"`      // One sentence, for the alt attribute
+	Summary  string  `json:"summary"`  // Alt plus every span, for a long description
+	Spans    []Span  `json:"spans"`
+}
+
+// Span is a stretch of time that reads the same in the DNA.
+type Span struct {
+	Start float64 `json:"start"` // Seconds
+	End   float64 `json:"end"`
+	Text  string  `json:"text"` // e.g. "dark blue", "loud, led by drums"
+
+	Color     string `json:"color,omitempty"`      // Video: average color as #rrggbb
+	ColorName string `json:"color_name,omitempty"` // Video: e.g. "dark blue"
+	Level     string `json:"level,omitempty"`      // Audio: silent, quiet, moderate or loud
+	Lead      string `json:"lead,omitempty"`       // Audio: loudest stem, when there are several
+}
+
+// CheckPath reports an error if path is not a .json or .html description,
+// so callers can fail before a long run.
+func CheckPath(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm", ".json":
+		return nil
+	}
+	return fmt.Errorf("unknown alt text format %q, use .html or .json", filepath.Ext(path))
+}
+
+// Video describes the DNA image at imagePath from the average colors of
+// equal time spans, in time order.
+func Video(imagePath, title string, duration float64, colors []color.RGBA) *Description {
+	var spans []Span
+	for i, c := range colors {
+		name := ColorName(c)
+		span := Span{
+			Start:     duration * float64(i) / float64(len(colors)),
+			End:       duration * float64(i+1) / float64(len(colors)),
+			Text:      name,
+			Color:     fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B),
+			ColorName: name,
+		}
+		if n := len(spans); n > 0 && spans[n-1].ColorName == name {
+			// The merged span keeps the color of its first part
+			spans[n-1].End = span.End
+			continue
+		}
+		spans = append(spans, span)
+	}
+
+	d := &Description{Image: imageName(imagePath), Kind: "video", Title: title, Duration: duration, Spans: spans}
+	d.Alt = fmt.Sprintf("Video DNA of %s (%s): %s.", title, Clock(duration), sequence("colors", spans))
+	d.Summary = summary(d.Alt, "Time runs along the image from start to end, each column (row when vertical) a moment of the video.", spans)
+	return d
+}
+
+// Audio describes the DNA image at imagePath from the mean RMS of equal
+// time spans of the mix, in time order. lead names the loudest stem of each
+// span (nil with a single stem).
+func Audio(imagePath, title string, duration float64, levels []float64, lead []string) *Description {
+	loudest := 0.0
+	for _, v := range levels {
+		loudest = max(loudest, v)
+	}
+
+	var spans []Span
+	for i, v := range levels {
+		span := Span{
+			Start: duration * float64(i) / float64(len(levels)),
+			End:   duration * float64(i+1) / float64(len(levels)),
+			Level: Level(v, loudest),
+		}
+		if i < len(lead) && span.Level != "silent" {
+			span.Lead = lead[i]
+		}
+		span.Text = span.Level
+		if span.Lead != "" {
+			span.Text += ", led by " + span.Lead
+		}
+		if n := len(spans); n > 0 && spans[n-1].Text == span.Text {
+			spans[n-1].End = span.End
+			continue
+		}
+		spans = append(spans, span)
+	}
+
+	d := &Description{Image: imageName(imagePath), Kind: "audio", Title: title, Duration: duration, Spans: spans}
+	d.Alt = fmt.Sprintf("Audio DNA of %s (%s): %s.", title, Clock(duration), sequence("loudness", spans))
+	d.Summary = summary(d.Alt, "Waveforms run from left to right; taller means louder.", spans)
+	return d
+}
+
+// imageName is the file name of the image ("" when written to stdout or
+// not at all).
+func imageName(path string) string {
+	if path == "" || path == "-" {
+		return ""
+	}
+	return filepath.Base(path)
+}
+
+// Level names a mean RMS relative to the loudest span: loud within 6 dB,
+// moderate within 18 dB, quiet within 40 dB, silent below.
+func Level(rms, loudest float64) string {
+	if rms <= 0 || loudest <= 0 {
+		return "silent"
+	}
+	switch db := 20 * math.Log10(rms/loudest); {
+	case db >= -6:
+		return "loud"
+	case db >= -18:
+		return "moderate"
+	case db >= -40:
+		return "quiet"
+	}
+	return "silent"
+}
+
+// ColorName names a color in plain words: a hue (red, orange, yellow,
+// green, cyan, blue, purple, pink, brown) or a gray, with "dark", "light"
+// or "pale" when it helps.
+func ColorName(c color.RGBA) string {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	hi, lo := max(r, g, b), min(r, g, b)
+	l := (hi + lo) / 2
+	s := 0.0
+	if hi > lo {
+		s = (hi - lo) / (1 - math.Abs(2*l-1))
+	}
+
+	if s < 0.15 || hi-lo < 0.06 {
+		switch {
+		case l < 0.12:
+			return "black"
+		case l < 0.35:
+			return "dark gray"
+		case l < 0.7:
+			return "gray"
+		case l < 0.92:
+			return "light gray"
+		}
+		return "white"
+	}
+
+	var h float64
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/(hi-lo), 6)
+	case g:
+		h = (b-r)/(hi-lo) + 2
+	default:
+		h = (r-g)/(hi-lo) + 4
+	}
+	h = math.Mod(h*60+360, 360)
+
+	var hue string
+	switch {
+	case h < 15 || h >= 340:
+		hue = "red"
+	case h < 45:
+		hue = "orange"
+	case h < 70:
+		hue = "yellow"
+	case h < 160:
+		hue = "green"
+	case h < 200:
+		hue = "cyan"
+	case h < 255:
+		hue = "blue"
+	case h < 290:
+		hue = "purple"
+	default:
+		hue = "pink"
+	}
+	if hue == "orange" && l < 0.4 {
+		return "brown"
+	}
+	if hue == "red" && l > 0.65 {
+		return "pink"
+	}
+
+	switch {
+	case l < 0.1:
+		return "black"
+	case l < 0.3:
+		return "dark " + hue
+	case l > 0.8:
+		return "pale " + hue
+	case l > 0.65:
+		return "light " + hue
+	}
+	return hue
+}
+
+// Clock formats seconds as M:SS, or H:MM:SS from an hour.
+func Clock(seconds float64) string {
+	s := int(seconds + 0.5)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// sequence lists the texts of spans, the short form of the alt text.
+func sequence(what string, spans []Span) string {
+	switch len(spans) {
+	case 0:
+		return "no content"
+	case 1:
+		return spans[0].Text + " throughout"
+	}
+	texts := make([]string, len(spans))
+	for i, s := range spans {
+		texts[i] = s.Text
+	}
+	return what + " from start to end: " + strings.Join(texts, "; ")
+}
+
+// summary is the alt text, how to read the image and one sentence per span.
+func summary(alt, reading string, spans []Span) string {
+	lines := []string{alt, reading}
+	for _, s := range spans {
+		lines = append(lines, fmt.Sprintf("%s to %s: %s.", Clock(s.Start), Clock(s.End), s.Text))
+	}
+	return strings.Join(lines, " ")
+}
+
+// Write writes d as JSON, or as an HTML figure: the image with the alt
+// text and a caption listing the spans.
+func Write(path string, d *Description) error {
+	if err := CheckPath(path); err != nil {
+		return err
+	}
+
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		out, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode alt text: %w", err)
+		}
+		data = append(out, '\n')
+	} else {
+		var b strings.Builder
+		fmt.Fprintf(&b, "<figure>\n  <img src=\"%s\" alt=\"%s\">\n", html.EscapeString(d.Image), html.EscapeString(d.Alt))
+		fmt.Fprintf(&b, "  <figcaption>\n    <p>%s</p>\n    <ol>\n", html.EscapeString(d.Title))
+		for _, s := range d.Spans {
+			fmt.Fprintf(&b, "      <li><time datetime=\"PT%.3fS\">%s</time> to <time datetime=\"PT%.3fS\">%s</time>: %s</li>\n",
+				s.Start, Clock(s.Start), s.End, Clock(s.End), html.EscapeString(s.Text))
+		}
+		b.WriteString("    </ol>\n  </figcaption>\n</figure>\n")
+		data = []byte(b.String())
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write alt text: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+// Package archive writes the archival bundle of a run: the DNA image, its
+// reports and sidecars, and a manifest recording checksums, the tool
+// version, the command line and the environment, in one .zip or .tar file
+// for long-term preservation workflows.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pforret/videodna/internal/checksum"
+	"github.com/pforret/videodna/internal/toolexec"
+	"github.com/pforret/videodna/internal/workdir"
+)
+
+// Format identifies the manifest of a bundle.
+const Format = "videodna-archive"
+
+// Version is the bundle layout version written to new manifests.
+const Version = 1
+
+// Names inside the bundle. Files are stored under dataDir, as in BagIt.
+const (
+	manifestName = "manifest.json"
+	sumsName     = "manifest-sha256.txt" // sha256sum -c compatible
+	dataDir      = "data/"
+)
+
+// Manifest describes a bundle and how its content was made.
+type Manifest struct {
+	Format      string         `json:"format"`
+	Version     int            `json:"version"`
+	Created     string         `json:"created"` // RFC 3339, UTC
+	Tool        string         `json:"tool"`
+	ToolVersion string         `json:"tool_version"`
+	CommandLine []string       `json:"command_line"`
+	Environment Environment    `json:"environment"`
+	Input       string         `json:"input"`
+	InputSums   *checksum.Sums `json:"input_checksums,omitempty"` // Nil when the input is not a local file
+	Files       []File         `json:"files"`
+}
+
+// Environment is where the run happened. Environment variables are not
+// recorded, since they may hold credentials.
+type Environment struct {
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	GoVersion string `json:"go_version"`
+	Hostname  string `json:"hostname,omitempty"`
+	FFmpeg    string `json:"ffmpeg,omitempty"` // First line of ffmpeg -version
+}
+
+// File is a file of the bundle.
+type File struct {
+	Path     string `json:"path"` // Inside the bundle
+	Role     string `json:"role"` // e.g. "image", "report", "fingerprint"
+	Bytes    int64  `json:"bytes"`
+	SHA256   string `json:"sha256"`
+	Modified string `json:"modified"` // RFC 3339, UTC
+}
+
+// CheckPath reports an error if path has no supported bundle extension, so
+// callers can fail before a long run.
+func CheckPath(path string) error {
+	switch bundleFormat(path) {
+	case "zip", "tar", "tgz":
+		return nil
+	}
+	return fmt.Errorf("unknown archive format %q, use .zip, .tar or .tar.gz", filepath.Ext(path))
+}
+
+func bundleFormat(path string) string {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tgz"
+	}
+	return ""
+}
+
+// Bundle collects the files of a run.
+type Bundle struct {
+	Tool        string   // "videodna" or "audiodna"
+	ToolVersion string   // Version of the tool
+	CommandLine []string // os.Args of the run
+	Input       string
+
+	files []entry
+}
+
+type entry struct {
+	path, role string
+}
+
+// Add adds the file at path with a role. Empty paths, missing files and
+// directories (tile pyramids) are skipped, so callers can add every
+// optional output.
+func (b *Bundle) Add(path, role string) {
+	if path == "" {
+		return
+	}
+	if st, err := os.Stat(path); err != nil || !st.Mode().IsRegular() {
+		return
+	}
+	b.files = append(b.files, entry{path, role})
+}
+
+// Write writes the bundle to dst; the format follows the extension (.zip,
+// .tar, .tar.gz or .tgz). It returns the manifest.
+func (b *Bundle) Write(dst string) (*Manifest, error) {
+	if err := CheckPath(dst); err != nil {
+		return nil, err
+	}
+	m := &Manifest{
+		Format:      Format,
+		Version:     Version,
+		Created:     time.Now().UTC().Format(time.RFC3339),
+		Tool:        b.Tool,
+		ToolVersion: b.ToolVersion,
+		CommandLine: b.CommandLine,
+		Environment: environment(),
+		Input:       b.Input,
+	}
+	if sums, err := checksum.Compute(b.Input, false); err == nil {
+		m.InputSums = sums
+	}
+
+	// Unique names inside data/, in the order added
+	used := map[string]bool{}
+	names := make([]string, len(b.files))
+	for i, e := range b.files {
+		name := filepath.Base(e.path)
+		for n := 2; used[name]; n++ {
+			ext := filepath.Ext(e.path)
+			name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(filepath.Base(e.path), ext), n, ext)
+		}
+		used[name] = true
+		names[i] = dataDir + name
+
+		st, err := os.Stat(e.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to archive %s: %w", e.path, err)
+		}
+		sums, err := checksum.Compute(e.path, false)
+		if err != nil {
+			return nil, err
+		}
+		m.Files = append(m.Files, File{
+			Path:     names[i],
+			Role:     e.role,
+			Bytes:    st.Size(),
+			SHA256:   sums.SHA256,
+			Modified: st.ModTime().UTC().Format(time.RFC3339),
+		})
+	}
+
+	manifest, _ := json.MarshalIndent(m, "", "  ")
+	var sums strings.Builder
+	for _, f := range m.Files {
+		fmt.Fprintf(&sums, "%s  %s\n", f.SHA256, f.Path)
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	w := newWriter(out, bundleFormat(dst))
+	err = w.add(manifestName, append(manifest, '\n'))
+	if err == nil {
+		err = w.add(sumsName, []byte(sums.String()))
+	}
+	for i := 0; err == nil && i < len(b.files); i++ {
+		err = w.addFile(names[i], b.files[i].path)
+	}
+	if err == nil {
+		err = w.close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return m, nil
+}
+
+// environment describes the machine and the ffmpeg build.
+func environment() Environment {
+	env := Environment{OS: runtime.GOOS, Arch: runtime.GOARCH, GoVersion: runtime.Version()}
+	env.Hostname, _ = os.Hostname()
+	if out, err := toolexec.Command(context.Background(), "ffmpeg", "-version").Output(); err == nil {
+		if line, _, _ := strings.Cut(string(out), "\n"); strings.HasPrefix(line, "ffmpeg version") {
+			env.FFmpeg = strings.TrimSpace(line)
+		}
+	}
+	return env
+}
+
+// writer adds files to a zip or tar stream.
+type writer struct {
+	zip *zip.Writer
+	tar *tar.Writer
+	gz  *gzip.Writer
+	buf *bufio.Writer
+}
+
+func newWriter(out io.Writer, format string) *writer {
+	w := &writer{buf: bufio.NewWriter(out)}
+	switch format {
+	case "zip":
+		w.zip = zip.NewWriter(w.buf)
+	case "tgz":
+		w.gz = gzip.NewWriter(w.buf)
+		w.tar = tar.NewWriter(w.gz)
+	default:
+		w.tar = tar.NewWriter(w.buf)
+	}
+	return w
+}
+
+// add stores data under name, dated now.
+func (w *writer) add(name string, data []byte) error {
+	dst, err := w.create(name, int64(len(data)), time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = dst.Write(data)
+	return err
+}
+
+// addFile stores the file at path under name.
+func (w *writer) addFile(name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := w.create(name, st.Size(), st.ModTime())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, f)
+	return err
+}
+
+func (w *writer) create(name string, size int64, modified time.Time) (io.Writer, error) {
+	if w.zip != nil {
+		return w.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	}
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: size, ModTime: modified, Format: tar.FormatPAX}
+	if err := w.tar.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+	return w.tar, nil
+}
+
+func (w *writer) close() error {
+	var err error
+	if w.zip != nil {
+		err = w.zip.Close()
+	} else {
+		err = w.tar.Close()
+	}
+	if w.gz != nil && err == nil {
+		err = w.gz.Close()
+	}
+	if err == nil {
+		err = w.buf.Flush()
+	}
+	return err
+}
+
+// ReportPath returns where the JSON report of an archived run goes: the
+// requested report, or report.json in a temp dir of work removed by
+// cleanup, since a bundle always holds the report.
+func ReportPath(work *workdir.Manager, requested string) (path string, cleanup func(), err error) {
+	if requested != "" {
+		return requested, func() {}, nil
+	}
+	dir, err := work.MkdirTemp("videodna-archive-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	return filepath.Join(dir, "report.json"), func() { os.RemoveAll(dir) }, nil
+}
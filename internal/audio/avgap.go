@@ -0,0 +1,42 @@
+package audio
+
+import "math"
+
+// avGapTolerance is the largest audio/video duration difference in seconds
+// that is not flagged (about one frame at low frame rates plus encoder
+// priming).
+const avGapTolerance = 0.2
+
+// A/V gap issues.
+const (
+	GapAudioShort = "audio-short" // Audio ends before the video: missing audio tail
+	GapAudioLong  = "audio-long"  // Audio runs past the end of the video
+)
+
+// AVGap compares the audio and video stream durations of a container.
+type AVGap struct {
+	AudioDuration float64 `json:"audio_duration"`
+	VideoDuration float64 `json:"video_duration"`
+	Difference    float64 `json:"difference"`      // Audio minus video duration in seconds
+	Issue         string  `json:"issue,omitempty"` // GapAudioShort, GapAudioLong or empty when within tolerance
+}
+
+// CheckAVGap returns the A/V duration comparison of a probed video file, or
+// nil when the file has no video stream or the durations are unknown.
+func CheckAVGap(info *Info) *AVGap {
+	if info.VideoDuration <= 0 || info.AudioDuration <= 0 {
+		return nil
+	}
+	gap := &AVGap{
+		AudioDuration: info.AudioDuration,
+		VideoDuration: info.VideoDuration,
+		Difference:    math.Round((info.AudioDuration-info.VideoDuration)*1000) / 1000,
+	}
+	switch {
+	case gap.Difference < -avGapTolerance:
+		gap.Issue = GapAudioShort
+	case gap.Difference > avGapTolerance:
+		gap.Issue = GapAudioLong
+	}
+	return gap
+}
@@ -0,0 +1,107 @@
+package audio
+
+import (
+	"math"
+)
+
+// compareHop is the chroma resolution used for version comparison.
+const compareHop = 0.5
+
+// ChromaMatch is the result of comparing two tracks by harmonic content.
+type ChromaMatch struct {
+	Similarity float64 `json:"similarity"` // 1 - mean aligned chroma distance (0.0 to 1.0)
+	Transpose  int     `json:"transpose"`  // Semitones the second track is shifted up to match the first
+	PathLength int     `json:"path_length"`
+}
+
+// CompareChroma scores whether two tracks are versions of the same
+// composition. The second track is transposed to the key that best matches
+// the first (global chroma profile), then aligned with dynamic time warping
+// so tempo differences and local timing changes do not count against it.
+func CompareChroma(a, b *WaveformData) ChromaMatch {
+	return CompareChromaFeatures(Chroma(a, compareHop), Chroma(b, compareHop))
+}
+
+// CompareChromaFeatures compares two chroma sequences (see CompareChroma).
+func CompareChromaFeatures(a, b [][]float64) ChromaMatch {
+	if len(a) == 0 || len(b) == 0 {
+		return ChromaMatch{}
+	}
+
+	// Optimal transposition index: rotation that best aligns mean profiles
+	profileA, profileB := meanProfile(a), meanProfile(b)
+	transpose, best := 0, -1.0
+	for shift := 0; shift < 12; shift++ {
+		if s := cosine(profileA, rotateChroma(profileB, shift)); s > best {
+			transpose, best = shift, s
+		}
+	}
+	shifted := make([][]float64, len(b))
+	for i, v := range b {
+		shifted[i] = rotateChroma(v, transpose)
+	}
+
+	cost, length := dtw(a, shifted)
+	if transpose > 6 {
+		transpose -= 12
+	}
+	return ChromaMatch{
+		Similarity: math.Max(0, 1-cost/float64(length)),
+		Transpose:  transpose,
+		PathLength: length,
+	}
+}
+
+// dtw aligns two sequences with cosine distance and returns the total cost
+// and length of the optimal warping path.
+func dtw(a, b [][]float64) (float64, int) {
+	n, m := len(a), len(b)
+	inf := math.Inf(1)
+	prevCost := make([]float64, m+1)
+	prevLen := make([]int, m+1)
+	curCost := make([]float64, m+1)
+	curLen := make([]int, m+1)
+	for j := 1; j <= m; j++ {
+		prevCost[j] = inf
+	}
+
+	for i := 1; i <= n; i++ {
+		curCost[0] = inf
+		for j := 1; j <= m; j++ {
+			d := 1 - cosine(a[i-1], b[j-1])
+			// Diagonal, vertical, horizontal predecessors
+			c, l := prevCost[j-1], prevLen[j-1]
+			if prevCost[j] < c {
+				c, l = prevCost[j], prevLen[j]
+			}
+			if curCost[j-1] < c {
+				c, l = curCost[j-1], curLen[j-1]
+			}
+			curCost[j], curLen[j] = c+d, l+1
+		}
+		prevCost, curCost = curCost, prevCost
+		prevLen, curLen = curLen, prevLen
+	}
+	return prevCost[m], prevLen[m]
+}
+
+// meanProfile returns the normalized mean of chroma vectors.
+func meanProfile(chroma [][]float64) []float64 {
+	mean := make([]float64, 12)
+	for _, v := range chroma {
+		for k := range mean {
+			mean[k] += v[k]
+		}
+	}
+	normalize(mean)
+	return mean
+}
+
+// rotateChroma shifts a chroma vector up by shift semitones.
+func rotateChroma(v []float64, shift int) []float64 {
+	out := make([]float64, 12)
+	for k := range v {
+		out[(k+shift)%12] = v[k]
+	}
+	return out
+}
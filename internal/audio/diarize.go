@@ -0,0 +1,281 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiarizerType represents the speaker diarization backend.
+type DiarizerType string
+
+const (
+	DiarizerCluster  DiarizerType = "cluster"  // Built-in MFCC k-means clustering
+	DiarizerPyannote DiarizerType = "pyannote" // pyannote.audio via python3 subprocess
+)
+
+// Diarization parameters for the built-in clusterer.
+const (
+	diarizeHop       = 0.5  // Analysis window in seconds
+	voiceLevel       = 0.01 // -40 dBFS RMS counts as speech
+	minTurnSeconds   = 1.0  // Shorter turns are merged into their neighbours
+	defaultSpeakers  = 2
+	kmeansIterations = 20
+)
+
+// DiarizeConfig configures speaker diarization.
+type DiarizeConfig struct {
+	Diarizer DiarizerType
+	Speakers int // Number of speakers (0 = default 2 for cluster, auto for pyannote)
+}
+
+// SpeakerTurn is a span of speech by one speaker.
+type SpeakerTurn struct {
+	Speaker string  `json:"speaker"`
+	Start   float64 `json:"start"` // Seconds
+	End     float64 `json:"end"`   // Seconds
+}
+
+// Diarize splits speech into speaker turns. The waveform is used by the
+// built-in clusterer; pyannote reads inputPath itself.
+func Diarize(ctx context.Context, inputPath string, waveform *WaveformData, config DiarizeConfig) ([]SpeakerTurn, error) {
+	switch config.Diarizer {
+	case DiarizerCluster:
+		return clusterSpeakers(waveform, config.Speakers), nil
+	case DiarizerPyannote:
+		return diarizeWithPyannote(ctx, inputPath, config.Speakers)
+	default:
+		return nil, fmt.Errorf("unknown diarizer: %s", config.Diarizer)
+	}
+}
+
+// clusterSpeakers groups voiced windows by timbre (MFCC c1-c12) with k-means.
+// It works for interviews and panels with distinct voices; overlapping speech
+// is assigned to a single speaker.
+func clusterSpeakers(waveform *WaveformData, speakers int) []SpeakerTurn {
+	if speakers <= 0 {
+		speakers = defaultSpeakers
+	}
+
+	mfcc := MFCC(waveform, diarizeHop, 13)
+	mono := monoSamples(waveform)
+	hop := int(diarizeHop * float64(waveform.SampleRate))
+
+	var voiced []int
+	var features [][]float64
+	for i, coeffs := range mfcc {
+		start := i * hop
+		end := start + hop
+		if end > len(mono) {
+			end = len(mono)
+		}
+		var sum float64
+		for _, v := range mono[start:end] {
+			sum += v * v
+		}
+		if end > start && math.Sqrt(sum/float64(end-start)) >= voiceLevel {
+			voiced = append(voiced, i)
+			features = append(features, coeffs[1:])
+		}
+	}
+	if len(features) == 0 {
+		return nil
+	}
+
+	labels := kmeans(features, speakers)
+
+	// Per-window speaker (-1 = silence), smoothed with a majority filter
+	windows := make([]int, len(mfcc))
+	for i := range windows {
+		windows[i] = -1
+	}
+	for j, i := range voiced {
+		windows[i] = labels[j]
+	}
+	windows = majorityFilter(windows, 2)
+
+	// Name speakers in order of first appearance
+	names := map[int]string{}
+	var turns []SpeakerTurn
+	for i, label := range windows {
+		if label < 0 {
+			continue
+		}
+		name, ok := names[label]
+		if !ok {
+			name = fmt.Sprintf("spk%d", len(names)+1)
+			names[label] = name
+		}
+		start := float64(i) * diarizeHop
+		if n := len(turns); n > 0 && turns[n-1].Speaker == name && turns[n-1].End >= start {
+			turns[n-1].End = start + diarizeHop
+			continue
+		}
+		turns = append(turns, SpeakerTurn{Speaker: name, Start: start, End: start + diarizeHop})
+	}
+	return mergeShortTurns(turns)
+}
+
+// kmeans clusters vectors into k groups using farthest-point initialization,
+// so results are deterministic.
+func kmeans(vectors [][]float64, k int) []int {
+	if k > len(vectors) {
+		k = len(vectors)
+	}
+	centroids := [][]float64{append([]float64(nil), vectors[0]...)}
+	for len(centroids) < k {
+		far, farDist := 0, -1.0
+		for i, v := range vectors {
+			d := math.Inf(1)
+			for _, c := range centroids {
+				d = math.Min(d, squaredDistance(v, c))
+			}
+			if d > farDist {
+				far, farDist = i, d
+			}
+		}
+		centroids = append(centroids, append([]float64(nil), vectors[far]...))
+	}
+
+	labels := make([]int, len(vectors))
+	for iter := 0; iter < kmeansIterations; iter++ {
+		changed := false
+		for i, v := range vectors {
+			best, bestDist := 0, math.Inf(1)
+			for c, centroid := range centroids {
+				if d := squaredDistance(v, centroid); d < bestDist {
+					best, bestDist = c, d
+				}
+			}
+			if labels[i] != best {
+				labels[i], changed = best, true
+			}
+		}
+		if !changed && iter > 0 {
+			break
+		}
+
+		counts := make([]int, k)
+		for c := range centroids {
+			for d := range centroids[c] {
+				centroids[c][d] = 0
+			}
+		}
+		for i, v := range vectors {
+			counts[labels[i]]++
+			for d, x := range v {
+				centroids[labels[i]][d] += x
+			}
+		}
+		for c := range centroids {
+			for d := range centroids[c] {
+				if counts[c] > 0 {
+					centroids[c][d] /= float64(counts[c])
+				}
+			}
+		}
+	}
+	return labels
+}
+
+func squaredDistance(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+// majorityFilter replaces each voiced label with the most common voiced
+// label within ±radius windows. Silence (-1) is left untouched.
+func majorityFilter(labels []int, radius int) []int {
+	out := make([]int, len(labels))
+	for i, label := range labels {
+		out[i] = label
+		if label < 0 {
+			continue
+		}
+		counts := map[int]int{}
+		for j := i - radius; j <= i+radius; j++ {
+			if j >= 0 && j < len(labels) && labels[j] >= 0 {
+				counts[labels[j]]++
+			}
+		}
+		for l, n := range counts {
+			if n > counts[out[i]] || (n == counts[out[i]] && l < out[i]) {
+				out[i] = l
+			}
+		}
+	}
+	return out
+}
+
+// mergeShortTurns folds turns shorter than minTurnSeconds into the previous
+// turn (or the next one for a leading short turn).
+func mergeShortTurns(turns []SpeakerTurn) []SpeakerTurn {
+	var merged []SpeakerTurn
+	for _, t := range turns {
+		n := len(merged)
+		switch {
+		case n > 0 && merged[n-1].Speaker == t.Speaker && t.Start-merged[n-1].End < minTurnSeconds:
+			merged[n-1].End = t.End
+		case n > 0 && t.End-t.Start < minTurnSeconds:
+			merged[n-1].End = math.Max(merged[n-1].End, t.End)
+		default:
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
+
+// pyannoteScript runs the pyannote pipeline and prints "start end speaker"
+// lines. The Hugging Face token is read from HF_TOKEN.
+const pyannoteScript = `
+import os, sys
+from pyannote.audio import Pipeline
+pipeline = Pipeline.from_pretrained("pyannote/speaker-diarization-3.1", use_auth_token=os.environ.get("HF_TOKEN"))
+kwargs = {"num_speakers": int(sys.argv[2])} if int(sys.argv[2]) > 0 else {}
+for turn, _, speaker in pipeline(sys.argv[1], **kwargs).itertracks(yield_label=True):
+    print(f"{turn.start:.3f} {turn.end:.3f} {speaker}")
+`
+
+func diarizeWithPyannote(ctx context.Context, inputPath string, speakers int) ([]SpeakerTurn, error) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		return nil, fmt.Errorf("python3 not found in PATH. Install pyannote with: pip install pyannote.audio")
+	}
+
+	cmd := exec.CommandContext(ctx, "python3", "-c", pyannoteScript, inputPath, strconv.Itoa(speakers))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pyannote failed: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// Map pyannote labels (SPEAKER_00, ...) to spk1, spk2 in order of appearance
+	names := map[string]string{}
+	var turns []SpeakerTurn
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		var start, end float64
+		var label string
+		if _, err := fmt.Sscanf(scanner.Text(), "%f %f %s", &start, &end, &label); err != nil {
+			continue
+		}
+		name, ok := names[label]
+		if !ok {
+			name = fmt.Sprintf("spk%d", len(names)+1)
+			names[label] = name
+		}
+		turns = append(turns, SpeakerTurn{Speaker: name, Start: start, End: end})
+	}
+	sort.Slice(turns, func(i, j int) bool { return turns[i].Start < turns[j].Start })
+	return turns, nil
+}
@@ -0,0 +1,93 @@
+package audio
+
+import (
+	"math"
+	"sort"
+)
+
+// drBlockSeconds is the block length of the DR measurement.
+const drBlockSeconds = 3.0
+
+// DynamicRange holds loudness-war style dynamic range scores.
+type DynamicRange struct {
+	DR  float64 `json:"dr"`  // Peak to RMS of the loudest 20% of 3s blocks (dB)
+	PLR float64 `json:"plr"` // Peak to loudness ratio: true peak minus integrated loudness (dB)
+}
+
+// MeasureDynamicRange computes the DR score (TT Dynamic Range Meter method)
+// and the PLR of a waveform. Higher values mean more dynamics; heavily
+// limited masters typically score DR 4-7.
+func MeasureDynamicRange(waveform *WaveformData) DynamicRange {
+	channels := waveform.Channels
+	if channels < 1 {
+		channels = 1
+	}
+	frames := len(waveform.Samples) / channels
+	blockFrames := int(drBlockSeconds * float64(waveform.SampleRate))
+	if blockFrames < 1 {
+		blockFrames = 1
+	}
+
+	var drSum float64
+	for ch := 0; ch < channels; ch++ {
+		var rms, peaks []float64
+		for start := 0; start < frames; start += blockFrames {
+			end := start + blockFrames
+			if end > frames {
+				end = frames
+			}
+			var sumSquares, peak float64
+			for i := start; i < end; i++ {
+				v := waveform.Samples[i*channels+ch]
+				sumSquares += v * v
+				if a := math.Abs(v); a > peak {
+					peak = a
+				}
+			}
+			// The DR meter scales RMS so a full-scale sine reads 0 dB
+			rms = append(rms, math.Sqrt(2*sumSquares/float64(end-start)))
+			peaks = append(peaks, peak)
+		}
+		drSum += channelDR(rms, peaks)
+	}
+
+	loudness := MeasureLoudness(waveform)
+	integrated := loudness.Integrated
+	if channels == 1 {
+		integrated += 10 * math.Log10(2) // Measure mono as played on both speakers
+	}
+
+	return DynamicRange{
+		DR:  drSum / float64(channels),
+		PLR: loudness.TruePeak - integrated,
+	}
+}
+
+// channelDR returns the second-highest block peak over the RMS of the loudest
+// 20% of blocks, in dB. Using the second peak ignores a single stray click.
+func channelDR(rms, peaks []float64) float64 {
+	if len(rms) == 0 {
+		return 0
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(rms)))
+	sort.Sort(sort.Reverse(sort.Float64Slice(peaks)))
+
+	top := len(rms) / 5
+	if top < 1 {
+		top = 1
+	}
+	var sumSquares float64
+	for _, r := range rms[:top] {
+		sumSquares += r * r
+	}
+	loudRMS := math.Sqrt(sumSquares / float64(top))
+
+	peak := peaks[0]
+	if len(peaks) > 1 {
+		peak = peaks[1]
+	}
+	if peak <= 0 || loudRMS <= 0 {
+		return 0
+	}
+	return 20 * math.Log10(peak/loudRMS)
+}
@@ -0,0 +1,84 @@
+package audio
+
+// Span kinds of a vocal balance.
+const (
+	SpanACappella    = "a-cappella"   // Vocals with (almost) no accompaniment
+	SpanInstrumental = "instrumental" // Accompaniment without vocals
+)
+
+const (
+	aCappellaBalance    = 0.85  // Minimum vocals share of an a cappella segment
+	instrumentalBalance = 0.05  // Maximum vocals share of an instrumental segment
+	balanceSilenceRMS   = 0.003 // Below this combined RMS (about -50 dBFS) a segment is silent
+	minVocalSpan        = 2.0   // Shortest reported span in seconds
+	maxVocalSpanGap     = 1.0   // Spans of the same kind closer than this are joined
+)
+
+// VocalBalance is the share of the vocals in the energy of a two-stem
+// separation over time, for karaoke and instrumental versions.
+type VocalBalance struct {
+	Hop     float64     `json:"hop"`     // Seconds per value
+	Balance []float64   `json:"balance"` // Vocals energy / total energy (0-1), -1 where silent
+	Spans   []VocalSpan `json:"spans"`   // A cappella and instrumental-only sections
+}
+
+// VocalSpan is a section where one side of the balance is (almost) alone.
+type VocalSpan struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Kind  string  `json:"kind"` // SpanACappella or SpanInstrumental
+}
+
+// MeasureVocalBalance compares the vocals and accompaniment segments of a
+// two-stem separation. Both must be un-normalized and cover the same time
+// range with the same number of segments.
+func MeasureVocalBalance(vocals, accompaniment []VolumeSegment, duration float64) *VocalBalance {
+	n := min(len(vocals), len(accompaniment))
+	if n == 0 || duration <= 0 {
+		return nil
+	}
+	vb := &VocalBalance{Hop: duration / float64(n), Balance: make([]float64, n)}
+
+	kinds := make([]string, n)
+	for i := 0; i < n; i++ {
+		v := vocals[i].RMS * vocals[i].RMS
+		a := accompaniment[i].RMS * accompaniment[i].RMS
+		if v+a < balanceSilenceRMS*balanceSilenceRMS {
+			vb.Balance[i] = -1
+			continue
+		}
+		b := v / (v + a)
+		vb.Balance[i] = b
+		switch {
+		case b >= aCappellaBalance:
+			kinds[i] = SpanACappella
+		case b <= instrumentalBalance:
+			kinds[i] = SpanInstrumental
+		}
+	}
+
+	// Runs of equal kind, joined across short interruptions, then filtered
+	// by length
+	var spans []VocalSpan
+	for i := 0; i < n; {
+		j := i
+		for j < n && kinds[j] == kinds[i] {
+			j++
+		}
+		if kinds[i] != "" {
+			span := VocalSpan{Start: float64(i) * vb.Hop, End: float64(j) * vb.Hop, Kind: kinds[i]}
+			if last := len(spans) - 1; last >= 0 && spans[last].Kind == span.Kind && span.Start-spans[last].End < maxVocalSpanGap {
+				spans[last].End = span.End
+			} else {
+				spans = append(spans, span)
+			}
+		}
+		i = j
+	}
+	for _, s := range spans {
+		if s.End-s.Start >= minVocalSpan {
+			vb.Spans = append(vb.Spans, s)
+		}
+	}
+	return vb
+}
@@ -0,0 +1,346 @@
+package audio
+
+import (
+	"math"
+)
+
+// silenceDB is reported instead of -Inf for digital silence, so results stay
+// representable in JSON.
+const silenceDB = -120.0
+
+// LoudnessStep is the time resolution of short-term loudness and true peak
+// series in seconds (the BS.1770 gating block hop).
+const LoudnessStep = 0.1
+
+// LoudnessResult contains ITU-R BS.1770 loudness measurements.
+type LoudnessResult struct {
+	Integrated float64   `json:"integrated_lufs"` // Gated integrated loudness (LUFS)
+	TruePeak   float64   `json:"true_peak_dbtp"`  // Maximum true peak (dBTP, 4x oversampled)
+	ShortTerm  []float64 `json:"short_term_lufs"` // 3s short-term loudness every LoudnessStep
+	Peaks      []float64 `json:"true_peaks_dbtp"` // True peak per LoudnessStep
+}
+
+// MeasureLoudness computes BS.1770 integrated loudness, short-term loudness
+// and true peak. Samples are interleaved with the given channel count; the
+// channels of a surround Layout get their BS.1770 weights (see
+// channelWeights), others unity weight.
+func MeasureLoudness(waveform *WaveformData) *LoudnessResult {
+	channels := waveform.Channels
+	if channels < 1 {
+		channels = 1
+	}
+	weights := channelWeights(waveform.Layout, channels)
+	rate := waveform.SampleRate
+	frames := len(waveform.Samples) / channels
+
+	// K-weight each channel and accumulate energy per 100ms step
+	stepFrames := int(float64(rate) * LoudnessStep)
+	if stepFrames < 1 {
+		stepFrames = 1
+	}
+	numSteps := (frames + stepFrames - 1) / stepFrames
+	stepEnergy := make([]float64, numSteps)
+	stepPeak := make([]float64, numSteps)
+
+	channel := make([]float64, frames)
+	for ch := 0; ch < channels; ch++ {
+		for i := 0; i < frames; i++ {
+			channel[i] = waveform.Samples[i*channels+ch]
+		}
+
+		for i, tp := range oversampledPeaks(channel, stepFrames) {
+			if tp > stepPeak[i] {
+				stepPeak[i] = tp
+			}
+		}
+
+		if weights[ch] == 0 {
+			continue
+		}
+		kWeight(channel, rate)
+		for i, v := range channel {
+			stepEnergy[i/stepFrames] += weights[ch] * v * v
+		}
+	}
+
+	result := &LoudnessResult{TruePeak: silenceDB}
+
+	// Gating blocks: 400ms windows with 75% overlap (4 steps, 1 step hop)
+	var blocks []float64
+	for i := 0; i+4 <= numSteps; i++ {
+		blocks = append(blocks, windowEnergy(stepEnergy[i:i+4], stepFrames))
+	}
+	result.Integrated = gatedLoudness(blocks)
+
+	// Short-term: 3s window ending at each step
+	for i := 0; i < numSteps; i++ {
+		start := i - 29
+		if start < 0 {
+			start = 0
+		}
+		result.ShortTerm = append(result.ShortTerm, energyToLUFS(windowEnergy(stepEnergy[start:i+1], stepFrames)))
+
+		tp := amplitudeToDB(stepPeak[i])
+		result.Peaks = append(result.Peaks, tp)
+		if tp > result.TruePeak {
+			result.TruePeak = tp
+		}
+	}
+
+	return result
+}
+
+// channelWeights returns the BS.1770 weight G of each channel of layout:
+// 1.41 (+1.5 dB) for the surrounds at 60-120 degrees (Ls/Rs of 5.x and
+// quad, the side pair of 7.1), 0 for the LFE, 1 for the rest. The weights
+// apply to the energy, so the LFE still counts toward the true peak.
+func channelWeights(layout string, channels int) []float64 {
+	weights := make([]float64, channels)
+	for i := range weights {
+		weights[i] = 1
+	}
+	names := channelLayouts[layout]
+	if len(names) != channels {
+		return weights
+	}
+	sides := false
+	for _, name := range names {
+		sides = sides || name == "SL"
+	}
+	for i, name := range names {
+		switch {
+		case name == "LFE":
+			weights[i] = 0
+		case name == "SL" || name == "SR":
+			weights[i] = 1.41
+		case (name == "BL" || name == "BR") && !sides:
+			weights[i] = 1.41
+		}
+	}
+	return weights
+}
+
+// gatedLoudness applies the BS.1770 absolute (-70 LUFS) and relative (-10 LU)
+// gates to block energies and returns integrated loudness.
+func gatedLoudness(blocks []float64) float64 {
+	var sum float64
+	n := 0
+	for _, e := range blocks {
+		if energyToLUFS(e) > -70 {
+			sum += e
+			n++
+		}
+	}
+	if n == 0 {
+		return silenceDB
+	}
+	relativeGate := energyToLUFS(sum/float64(n)) - 10
+
+	sum, n = 0, 0
+	for _, e := range blocks {
+		if l := energyToLUFS(e); l > -70 && l > relativeGate {
+			sum += e
+			n++
+		}
+	}
+	if n == 0 {
+		return silenceDB
+	}
+	return energyToLUFS(sum / float64(n))
+}
+
+// windowEnergy returns the mean square over consecutive step energies.
+func windowEnergy(steps []float64, stepFrames int) float64 {
+	var sum float64
+	for _, e := range steps {
+		sum += e
+	}
+	return sum / float64(len(steps)*stepFrames)
+}
+
+func energyToLUFS(e float64) float64 {
+	if e <= 0 {
+		return silenceDB
+	}
+	l := -0.691 + 10*math.Log10(e)
+	if l < silenceDB {
+		return silenceDB
+	}
+	return l
+}
+
+func amplitudeToDB(a float64) float64 {
+	if a <= 0 {
+		return silenceDB
+	}
+	db := 20 * math.Log10(a)
+	if db < silenceDB {
+		return silenceDB
+	}
+	return db
+}
+
+// kWeight applies the BS.1770 K-weighting pre-filter (high shelf followed by
+// high pass) in place. Coefficients are derived for any sample rate.
+func kWeight(samples []float64, rate int) {
+	fs := float64(rate)
+
+	// Stage 1: high shelf
+	f0, g, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, g/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	biquad(samples,
+		(vh+vb*k/q+k*k)/a0, 2*(k*k-vh)/a0, (vh-vb*k/q+k*k)/a0,
+		2*(k*k-1)/a0, (1-k/q+k*k)/a0)
+
+	// Stage 2: high pass
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+	biquad(samples, 1, -2, 1, 2*(k*k-1)/a0, (1-k/q+k*k)/a0)
+}
+
+// biquad filters samples in place (direct form I).
+func biquad(samples []float64, b0, b1, b2, a1, a2 float64) {
+	var x1, x2, y1, y2 float64
+	for i, x := range samples {
+		y := b0*x + b1*x1 + b2*x2 - a1*y1 - a2*y2
+		x2, x1 = x1, x
+		y2, y1 = y1, y
+		samples[i] = y
+	}
+}
+
+// truePeakTaps is the half-length of the windowed-sinc interpolation kernel.
+const truePeakTaps = 8
+
+// truePeakKernel holds interpolation coefficients for the 3 intermediate
+// phases of 4x oversampling.
+var truePeakKernel = func() [3][2 * truePeakTaps]float64 {
+	var kernel [3][2 * truePeakTaps]float64
+	for p := 1; p <= 3; p++ {
+		frac := float64(p) / 4
+		for t := 0; t < 2*truePeakTaps; t++ {
+			x := float64(t-truePeakTaps+1) - frac
+			sinc := 1.0
+			if x != 0 {
+				sinc = math.Sin(math.Pi*x) / (math.Pi * x)
+			}
+			window := 0.5 + 0.5*math.Cos(math.Pi*x/float64(truePeakTaps))
+			kernel[p-1][t] = sinc * window
+		}
+	}
+	return kernel
+}()
+
+// oversampledPeaks returns the 4x oversampled absolute peak of every block of
+// blockSize samples.
+func oversampledPeaks(samples []float64, blockSize int) []float64 {
+	peaks := make([]float64, (len(samples)+blockSize-1)/blockSize)
+	for i := range samples {
+		if peak := truePeakAt(samples, i); peak > peaks[i/blockSize] {
+			peaks[i/blockSize] = peak
+		}
+	}
+	return peaks
+}
+
+// truePeakAt returns the largest absolute value of sample i and the three
+// 4x-oversampled points following it.
+func truePeakAt(samples []float64, i int) float64 {
+	peak := math.Abs(samples[i])
+	for p := range truePeakKernel {
+		var v float64
+		for t, c := range truePeakKernel[p] {
+			j := i + t - truePeakTaps + 1
+			if j >= 0 && j < len(samples) {
+				v += samples[j] * c
+			}
+		}
+		if a := math.Abs(v); a > peak {
+			peak = a
+		}
+	}
+	return peak
+}
+
+// LoudnessTarget defines a delivery loudness specification.
+type LoudnessTarget struct {
+	Name         string  `json:"name"`
+	Integrated   float64 `json:"integrated_lufs"`     // Target integrated loudness
+	Tolerance    float64 `json:"tolerance_lu"`        // Allowed deviation from target
+	MaxTruePeak  float64 `json:"max_true_peak_dbtp"`  // Maximum true peak
+	MaxShortTerm float64 `json:"max_short_term_lufs"` // Maximum short-term loudness (0 = unchecked)
+}
+
+// Standard delivery targets.
+var (
+	TargetEBU  = LoudnessTarget{Name: "EBU R128", Integrated: -23, Tolerance: 0.5, MaxTruePeak: -1, MaxShortTerm: -18}
+	TargetATSC = LoudnessTarget{Name: "ATSC A/85", Integrated: -24, Tolerance: 2, MaxTruePeak: -2}
+)
+
+// LoudnessViolation is a time span that exceeds a target limit.
+type LoudnessViolation struct {
+	Type  string  `json:"type"`  // "true_peak" or "short_term"
+	Start float64 `json:"start"` // Seconds
+	End   float64 `json:"end"`   // Seconds
+	Value float64 `json:"value"` // Worst value within the span
+}
+
+// ComplianceReport is the pass/fail result of a loudness check.
+type ComplianceReport struct {
+	Target       LoudnessTarget      `json:"target"`
+	Integrated   float64             `json:"integrated_lufs"`
+	TruePeak     float64             `json:"true_peak_dbtp"`
+	IntegratedOK bool                `json:"integrated_ok"`
+	TruePeakOK   bool                `json:"true_peak_ok"`
+	ShortTermOK  bool                `json:"short_term_ok"`
+	Pass         bool                `json:"pass"`
+	Violations   []LoudnessViolation `json:"violations"`
+}
+
+// CheckCompliance checks loudness measurements against a target.
+func CheckCompliance(loudness *LoudnessResult, target LoudnessTarget) *ComplianceReport {
+	report := &ComplianceReport{
+		Target:       target,
+		Integrated:   loudness.Integrated,
+		TruePeak:     loudness.TruePeak,
+		IntegratedOK: math.Abs(loudness.Integrated-target.Integrated) <= target.Tolerance,
+		TruePeakOK:   loudness.TruePeak <= target.MaxTruePeak,
+		ShortTermOK:  true,
+	}
+
+	report.Violations = append(report.Violations, findViolations("true_peak", loudness.Peaks, target.MaxTruePeak)...)
+	if target.MaxShortTerm != 0 {
+		shortTerm := findViolations("short_term", loudness.ShortTerm, target.MaxShortTerm)
+		report.ShortTermOK = len(shortTerm) == 0
+		report.Violations = append(report.Violations, shortTerm...)
+	}
+
+	report.Pass = report.IntegratedOK && report.TruePeakOK && report.ShortTermOK
+	return report
+}
+
+// findViolations groups consecutive steps above limit into spans.
+func findViolations(kind string, values []float64, limit float64) []LoudnessViolation {
+	var violations []LoudnessViolation
+	var current *LoudnessViolation
+	for i, v := range values {
+		if v <= limit {
+			current = nil
+			continue
+		}
+		t := float64(i) * LoudnessStep
+		if current == nil {
+			violations = append(violations, LoudnessViolation{Type: kind, Start: t, Value: v})
+			current = &violations[len(violations)-1]
+		}
+		current.End = t + LoudnessStep
+		if v > current.Value {
+			current.Value = v
+		}
+	}
+	return violations
+}
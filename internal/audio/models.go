@@ -0,0 +1,140 @@
+package audio
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pforret/videodna/internal/toolexec"
+)
+
+// DemucsModel describes a pretrained demucs model and its checkpoint files.
+type DemucsModel struct {
+	Name        string
+	Stems       int
+	Description string
+	Files       []string // Checkpoints in the model directory, named <signature>-<sha256 prefix>.th
+}
+
+// DemucsModels lists the pretrained models audiodna uses or suggests.
+var DemucsModels = []DemucsModel{
+	{Name: "htdemucs", Stems: 4, Description: "Hybrid Transformer Demucs (default, 2 and 4 stems)",
+		Files: []string{"955717e8-8726e21a.th"}},
+	{Name: "htdemucs_6s", Stems: 6, Description: "6 stems, adds piano and guitar",
+		Files: []string{"5c90dfd2-34c22ccb.th"}},
+	{Name: "htdemucs_ft", Stems: 4, Description: "Fine-tuned per stem, better quality, 4x slower",
+		Files: []string{"f7e0c4bc-ba3fe64a.th", "d12395a8-e57c48e6.th", "92cfc3b6-ef3bcb9c.th", "04573f0d-f3cf25b2.th"}},
+}
+
+// FindDemucsModel returns the known model with the given name.
+func FindDemucsModel(name string) (DemucsModel, bool) {
+	for _, m := range DemucsModels {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return DemucsModel{}, false
+}
+
+// TorchHome returns the torch cache directory: dir when set, else
+// $TORCH_HOME, else $XDG_CACHE_HOME/torch or ~/.cache/torch, as torch does.
+func TorchHome(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	if v := os.Getenv("TORCH_HOME"); v != "" {
+		return v
+	}
+	if v := os.Getenv("XDG_CACHE_HOME"); v != "" {
+		return filepath.Join(v, "torch")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".cache", "torch")
+}
+
+// ModelDir returns the directory demucs downloads checkpoints to.
+func ModelDir(torchHome string) string {
+	return filepath.Join(TorchHome(torchHome), "hub", "checkpoints")
+}
+
+// ModelStatus is the state of a model in the cache.
+type ModelStatus struct {
+	Model   DemucsModel
+	Present int      // Checkpoint files found
+	Size    int64    // Total size of the files found
+	Corrupt []string // Files whose checksum does not match their name (when verified)
+}
+
+// Complete reports whether all checkpoints are present and none is corrupt.
+func (s ModelStatus) Complete() bool {
+	return s.Present == len(s.Model.Files) && len(s.Corrupt) == 0
+}
+
+// CheckModel looks up the checkpoints of a model in the cache. With verify,
+// each file is hashed and checked against the SHA-256 prefix in its name,
+// like torch hub does after downloading.
+func CheckModel(torchHome string, model DemucsModel, verify bool) (ModelStatus, error) {
+	status := ModelStatus{Model: model}
+	dir := ModelDir(torchHome)
+	for _, name := range model.Files {
+		path := filepath.Join(dir, name)
+		st, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		status.Present++
+		status.Size += st.Size()
+		if !verify {
+			continue
+		}
+		ok, err := verifyCheckpoint(path)
+		if err != nil {
+			return status, err
+		}
+		if !ok {
+			status.Corrupt = append(status.Corrupt, name)
+		}
+	}
+	return status, nil
+}
+
+// verifyCheckpoint compares the SHA-256 of a checkpoint with the hash
+// prefix after the last "-" of its file name.
+func verifyCheckpoint(path string) (bool, error) {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	i := strings.LastIndex(base, "-")
+	if i < 0 {
+		return false, fmt.Errorf("%s has no checksum in its name", path)
+	}
+	want := base[i+1:]
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return strings.HasPrefix(hex.EncodeToString(h.Sum(nil)), want), nil
+}
+
+// DownloadModel downloads a demucs model into the torch cache with the
+// demucs Python package, so later separations run without network access.
+func DownloadModel(ctx context.Context, torchHome, name string) error {
+	script := "import sys\nfrom demucs.pretrained import get_model\nget_model(sys.argv[1])"
+	cmd := toolexec.Command(ctx, "python3", "-c", script, name)
+	cmd.Env = append(os.Environ(), "TORCH_HOME="+TorchHome(torchHome))
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to download demucs model %s: %w", name, err)
+	}
+	return nil
+}
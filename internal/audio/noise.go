@@ -0,0 +1,162 @@
+package audio
+
+import (
+	"math"
+	"sort"
+)
+
+// Noise analysis parameters.
+const (
+	humFFTSize      = 16384 // ~2.7 Hz resolution at 44.1 kHz
+	humHarmonics    = 4     // Fundamental plus three harmonics
+	humProminence   = 12.0  // Mean harmonic peak over local median (dB) that counts as hum
+	minHumSeconds   = 3     // Shortest reported hum span
+	noiseBlock      = 0.5   // Noise floor resolution in seconds
+	quietMargin     = 6.0   // Blocks within this many dB of the floor are quiet passages
+	noiseThreshold  = -60.0 // Quiet passages above this level (dBFS) are reported as noisy
+	digitalSilence  = -100.0
+	noiseFloorPct   = 0.05 // Percentile of block levels taken as the noise floor
+	humSearchRadius = 30   // Bins around each harmonic used for the local median
+)
+
+// NoiseSpan is a time span affected by hum or noise.
+type NoiseSpan struct {
+	Start float64 `json:"start"` // Seconds
+	End   float64 `json:"end"`   // Seconds
+	Value float64 `json:"value"` // Hum prominence (dB) or noise level (dBFS)
+}
+
+// NoiseReport contains hum and noise floor findings.
+type NoiseReport struct {
+	NoiseFloor   float64     `json:"noise_floor_dbfs"` // Level of the quietest passages
+	HumFrequency float64     `json:"hum_hz"`           // Mains frequency of detected hum (0 = none)
+	Hum          []NoiseSpan `json:"hum"`              // Spans with tonal hum
+	Noisy        []NoiseSpan `json:"noisy"`            // Quiet passages with audible broadband noise
+}
+
+// AnalyzeNoise detects constant mains hum (50/60 Hz and harmonics) and
+// measures the broadband noise floor during quiet passages.
+func AnalyzeNoise(waveform *WaveformData) *NoiseReport {
+	report := &NoiseReport{NoiseFloor: silenceDB}
+
+	// Hum: per-second harmonic prominence at 50 and 60 Hz
+	binHz := float64(waveform.SampleRate) / humFFTSize
+	var scores [2][]float64
+	mains := [2]float64{50, 60}
+	for _, power := range averageSpectra(waveform, 1, humFFTSize) {
+		for m, f := range mains {
+			scores[m] = append(scores[m], humScore(power, f, binHz))
+		}
+	}
+	for m := range mains {
+		spans := thresholdSpans(scores[m], 1, humProminence, minHumSeconds)
+		if spanTotal(spans) > spanTotal(report.Hum) {
+			report.Hum = spans
+			report.HumFrequency = mains[m]
+		}
+	}
+
+	// Noise floor: low percentile of non-silent block levels
+	mono := monoSamples(waveform)
+	size := int(noiseBlock * float64(waveform.SampleRate))
+	if size < 1 {
+		return report
+	}
+	var levels []float64
+	for start := 0; start+size <= len(mono); start += size {
+		var sum float64
+		for _, v := range mono[start : start+size] {
+			sum += v * v
+		}
+		levels = append(levels, amplitudeToDB(math.Sqrt(sum/float64(size))))
+	}
+
+	var audible []float64
+	for _, l := range levels {
+		if l > digitalSilence {
+			audible = append(audible, l)
+		}
+	}
+	if len(audible) == 0 {
+		return report
+	}
+	sorted := append([]float64(nil), audible...)
+	sort.Float64s(sorted)
+	report.NoiseFloor = sorted[int(float64(len(sorted)-1)*noiseFloorPct)]
+
+	// Quiet passages that still sit above the noise threshold
+	noisy := make([]float64, len(levels))
+	for i, l := range levels {
+		noisy[i] = math.Inf(-1)
+		if l > digitalSilence && l <= report.NoiseFloor+quietMargin {
+			noisy[i] = l
+		}
+	}
+	report.Noisy = thresholdSpans(noisy, noiseBlock, noiseThreshold, 1)
+	return report
+}
+
+// humScore returns the mean prominence (dB) of the mains fundamental and
+// harmonics over the local spectral median.
+func humScore(power []float64, mains, binHz float64) float64 {
+	var sum float64
+	for h := 1; h <= humHarmonics; h++ {
+		bin := int(math.Round(float64(h) * mains / binHz))
+		if bin+humSearchRadius >= len(power) {
+			return 0
+		}
+		peak := math.Max(power[bin], math.Max(power[bin-1], power[bin+1]))
+
+		var around []float64
+		for k := bin - humSearchRadius; k <= bin+humSearchRadius; k++ {
+			if k > 0 && (k < bin-3 || k > bin+3) {
+				around = append(around, power[k])
+			}
+		}
+		sort.Float64s(around)
+		median := around[len(around)/2]
+		if median <= 0 || peak <= 0 {
+			continue
+		}
+		sum += 10 * math.Log10(peak/median)
+	}
+	return sum / humHarmonics
+}
+
+// thresholdSpans groups consecutive values above limit into spans of at
+// least minLength steps. Each span reports its mean value.
+func thresholdSpans(values []float64, step, limit float64, minLength int) []NoiseSpan {
+	var spans []NoiseSpan
+	start := -1
+	var sum float64
+	flush := func(end int) {
+		if start >= 0 && end-start >= minLength {
+			spans = append(spans, NoiseSpan{
+				Start: float64(start) * step,
+				End:   float64(end) * step,
+				Value: sum / float64(end-start),
+			})
+		}
+		start, sum = -1, 0
+	}
+	for i, v := range values {
+		if v > limit {
+			if start < 0 {
+				start = i
+			}
+			sum += v
+			continue
+		}
+		flush(i)
+	}
+	flush(len(values))
+	return spans
+}
+
+func spanTotal(spans []NoiseSpan) float64 {
+	var total float64
+	for _, s := range spans {
+		total += s.End - s.Start
+	}
+	return total
+}
@@ -0,0 +1,203 @@
+package audio
+
+import (
+	"math"
+	"sort"
+)
+
+// Podcast segment kinds.
+const (
+	PodcastIntro = "intro"
+	PodcastOutro = "outro"
+	PodcastAd    = "ad"
+)
+
+const (
+	podcastSmoothing   = 5.0   // Seconds of smoothing of the music/speech flags
+	podcastLevelSmooth = 10.0  // Seconds of smoothing of the level
+	podcastActiveRMS   = 0.01  // Minimum RMS (-40 dBFS) of a stem to count as present
+	podcastShare       = 0.3   // Minimum energy share of a present stem
+	podcastEdge        = 5.0   // Intros must start, outros end, this close to the edge
+	podcastMinEdge     = 3.0   // Shortest intro or outro in seconds
+	podcastMaxEdge     = 120.0 // Longest intro or outro in seconds
+	podcastMinAd       = 15.0  // Shortest mid-roll in seconds
+	podcastMaxAd       = 120.0 // Longest mid-roll in seconds
+	podcastAdLevel     = 3.0   // Mid-rolls are this many dB louder than the episode median
+)
+
+// PodcastSegment is a likely intro, outro or mid-roll ad of an episode.
+type PodcastSegment struct {
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Kind       string  `json:"kind"`       // PodcastIntro, PodcastOutro or PodcastAd
+	Confidence float64 `json:"confidence"` // 0-1, share of the heuristics that agree
+	Reason     string  `json:"reason"`
+}
+
+// DetectPodcastSegments flags likely intro music, outro and mid-roll ads
+// from the un-normalized vocals and accompaniment segments of a two-stem
+// separation. The music/speech decision per segment comes from the stems:
+// accompaniment energy is music, vocals energy is speech. Intros and outros
+// are music at the edges of the episode; mid-rolls are stretches in between
+// that are markedly louder than the episode or carry a music bed under the
+// speech.
+func DetectPodcastSegments(vocals, accompaniment []VolumeSegment, duration float64) []PodcastSegment {
+	n := min(len(vocals), len(accompaniment))
+	if n == 0 || duration <= 0 {
+		return nil
+	}
+	hop := duration / float64(n)
+
+	music := make([]float64, n)
+	speech := make([]float64, n)
+	level := make([]float64, n)
+	for i := 0; i < n; i++ {
+		v := vocals[i].RMS * vocals[i].RMS
+		a := accompaniment[i].RMS * accompaniment[i].RMS
+		total := v + a
+		level[i] = 10 * math.Log10(total+1e-10)
+		if total == 0 {
+			continue
+		}
+		if accompaniment[i].RMS >= podcastActiveRMS && a/total >= podcastShare {
+			music[i] = 1
+		}
+		if vocals[i].RMS >= podcastActiveRMS && v/total >= podcastShare {
+			speech[i] = 1
+		}
+	}
+	music = movingAverage(music, int(podcastSmoothing/hop))
+	speech = movingAverage(speech, int(podcastSmoothing/hop))
+	level = movingAverage(level, int(podcastLevelSmooth/hop))
+
+	var segments []PodcastSegment
+
+	// Intro: music from the start until it fades or speech takes over alone
+	introEnd := 0
+	if first := firstAbove(music, 0.5); first >= 0 && float64(first)*hop <= podcastEdge {
+		end := first
+		for end < n && music[end] > 0.5 {
+			end++
+		}
+		if length := float64(end-first) * hop; length >= podcastMinEdge && length <= podcastMaxEdge {
+			conf := 0.5
+			if float64(end)*hop < duration/4 {
+				conf += 0.25
+			}
+			if end < n && speech[end] > 0.5 {
+				conf += 0.25 // Speech follows the music
+			}
+			segments = append(segments, PodcastSegment{Start: float64(first) * hop, End: float64(end) * hop, Kind: PodcastIntro, Confidence: conf, Reason: "music at the start"})
+			introEnd = end
+		}
+	}
+
+	// Outro: music running into the end
+	outroStart := n
+	if last := lastAbove(music, 0.5); last >= 0 && duration-float64(last+1)*hop <= podcastEdge {
+		start := last
+		for start > introEnd && music[start-1] > 0.5 {
+			start--
+		}
+		if length := float64(last+1-start) * hop; length >= podcastMinEdge && length <= podcastMaxEdge {
+			conf := 0.5
+			if float64(start)*hop > duration*3/4 {
+				conf += 0.25
+			}
+			if start > 0 && speech[start-1] > 0.5 {
+				conf += 0.25 // Speech precedes the music
+			}
+			segments = append(segments, PodcastSegment{Start: float64(start) * hop, End: float64(last+1) * hop, Kind: PodcastOutro, Confidence: conf, Reason: "music at the end"})
+			outroStart = start
+		}
+	}
+
+	// Mid-rolls: louder than the episode, or speech over a music bed
+	if outroStart > introEnd {
+		median := medianOf(level[introEnd:outroStart])
+		loud := func(i int) bool { return level[i] >= median+podcastAdLevel }
+		bed := func(i int) bool { return music[i] > 0.5 && speech[i] > 0.5 }
+		for i := introEnd; i < outroStart; {
+			if !loud(i) && !bed(i) {
+				i++
+				continue
+			}
+			j := i
+			var loudCount, bedCount int
+			for j < outroStart && (loud(j) || bed(j)) {
+				if loud(j) {
+					loudCount++
+				}
+				if bed(j) {
+					bedCount++
+				}
+				j++
+			}
+			if length := float64(j-i) * hop; length >= podcastMinAd && length <= podcastMaxAd {
+				seg := PodcastSegment{Start: float64(i) * hop, End: float64(j) * hop, Kind: PodcastAd}
+				switch {
+				case loudCount*2 >= j-i && bedCount*2 >= j-i:
+					seg.Confidence, seg.Reason = 1, "louder than the episode, music bed"
+				case loudCount*2 >= j-i:
+					seg.Confidence, seg.Reason = 0.6, "louder than the episode"
+				default:
+					seg.Confidence, seg.Reason = 0.5, "music bed under speech"
+				}
+				segments = append(segments, seg)
+			}
+			i = j
+		}
+	}
+
+	sort.Slice(segments, func(a, b int) bool { return segments[a].Start < segments[b].Start })
+	return segments
+}
+
+// movingAverage smooths values over a centered window of size samples.
+func movingAverage(values []float64, size int) []float64 {
+	if size <= 1 {
+		return values
+	}
+	out := make([]float64, len(values))
+	var sum float64
+	lo, hi := 0, 0 // Window is values[lo:hi]
+	for i := range values {
+		for hi < len(values) && hi <= i+size/2 {
+			sum += values[hi]
+			hi++
+		}
+		for lo < i-size/2 {
+			sum -= values[lo]
+			lo++
+		}
+		out[i] = sum / float64(hi-lo)
+	}
+	return out
+}
+
+func firstAbove(values []float64, limit float64) int {
+	for i, v := range values {
+		if v > limit {
+			return i
+		}
+	}
+	return -1
+}
+
+func lastAbove(values []float64, limit float64) int {
+	for i := len(values) - 1; i >= 0; i-- {
+		if values[i] > limit {
+			return i
+		}
+	}
+	return -1
+}
+
+func medianOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
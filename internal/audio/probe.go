@@ -2,11 +2,13 @@
 package audio
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"strconv"
 	"strings"
+
+	"github.com/pforret/videodna/internal/toolexec"
 )
 
 // Info contains metadata about an audio file.
@@ -16,6 +18,10 @@ type Info struct {
 	Channels   int     // Number of audio channels
 	BitRate    int     // Bit rate in bps
 	Codec      string  // Audio codec name
+
+	// Stream durations of a video container (0 = unknown or no video)
+	AudioDuration float64 // Duration of the audio stream
+	VideoDuration float64 // Duration of the first video stream (cover art excluded)
 }
 
 type probeResult struct {
@@ -24,10 +30,34 @@ type probeResult struct {
 }
 
 type probeStream struct {
-	CodecName  string `json:"codec_name"`
-	SampleRate string `json:"sample_rate"`
-	Channels   int    `json:"channels"`
-	BitRate    string `json:"bit_rate"`
+	CodecName   string `json:"codec_name"`
+	CodecType   string `json:"codec_type"`
+	SampleRate  string `json:"sample_rate"`
+	Channels    int    `json:"channels"`
+	Layout      string `json:"channel_layout"`
+	BitRate     string `json:"bit_rate"`
+	Duration    string `json:"duration"`
+	Disposition struct {
+		AttachedPic int `json:"attached_pic"`
+	} `json:"disposition"`
+	Tags struct {
+		Duration string `json:"DURATION"` // Matroska keeps stream durations in tags
+		Language string `json:"language"`
+		Title    string `json:"title"`
+	} `json:"tags"`
+}
+
+// duration returns the stream duration in seconds (0 = unknown).
+func (s *probeStream) duration() float64 {
+	if d, err := strconv.ParseFloat(s.Duration, 64); err == nil {
+		return d
+	}
+	var h, m int
+	var sec float64
+	if _, err := fmt.Sscanf(s.Tags.Duration, "%d:%d:%f", &h, &m, &sec); err == nil {
+		return float64(h*3600+m*60) + sec
+	}
+	return 0
 }
 
 type probeFormat struct {
@@ -37,12 +67,11 @@ type probeFormat struct {
 
 // GetInfo retrieves audio metadata using ffprobe.
 func GetInfo(inputPath string) (*Info, error) {
-	cmd := exec.Command("ffprobe",
+	cmd := toolexec.Command(context.Background(), "ffprobe",
 		"-v", "quiet",
 		"-print_format", "json",
 		"-show_format",
 		"-show_streams",
-		"-select_streams", "a:0",
 		inputPath,
 	)
 
@@ -56,12 +85,22 @@ func GetInfo(inputPath string) (*Info, error) {
 		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
 	}
 
-	if len(result.Streams) == 0 {
+	// First audio stream; the first real video stream gives the video duration
+	var stream *probeStream
+	var videoDuration float64
+	for i := range result.Streams {
+		s := &result.Streams[i]
+		switch {
+		case s.CodecType == "audio" && stream == nil:
+			stream = s
+		case s.CodecType == "video" && s.Disposition.AttachedPic == 0 && videoDuration == 0:
+			videoDuration = s.duration()
+		}
+	}
+	if stream == nil {
 		return nil, fmt.Errorf("no audio stream found in %s", inputPath)
 	}
 
-	stream := result.Streams[0]
-
 	info := &Info{
 		Codec:    stream.CodecName,
 		Channels: stream.Channels,
@@ -72,6 +111,11 @@ func GetInfo(inputPath string) (*Info, error) {
 		info.Duration, _ = strconv.ParseFloat(result.Format.Duration, 64)
 	}
 
+	if videoDuration > 0 {
+		info.VideoDuration = videoDuration
+		info.AudioDuration = stream.duration()
+	}
+
 	// Parse sample rate
 	if stream.SampleRate != "" {
 		info.SampleRate, _ = strconv.Atoi(stream.SampleRate)
@@ -88,3 +132,61 @@ func GetInfo(inputPath string) (*Info, error) {
 
 	return info, nil
 }
+
+// Track is one audio stream of a media file.
+type Track struct {
+	Index    int    // 1-based audio track number (WaveformConfig.Track)
+	Codec    string // Audio codec name
+	Channels int    // Number of channels
+	Layout   string // ffmpeg channel layout (stereo, 5.1, 5.1(side), ...)
+	Language string // ISO 639 language tag ("" = unknown)
+	Title    string // Track title from the metadata
+}
+
+// Label returns a short lane label: the track number with the language and
+// title when tagged.
+func (t Track) Label() string {
+	parts := []string{fmt.Sprint(t.Index)}
+	if t.Language != "" && t.Language != "und" {
+		parts = append(parts, t.Language)
+	}
+	if t.Title != "" {
+		parts = append(parts, t.Title)
+	}
+	return strings.ToLower(strings.Join(parts, " "))
+}
+
+// ListTracks returns the audio tracks of a media file in stream order.
+func ListTracks(inputPath string) ([]Track, error) {
+	cmd := toolexec.Command(context.Background(), "ffprobe",
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_streams",
+		"-select_streams", "a",
+		inputPath,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+	var result probeResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	if len(result.Streams) == 0 {
+		return nil, fmt.Errorf("no audio stream found in %s", inputPath)
+	}
+
+	tracks := make([]Track, len(result.Streams))
+	for i, s := range result.Streams {
+		tracks[i] = Track{
+			Index:    i + 1,
+			Codec:    s.CodecName,
+			Channels: s.Channels,
+			Layout:   s.Layout,
+			Language: s.Tags.Language,
+			Title:    s.Tags.Title,
+		}
+	}
+	return tracks, nil
+}
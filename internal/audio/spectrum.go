@@ -0,0 +1,217 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// fft computes an in-place radix-2 FFT. len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*w
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
+
+// averageSpectra returns the mean power spectrum (fftSize/2+1 bins) of every
+// hop of hopSeconds. Hops longer than one FFT frame average all frames they
+// contain; shorter hops use the single frame starting at the hop. Channels
+// are mixed to mono first.
+func averageSpectra(waveform *WaveformData, hopSeconds float64, fftSize int) [][]float64 {
+	mono := monoSamples(waveform)
+	hop := int(hopSeconds * float64(waveform.SampleRate))
+	if hop < 1 || len(mono) == 0 {
+		return nil
+	}
+
+	window := make([]float64, fftSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(fftSize))
+	}
+
+	buf := make([]complex128, fftSize)
+	var spectra [][]float64
+	for start := 0; start < len(mono); start += hop {
+		power := make([]float64, fftSize/2+1)
+		frames := 0
+		for frame := start; frame == start || frame+fftSize <= start+hop; frame += fftSize {
+			for i := range buf {
+				v := 0.0
+				if frame+i < len(mono) {
+					v = mono[frame+i]
+				}
+				buf[i] = complex(v*window[i], 0)
+			}
+			fft(buf)
+			for k := range power {
+				re, im := real(buf[k]), imag(buf[k])
+				power[k] += re*re + im*im
+			}
+			frames++
+		}
+		for k := range power {
+			power[k] /= float64(frames)
+		}
+		spectra = append(spectra, power)
+	}
+	return spectra
+}
+
+// monoSamples returns the waveform mixed down to one channel.
+func monoSamples(waveform *WaveformData) []float64 {
+	if waveform.Channels <= 1 {
+		return waveform.Samples
+	}
+	channels := waveform.Channels
+	mono := make([]float64, len(waveform.Samples)/channels)
+	for i := range mono {
+		var sum float64
+		for ch := 0; ch < channels; ch++ {
+			sum += waveform.Samples[i*channels+ch]
+		}
+		mono[i] = sum / float64(channels)
+	}
+	return mono
+}
+
+// Chroma returns a 12-bin pitch class profile (C, C#, ... B) per hop of
+// hopSeconds, each normalized to unit length. Silent hops are all zero.
+func Chroma(waveform *WaveformData, hopSeconds float64) [][]float64 {
+	const fftSize = 4096
+	binHz := float64(waveform.SampleRate) / fftSize
+
+	var chroma [][]float64
+	for _, power := range averageSpectra(waveform, hopSeconds, fftSize) {
+		v := make([]float64, 12)
+		for k := 1; k < len(power); k++ {
+			hz := float64(k) * binHz
+			if hz < 55 || hz > 5000 {
+				continue
+			}
+			// MIDI note number; pitch class 0 = C
+			note := int(math.Round(69+12*math.Log2(hz/440))) % 12
+			v[note] += power[k]
+		}
+		normalize(v)
+		chroma = append(chroma, v)
+	}
+	return chroma
+}
+
+// normalize scales v to unit length in place (zero vectors stay zero).
+func normalize(v []float64) {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	if sum == 0 {
+		return
+	}
+	norm := math.Sqrt(sum)
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
+// cosine returns the cosine similarity of two vectors.
+func cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// MFCC returns numCoeffs mel-frequency cepstral coefficients per hop of
+// hopSeconds (40 mel bands up to 8 kHz, DCT-II of log band energies).
+// Coefficient 0 tracks overall log energy; the rest describe timbre.
+func MFCC(waveform *WaveformData, hopSeconds float64, numCoeffs int) [][]float64 {
+	const fftSize = 2048
+	const numBands = 40
+	if numCoeffs < 1 || numCoeffs > numBands {
+		numCoeffs = 13
+	}
+
+	filters := melFilterbank(numBands, fftSize, waveform.SampleRate, 8000)
+	var mfcc [][]float64
+	bands := make([]float64, numBands)
+	for _, power := range averageSpectra(waveform, hopSeconds, fftSize) {
+		for b, filter := range filters {
+			var e float64
+			for k, w := range filter.weights {
+				e += w * power[filter.start+k]
+			}
+			bands[b] = math.Log(e + 1e-10)
+		}
+
+		coeffs := make([]float64, numCoeffs)
+		for c := range coeffs {
+			for b, e := range bands {
+				coeffs[c] += e * math.Cos(math.Pi*float64(c)*(float64(b)+0.5)/numBands)
+			}
+		}
+		mfcc = append(mfcc, coeffs)
+	}
+	return mfcc
+}
+
+// melFilter is a triangular filter over consecutive FFT bins.
+type melFilter struct {
+	start   int
+	weights []float64
+}
+
+// melFilterbank builds numBands triangular filters spaced evenly on the mel
+// scale between 0 Hz and maxHz.
+func melFilterbank(numBands, fftSize, sampleRate int, maxHz float64) []melFilter {
+	if nyquist := float64(sampleRate) / 2; maxHz > nyquist {
+		maxHz = nyquist
+	}
+	toMel := func(hz float64) float64 { return 2595 * math.Log10(1+hz/700) }
+	toHz := func(mel float64) float64 { return 700 * (math.Pow(10, mel/2595) - 1) }
+
+	binHz := float64(sampleRate) / float64(fftSize)
+	edges := make([]float64, numBands+2)
+	for i := range edges {
+		edges[i] = toHz(toMel(maxHz)*float64(i)/float64(numBands+1)) / binHz
+	}
+
+	filters := make([]melFilter, numBands)
+	for b := range filters {
+		lo, mid, hi := edges[b], edges[b+1], edges[b+2]
+		start := int(math.Ceil(lo))
+		f := melFilter{start: start}
+		for k := start; float64(k) <= hi && k <= fftSize/2; k++ {
+			w := (float64(k) - lo) / (mid - lo)
+			if float64(k) > mid {
+				w = (hi - float64(k)) / (hi - mid)
+			}
+			f.weights = append(f.weights, math.Max(w, 0))
+		}
+		filters[b] = f
+	}
+	return filters
+}
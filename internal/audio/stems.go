@@ -4,27 +4,35 @@ package audio
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
-	"time"
+
+	"github.com/pforret/videodna/internal/progress"
+	"github.com/pforret/videodna/internal/retry"
+	"github.com/pforret/videodna/internal/toolexec"
+	"github.com/pforret/videodna/internal/workdir"
 )
 
 // StemType represents different audio stems.
 type StemType string
 
 const (
-	StemVocals  StemType = "vocals"
-	StemDrums   StemType = "drums"
-	StemBass    StemType = "bass"
-	StemOther   StemType = "other"
-	StemPiano   StemType = "piano"
-	StemGuitar  StemType = "guitar"
-	StemMixed   StemType = "mixed" // Original mixed audio
+	StemVocals StemType = "vocals"
+	StemDrums  StemType = "drums"
+	StemBass   StemType = "bass"
+	StemOther  StemType = "other"
+	StemPiano  StemType = "piano"
+	StemGuitar StemType = "guitar"
+	StemMixed  StemType = "mixed" // Original mixed audio
 )
 
 // SeparatorType represents the stem separation backend.
@@ -35,15 +43,87 @@ const (
 	SeparatorSpleeter SeparatorType = "spleeter"
 )
 
+// Separators lists the stem separators, the default first.
+var Separators = []SeparatorType{SeparatorDemucs, SeparatorSpleeter}
+
+// SeparatorStems lists the stem counts each separator supports.
+var SeparatorStems = map[SeparatorType][]int{
+	SeparatorDemucs:   {2, 4, 6},
+	SeparatorSpleeter: {2, 4, 5},
+}
+
+// StemNames returns the stems sep writes when separating into numStems
+// stems with its default model, or nil when it does not support the count.
+func StemNames(sep SeparatorType, numStems int) []string {
+	if CheckStemCount(sep, numStems) != nil {
+		return nil
+	}
+	return expectedStemNames(StemConfig{Separator: sep, NumStems: numStems})
+}
+
+// CheckStemCount returns an error naming the supported counts when sep
+// cannot separate into numStems stems.
+func CheckStemCount(sep SeparatorType, numStems int) error {
+	counts, ok := SeparatorStems[sep]
+	if !ok {
+		return fmt.Errorf("unknown separator: %s", sep)
+	}
+	names := make([]string, len(counts))
+	for i, n := range counts {
+		if n == numStems {
+			return nil
+		}
+		names[i] = fmt.Sprint(n)
+	}
+	return fmt.Errorf("%s does not support %d stems (supported: %s)", sep, numStems, strings.Join(names, ", "))
+}
+
 // StemConfig configures stem separation.
 type StemConfig struct {
-	Separator  SeparatorType
-	NumStems   int    // 2, 4, or 5 stems
-	Model      string // Model name (e.g., "htdemucs", "htdemucs_6s")
-	OutputDir  string // Directory to write stems
-	Device     string // "cpu" or "cuda"
+	Separator SeparatorType
+	NumStems  int    // 2, 4, 5 (spleeter) or 6 (demucs) stems
+	Model     string // Model name (e.g., "htdemucs", "htdemucs_6s")
+	OutputDir string // Directory to write stems
+	Device    string // "cpu" or "cuda"
+	Resume    bool   // Reuse valid stems of a previous run (stable OutputDir when empty)
+	Strict    bool   // Fail when stems are missing from the separator output, instead of warning
+	TorchHome string // Demucs model cache (TORCH_HOME; empty = environment or ~/.cache/torch)
+	Silent    bool   // Suppress the warnings about discarded stems of a previous run
+
+	// Segment is the demucs segment length in seconds (0 = 7, the htdemucs
+	// maximum); OOMFallback selects what happens when it runs out of GPU
+	// memory.
+	Segment     int
+	OOMFallback OOMFallback
+
+	// Retry reruns a crashing separator; a GPU run falls back to the cpu
+	// on the last attempt (zero value = no retry).
+	Retry retry.Policy
+
+	// Progress receives demucs progress in seconds of audio separated, with
+	// the rolling throughput and ETA (nil = none; spleeter reports none).
+	Progress progress.Func
 }
 
+// OOMFallback selects how demucs recovers from running out of GPU memory.
+type OOMFallback string
+
+const (
+	OOMFallbackAuto    OOMFallback = "auto"    // Halve the segment length, then run on the cpu
+	OOMFallbackSegment OOMFallback = "segment" // Only halve the segment length
+	OOMFallbackCPU     OOMFallback = "cpu"     // Run on the cpu right away
+	OOMFallbackOff     OOMFallback = "off"     // Fail
+)
+
+// ErrCUDAOutOfMemory is wrapped by the error of a demucs run that ran out of
+// GPU memory.
+var ErrCUDAOutOfMemory = errors.New("CUDA out of memory")
+
+const (
+	defaultDemucsSegment = 7 // Seconds; htdemucs supports at most 7.8
+	minDemucsSegment     = 1
+)
+
 // DefaultStemConfig returns default configuration.
 func DefaultStemConfig() StemConfig {
 	return StemConfig{
@@ -51,6 +131,7 @@ func DefaultStemConfig() StemConfig {
 		NumStems:  4,
 		Model:     "htdemucs",
 		Device:    "cpu",
+		Strict:    true,
 	}
 }
 
@@ -62,6 +143,8 @@ type StemFiles struct {
 	Other  string
 	Piano  string
 	Guitar string
+
+	Resumed bool // Stems were reused from a previous run
 }
 
 // GetStemPaths returns a slice of all non-empty stem paths.
@@ -114,9 +197,19 @@ func (s *StemFiles) GetStemLabels() []string {
 
 // SeparateStems separates an audio file into individual stems.
 func SeparateStems(ctx context.Context, inputPath string, config StemConfig) (*StemFiles, error) {
-	// Ensure output directory exists
+	if err := CheckStemCount(config.Separator, config.NumStems); err != nil {
+		return nil, err
+	}
+
+	// Ensure output directory exists; resumable runs use a directory named
+	// after the input so a later run finds the stems again, other runs the
+	// run directory that is removed when the run ends
+	work := workdir.FromContext(ctx)
+	if config.OutputDir == "" && config.Resume {
+		config.OutputDir = resumeDir(inputPath, work.Base())
+	}
 	if config.OutputDir == "" {
-		tmpDir, err := os.MkdirTemp("", "audiodna-stems-*")
+		tmpDir, err := work.MkdirTemp("audiodna-stems-*")
 		if err != nil {
 			return nil, fmt.Errorf("failed to create temp dir: %w", err)
 		}
@@ -127,37 +220,160 @@ func SeparateStems(ctx context.Context, inputPath string, config StemConfig) (*S
 		return nil, fmt.Errorf("failed to create output dir: %w", err)
 	}
 
-	switch config.Separator {
-	case SeparatorDemucs:
-		return separateWithDemucs(ctx, inputPath, config)
-	case SeparatorSpleeter:
-		return separateWithSpleeter(ctx, inputPath, config)
-	default:
-		return nil, fmt.Errorf("unknown separator: %s", config.Separator)
+	if config.Resume {
+		if stems := validStems(inputPath, config); stems != nil {
+			return stems, nil
+		}
 	}
+
+	// Fail fast when the stems will not fit, rather than on a write error
+	// of the separator half way through
+	if info, err := GetInfo(inputPath); err == nil {
+		if err := workdir.CheckSpace(config.OutputDir, StemSpace(info.Duration, config.NumStems)); err != nil {
+			return nil, err
+		}
+	}
+
+	release, err := toolexec.Acquire(ctx, toolexec.ToolMemory(string(config.Separator), config.Device))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var stems *StemFiles
+	err = config.Retry.Do(ctx, string(config.Separator), func(attempt int) error {
+		run := config
+		if attempt > 1 && attempt == config.Retry.Attempts && run.Device != "" && run.Device != "cpu" {
+			fmt.Fprintf(os.Stderr, "Warning: running %s on cpu instead of %s\n", config.Separator, run.Device)
+			run.Device = "cpu"
+		}
+		var err error
+		switch run.Separator {
+		case SeparatorDemucs:
+			stems, err = demucsWithFallback(ctx, inputPath, run)
+			if errors.Is(err, ErrCUDAOutOfMemory) {
+				return retry.Permanent(err) // The fallbacks are used up
+			}
+		case SeparatorSpleeter:
+			stems, err = separateWithSpleeter(ctx, inputPath, run)
+		default:
+			return retry.Permanent(fmt.Errorf("unknown separator: %s", run.Separator))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStems(inputPath, config, stems); err != nil {
+		if config.Strict {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return stems, nil
 }
 
-func separateWithDemucs(ctx context.Context, inputPath string, config StemConfig) (*StemFiles, error) {
-	// Determine model based on stem count
-	model := config.Model
-	if model == "" {
+// checkStems reports the expected and found files when the separator output
+// does not contain every expected stem, e.g. after a naming change.
+func checkStems(inputPath string, config StemConfig, stems *StemFiles) error {
+	expected := expectedStemNames(config)
+	if len(stems.GetStemPaths()) >= len(expected) {
+		return nil
+	}
+	dir := stemDir(inputPath, config)
+	found := "nothing"
+	if entries, err := os.ReadDir(dir); err == nil {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		if len(names) > 0 {
+			found = strings.Join(names, ", ")
+		}
+	} else {
+		found = "no such directory"
+	}
+	return fmt.Errorf("%s produced %d of %d stems in %s: expected %s.wav, found %s",
+		config.Separator, len(stems.GetStemPaths()), len(expected), dir, strings.Join(expected, ".wav, "), found)
+}
+
+// expectedStemNames returns the file names (without extension) of the stems
+// a complete separation produces.
+func expectedStemNames(config StemConfig) []string {
+	if config.Separator == SeparatorSpleeter {
 		switch config.NumStems {
 		case 2:
-			model = "htdemucs" // Will use vocals + no_vocals
-		case 4:
-			model = "htdemucs"
-		case 6:
-			model = "htdemucs_6s"
+			return []string{"vocals", "accompaniment"}
+		case 5:
+			return []string{"vocals", "drums", "bass", "piano", "other"}
+		}
+		return []string{"vocals", "drums", "bass", "other"}
+	}
+	switch {
+	case config.NumStems == 2:
+		return []string{"vocals", "no_vocals"}
+	case strings.HasSuffix(demucsModel(config), "_6s"):
+		return []string{"vocals", "drums", "bass", "other", "guitar", "piano"}
+	}
+	return []string{"vocals", "drums", "bass", "other"}
+}
+
+// demucsModel returns the demucs model for the configured stem count.
+func demucsModel(config StemConfig) string {
+	if config.Model != "" {
+		return config.Model
+	}
+	switch config.NumStems {
+	case 2:
+		return "htdemucs" // Will use vocals + no_vocals
+	case 6:
+		return "htdemucs_6s"
+	default:
+		return "htdemucs"
+	}
+}
+
+// demucsWithFallback runs demucs and, when it runs out of GPU memory, runs it
+// again with halved segments and then on the cpu, as config.OOMFallback
+// allows.
+func demucsWithFallback(ctx context.Context, inputPath string, config StemConfig) (*StemFiles, error) {
+	if config.Segment <= 0 {
+		config.Segment = defaultDemucsSegment
+	}
+	segment, fallback := config.Segment, config.OOMFallback
+	if fallback == "" {
+		fallback = OOMFallbackAuto
+	}
+	for {
+		stems, err := separateWithDemucs(ctx, inputPath, config)
+		if !errors.Is(err, ErrCUDAOutOfMemory) {
+			return stems, err
+		}
+		switch {
+		case (fallback == OOMFallbackAuto || fallback == OOMFallbackSegment) && config.Segment > minDemucsSegment:
+			config.Segment = max(config.Segment/2, minDemucsSegment)
+			fmt.Fprintf(os.Stderr, "Warning: demucs ran out of GPU memory, retrying with %ds segments\n", config.Segment)
+		case (fallback == OOMFallbackAuto || fallback == OOMFallbackCPU) && config.Device != "cpu":
+			config.Device, config.Segment = "cpu", segment // Memory is no longer tight
+			fmt.Fprintf(os.Stderr, "Warning: demucs ran out of GPU memory, retrying on cpu\n")
 		default:
-			model = "htdemucs"
+			return nil, err
 		}
 	}
+}
+
+func separateWithDemucs(ctx context.Context, inputPath string, config StemConfig) (*StemFiles, error) {
+	model := demucsModel(config)
+	segment := config.Segment
+	if segment <= 0 {
+		segment = defaultDemucsSegment
+	}
 
 	args := []string{
 		"-n", model,
 		"-o", config.OutputDir,
 		"--device", config.Device,
-		"--segment", "7", // Prevent OOM on long files (htdemucs max is 7.8s)
+		"--segment", fmt.Sprint(segment), // Prevent OOM on long files
 	}
 
 	// Add two-stems flag for 2-stem separation
@@ -167,7 +383,17 @@ func separateWithDemucs(ctx context.Context, inputPath string, config StemConfig
 
 	args = append(args, inputPath)
 
-	cmd := exec.CommandContext(ctx, "demucs", args...)
+	if m, ok := FindDemucsModel(model); ok {
+		if status, err := CheckModel(config.TorchHome, m, false); err == nil && !status.Complete() {
+			fmt.Fprintf(os.Stderr, "Warning: demucs model %s is not in %s and will be downloaded (pre-fetch with: audiodna models download %s)\n",
+				model, ModelDir(config.TorchHome), model)
+		}
+	}
+
+	cmd := toolexec.Command(ctx, "demucs", args...)
+	if config.TorchHome != "" {
+		cmd.Env = append(os.Environ(), "TORCH_HOME="+config.TorchHome)
+	}
 
 	// Capture stderr to filter progress output
 	stderr, err := cmd.StderrPipe()
@@ -176,20 +402,52 @@ func separateWithDemucs(ctx context.Context, inputPath string, config StemConfig
 	}
 
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start demucs: %w", err)
+		return nil, notInstalled(fmt.Errorf("failed to start demucs: %w", err))
 	}
 
-	// Process stderr in background, showing filtered progress
-	go filterDemucsOutput(stderr)
+	// Show filtered progress; the pipe must be drained before Wait
+	outOfMemory, unknownModel := filterDemucsOutput(stderr, config.Progress)
 
 	if err := cmd.Wait(); err != nil {
+		if outOfMemory {
+			return nil, fmt.Errorf("demucs failed: %w: %w", ErrCUDAOutOfMemory, err)
+		}
+		if unknownModel {
+			return nil, retry.Permanent(fmt.Errorf("demucs failed: unknown model %s: %w", model, err))
+		}
 		return nil, fmt.Errorf("demucs failed: %w", err)
 	}
 
-	// Find output files
+	return findStems(inputPath, config), nil
+}
+
+// notInstalled marks err as Permanent when the separator is not installed,
+// which retrying cannot fix.
+func notInstalled(err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return retry.Permanent(err)
+	}
+	return err
+}
+
+// stemDir returns the directory the separator writes the stems of inputPath to.
+func stemDir(inputPath string, config StemConfig) string {
 	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	stemDir := filepath.Join(config.OutputDir, model, baseName)
+	if config.Separator == SeparatorSpleeter {
+		return filepath.Join(config.OutputDir, baseName)
+	}
+	return filepath.Join(config.OutputDir, demucsModel(config), baseName)
+}
 
+// findStems collects the stem files present in the separator output.
+func findStems(inputPath string, config StemConfig) *StemFiles {
+	if config.Separator == SeparatorSpleeter {
+		return findSpleeterStems(stemDir(inputPath, config))
+	}
+	return findDemucsStems(stemDir(inputPath, config))
+}
+
+func findDemucsStems(stemDir string) *StemFiles {
 	stems := &StemFiles{}
 
 	// Check for each possible stem file (try both .wav and .mp3)
@@ -217,7 +475,7 @@ func separateWithDemucs(ctx context.Context, inputPath string, config StemConfig
 		}
 	}
 
-	return stems, nil
+	return stems
 }
 
 func separateWithSpleeter(ctx context.Context, inputPath string, config StemConfig) (*StemFiles, error) {
@@ -239,17 +497,17 @@ func separateWithSpleeter(ctx context.Context, inputPath string, config StemConf
 		inputPath,
 	}
 
-	cmd := exec.CommandContext(ctx, "spleeter", args...)
+	cmd := toolexec.Command(ctx, "spleeter", args...)
 	cmd.Stderr = os.Stderr
 
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("spleeter failed: %w", err)
+		return nil, notInstalled(fmt.Errorf("spleeter failed: %w", err))
 	}
 
-	// Find output files
-	baseName := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	stemDir := filepath.Join(config.OutputDir, baseName)
+	return findStems(inputPath, config), nil
+}
 
+func findSpleeterStems(stemDir string) *StemFiles {
 	stems := &StemFiles{}
 
 	// Check for each possible stem file
@@ -272,7 +530,67 @@ func separateWithSpleeter(ctx context.Context, inputPath string, config StemConf
 		}
 	}
 
-	return stems, nil
+	return stems
+}
+
+// StemSpace estimates the disk space of numStems stems of duration seconds:
+// demucs and spleeter write 44.1 kHz 16-bit stereo WAV files. A tenth is
+// added for headers and the separators' own scratch files.
+func StemSpace(duration float64, numStems int) int64 {
+	const bytesPerSecond = 44100 * 2 * 2
+	return int64(duration * bytesPerSecond * float64(numStems) * 1.1)
+}
+
+// resumeDir returns a stems directory named after the input path, size and
+// modification time under base, so a changed file is never matched with old
+// stems.
+func resumeDir(inputPath, base string) string {
+	key := inputPath
+	if abs, err := filepath.Abs(inputPath); err == nil {
+		key = abs
+	}
+	if st, err := os.Stat(inputPath); err == nil {
+		key = fmt.Sprintf("%s|%d|%d", key, st.Size(), st.ModTime().UnixNano())
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(base, "audiodna-stems-"+hex.EncodeToString(sum[:6]))
+}
+
+// stemDurationTolerance is the largest difference in seconds allowed between
+// a reused stem and its input (encoder padding); long inputs allow 1%.
+const stemDurationTolerance = 0.5
+
+// validStems returns the stems of a previous run when every expected stem
+// exists and lasts as long as the input, or nil when the separation must run
+// again. Truncated stems left by a failed run are removed.
+func validStems(inputPath string, config StemConfig) *StemFiles {
+	stems := findStems(inputPath, config)
+	paths := stems.GetStemPaths()
+	if len(paths) == 0 {
+		return nil
+	}
+	info, err := GetInfo(inputPath)
+	if err != nil {
+		return nil
+	}
+
+	valid := 0
+	for _, path := range paths {
+		stem, err := GetInfo(path)
+		if err == nil && math.Abs(stem.Duration-info.Duration) <= math.Max(stemDurationTolerance, info.Duration*0.01) {
+			valid++
+			continue
+		}
+		if !config.Silent {
+			fmt.Fprintf(os.Stderr, "Warning: discarding incomplete stem %s\n", path)
+		}
+		os.Remove(path)
+	}
+	if valid < len(expectedStemNames(config)) || valid < len(paths) {
+		return nil
+	}
+	stems.Resumed = true
+	return stems
 }
 
 // CheckSeparatorAvailable checks if the specified separator is installed.
@@ -287,25 +605,34 @@ func CheckSeparatorAvailable(sep SeparatorType) error {
 		return fmt.Errorf("unknown separator: %s", sep)
 	}
 
-	_, err := exec.LookPath(cmd)
-	if err != nil {
-		return fmt.Errorf("%s not found in PATH. Install it with: pip install %s", cmd, cmd)
+	if err := toolexec.Available(cmd); err != nil {
+		return fmt.Errorf("%w. Install it with: pip install %s", err, cmd)
 	}
 	return nil
 }
 
-// filterDemucsOutput reads demucs stderr and shows clean progress
-func filterDemucsOutput(r io.Reader) {
+// filterDemucsOutput reads demucs stderr, shows clean progress with an ETA,
+// passes it to fn (nil = none) and reports whether demucs ran out of GPU
+// memory or did not know the model.
+func filterDemucsOutput(r io.Reader, fn progress.Func) (outOfMemory, unknownModel bool) {
 	scanner := bufio.NewScanner(r)
 	// Match progress lines like "100%|██████| 5.85/5.85 [00:03<00:00, 1.91seconds/s]"
 	progressRe := regexp.MustCompile(`(\d+)%\|[^|]*\|\s*([\d.]+)/([\d.]+)\s*\[([^\]]+)\]`)
 	lastPct := -1
-	startTime := time.Now()
+	var meter *progress.Meter
 	var lastLine string
 
 	for scanner.Scan() {
 		line := scanner.Text()
 
+		// PyTorch reports "CUDA out of memory" or raises OutOfMemoryError
+		if strings.Contains(line, "CUDA out of memory") || strings.Contains(line, "OutOfMemoryError") {
+			outOfMemory = true
+		}
+		if strings.Contains(line, "is neither a single pre-trained model or a bag of models") {
+			unknownModel = true
+		}
+
 		// Skip OpenBLAS warnings and empty lines
 		if strings.Contains(line, "OpenBLAS Warning") || strings.TrimSpace(line) == "" {
 			continue
@@ -314,20 +641,30 @@ func filterDemucsOutput(r io.Reader) {
 		// Check for progress updates
 		if matches := progressRe.FindStringSubmatch(line); matches != nil {
 			pct := 0
+			var current, total float64
 			fmt.Sscanf(matches[1], "%d", &pct)
+			fmt.Sscanf(matches[2], "%f", &current)
+			fmt.Sscanf(matches[3], "%f", &total)
+			if meter == nil {
+				meter = progress.NewMeter("separation", "seconds", total)
+			}
+			if pct == lastPct {
+				continue
+			}
+			e := meter.Update(current)
+			if fn != nil {
+				fn(e)
+			}
 
 			// Only show progress at 10% intervals
 			if pct/10 > lastPct/10 || pct == 100 {
-				elapsed := time.Since(startTime).Seconds()
-				throughput := 0.0
-				if elapsed > 0 {
-					var current float64
-					fmt.Sscanf(matches[2], "%f", &current)
-					throughput = current / elapsed
+				eta := progress.FormatETA(e)
+				if eta != "" {
+					eta = ", " + eta
 				}
-				fmt.Printf("  Stem separation: %3d%% (%.1f sec/s)\n", pct, throughput)
-				lastPct = pct
+				fmt.Printf("  Stem separation: %3d%% (%.1f sec/s%s)\n", pct, e.Rate, eta)
 			}
+			lastPct = pct
 			lastLine = line
 		} else if strings.Contains(line, "Downloading") {
 			// Show download progress
@@ -335,4 +672,5 @@ func filterDemucsOutput(r io.Reader) {
 		}
 	}
 	_ = lastLine // suppress unused warning
+	return outOfMemory, unknownModel
 }
@@ -0,0 +1,147 @@
+package audio
+
+import (
+	"math"
+)
+
+// Structure segmentation parameters.
+const (
+	structureHop     = 0.5  // Feature resolution in seconds
+	noveltyHalfWidth = 16   // Checkerboard kernel half-width in hops (8s)
+	minSectionHops   = 16   // Shortest section in hops (8s)
+	sectionMatch     = 0.95 // Cosine similarity at which two sections share a label
+)
+
+// Section is a labeled span of a track. Sections with the same label have
+// similar harmonic content (e.g. repeated choruses).
+type Section struct {
+	Label string  `json:"label"` // "A", "B", ...
+	Start float64 `json:"start"` // Seconds
+	End   float64 `json:"end"`   // Seconds
+}
+
+// SegmentStructure splits a track into sections using Foote novelty over a
+// chroma self-similarity matrix, then labels sections by similarity of their
+// mean chroma.
+func SegmentStructure(waveform *WaveformData) []Section {
+	chroma := Chroma(waveform, structureHop)
+	n := len(chroma)
+	if n == 0 {
+		return nil
+	}
+
+	boundaries := []int{0}
+	for _, b := range pickPeaks(novelty(chroma), minSectionHops) {
+		if b-boundaries[len(boundaries)-1] >= minSectionHops && n-b >= minSectionHops {
+			boundaries = append(boundaries, b)
+		}
+	}
+	boundaries = append(boundaries, n)
+
+	// Label sections by their mean chroma
+	var sections []Section
+	var profiles [][]float64
+	var labels []string
+	for i := 0; i+1 < len(boundaries); i++ {
+		start, end := boundaries[i], boundaries[i+1]
+		mean := make([]float64, 12)
+		for _, v := range chroma[start:end] {
+			for k := range mean {
+				mean[k] += v[k]
+			}
+		}
+		normalize(mean)
+
+		label := ""
+		best := sectionMatch
+		for j, p := range profiles {
+			if s := cosine(mean, p); s >= best {
+				label, best = labels[j], s
+			}
+		}
+		if label == "" {
+			label = sectionLabel(len(profiles))
+			profiles = append(profiles, mean)
+			labels = append(labels, label)
+		}
+
+		sectionEnd := float64(end) * structureHop
+		if sectionEnd > waveform.Duration {
+			sectionEnd = waveform.Duration
+		}
+		sections = append(sections, Section{Label: label, Start: float64(start) * structureHop, End: sectionEnd})
+	}
+	return sections
+}
+
+// sectionLabel returns "A".."Z", then "A2".."Z2" and so on.
+func sectionLabel(i int) string {
+	label := string(rune('A' + i%26))
+	if i >= 26 {
+		label += string(rune('0' + 1 + i/26))
+	}
+	return label
+}
+
+// novelty correlates a Gaussian-tapered checkerboard kernel along the
+// diagonal of the self-similarity matrix. Peaks mark changes between
+// internally consistent passages.
+func novelty(features [][]float64) []float64 {
+	n := len(features)
+	l := noveltyHalfWidth
+	curve := make([]float64, n)
+	for i := 0; i < n; i++ {
+		var sum float64
+		for a := -l; a < l; a++ {
+			for b := -l; b < l; b++ {
+				x, y := i+a, i+b
+				if x < 0 || y < 0 || x >= n || y >= n {
+					continue
+				}
+				sign := 1.0
+				if (a < 0) != (b < 0) {
+					sign = -1
+				}
+				da, db := (float64(a)+0.5)/float64(l), (float64(b)+0.5)/float64(l)
+				taper := math.Exp(-2 * (da*da + db*db))
+				sum += sign * taper * cosine(features[x], features[y])
+			}
+		}
+		curve[i] = sum
+	}
+	return curve
+}
+
+// pickPeaks returns the local maxima of curve within ±window that exceed the
+// curve mean by half a standard deviation, in ascending order.
+func pickPeaks(curve []float64, window int) []int {
+	if len(curve) == 0 {
+		return nil
+	}
+	var mean, sq float64
+	for _, v := range curve {
+		mean += v
+	}
+	mean /= float64(len(curve))
+	for _, v := range curve {
+		sq += (v - mean) * (v - mean)
+	}
+	threshold := mean + 0.5*math.Sqrt(sq/float64(len(curve)))
+
+	var peaks []int
+	for i, v := range curve {
+		if v <= threshold {
+			continue
+		}
+		isMax := true
+		for j := i - window; j <= i+window && isMax; j++ {
+			if j >= 0 && j < len(curve) && j != i && (curve[j] > v || (curve[j] == v && j < i)) {
+				isMax = false
+			}
+		}
+		if isMax {
+			peaks = append(peaks, i)
+		}
+	}
+	return peaks
+}
@@ -0,0 +1,58 @@
+package audio
+
+import "fmt"
+
+// SurroundChannel is one channel of a multi-channel track.
+type SurroundChannel struct {
+	Name  string // ffmpeg channel name for WaveformConfig.Channel (FL, FC, LFE, ...)
+	Label string // Delivery label (L, R, C, LFE, Ls, Rs, ...)
+}
+
+// channelLabels maps ffmpeg channel names to the labels used on delivery
+// sheets. In 7.1 the back pair are the rear surrounds.
+var channelLabels = map[string]string{
+	"FL": "L", "FR": "R", "FC": "C", "LFE": "LFE",
+	"BL": "Ls", "BR": "Rs", "SL": "Ls", "SR": "Rs", "BC": "Cs",
+}
+
+// channelLayouts lists the channels of common layouts in stream order.
+var channelLayouts = map[string][]string{
+	"mono":      {"FC"},
+	"stereo":    {"FL", "FR"},
+	"2.1":       {"FL", "FR", "LFE"},
+	"3.0":       {"FL", "FR", "FC"},
+	"quad":      {"FL", "FR", "BL", "BR"},
+	"4.0":       {"FL", "FR", "FC", "BC"},
+	"5.0":       {"FL", "FR", "FC", "BL", "BR"},
+	"5.0(side)": {"FL", "FR", "FC", "SL", "SR"},
+	"5.1":       {"FL", "FR", "FC", "LFE", "BL", "BR"},
+	"5.1(side)": {"FL", "FR", "FC", "LFE", "SL", "SR"},
+	"7.1":       {"FL", "FR", "FC", "LFE", "BL", "BR", "SL", "SR"},
+}
+
+// SurroundChannels returns the channels of a track from its ffprobe channel
+// layout. Unknown layouts get numbered channels (ch1, ch2, ...) addressed by
+// index.
+func SurroundChannels(layout string, channels int) []SurroundChannel {
+	names, ok := channelLayouts[layout]
+	if !ok || (channels > 0 && len(names) != channels) {
+		result := make([]SurroundChannel, channels)
+		for i := range result {
+			result[i] = SurroundChannel{Name: fmt.Sprintf("c%d", i), Label: fmt.Sprintf("ch%d", i+1)}
+		}
+		return result
+	}
+
+	result := make([]SurroundChannel, len(names))
+	for i, name := range names {
+		label := channelLabels[name]
+		if layout == "7.1" && (name == "BL" || name == "BR") {
+			label = "Lrs"
+			if name == "BR" {
+				label = "Rrs"
+			}
+		}
+		result[i] = SurroundChannel{Name: name, Label: label}
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package audio
+
+import (
+	"math"
+)
+
+// Tempo search range and onset envelope resolution.
+const (
+	minBPM         = 60.0
+	maxBPM         = 200.0
+	onsetHop       = 0.01 // Onset envelope resolution in seconds
+	beatsPerBar    = 4
+	preferredBPM   = 120.0 // Center of the log-Gaussian tempo prior
+	tempoPriorSpan = 1.0   // Width of the tempo prior in octaves
+)
+
+// TempoResult contains an estimated tempo and beat grid.
+type TempoResult struct {
+	BPM         float64 `json:"bpm"`           // Beats per minute
+	Offset      float64 `json:"offset"`        // Time of the first beat in seconds
+	BeatsPerBar int     `json:"beats_per_bar"` // Assumed meter (always 4)
+	Confidence  float64 `json:"confidence"`    // Autocorrelation peak strength (0.0 to 1.0)
+}
+
+// BeatInterval returns the beat period in seconds.
+func (t *TempoResult) BeatInterval() float64 {
+	if t.BPM <= 0 {
+		return 0
+	}
+	return 60 / t.BPM
+}
+
+// EstimateTempo estimates a constant tempo by autocorrelating an onset
+// strength envelope, then picks the beat phase that best aligns with onsets.
+// It assumes a steady tempo; rubato or tempo changes yield an average.
+func EstimateTempo(waveform *WaveformData) *TempoResult {
+	onsets := onsetEnvelope(waveform)
+	if len(onsets) == 0 {
+		return nil
+	}
+
+	minLag := int(60 / maxBPM / onsetHop)
+	maxLag := int(60 / minBPM / onsetHop)
+	if maxLag+1 >= len(onsets) {
+		return nil
+	}
+
+	var zeroLag float64
+	for _, v := range onsets {
+		zeroLag += v * v
+	}
+	if zeroLag == 0 {
+		return nil
+	}
+
+	corrs := make([]float64, maxLag+2)
+	for lag := minLag - 1; lag <= maxLag+1; lag++ {
+		for i := lag; i < len(onsets); i++ {
+			corrs[lag] += onsets[i] * onsets[i-lag]
+		}
+		corrs[lag] /= zeroLag
+	}
+
+	bestLag, bestScore := 0, 0.0
+	for lag := minLag; lag <= maxLag; lag++ {
+		// Weight towards common tempi to avoid half/double tempo errors
+		bpm := 60 / (float64(lag) * onsetHop)
+		octaves := math.Log2(bpm / preferredBPM)
+		score := corrs[lag] * math.Exp(-0.5*(octaves/tempoPriorSpan)*(octaves/tempoPriorSpan))
+		if score > bestScore {
+			bestLag, bestScore = lag, score
+		}
+	}
+	if bestLag == 0 {
+		return nil
+	}
+
+	// Refine the period between envelope hops with a parabolic fit, so the
+	// beat grid does not drift over long tracks
+	period := float64(bestLag)
+	y0, y1, y2 := corrs[bestLag-1], corrs[bestLag], corrs[bestLag+1]
+	if d := y0 - 2*y1 + y2; d < 0 {
+		period += 0.5 * (y0 - y2) / d
+	}
+
+	// Beat phase: offset whose beat train collects the most onset strength
+	bestPhase, bestSum := 0, -1.0
+	for phase := 0; phase < bestLag; phase++ {
+		var sum float64
+		for beat := 0; ; beat++ {
+			i := phase + int(math.Round(float64(beat)*period))
+			if i >= len(onsets) {
+				break
+			}
+			sum += onsets[i]
+		}
+		if sum > bestSum {
+			bestPhase, bestSum = phase, sum
+		}
+	}
+
+	return &TempoResult{
+		BPM:         60 / (period * onsetHop),
+		Offset:      float64(bestPhase) * onsetHop,
+		BeatsPerBar: beatsPerBar,
+		Confidence:  math.Min(corrs[bestLag], 1),
+	}
+}
+
+// onsetEnvelope returns the half-wave rectified log energy difference per
+// onsetHop, mean-removed so sustained loudness does not dominate.
+func onsetEnvelope(waveform *WaveformData) []float64 {
+	channels := waveform.Channels
+	if channels < 1 {
+		channels = 1
+	}
+	hop := int(onsetHop*float64(waveform.SampleRate)) * channels
+	if hop < 1 {
+		return nil
+	}
+
+	numHops := len(waveform.Samples) / hop
+	onsets := make([]float64, numHops)
+	prev := 0.0
+	var mean float64
+	for i := 0; i < numHops; i++ {
+		var energy float64
+		for _, v := range waveform.Samples[i*hop : (i+1)*hop] {
+			energy += v * v
+		}
+		level := math.Log1p(1000 * energy / float64(hop))
+		if d := level - prev; d > 0 && i > 0 {
+			onsets[i] = d
+		}
+		prev = level
+		mean += onsets[i]
+	}
+	if numHops == 0 {
+		return nil
+	}
+
+	mean /= float64(numHops)
+	for i := range onsets {
+		onsets[i] = math.Max(onsets[i]-mean, 0)
+	}
+	return onsets
+}
@@ -8,21 +8,31 @@ import (
 	"fmt"
 	"io"
 	"math"
-	"os/exec"
+	"strings"
+
+	"github.com/pforret/videodna/internal/toolexec"
 )
 
 // WaveformData contains amplitude data for an audio file.
 type WaveformData struct {
-	Samples    []float64 // Normalized samples (-1.0 to 1.0)
+	Samples    []float64 // Normalized samples (-1.0 to 1.0), interleaved if Channels > 1
 	SampleRate int       // Sample rate in Hz
 	Duration   float64   // Duration in seconds
-	Channels   int       // Number of channels (mixed to mono)
+	Channels   int       // Number of interleaved channels (1 when mixed to mono)
+	Layout     string    // Channel layout of Samples: mono, stereo or WaveformConfig.Layout
 }
 
 // WaveformConfig configures waveform extraction.
 type WaveformConfig struct {
-	SampleRate int // Target sample rate (default: 44100)
-	Mono       bool // Mix to mono (default: true)
+	SampleRate int    // Target sample rate (default: 44100)
+	Mono       bool   // Mix to mono (default: true)
+	BitDepth   int    // PCM depth: 16, 24, or 32 (float) (default: 16)
+	Track      int    // 1-based audio track to decode (0 = ffmpeg's default stream)
+	Channel    string // Decode only this channel, by ffmpeg name (FC, LFE) or index (c2); implies mono
+
+	// Layout keeps every channel of a known ffprobe layout (5.1, 7.1, ...)
+	// in stream order instead of a mono or stereo mix ("" = by Mono).
+	Layout string
 }
 
 // DefaultWaveformConfig returns default configuration.
@@ -30,45 +40,127 @@ func DefaultWaveformConfig() WaveformConfig {
 	return WaveformConfig{
 		SampleRate: 44100,
 		Mono:       true,
+		BitDepth:   16,
 	}
 }
 
 // ExtractWaveform extracts raw waveform data from an audio file.
 func ExtractWaveform(ctx context.Context, inputPath string, config WaveformConfig) (*WaveformData, error) {
+	waveforms, err := extractPCM(ctx, []string{inputPath}, config)
+	if err != nil {
+		return nil, err
+	}
+	return waveforms[0], nil
+}
+
+// ExtractWaveforms extracts the waveforms of several files of equal length,
+// such as the stems of one separation run, with a single ffmpeg process: the
+// inputs are resampled and merged into one multi-channel stream (amerge),
+// which is split again while reading. The merged stream ends with the
+// shortest input.
+func ExtractWaveforms(ctx context.Context, inputPaths []string, config WaveformConfig) ([]*WaveformData, error) {
+	if len(inputPaths) == 0 {
+		return nil, nil
+	}
+	return extractPCM(ctx, inputPaths, config)
+}
+
+// extractPCM decodes one or more inputs into raw PCM with one ffmpeg process.
+func extractPCM(ctx context.Context, inputPaths []string, config WaveformConfig) ([]*WaveformData, error) {
 	if config.SampleRate == 0 {
 		config.SampleRate = 44100
 	}
+	if config.BitDepth == 0 {
+		config.BitDepth = 16
+	}
 
-	// Build ffmpeg command to output raw PCM
-	args := []string{
-		"-i", inputPath,
-		"-f", "s16le",        // 16-bit signed little-endian
-		"-acodec", "pcm_s16le",
-		"-ar", fmt.Sprintf("%d", config.SampleRate),
+	// Raw PCM format per bit depth
+	var format, codec string
+	switch config.BitDepth {
+	case 16:
+		format, codec = "s16le", "pcm_s16le" // 16-bit signed little-endian
+	case 24:
+		format, codec = "s24le", "pcm_s24le" // 24-bit signed little-endian
+	case 32:
+		format, codec = "f32le", "pcm_f32le" // 32-bit float little-endian
+	default:
+		return nil, fmt.Errorf("unsupported bit depth %d (use 16, 24, or 32)", config.BitDepth)
 	}
+	sampleSize := config.BitDepth / 8
 
-	if config.Mono {
-		args = append(args, "-ac", "1") // Mix to mono
+	channels := 1
+	layout := "mono"
+	if names := channelLayouts[config.Layout]; len(names) > 0 && config.Channel == "" {
+		channels = len(names)
+		layout = config.Layout
+	} else if !config.Mono && config.Channel == "" {
+		channels = 2
+		layout = "stereo"
 	}
 
+	// Build ffmpeg command to output raw PCM
+	var args []string
+	for _, path := range inputPaths {
+		args = append(args, "-i", path)
+	}
+	if len(inputPaths) == 1 {
+		if config.Track > 0 {
+			args = append(args, "-map", fmt.Sprintf("0:a:%d", config.Track-1))
+		}
+		if config.Channel != "" {
+			args = append(args, "-af", "pan=mono|c0="+config.Channel)
+		} else if channels > 2 {
+			args = append(args, "-af", "aformat=channel_layouts="+layout)
+		}
+		args = append(args,
+			"-f", format,
+			"-acodec", codec,
+			"-ar", fmt.Sprintf("%d", config.SampleRate),
+			"-ac", fmt.Sprintf("%d", channels), // Mono mix, interleaved stereo or layout
+		)
+	} else {
+		// Convert every input, then interleave them channel by channel
+		var graph, merged strings.Builder
+		stream := "a"
+		if config.Track > 0 {
+			stream = fmt.Sprintf("a:%d", config.Track-1)
+		}
+		for i := range inputPaths {
+			fmt.Fprintf(&graph, "[%d:%s]aformat=sample_fmts=fltp:sample_rates=%d:channel_layouts=%s[a%d];", i, stream, config.SampleRate, layout, i)
+			fmt.Fprintf(&merged, "[a%d]", i)
+		}
+		fmt.Fprintf(&graph, "%samerge=inputs=%d[out]", merged.String(), len(inputPaths))
+		args = append(args,
+			"-filter_complex", graph.String(),
+			"-map", "[out]",
+			"-f", format,
+			"-acodec", codec,
+		)
+	}
 	args = append(args, "-") // Output to stdout
 
-	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd := toolexec.Command(ctx, "ffmpeg", args...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
+	release, err := toolexec.Acquire(ctx, toolexec.FFmpegMemory(0, 0))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("ffmpeg failed to start: %w", err)
 	}
 
-	// Read samples
+	// Read samples; in a merged stream each frame holds channels samples
+	// of every input in order
 	reader := bufio.NewReaderSize(stdout, 1024*1024) // 1MB buffer
-	var samples []float64
+	samples := make([][]float64, len(inputPaths))
 
-	buf := make([]byte, 2) // 16-bit = 2 bytes
-	for {
+	buf := make([]byte, sampleSize)
+	for n := 0; ; n++ {
 		_, err := io.ReadFull(reader, buf)
 		if err == io.EOF {
 			break
@@ -77,30 +169,42 @@ func ExtractWaveform(ctx context.Context, inputPath string, config WaveformConfi
 			break
 		}
 
-		// Convert to float64 normalized to -1.0 to 1.0
-		sample := int16(binary.LittleEndian.Uint16(buf))
-		samples = append(samples, float64(sample)/32768.0)
+		input := n / channels % len(inputPaths)
+		samples[input] = append(samples[input], decodeSample(buf))
 	}
 
 	if err := cmd.Wait(); err != nil {
 		// Ignore exit errors if we got samples (ffmpeg sometimes exits with error after EOF)
-		if len(samples) == 0 {
+		if len(samples[0]) == 0 {
 			return nil, fmt.Errorf("ffmpeg failed: %w", err)
 		}
 	}
 
-	channels := 1
-	if !config.Mono {
-		// Would need to track original channel count
-		channels = 2
+	waveforms := make([]*WaveformData, len(inputPaths))
+	for i, s := range samples {
+		waveforms[i] = &WaveformData{
+			Samples:    s,
+			SampleRate: config.SampleRate,
+			Duration:   float64(len(s)/channels) / float64(config.SampleRate),
+			Channels:   channels,
+			Layout:     layout,
+		}
 	}
+	return waveforms, nil
+}
 
-	return &WaveformData{
-		Samples:    samples,
-		SampleRate: config.SampleRate,
-		Duration:   float64(len(samples)) / float64(config.SampleRate),
-		Channels:   channels,
-	}, nil
+// decodeSample converts one little-endian PCM sample to float64 normalized
+// to -1.0 to 1.0. The sample size selects the format (2: s16, 3: s24, 4: f32).
+func decodeSample(buf []byte) float64 {
+	switch len(buf) {
+	case 3:
+		v := int32(uint32(buf[0])<<8|uint32(buf[1])<<16|uint32(buf[2])<<24) >> 8
+		return float64(v) / 8388608.0
+	case 4:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(buf)))
+	default:
+		return float64(int16(binary.LittleEndian.Uint16(buf))) / 32768.0
+	}
 }
 
 // VolumeSegment represents volume data for a time segment.
@@ -111,18 +215,38 @@ type VolumeSegment struct {
 	Peak      float64 // Peak amplitude (0.0 to 1.0)
 	Min       float64 // Minimum amplitude (-1.0 to 1.0)
 	Max       float64 // Maximum amplitude (-1.0 to 1.0)
+	TruePeak  float64 // 4x oversampled peak amplitude (may exceed 1.0)
+	Crest     float64 // Crest factor: true peak to RMS ratio in dB
+}
+
+// VolumeConfig configures windowed volume extraction.
+type VolumeConfig struct {
+	NumSegments int     // Number of segments; the hop is duration / NumSegments
+	Overlap     float64 // Window overlap, 0.0 to <1.0 (0 = adjacent buckets, 0.5 = 50%)
 }
 
 // ExtractVolume extracts volume data segmented into time buckets.
 func ExtractVolume(waveform *WaveformData, numSegments int) []VolumeSegment {
+	return ExtractVolumeWindowed(waveform, VolumeConfig{NumSegments: numSegments})
+}
+
+// ExtractVolumeWindowed extracts volume data using analysis windows that may
+// overlap. Segments stay spaced one hop apart, but each window is widened to
+// hop / (1 - Overlap) samples centered on its hop, smoothing the envelope.
+func ExtractVolumeWindowed(waveform *WaveformData, config VolumeConfig) []VolumeSegment {
+	numSegments := config.NumSegments
 	if numSegments <= 0 || len(waveform.Samples) == 0 {
 		return nil
 	}
+	if config.Overlap < 0 || config.Overlap >= 1 {
+		config.Overlap = 0
+	}
 
 	samplesPerSegment := len(waveform.Samples) / numSegments
 	if samplesPerSegment < 1 {
 		samplesPerSegment = 1
 	}
+	windowSize := int(float64(samplesPerSegment) / (1 - config.Overlap))
 
 	segments := make([]VolumeSegment, numSegments)
 	secondsPerSample := 1.0 / float64(waveform.SampleRate)
@@ -133,6 +257,13 @@ func ExtractVolume(waveform *WaveformData, numSegments int) []VolumeSegment {
 		if i == numSegments-1 {
 			endIdx = len(waveform.Samples) // Last segment gets remaining samples
 		}
+		if windowSize > samplesPerSegment {
+			startIdx += samplesPerSegment/2 - windowSize/2
+			endIdx = startIdx + windowSize
+			if startIdx < 0 {
+				startIdx = 0
+			}
+		}
 		if endIdx > len(waveform.Samples) {
 			endIdx = len(waveform.Samples)
 		}
@@ -162,11 +293,17 @@ func ExtractVolume(waveform *WaveformData, numSegments int) []VolumeSegment {
 			if absSample > segment.Peak {
 				segment.Peak = absSample
 			}
+			if tp := truePeakAt(waveform.Samples, j); tp > segment.TruePeak {
+				segment.TruePeak = tp
+			}
 		}
 
 		if count > 0 {
 			segment.RMS = math.Sqrt(sumSquares / float64(count))
 		}
+		if segment.RMS > 0 {
+			segment.Crest = 20 * math.Log10(segment.TruePeak/segment.RMS)
+		}
 	}
 
 	return segments
@@ -199,3 +336,52 @@ func NormalizeVolume(segments []VolumeSegment) {
 		}
 	}
 }
+
+// ResampleSegments maps segments onto n buckets. When reducing, each bucket
+// merges the segments it covers (mean RMS, extreme peak/min/max); when
+// enlarging, buckets repeat the nearest segment.
+func ResampleSegments(segments []VolumeSegment, n int) []VolumeSegment {
+	if n <= 0 || len(segments) == 0 {
+		return nil
+	}
+	if n == len(segments) {
+		return segments
+	}
+
+	out := make([]VolumeSegment, n)
+	for i := range out {
+		start := i * len(segments) / n
+		end := (i + 1) * len(segments) / n
+		if end <= start {
+			end = start + 1
+		}
+
+		merged := segments[start]
+		var rmsSum, peakLevel float64
+		for _, seg := range segments[start:end] {
+			rmsSum += seg.RMS
+			// Peak on the RMS scale: normalization rescales RMS but not
+			// TruePeak, so the crest is merged on that scale
+			peakLevel = math.Max(peakLevel, seg.RMS*math.Pow(10, seg.Crest/20))
+			if seg.Peak > merged.Peak {
+				merged.Peak = seg.Peak
+			}
+			if seg.Min < merged.Min {
+				merged.Min = seg.Min
+			}
+			if seg.Max > merged.Max {
+				merged.Max = seg.Max
+			}
+			if seg.TruePeak > merged.TruePeak {
+				merged.TruePeak = seg.TruePeak
+			}
+		}
+		merged.RMS = rmsSum / float64(end-start)
+		if end-start > 1 && merged.RMS > 0 && peakLevel > 0 {
+			merged.Crest = 20 * math.Log10(peakLevel/merged.RMS)
+		}
+		merged.TimeEnd = segments[end-1].TimeEnd
+		out[i] = merged
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package audiodna
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/pforret/videodna/internal/alttext"
+	"github.com/pforret/videodna/internal/audio"
+)
+
+// audioAltText describes the loudness of the mix and its loudest stem over
+// alttext.Spans time spans. raw holds the segments of each stem before
+// normalization, which would make every stem look equally loud (nil = use
+// the stem segments).
+func audioAltText(stems []StemData, raw [][]audio.VolumeSegment, outputPath, inputPath string, duration float64) *alttext.Description {
+	segments := make([][]audio.VolumeSegment, len(stems))
+	n := 0
+	for i, stem := range stems {
+		segments[i] = stem.Segments
+		if i < len(raw) && raw[i] != nil {
+			segments[i] = raw[i]
+		}
+		n = max(n, len(segments[i]))
+	}
+	n = min(n, alttext.Spans)
+
+	levels := make([]float64, n)
+	var lead []string
+	if len(stems) > 1 {
+		lead = make([]string, n)
+	}
+	for k := 0; k < n; k++ {
+		var energy, loudest float64
+		for i, segs := range segments {
+			from, to := k*len(segs)/n, (k+1)*len(segs)/n
+			if to <= from {
+				continue
+			}
+			var sum float64
+			for _, seg := range segs[from:to] {
+				sum += seg.RMS * seg.RMS
+			}
+			// Stems add up as uncorrelated signals
+			energy += sum / float64(to-from)
+			if mean := math.Sqrt(sum / float64(to-from)); lead != nil && mean > loudest {
+				loudest, lead[k] = mean, stems[i].Label
+			}
+		}
+		levels[k] = math.Sqrt(energy)
+	}
+
+	title := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	return alttext.Audio(outputPath, title, duration, levels, lead)
+}
@@ -0,0 +1,52 @@
+package audiodna
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// classStripHeight is the height of the speech/music/silence strip above the stems.
+const classStripHeight = 8
+
+// classColors are the strip colors per content class.
+var classColors = map[string]color.RGBA{
+	audio.ClassSpeech:  {R: 230, G: 190, B: 80, A: 255}, // Amber
+	audio.ClassMusic:   {R: 80, G: 140, B: 220, A: 255}, // Blue
+	audio.ClassSilence: {R: 40, G: 40, B: 46, A: 255},   // Near background
+}
+
+// measureContent extracts the mono mix and classifies speech, music and silence.
+func measureContent(ctx context.Context, pcm *pcmCache, inputPath string) (*audio.ContentReport, error) {
+	waveform, err := pcm.waveform(ctx, inputPath, audio.DefaultWaveformConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract waveform for classification: %w", err)
+	}
+	return audio.ClassifyContent(waveform), nil
+}
+
+// drawContent draws the content class spans as a colored strip starting at
+// yOffset, following the time transform applied to the waveform.
+func drawContent(img *image.RGBA, content *audio.ContentReport, duration float64, yOffset, width int, t transform.Options, scale int) {
+	stripHeight := classStripHeight * scale
+	if duration <= 0 {
+		return
+	}
+	for _, s := range content.Spans {
+		c := classColors[s.Class]
+		x0 := int(t.MapTime(min(s.Start/duration, 1)) * float64(width))
+		x1 := int(t.MapTime(min(s.End/duration, 1)) * float64(width))
+		if x1 < x0 {
+			x0, x1 = x1, x0
+		}
+		for x := x0; x < x1 && x < width; x++ {
+			for y := yOffset; y < yOffset+stripHeight; y++ {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
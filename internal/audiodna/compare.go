@@ -0,0 +1,55 @@
+package audiodna
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pforret/videodna/internal/audio"
+)
+
+// Comparison methods.
+const (
+	CompareChroma = "chroma" // Key- and tempo-invariant harmonic similarity (cover detection)
+)
+
+// CompareResult is the outcome of comparing two tracks.
+type CompareResult struct {
+	A          string            `json:"a"`
+	B          string            `json:"b"`
+	Method     string            `json:"method"`
+	Match      audio.ChromaMatch `json:"match"`
+	Threshold  float64           `json:"threshold"`
+	SameSource bool              `json:"same_composition"` // Similarity >= Threshold
+}
+
+// Compare scores whether two audio files are versions of the same
+// composition using the given method.
+func Compare(ctx context.Context, pathA, pathB, method string, threshold float64) (*CompareResult, error) {
+	if method != CompareChroma {
+		return nil, fmt.Errorf("unknown compare method %q (use %s)", method, CompareChroma)
+	}
+
+	waveforms := make([]*audio.WaveformData, 2)
+	for i, path := range []string{pathA, pathB} {
+		waveform, err := audio.ExtractWaveform(ctx, path, audio.DefaultWaveformConfig())
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract waveform for %s: %w", path, err)
+		}
+		waveforms[i] = waveform
+	}
+
+	match := audio.CompareChroma(waveforms[0], waveforms[1])
+	return &CompareResult{
+		A:          pathA,
+		B:          pathB,
+		Method:     method,
+		Match:      match,
+		Threshold:  threshold,
+		SameSource: match.Similarity >= threshold,
+	}, nil
+}
+
+// WriteJSON writes the comparison result as indented JSON.
+func (r *CompareResult) WriteJSON(path string) error {
+	return writeJSON(path, r)
+}
@@ -0,0 +1,94 @@
+package audiodna
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/events"
+)
+
+// minSilence is the shortest silence exported as an event.
+const minSilence = 2.0
+
+// Silence is a span of the mix below the silence level (-50 dBFS).
+type Silence struct {
+	Start float64 `json:"start"` // Seconds
+	End   float64 `json:"end"`   // Seconds
+}
+
+// measureSilences extracts the mix and finds silences of at least minSilence.
+func measureSilences(ctx context.Context, pcm *pcmCache, inputPath string) ([]Silence, error) {
+	waveform, err := pcm.waveform(ctx, inputPath, audio.DefaultWaveformConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract waveform for silence detection: %w", err)
+	}
+
+	var silences []Silence
+	levels := blockLevels(waveform, silenceBlock)
+	start := -1
+	for i := 0; i <= len(levels); i++ {
+		if i < len(levels) && levels[i] < silenceLevel {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && float64(i-start)*silenceBlock >= minSilence {
+			silences = append(silences, Silence{Start: float64(start) * silenceBlock, End: float64(i) * silenceBlock})
+		}
+		start = -1
+	}
+	return silences, nil
+}
+
+// Events returns the detected events of a result for export: sections,
+// speaker turns, silences, loudness violations, hum and noisy passages, and
+// a cappella / instrumental spans, podcast intro, outro and ads, speech
+// and music spans, and an A/V duration mismatch.
+func (r *Result) Events() []events.Event {
+	var list []events.Event
+	for _, s := range r.Sections {
+		list = append(list, events.Event{Start: s.Start, End: s.End, Kind: "section", Label: "section " + s.Label})
+	}
+	for _, t := range r.Speakers {
+		list = append(list, events.Event{Start: t.Start, End: t.End, Kind: "speaker", Label: t.Speaker})
+	}
+	for _, s := range r.Silences {
+		list = append(list, events.Event{Start: s.Start, End: s.End, Kind: "silence", Label: "silence"})
+	}
+	if r.Compliance != nil {
+		for _, v := range r.Compliance.Violations {
+			list = append(list, events.Event{Start: v.Start, End: v.End, Kind: "loudness", Label: fmt.Sprintf("%s %.1f", v.Type, v.Value)})
+		}
+	}
+	if r.Noise != nil {
+		for _, s := range r.Noise.Hum {
+			list = append(list, events.Event{Start: s.Start, End: s.End, Kind: "hum", Label: fmt.Sprintf("hum %.0f Hz", r.Noise.HumFrequency)})
+		}
+		for _, s := range r.Noise.Noisy {
+			list = append(list, events.Event{Start: s.Start, End: s.End, Kind: "noise", Label: fmt.Sprintf("noise %.0f dBFS", s.Value)})
+		}
+	}
+	if r.Karaoke != nil {
+		for _, s := range r.Karaoke.Spans {
+			list = append(list, events.Event{Start: s.Start, End: s.End, Kind: "karaoke", Label: s.Kind})
+		}
+	}
+	for _, s := range r.Podcast {
+		list = append(list, events.Event{Start: s.Start, End: s.End, Kind: "podcast", Label: s.Kind})
+	}
+	if r.Content != nil {
+		for _, s := range r.Content.Spans {
+			if s.Class != audio.ClassSilence {
+				list = append(list, events.Event{Start: s.Start, End: s.End, Kind: "content", Label: s.Class})
+			}
+		}
+	}
+	if g := r.AVGap; g != nil && g.Issue != "" {
+		start, end := math.Min(g.AudioDuration, g.VideoDuration), math.Max(g.AudioDuration, g.VideoDuration)
+		list = append(list, events.Event{Start: start, End: end, Kind: "av-gap", Label: g.Issue})
+	}
+	return list
+}
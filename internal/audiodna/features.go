@@ -0,0 +1,17 @@
+package audiodna
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pforret/videodna/internal/audio"
+)
+
+// measureMFCC extracts the mono mix and computes MFCCs per hop.
+func measureMFCC(ctx context.Context, pcm *pcmCache, inputPath string, hop float64, numCoeffs int) ([][]float64, error) {
+	waveform, err := pcm.waveform(ctx, inputPath, audio.DefaultWaveformConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract waveform for MFCC: %w", err)
+	}
+	return audio.MFCC(waveform, hop, numCoeffs), nil
+}
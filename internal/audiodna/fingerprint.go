@@ -0,0 +1,43 @@
+package audiodna
+
+import (
+	"github.com/pforret/videodna/internal/fingerprint"
+)
+
+// audioFingerprint builds a fingerprint pyramid from the stem segments: each
+// column holds the RMS and peak of every stem.
+func audioFingerprint(stems []StemData, source string, duration float64, levels []int) *fingerprint.Fingerprint {
+	var features []string
+	numSegments := 0
+	for _, stem := range stems {
+		features = append(features, stem.Label+".rms", stem.Label+".peak")
+		numSegments = max(numSegments, len(stem.Segments))
+	}
+
+	columns := make([][]float64, numSegments)
+	for i := range columns {
+		col := make([]float64, 0, len(features))
+		for _, stem := range stems {
+			if i < len(stem.Segments) {
+				col = append(col, stem.Segments[i].RMS, stem.Segments[i].Peak)
+			} else {
+				col = append(col, 0, 0)
+			}
+		}
+		columns[i] = col
+	}
+	return fingerprint.New("audio", source, duration, features, columns, levels)
+}
+
+// fingerprintSegments returns the number of segments needed for the finest
+// fingerprint level.
+func fingerprintSegments(levels []int) int {
+	if len(levels) == 0 {
+		levels = fingerprint.DefaultLevels
+	}
+	finest := 0
+	for _, n := range levels {
+		finest = max(finest, n)
+	}
+	return finest
+}
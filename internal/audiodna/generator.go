@@ -2,41 +2,130 @@
 package audiodna
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"image"
 	"image/color"
-	"image/png"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/pforret/videodna/internal/alttext"
 	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/checksum"
+	"github.com/pforret/videodna/internal/events"
+	"github.com/pforret/videodna/internal/fingerprint"
+	"github.com/pforret/videodna/internal/font"
+	"github.com/pforret/videodna/internal/hooks"
+	"github.com/pforret/videodna/internal/icc"
+	"github.com/pforret/videodna/internal/imagesize"
+	"github.com/pforret/videodna/internal/lanespec"
+	"github.com/pforret/videodna/internal/locale"
+	"github.com/pforret/videodna/internal/plot"
+	"github.com/pforret/videodna/internal/progress"
+	"github.com/pforret/videodna/internal/retry"
+	"github.com/pforret/videodna/internal/sink"
+	"github.com/pforret/videodna/internal/tiles"
+	"github.com/pforret/videodna/internal/timing"
+	"github.com/pforret/videodna/internal/transform"
+	"github.com/pforret/videodna/internal/workdir"
+	"github.com/pforret/videodna/internal/xmp"
 )
 
 // Config configures DNA generation.
 type Config struct {
-	Width        int                 // Output width in pixels (0 = auto from duration)
-	Height       int                 // Output height in pixels (auto-calculated if 0)
-	StemConfig   audio.StemConfig    // Stem separation config
-	SkipStems    bool                // If true, use original audio only
-	Normalize    bool                // Normalize volume levels
-	ColorScheme  ColorScheme         // Color scheme for visualization
-	StemHeight   int                 // Height per stem in pixels (default: 50)
-	ShowLabels   bool                // Show stem labels at top
-	LabelHeight  int                 // Height of label area at top (default: 20)
-	Timeout      int                 // Timeout in seconds
-	Silent       bool                // Suppress progress output
-	ResizeWidth  int                 // Final resize width (0 = no resize)
-	ResizeHeight int                 // Final resize height (0 = no resize)
+	Width          int                   // Output width in pixels (0 = auto from duration)
+	Height         int                   // Output height in pixels (auto-calculated if 0)
+	StemConfig     audio.StemConfig      // Stem separation config
+	SkipStems      bool                  // If true, use original audio only
+	Normalize      bool                  // Normalize volume levels
+	ColorScheme    ColorScheme           // Color scheme for visualization
+	StemHeight     int                   // Height per stem in pixels (default: 50)
+	ShowLabels     bool                  // Show stem labels at top
+	LabelHeight    int                   // Height of label area at top (default: 20)
+	Timeout        int                   // Timeout in seconds
+	Silent         bool                  // Suppress progress output
+	ResizeWidth    int                   // Final resize width (0 = as rendered; both 0 = no resize)
+	ResizeHeight   int                   // Final resize height (0 = as rendered; both 0 = no resize)
+	LoudnessTarget *audio.LoudnessTarget // Check loudness compliance of the mix (nil = off)
+	ReportPath     string                // Write JSON report (empty = none)
+	CSVPath        string                // Write per-segment CSV (empty = none)
+	BitDepth       int                   // PCM extraction depth: 16, 24, or 32 float (default: 16)
+	Overlap        float64               // Volume window overlap, 0.0 to <1.0 (0 = adjacent buckets)
+	PeakOutline    bool                  // Draw true peak as a thin outline over the RMS body
+	Envelope       bool                  // Draw the min-max sample span in a translucent color behind the RMS body
+	DynamicRange   bool                  // Compute DR/PLR scores per stem and for the mix
+	BeatGrid       bool                  // Detect tempo and draw bar/beat grid lines behind the waveforms
+	Structure      bool                  // Segment the track into labeled sections shown above the stems
+	MFCC           int                   // Export this many MFCCs per segment of the mix (0 = off)
+	Diarize        audio.DiarizeConfig   // Color the vocals lane by speaker (empty Diarizer = off)
+	Noise          bool                  // Detect mains hum and noise floor, mark affected spans
+	Transform      transform.Options     // Reverse, flip or log-map the rendered time axis
+	Patterns       bool                  // Texture each stem with its own fill pattern (hatch, dots, ...)
+	Karaoke        bool                  // 2 stems: show the vocals/accompaniment balance above the stems
+	Podcast        bool                  // 2 stems: flag likely intro, outro and mid-roll ads
+	Classify       bool                  // Classify speech, music and silence, shown as a strip above the stems
+	Tracks         bool                  // One lane per audio track instead of stems (languages, M&E)
+	Channels       bool                  // One lane per channel (L, R, C, LFE, Ls, Rs) instead of stems
+	EventsPath     string                // Export sections, silences and QC spans as .edl, .ffmeta or .txt chapters
+	AltTextPath    string                // Write a text alternative (loud and quiet passages) as .html or .json
+	Scale          int                   // UI scale factor for HiDPI displays: labels, strips and text (default: 1)
+	ShowTimings    bool                  // Show the run time and its slowest stage in the label bar
+	MaxDimension   int                   // Cap of the output width in pixels; longer inputs use longer segments (0 = no cap)
+	ICCProfile     string                // Color profile of the PNG: srgb, rec709, none or an .icc path ("" = srgb)
+	XMP            bool                  // Write an XMP sidecar (output with .xmp) with the report
+	Checksum       checksum.Options      // Record (and verify) the SHA-256/MD5 of the input in the report and sidecar
+	Locale         locale.Locale         // Language of the labels and settings errors ("" = English)
+	Lanes          lanespec.Spec         // Order, height and visibility of the stem lanes (nil = all, StemHeight each)
+	Progress       progress.Func         // Stem separation progress with throughput and ETA (nil = none)
+
+	// AdaptiveColumns shares the width by loudness: loud passages get up to
+	// four times the columns of silence, with tick marks at round times
+	// and Result.ColumnTimes mapping columns back to timestamps.
+	AdaptiveColumns bool
+
+	// SegmentsPerSecond fixes the analysis resolution independently of the
+	// image width (0 = one segment per output pixel column).
+	SegmentsPerSecond float64
+
+	// FingerprintPath writes the stem segments as a multi-resolution
+	// fingerprint (FingerprintLevels columns each, default 64/256/1024/4096).
+	FingerprintPath   string
+	FingerprintLevels []int
+
+	// Hooks run user commands after probing (a failing hook aborts).
+	Hooks *hooks.Runner
+
+	// StemJobs limits the stem waveforms decoded at once (0 = all stems).
+	// Stems decoded together share one ffmpeg process; lower it when stems
+	// live on network storage or memory is tight.
+	StemJobs int
+
+	// Workdir is the parent of the per-run temp directory (stems), removed
+	// when Generate returns ("" = $TMPDIR). Ignored when ctx already carries
+	// a workdir.Manager. Without StemConfig.TorchHome or $TORCH_HOME, demucs
+	// model checkpoints are kept in its torch subdirectory.
+	Workdir string
+
+	// Retry reruns a crashing stem separator with backoff (see
+	// retry.DefaultPolicy; zero value = no retry).
+	Retry retry.Policy
+
+	// Sinks also receive the outputs, wherever they go (see package sink):
+	// ImageSink the image as PNG, ReportSink the JSON report (nil = none).
+	ImageSink  sink.Sink
+	ReportSink sink.Sink
 }
 
 // DefaultConfig returns default configuration.
 func DefaultConfig() Config {
 	return Config{
-		Width:        0,    // Auto-calculate from duration
-		Height:       0,    // Auto-calculate from stems
+		Width:        0, // Auto-calculate from duration
+		Height:       0, // Auto-calculate from stems
 		StemConfig:   audio.DefaultStemConfig(),
 		SkipStems:    false,
 		Normalize:    true,
@@ -44,18 +133,25 @@ func DefaultConfig() Config {
 		StemHeight:   50,
 		ShowLabels:   true,
 		LabelHeight:  20,
+		BitDepth:     16,
 		Timeout:      600, // 10 minutes default for stem separation
 		Silent:       false,
 		ResizeWidth:  0, // No resize by default
 		ResizeHeight: 0,
+		Scale:        1,
+		MaxDimension: DefaultMaxDimension,
+		Retry:        retry.DefaultPolicy(),
 	}
 }
 
 const (
-	defaultFPS      = 24  // Assumed FPS for audio files
-	minOutputWidth  = 720 // Minimum output width
+	defaultFPS     = 24  // Assumed FPS for audio files
+	minOutputWidth = 720 // Minimum output width
 )
 
+// DefaultMaxDimension is the default cap of the output width in pixels.
+const DefaultMaxDimension = imagesize.DefaultMaxDimension
+
 // ColorScheme defines how stems are colored.
 type ColorScheme string
 
@@ -64,6 +160,8 @@ const (
 	SchemeMonochrome ColorScheme = "monochrome" // Grayscale
 	SchemeHeatmap    ColorScheme = "heatmap"    // Volume as heat colors
 	SchemeSpectrum   ColorScheme = "spectrum"   // Rainbow spectrum
+	SchemeColorblind ColorScheme = "colorblind" // Okabe-Ito color-blind safe palette
+	SchemeTol        ColorScheme = "tol"        // Paul Tol bright color-blind safe palette
 )
 
 // StemColors maps stem types to colors.
@@ -82,17 +180,86 @@ type StemData struct {
 	Label    string
 	Segments []audio.VolumeSegment
 	Color    color.RGBA
+	Dynamics *audio.DynamicRange // DR/PLR scores (nil unless requested)
+	Pattern  FillPattern         // Fill texture over the waveform body
 }
 
 // Result contains the generated DNA image and metadata.
 type Result struct {
-	Image    *image.RGBA
-	Stems    []StemData
-	Duration float64
+	Image      *image.RGBA
+	Stems      []StemData
+	Duration   float64
+	Loudness   *audio.LoudnessResult   // Loudness of the mix (nil unless a target is set)
+	Compliance *audio.ComplianceReport // Loudness compliance (nil unless a target is set)
+	Dynamics   *audio.DynamicRange     // DR/PLR scores of the mix (nil unless requested)
+	Tempo      *audio.TempoResult      // Estimated tempo of the mix (nil unless requested or undetected)
+	Sections   []audio.Section         // Structure sections of the mix (nil unless requested)
+	Features   *Features               // Per-segment features of the mix (nil unless requested)
+	Speakers   []audio.SpeakerTurn     // Speaker turns in the vocals (nil unless requested)
+	Noise      *audio.NoiseReport      // Hum and noise floor findings (nil unless requested)
+	Silences   []Silence               // Silences of the mix (nil unless events are exported)
+	Karaoke    *audio.VocalBalance     // Vocals/accompaniment balance (nil unless requested with 2 stems)
+	Podcast    []audio.PodcastSegment  // Likely intro, outro and ads (nil unless requested with 2 stems)
+	Content    *audio.ContentReport    // Speech/music/silence classes of the mix (nil unless requested)
+	AVGap      *audio.AVGap            // Audio vs video stream duration (nil unless the input has video)
+	Checksums  *checksum.Sums          // Digests of the input file (nil unless requested)
+	Timings    timing.Timings          // Seconds per stage of the run
+
+	// ColumnTimes holds the time in seconds at the left edge of each image
+	// column when the time axis follows the content (AdaptiveColumns,
+	// CompressTail), so positions map back to timestamps (nil = linear).
+	ColumnTimes []float64
+}
+
+// Features holds per-segment content features for similarity search.
+type Features struct {
+	Hop  float64     `json:"hop"`  // Seconds between feature vectors (matches stem segments)
+	MFCC [][]float64 `json:"mfcc"` // MFCC vectors, one per segment
 }
 
 // Generate creates a DNA visualization from an audio file.
 func Generate(ctx context.Context, inputPath, outputPath string, config Config) (*Result, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	profile, err := icc.Resolve(config.ICCProfile)
+	if err != nil {
+		return nil, err
+	}
+	clock := timing.Start()
+	var timings timing.Timings
+
+	// Model checkpoints go with the other large files under the workdir
+	if config.Workdir != "" && config.StemConfig.TorchHome == "" && os.Getenv("TORCH_HOME") == "" {
+		config.StemConfig.TorchHome = filepath.Join(config.Workdir, "torch")
+	}
+
+	// Temp files of this run live in one directory that is removed on return
+	if workdir.FromContext(ctx) == nil {
+		work, err := workdir.New(config.Workdir)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			size, err := work.Cleanup()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			} else if size > 0 && !config.Silent {
+				fmt.Printf("Removed %s of temp files\n", workdir.FormatSize(size))
+			}
+		}()
+		ctx = workdir.NewContext(ctx, work)
+	}
+
+	// Checksum before any work, so a mismatched input is refused
+	sums, err := config.Checksum.Run(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if sums != nil && !config.Silent {
+		fmt.Printf("SHA-256: %s\n", sums.SHA256)
+	}
+
 	// Get audio info
 	info, err := audio.GetInfo(inputPath)
 	if err != nil {
@@ -109,14 +276,72 @@ func Generate(ctx context.Context, inputPath, outputPath string, config Config)
 		}
 	}
 
+	if limit := config.MaxDimension / max(config.Scale, 1); config.MaxDimension > 0 && config.Width > limit {
+		fmt.Fprintf(os.Stderr, "Warning: width %d exceeds the maximum dimension of %d pixels, using %d columns (raise -max-dimension, 0 = no limit)\n",
+			config.Width, config.MaxDimension, limit)
+		config.Width = max(limit, 1)
+	}
+
 	if !config.Silent {
 		fmt.Printf("Input: %s (%.1fs, %dHz, %dch, %dpx)\n",
 			inputPath, info.Duration, info.SampleRate, info.Channels, config.Width)
 	}
 
+	if err := config.Hooks.Run(ctx, hooks.Context{Point: hooks.AfterProbe, Input: inputPath, Output: outputPath, Info: map[string]any{
+		"duration":    info.Duration,
+		"sample_rate": info.SampleRate,
+		"channels":    info.Channels,
+		"bit_rate":    info.BitRate,
+		"codec":       info.Codec,
+	}}); err != nil {
+		return nil, err
+	}
+
+	avGap := audio.CheckAVGap(info)
+	if avGap != nil && avGap.Issue != "" && !config.Silent {
+		fmt.Printf("A/V gap: %s\n", avGapText(config.Locale, avGap))
+	}
+
 	var stemFiles *audio.StemFiles
 	var stemLabels []string
 	var stemPaths []string
+	var stemLanes []laneSource // Track and channel per lane (Tracks and Channels modes)
+
+	if config.Tracks || config.Channels {
+		tracks, err := audio.ListTracks(inputPath)
+		if err != nil {
+			return nil, err
+		}
+		if !config.Tracks {
+			tracks = tracks[:1]
+		}
+		for _, t := range tracks {
+			if !config.Channels {
+				stemPaths = append(stemPaths, inputPath)
+				stemLabels = append(stemLabels, t.Label())
+				stemLanes = append(stemLanes, laneSource{Track: t.Index})
+				continue
+			}
+			for _, ch := range audio.SurroundChannels(t.Layout, t.Channels) {
+				label := ch.Label
+				if config.Tracks {
+					label = t.Label() + " " + ch.Label
+				}
+				stemPaths = append(stemPaths, inputPath)
+				stemLabels = append(stemLabels, label)
+				stemLanes = append(stemLanes, laneSource{Track: t.Index, Channel: ch.Name})
+			}
+		}
+		if !config.Silent {
+			if config.Channels {
+				fmt.Printf("Audio channels: %d (%s)\n", len(stemLanes), tracks[0].Layout)
+			} else {
+				fmt.Printf("Audio tracks: %d\n", len(tracks))
+			}
+		}
+		config.SkipStems = true
+	}
+	timings.Probe = clock.Lap()
 
 	if !config.SkipStems {
 		// Check if separator is available
@@ -134,17 +359,31 @@ func Generate(ctx context.Context, inputPath, outputPath string, config Config)
 				config.StemConfig.NumStems, config.StemConfig.Separator)
 		}
 
-		stemFiles, err = audio.SeparateStems(ctx, inputPath, config.StemConfig)
+		stemConfig := config.StemConfig
+		stemConfig.Retry = config.Retry
+		stemConfig.Silent = config.Silent
+		if stemConfig.Progress == nil {
+			stemConfig.Progress = config.Progress
+		}
+		stemFiles, err = audio.SeparateStems(ctx, inputPath, stemConfig)
 		if err != nil {
 			return nil, fmt.Errorf("stem separation failed: %w", err)
 		}
 
 		stemPaths = stemFiles.GetStemPaths()
 		stemLabels = stemFiles.GetStemLabels()
+		if stemFiles.Resumed && !config.Silent {
+			fmt.Printf("Reusing %d stems from a previous run\n", len(stemPaths))
+		}
 	}
 
+	timings.Separation = clock.Lap()
+
 	// If no stems, use original audio
 	if len(stemPaths) == 0 {
+		if stemFiles != nil {
+			fmt.Fprintln(os.Stderr, "Warning: stem separation produced no stems, using original audio")
+		}
 		stemPaths = []string{inputPath}
 		stemLabels = []string{"mixed"}
 	}
@@ -153,56 +392,338 @@ func Generate(ctx context.Context, inputPath, outputPath string, config Config)
 		fmt.Printf("Extracting waveforms: %s\n", strings.Join(stemLabels, ", "))
 	}
 
-	// Process each stem in parallel
+	// Decoded PCM of the mix is shared by the analyses below
+	pcm := newPCMCache(inputPath)
+
+	// Decode the stems in groups of StemJobs, one ffmpeg per group, and
+	// compute their volume segments in parallel
+	jobs := config.StemJobs
+	if jobs <= 0 || jobs > len(stemPaths) {
+		jobs = len(stemPaths)
+	}
 	waveformConfig := audio.DefaultWaveformConfig()
+	if config.BitDepth != 0 {
+		waveformConfig.BitDepth = config.BitDepth
+	}
 	stemDataList := make([]StemData, len(stemPaths))
-	var wg sync.WaitGroup
-	var processErr error
-	var errMu sync.Mutex
+	rawSegments := make([][]audio.VolumeSegment, len(stemPaths)) // Before normalization (karaoke, podcast, alt text)
+
+	for start := 0; start < len(stemPaths); start += jobs {
+		end := min(start+jobs, len(stemPaths))
+		var lanes []laneSource
+		if stemLanes != nil {
+			lanes = stemLanes[start:end]
+		}
+		waveforms, err := decodeStems(ctx, pcm, stemPaths[start:end], stemLabels[start:end], lanes, waveformConfig)
+		if err != nil {
+			return nil, err
+		}
 
-	for i, stemPath := range stemPaths {
-		wg.Add(1)
-		go func(idx int, path, label string) {
-			defer wg.Done()
+		var wg sync.WaitGroup
+		for j, waveform := range waveforms {
+			wg.Add(1)
+			go func(idx int, waveform *audio.WaveformData, label string) {
+				defer wg.Done()
+
+				numSegments := config.Width
+				if config.SegmentsPerSecond > 0 {
+					numSegments = int(math.Ceil(waveform.Duration * config.SegmentsPerSecond))
+				} else if config.FingerprintPath != "" {
+					// Analyze at the finest fingerprint level; the image is resampled
+					numSegments = max(numSegments, fingerprintSegments(config.FingerprintLevels))
+				}
+				segments := audio.ExtractVolumeWindowed(waveform, audio.VolumeConfig{
+					NumSegments: numSegments,
+					Overlap:     config.Overlap,
+				})
+				if config.Karaoke || config.Podcast || config.AltTextPath != "" {
+					rawSegments[idx] = append([]audio.VolumeSegment(nil), segments...)
+				}
+				if config.Normalize && stemLanes == nil {
+					audio.NormalizeVolume(segments)
+				}
 
-			waveform, err := audio.ExtractWaveform(ctx, path, waveformConfig)
-			if err != nil {
-				errMu.Lock()
-				if processErr == nil {
-					processErr = fmt.Errorf("failed to extract waveform for %s: %w", label, err)
+				stemDataList[idx] = StemData{
+					Label:    label,
+					Segments: segments,
+					Color:    stemColor(label, idx, config.ColorScheme),
+				}
+				if stemLanes != nil {
+					stemDataList[idx].Color = laneColor(idx, config.ColorScheme)
+				}
+				if config.Patterns {
+					stemDataList[idx].Pattern = stemPattern(idx)
+				}
+				if config.DynamicRange {
+					dr := audio.MeasureDynamicRange(waveform)
+					stemDataList[idx].Dynamics = &dr
+				}
+			}(start+j, waveform, stemLabels[start+j])
+		}
+		wg.Wait()
+	}
+
+	if stemLanes != nil {
+		// Lanes share one scale, so a silent track or channel stays flat
+		for i, stem := range stemDataList {
+			if !silentLane(stem.Segments) {
+				continue
+			}
+			switch {
+			case stemLanes[i].Channel == "":
+				fmt.Fprintf(os.Stderr, "Warning: audio track %s is silent\n", stem.Label)
+			case stemLanes[i].Channel == "FC":
+				fmt.Fprintf(os.Stderr, "Warning: center channel %s is silent (no dialogue)\n", stem.Label)
+			default:
+				fmt.Fprintf(os.Stderr, "Warning: channel %s is silent\n", stem.Label)
+			}
+		}
+		if config.Normalize {
+			normalizeLanes(stemDataList)
+		}
+	}
+
+	timings.Waveform = clock.Lap()
+
+	var loudness *audio.LoudnessResult
+	var compliance *audio.ComplianceReport
+	if config.LoudnessTarget != nil {
+		loudness, err = measureLoudness(ctx, pcm, inputPath)
+		if err != nil {
+			return nil, err
+		}
+		compliance = audio.CheckCompliance(loudness, *config.LoudnessTarget)
+
+		if !config.Silent {
+			fmt.Printf("Loudness: %.1f LUFS integrated, %.1f dBTP true peak\n", loudness.Integrated, loudness.TruePeak)
+			status := "PASS"
+			if !compliance.Pass {
+				status = "FAIL"
+			}
+			fmt.Printf("Compliance (%s): %s, %d violations\n", compliance.Target.Name, status, len(compliance.Violations))
+		}
+	}
+
+	var dynamics *audio.DynamicRange
+	if config.DynamicRange {
+		dynamics, err = measureDynamics(ctx, pcm, inputPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !config.Silent {
+			var scores []string
+			for _, stem := range stemDataList {
+				scores = append(scores, fmt.Sprintf("%s DR%.0f (PLR %.1f)", stem.Label, stem.Dynamics.DR, stem.Dynamics.PLR))
+			}
+			scores = append(scores, fmt.Sprintf("mix DR%.0f (PLR %.1f)", dynamics.DR, dynamics.PLR))
+			fmt.Printf("Dynamic range: %s\n", strings.Join(scores, ", "))
+		}
+	}
+
+	var tempo *audio.TempoResult
+	if config.BeatGrid {
+		tempo, err = measureTempo(ctx, pcm, inputPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !config.Silent {
+			if tempo != nil {
+				fmt.Printf("Tempo: %.1f BPM (first beat %.2fs, confidence %.2f)\n", tempo.BPM, tempo.Offset, tempo.Confidence)
+			} else {
+				fmt.Println("Tempo: not detected, no beat grid")
+			}
+		}
+	}
+
+	var sections []audio.Section
+	if config.Structure {
+		sections, err = measureStructure(ctx, pcm, inputPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !config.Silent {
+			var form []string
+			for _, s := range sections {
+				form = append(form, s.Label)
+			}
+			fmt.Printf("Structure: %s (%d sections)\n", strings.Join(form, " "), len(sections))
+		}
+	}
+
+	var features *Features
+	if config.MFCC > 0 && len(stemDataList[0].Segments) > 0 {
+		hop := info.Duration / float64(len(stemDataList[0].Segments))
+		mfcc, err := measureMFCC(ctx, pcm, inputPath, hop, config.MFCC)
+		if err != nil {
+			return nil, err
+		}
+		features = &Features{Hop: hop, MFCC: mfcc}
+
+		if !config.Silent {
+			fmt.Printf("Features: %d MFCC vectors of %d coefficients\n", len(mfcc), config.MFCC)
+		}
+	}
+
+	var speakers []audio.SpeakerTurn
+	twoStems := len(stemLabels) == 2 && stemLabels[0] == "vocals" && stemLabels[1] == "other"
+	var karaoke *audio.VocalBalance
+	if config.Karaoke {
+		if twoStems {
+			karaoke = audio.MeasureVocalBalance(rawSegments[0], rawSegments[1], info.Duration)
+		} else {
+			fmt.Fprintln(os.Stderr, "Warning: the karaoke lane needs a 2-stem separation (-stems 2), skipped")
+		}
+
+		if karaoke != nil && !config.Silent {
+			var acappella, instrumental float64
+			for _, s := range karaoke.Spans {
+				if s.Kind == audio.SpanACappella {
+					acappella += s.End - s.Start
+				} else {
+					instrumental += s.End - s.Start
 				}
-				errMu.Unlock()
-				return
 			}
+			fmt.Printf("Karaoke: %.0fs a cappella, %.0fs instrumental-only in %d spans\n", acappella, instrumental, len(karaoke.Spans))
+		}
+	}
 
-			segments := audio.ExtractVolume(waveform, config.Width)
-			if config.Normalize {
-				audio.NormalizeVolume(segments)
+	var podcast []audio.PodcastSegment
+	if config.Podcast {
+		if twoStems {
+			podcast = audio.DetectPodcastSegments(rawSegments[0], rawSegments[1], info.Duration)
+		} else {
+			fmt.Fprintln(os.Stderr, "Warning: podcast segments need a 2-stem separation (-stems 2), skipped")
+		}
+
+		if twoStems && !config.Silent {
+			counts := map[string]int{}
+			for _, s := range podcast {
+				counts[s.Kind]++
 			}
+			fmt.Printf("Podcast: %d intro, %d outro, %d likely ads\n", counts[audio.PodcastIntro], counts[audio.PodcastOutro], counts[audio.PodcastAd])
+		}
+	}
+
+	var content *audio.ContentReport
+	if config.Classify {
+		content, err = measureContent(ctx, pcm, inputPath)
+		if err != nil {
+			return nil, err
+		}
 
-			stemColor := StemColors[label]
-			if stemColor.A == 0 {
-				stemColor = StemColors["mixed"]
+		if content != nil && !config.Silent {
+			fmt.Printf("Content: %.0f%% speech, %.0f%% music, %.0f%% silence\n",
+				content.Share[audio.ClassSpeech]*100, content.Share[audio.ClassMusic]*100, content.Share[audio.ClassSilence]*100)
+		}
+	}
+
+	speechStem := -1
+	if config.Diarize.Diarizer != "" {
+		// Diarize the vocals stem when separated, otherwise the mix
+		speechStem = 0
+		for i, label := range stemLabels {
+			if label == "vocals" {
+				speechStem = i
 			}
+		}
+		speakers, err = measureSpeakers(ctx, pcm, stemPaths[speechStem], config.Diarize)
+		if err != nil {
+			return nil, err
+		}
 
-			stemDataList[idx] = StemData{
-				Label:    label,
-				Segments: segments,
-				Color:    stemColor,
+		if !config.Silent {
+			fmt.Printf("Speakers: %d turns (%s)\n", len(speakers), speakerSummary(speakers))
+		}
+	}
+
+	var noise *audio.NoiseReport
+	if config.Noise {
+		noise, err = measureNoise(ctx, pcm, inputPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !config.Silent {
+			hum := "none"
+			if noise.HumFrequency > 0 {
+				hum = fmt.Sprintf("%.0f Hz in %d spans", noise.HumFrequency, len(noise.Hum))
 			}
-		}(i, stemPath, stemLabels[i])
+			fmt.Printf("Noise: floor %.1f dBFS, %d noisy quiet passages, hum %s\n", noise.NoiseFloor, len(noise.Noisy), hum)
+		}
 	}
 
-	wg.Wait()
+	var silences []Silence
+	if config.EventsPath != "" {
+		silences, err = measureSilences(ctx, pcm, inputPath)
+		if err != nil {
+			return nil, err
+		}
+		if !config.Silent {
+			fmt.Printf("Silences: %d of %.0fs or longer\n", len(silences), minSilence)
+		}
+	}
+
+	timings.Decode = clock.Lap()
+
+	if (config.Transform.CompressTail || config.AdaptiveColumns) && len(stemDataList) > 0 {
+		loudness := make([]float64, len(stemDataList[0].Segments))
+		for _, stem := range stemDataList {
+			for i := 0; i < len(stem.Segments) && i < len(loudness); i++ {
+				loudness[i] += stem.Segments[i].RMS
+			}
+		}
+		if config.Transform.CompressTail {
+			config.Transform.Tail = transform.TailStart(loudness)
+			if !config.Silent && config.Transform.Tail > 0 {
+				fmt.Printf("Quiet tail from %.1fs compressed\n", config.Transform.Tail*info.Duration)
+			}
+		}
+		if config.AdaptiveColumns {
+			// Loud passages spread their share over two seconds around them
+			window := 1
+			if info.Duration > 0 {
+				window = int(2 * float64(len(loudness)) / info.Duration)
+			}
+			config.Transform.Warp = transform.ActivityWarp(loudness, window)
+		}
+	}
 
-	if processErr != nil {
-		return nil, processErr
+	// Arrange the stems: order, height and visibility from the lane spec
+	names := make([]string, len(stemDataList))
+	for i, stem := range stemDataList {
+		names[i] = stem.Label
+	}
+	order, stemHeights, unknown := config.Lanes.Arrange(names)
+	for _, name := range unknown {
+		fmt.Fprintf(os.Stderr, "Warning: no stem named %q to arrange\n", name)
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("the lane spec hides every stem")
+	}
+	stemsHeight := 0
+	for i, h := range stemHeights {
+		if h == 0 {
+			stemHeights[i] = config.StemHeight
+		}
+		stemsHeight += stemHeights[i]
+	}
+	visible := make([]StemData, len(order))
+	for n, i := range order {
+		visible[n] = stemDataList[i]
 	}
 
 	// Calculate waveform dimensions (without labels)
 	waveformHeight := config.Height
 	if waveformHeight == 0 {
-		waveformHeight = len(stemDataList) * config.StemHeight
+		waveformHeight = stemsHeight
+	} else {
+		// Share a fixed height in proportion to the stem heights
+		for i := range stemHeights {
+			stemHeights[i] = stemHeights[i] * waveformHeight / stemsHeight
+		}
 	}
 	waveformWidth := config.Width
 
@@ -217,55 +738,131 @@ func Generate(ctx context.Context, inputPath, outputPath string, config Config)
 		}
 	}
 
-	// Draw each stem
-	stemPixelHeight := waveformHeight / len(stemDataList)
+	if tempo != nil {
+		drawBeatGrid(waveformImg, tempo, info.Duration)
+	}
 
-	for i, stemData := range stemDataList {
-		yStart := i * stemPixelHeight
+	// Draw each stem
+	yStart := 0
+	for n, i := range order {
+		stemData := stemDataList[i]
+		stemPixelHeight := stemHeights[n]
 		yMid := yStart + stemPixelHeight/2
 
-		// Draw waveform
-		for x, seg := range stemData.Segments {
-			if x >= waveformWidth {
-				break
-			}
+		segments := audio.ResampleSegments(stemData.Segments, waveformWidth)
 
-			// Calculate bar height based on RMS
-			barHeight := int(seg.RMS * float64(stemPixelHeight) * 0.8)
-			if barHeight < 1 {
-				barHeight = 1
+		// With a peak outline or envelope, scale the lane so the highest
+		// peak fits
+		laneScale := 1.0
+		if config.PeakOutline || config.Envelope {
+			var maxPeak float64
+			for _, seg := range segments {
+				if p := peakLevel(seg); p > maxPeak {
+					maxPeak = p
+				}
+			}
+			if maxPeak > 1 {
+				laneScale = 1 / maxPeak
 			}
+		}
+		outlineColor := lightenColor(stemData.Color)
 
-			// Draw symmetric waveform
-			halfHeight := barHeight / 2
+		var columnColors []*color.RGBA
+		if i == speechStem {
+			columnColors = speakerColumns(speakers, info.Duration, waveformWidth)
+		}
 
-			for y := yMid - halfHeight; y <= yMid+halfHeight; y++ {
-				if y >= yStart && y < yStart+stemPixelHeight {
-					// Calculate intensity based on distance from center
-					dist := abs(y - yMid)
-					intensity := 1.0 - float64(dist)/float64(halfHeight+1)*0.3
+		// Draw waveform
+		levels := make([]float64, len(segments))
+		for x, seg := range segments {
+			levels[x] = seg.RMS * laneScale
+		}
+		paint := func(x, y int, intensity float64) color.RGBA {
+			base := stemData.Color
+			if columnColors != nil && columnColors[x] != nil {
+				base = *columnColors[x]
+			}
+			if patternMask(stemData.Pattern, x, y) {
+				intensity *= 0.55
+			}
+			return scaleColor(base, intensity)
+		}
+		laneRect := image.Rect(0, yStart, waveformWidth, yStart+stemPixelHeight)
+		if config.Envelope {
+			lows, highs := envelopeLevels(segments, laneScale)
+			plot.DrawSpan(waveformImg, laneRect, lows, highs, func(x, y int, intensity float64) color.RGBA {
+				return plot.Blend(waveformImg.RGBAAt(x, y), paint(x, y, intensity), envelopeAlpha)
+			})
+		}
+		plot.Draw(waveformImg, laneRect, levels, plot.Waveform, paint)
 
-					c := scaleColor(stemData.Color, intensity)
-					waveformImg.SetRGBA(x, y, c)
+		if config.PeakOutline {
+			for x, seg := range segments {
+				if x >= waveformWidth {
+					break
+				}
+				peakHalf := int(peakLevel(seg)*laneScale*float64(stemPixelHeight)*0.8) / 2
+				for _, y := range []int{yMid - peakHalf, yMid + peakHalf} {
+					if y >= yStart && y < yStart+stemPixelHeight-1 {
+						waveformImg.SetRGBA(x, y, outlineColor)
+					}
 				}
 			}
 		}
 
 		// Draw separator line
-		if i < len(stemDataList)-1 {
+		if n < len(order)-1 {
 			sepY := yStart + stemPixelHeight - 1
 			sepColor := color.RGBA{R: 50, G: 50, B: 55, A: 255}
 			for x := 0; x < waveformWidth; x++ {
 				waveformImg.SetRGBA(x, sepY, sepColor)
 			}
 		}
+		yStart += stemPixelHeight
+	}
+
+	if compliance != nil {
+		drawViolations(waveformImg, compliance.Violations, info.Duration)
+	}
+	if noise != nil {
+		drawNoise(waveformImg, noise, info.Duration)
+	}
+	if !config.Transform.IsZero() {
+		waveformImg = config.Transform.Apply(waveformImg, true)
 	}
 
 	// Resize waveform if requested (before adding labels)
 	finalWaveform := waveformImg
-	if config.ResizeWidth > 0 && config.ResizeHeight > 0 {
-		finalWaveform = resizeImage(waveformImg, config.ResizeWidth, config.ResizeHeight)
+	if config.ResizeWidth > 0 || config.ResizeHeight > 0 {
+		width, height := waveformImg.Bounds().Dx(), waveformImg.Bounds().Dy()
+		if config.ResizeWidth > 0 {
+			width = config.ResizeWidth
+		}
+		if config.ResizeHeight > 0 {
+			height = config.ResizeHeight
+		}
+		finalWaveform = resizeImage(waveformImg, width, height)
+	}
+
+	// HiDPI: enlarge the waveform without interpolation so the time
+	// resolution stays the same, and draw labels and strips at the same scale
+	scale := max(config.Scale, 1)
+	if scale > 1 {
+		finalWaveform = scaleNearest(finalWaveform, scale)
+	}
+	var columnTimes []float64
+	if config.Transform.Warped() {
+		transform.DrawTicks(finalWaveform, finalWaveform.Bounds(), config.Transform.Ticks(info.Duration), true, scale)
+		columnTimes = make([]float64, finalWaveform.Bounds().Dx())
+		for x := range columnTimes {
+			columnTimes[x] = config.Transform.SourceTime(float64(x)/float64(len(columnTimes))) * info.Duration
+		}
 	}
+	labelHeight := config.LabelHeight * scale
+	sectionHeight := sectionStripHeight * scale
+	karaokeHeight := karaokeStripHeight * scale
+	podcastHeight := podcastStripHeight * scale
+	classHeight := classStripHeight * scale
 
 	// Create final image with labels on top
 	finalWidth := finalWaveform.Bounds().Dx()
@@ -274,8 +871,28 @@ func Generate(ctx context.Context, inputPath, outputPath string, config Config)
 	labelOffset := 0
 
 	if config.ShowLabels {
-		finalHeight += config.LabelHeight
-		labelOffset = config.LabelHeight
+		finalHeight += labelHeight
+		labelOffset = labelHeight
+	}
+	sectionsOffset := labelOffset
+	if len(sections) > 0 {
+		finalHeight += sectionHeight
+		labelOffset += sectionHeight
+	}
+	karaokeOffset := labelOffset
+	if karaoke != nil {
+		finalHeight += karaokeHeight
+		labelOffset += karaokeHeight
+	}
+	podcastOffset := labelOffset
+	if config.Podcast && twoStems {
+		finalHeight += podcastHeight
+		labelOffset += podcastHeight
+	}
+	contentOffset := labelOffset
+	if content != nil {
+		finalHeight += classHeight
+		labelOffset += classHeight
 	}
 
 	img := image.NewRGBA(image.Rect(0, 0, finalWidth, finalHeight))
@@ -283,7 +900,7 @@ func Generate(ctx context.Context, inputPath, outputPath string, config Config)
 	// Fill label area background
 	if config.ShowLabels {
 		labelBg := color.RGBA{R: 25, G: 25, B: 30, A: 255}
-		for y := 0; y < config.LabelHeight; y++ {
+		for y := 0; y < labelHeight; y++ {
 			for x := 0; x < finalWidth; x++ {
 				img.SetRGBA(x, y, labelBg)
 			}
@@ -297,30 +914,176 @@ func Generate(ctx context.Context, inputPath, outputPath string, config Config)
 		}
 	}
 
+	if len(sections) > 0 {
+		drawSections(img, sections, info.Duration, sectionsOffset, finalWidth, config.Transform, scale)
+	}
+	if karaoke != nil {
+		drawVocalBalance(img, karaoke, info.Duration, karaokeOffset, finalWidth, config.Transform, scale)
+	}
+	if config.Podcast && twoStems {
+		drawPodcast(img, podcast, info.Duration, podcastOffset, finalWidth, config.Transform, config.Locale, scale)
+	}
+	if content != nil {
+		drawContent(img, content, info.Duration, contentOffset, finalWidth, config.Transform, scale)
+	}
+
 	// Draw labels at top if enabled
 	if config.ShowLabels {
-		drawLabelsTop(img, stemDataList, config.LabelHeight, finalWidth)
+		drawLabelsTop(img, visible, labelHeight, finalWidth, config.Locale, scale)
+		right := finalWidth - 10*scale
+		if config.ShowTimings {
+			sofar := timings
+			sofar.Render, sofar.Total = clock.Lap(), clock.Total()
+			timings.Render = sofar.Render
+			right = drawStatusText(img, sofar.SummaryIn(config.Locale), labelHeight, right, color.RGBA{R: 150, G: 150, B: 160, A: 255}, scale) - 16*scale
+		}
+		if avGap != nil && avGap.Issue != "" {
+			right = drawStatusText(img, avGapText(config.Locale, avGap), labelHeight, right, color.RGBA{R: 255, G: 100, B: 100, A: 255}, scale) - 16*scale
+		}
+		if dynamics != nil {
+			right = drawStatusText(img, dynamicsText(config.Locale.T("mix"), dynamics), labelHeight, right, color.RGBA{R: 200, G: 200, B: 200, A: 255}, scale) - 16*scale
+		}
+		if compliance != nil {
+			statusColor := color.RGBA{R: 100, G: 255, B: 150, A: 255}
+			if !compliance.Pass {
+				statusColor = color.RGBA{R: 255, G: 100, B: 100, A: 255}
+			}
+			drawStatusText(img, complianceText(config.Locale, compliance), labelHeight, right, statusColor, scale)
+		}
 	}
 
+	timings.Render += clock.Lap()
+
 	// Save output
-	if outputPath != "" {
-		if err := saveImage(img, outputPath); err != nil {
-			return nil, fmt.Errorf("failed to save image: %w", err)
+	if config.ImageSink != nil || config.ReportSink != nil {
+		if err := config.Hooks.Run(ctx, hooks.Context{Point: hooks.BeforeUpload, Input: inputPath, Output: outputPath}); err != nil {
+			return nil, err
+		}
+	}
+	format, err := deliverImage(ctx, img, outputPath, config.ImageSink, profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save image: %w", err)
+	}
+
+	result := &Result{
+		Image:      img,
+		Stems:      stemDataList,
+		Duration:   info.Duration,
+		Loudness:   loudness,
+		Compliance: compliance,
+		Dynamics:   dynamics,
+		Tempo:      tempo,
+		Sections:   sections,
+		Features:   features,
+		Speakers:   speakers,
+		Noise:      noise,
+		Silences:   silences,
+		Karaoke:    karaoke,
+		Podcast:    podcast,
+		Content:    content,
+		AVGap:      avGap,
+		Checksums:  sums,
+	}
+	result.ColumnTimes = columnTimes
+
+	if config.FingerprintPath != "" {
+		fp := audioFingerprint(result.Stems, inputPath, result.Duration, config.FingerprintLevels)
+		if err := fingerprint.Write(config.FingerprintPath, fp); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.EventsPath != "" {
+		if err := events.Write(config.EventsPath, result.Events(), result.Duration, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.AltTextPath != "" {
+		alt := audioAltText(result.Stems, rawSegments, outputPath, inputPath, result.Duration)
+		if err := alttext.Write(config.AltTextPath, alt); err != nil {
+			return nil, err
+		}
+	}
+
+	timings.Encode = clock.Lap()
+	timings.Total = clock.Total()
+	result.Timings = timings
+	if !config.Silent {
+		fmt.Printf("Timings: probe %.1fs, separation %.1fs, waveform %.1fs, decode %.1fs, render %.1fs, encode %.1fs\n",
+			timings.Probe, timings.Separation, timings.Waveform, timings.Decode, timings.Render, timings.Encode)
+	}
+
+	if reports := sink.Multi(sink.File(config.ReportPath), config.ReportSink); reports != nil {
+		if err := putJSON(ctx, reports, NewReport(inputPath, result)); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.CSVPath != "" {
+		if err := writeCSV(config.CSVPath, result.Stems, result.Features); err != nil {
+			return nil, err
 		}
 	}
 
-	return &Result{
-		Image:    img,
-		Stems:    stemDataList,
-		Duration: info.Duration,
-	}, nil
+	if config.XMP && outputPath != "" {
+		path := xmp.SidecarPath(outputPath)
+		bounds := img.Bounds()
+		err := xmp.Write(path, xmp.Packet{
+			Title:       strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath)),
+			Source:      inputPath,
+			Format:      format,
+			CreatorTool: "audiodna",
+			Created:     time.Now(),
+			Width:       bounds.Dx(),
+			Height:      bounds.Dy(),
+			Metadata:    map[string]any{"analysis": NewReport(inputPath, result)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !config.Silent {
+			fmt.Printf("XMP sidecar: %s\n", path)
+		}
+	}
+
+	return result, nil
+}
+
+// peakLevel returns the true peak on the (possibly normalized) RMS scale of a
+// segment, derived from its crest factor.
+func peakLevel(seg audio.VolumeSegment) float64 {
+	return seg.RMS * math.Pow(10, seg.Crest/20)
 }
 
-func abs(x int) int {
-	if x < 0 {
-		return -x
+// envelopeAlpha is the opacity of the min-max envelope behind the RMS body.
+const envelopeAlpha = 0.35
+
+// envelopeLevels returns the lowest and highest sample of each segment as
+// signed levels on the scale of the RMS body: the ratio of peakLevel to the
+// true peak carries the lane normalization over to the raw samples.
+func envelopeLevels(segments []audio.VolumeSegment, laneScale float64) (lows, highs []float64) {
+	lows = make([]float64, len(segments))
+	highs = make([]float64, len(segments))
+	for x, seg := range segments {
+		if seg.TruePeak <= 0 {
+			lows[x], highs[x] = math.NaN(), math.NaN()
+			continue
+		}
+		k := peakLevel(seg) / seg.TruePeak * laneScale
+		lows[x], highs[x] = seg.Min*k, seg.Max*k
+	}
+	return lows, highs
+}
+
+// lightenColor moves a color halfway towards white.
+func lightenColor(c color.RGBA) color.RGBA {
+	return color.RGBA{
+		R: c.R + (255-c.R)/2,
+		G: c.G + (255-c.G)/2,
+		B: c.B + (255-c.B)/2,
+		A: c.A,
 	}
-	return x
 }
 
 func scaleColor(c color.RGBA, scale float64) color.RGBA {
@@ -332,8 +1095,17 @@ func scaleColor(c color.RGBA, scale float64) color.RGBA {
 	}
 }
 
-// resizeImage resizes an image using bilinear interpolation
+// resizeImage resizes an image. Dimensions that shrink are box-filtered
+// first, so thin waveform peaks are averaged instead of aliasing; dimensions
+// that grow use bilinear interpolation.
 func resizeImage(src *image.RGBA, newWidth, newHeight int) *image.RGBA {
+	if newWidth < src.Bounds().Dx() || newHeight < src.Bounds().Dy() {
+		src = shrinkImage(src, min(newWidth, src.Bounds().Dx()), min(newHeight, src.Bounds().Dy()))
+		if src.Bounds().Dx() == newWidth && src.Bounds().Dy() == newHeight {
+			return src
+		}
+	}
+
 	srcBounds := src.Bounds()
 	srcW := srcBounds.Dx()
 	srcH := srcBounds.Dy()
@@ -389,23 +1161,65 @@ func resizeImage(src *image.RGBA, newWidth, newHeight int) *image.RGBA {
 	return dst
 }
 
-func bilinear(c00, c10, c01, c11, xFrac, yFrac float64) float64 {
-	return c00*(1-xFrac)*(1-yFrac) + c10*xFrac*(1-yFrac) + c01*(1-xFrac)*yFrac + c11*xFrac*yFrac
+// shrinkImage scales an image down with a box filter: each pixel is the
+// average of the source pixels it covers, weighted by their overlap.
+func shrinkImage(src *image.RGBA, newWidth, newHeight int) *image.RGBA {
+	bounds := src.Bounds()
+	xTaps := boxTaps(bounds.Dx(), newWidth)
+	yTaps := boxTaps(bounds.Dy(), newHeight)
+
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	for y, ys := range yTaps {
+		for x, xs := range xTaps {
+			var sum [4]float64
+			var total float64
+			for _, ty := range ys {
+				for _, tx := range xs {
+					weight := tx.weight * ty.weight
+					p := src.Pix[src.PixOffset(bounds.Min.X+tx.index, bounds.Min.Y+ty.index):]
+					for c := range sum {
+						sum[c] += float64(p[c]) * weight
+					}
+					total += weight
+				}
+			}
+			p := dst.Pix[dst.PixOffset(x, y):]
+			for c := range sum {
+				p[c] = uint8(sum[c]/total + 0.5)
+			}
+		}
+	}
+	return dst
 }
 
-// stemDisplayNames maps internal stem names to display names
-var stemDisplayNames = map[string]string{
-	"vocals": "vocals",
-	"drums":  "drums",
-	"bass":   "bass",
-	"other":  "other",
-	"piano":  "piano",
-	"guitar": "guitar",
-	"mixed":  "mixed",
+// boxTap is a source pixel of a box filter and its share of the output pixel.
+type boxTap struct {
+	index  int
+	weight float64
+}
+
+// boxTaps returns the source pixels covered by each of to output pixels
+// when from pixels are scaled down to to.
+func boxTaps(from, to int) [][]boxTap {
+	taps := make([][]boxTap, to)
+	ratio := float64(from) / float64(to)
+	for d := range taps {
+		lo, hi := float64(d)*ratio, float64(d+1)*ratio
+		for s := int(lo); s < from && float64(s) < hi; s++ {
+			if w := min(float64(s+1), hi) - max(float64(s), lo); w > 0 {
+				taps[d] = append(taps[d], boxTap{index: s, weight: w})
+			}
+		}
+	}
+	return taps
+}
+
+func bilinear(c00, c10, c01, c11, xFrac, yFrac float64) float64 {
+	return c00*(1-xFrac)*(1-yFrac) + c10*xFrac*(1-yFrac) + c01*(1-xFrac)*yFrac + c11*xFrac*yFrac
 }
 
 // drawLabelsTop draws stem labels horizontally at the top of the image
-func drawLabelsTop(img *image.RGBA, stems []StemData, labelHeight, totalWidth int) {
+func drawLabelsTop(img *image.RGBA, stems []StemData, labelHeight, totalWidth int, l locale.Locale, scale int) {
 	// Calculate spacing for labels
 	numStems := len(stems)
 	if numStems == 0 {
@@ -425,84 +1239,95 @@ func drawLabelsTop(img *image.RGBA, stems []StemData, labelHeight, totalWidth in
 	yMid := labelHeight / 2
 
 	for i, stem := range stems {
-		xStart := i*labelSpacing + 10
+		xStart := i*labelSpacing + 10*scale
 
 		// Draw color indicator square
-		indicatorSize := 8
+		indicatorSize := 8 * scale
 		for y := yMid - indicatorSize/2; y <= yMid+indicatorSize/2; y++ {
 			for x := xStart; x < xStart+indicatorSize; x++ {
-				img.SetRGBA(x, y, stem.Color)
+				if patternMask(stem.Pattern, (x-xStart)/scale, y/scale) {
+					img.SetRGBA(x, y, scaleColor(stem.Color, 0.55))
+				} else {
+					img.SetRGBA(x, y, stem.Color)
+				}
 			}
 		}
 
 		// Draw label text
-		displayName := stemDisplayNames[stem.Label]
-		if displayName == "" {
-			displayName = stem.Label
+		displayName := l.T(stem.Label)
+		if stem.Dynamics != nil {
+			displayName = dynamicsText(displayName, stem.Dynamics)
 		}
-		drawText(img, displayName, xStart+indicatorSize+4, yMid-3, stem.Color)
+		drawTextScaled(img, displayName, xStart+indicatorSize+4*scale, yMid-3*scale, stem.Color, scale)
 	}
 }
 
+// drawStatusText draws status text in the label bar, right-aligned to x =
+// right, and returns the x where the text starts.
+func drawStatusText(img *image.RGBA, text string, labelHeight, right int, c color.RGBA, scale int) int {
+	x := right - textWidth(text)*scale
+	drawTextScaled(img, text, x, labelHeight/2-3*scale, c, scale)
+	return x
+}
+
+// textWidth returns the rendered width of text in pixels.
+func textWidth(text string) int {
+	return font.Width(text)
+}
+
 // drawText draws text using a simple bitmap font
 func drawText(img *image.RGBA, text string, x, y int, c color.RGBA) {
-	for _, ch := range text {
-		pattern, ok := bitmapFont[byte(ch)]
-		if !ok {
-			x += 6 // space for unknown chars
-			continue
-		}
+	drawTextScaled(img, text, x, y, c, 1)
+}
 
-		for dy, row := range pattern {
-			for dx, pixel := range row {
-				if pixel == '#' {
-					img.SetRGBA(x+dx, y+dy, c)
-				}
-			}
+// drawTextScaled draws text with every font pixel enlarged to scale x scale.
+func drawTextScaled(img *image.RGBA, text string, x, y int, c color.RGBA, scale int) {
+	font.Draw(img, text, x, y, c, scale)
+}
+
+// scaleNearest enlarges an image by an integer factor without interpolation.
+func scaleNearest(src *image.RGBA, factor int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w*factor, h*factor))
+	for y := 0; y < h*factor; y++ {
+		for x := 0; x < w*factor; x++ {
+			dst.SetRGBA(x, y, src.RGBAAt(src.Bounds().Min.X+x/factor, src.Bounds().Min.Y+y/factor))
 		}
-		x += len(pattern[0]) + 1 // char width + spacing
 	}
+	return dst
 }
 
-// bitmapFont is a simple 5x7 bitmap font
-var bitmapFont = map[byte][]string{
-	'a': {"..#..", ".#.#.", "#...#", "#####", "#...#", "#...#", "#...#"},
-	'b': {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
-	'c': {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
-	'd': {"####.", "#...#", "#...#", "#...#", "#...#", "#...#", "####."},
-	'e': {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
-	'g': {".###.", "#....", "#....", "#.###", "#...#", "#...#", ".###."},
-	'h': {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
-	'i': {".###.", "..#..", "..#..", "..#..", "..#..", "..#..", ".###."},
-	'l': {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
-	'm': {"#...#", "##.##", "#.#.#", "#...#", "#...#", "#...#", "#...#"},
-	'n': {"#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#", "#...#"},
-	'o': {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
-	'p': {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
-	'r': {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
-	's': {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
-	't': {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
-	'u': {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
-	'v': {"#...#", "#...#", "#...#", "#...#", ".#.#.", ".#.#.", "..#.."},
-	'x': {"#...#", ".#.#.", "..#..", "..#..", "..#..", ".#.#.", "#...#"},
+// saveImage writes img as PNG tagged with profile (nil = untagged), or as a
+// Deep Zoom tile pyramid when path ends in .dzi; "-" writes the PNG to
+// standard output.
+func saveImage(img *image.RGBA, path string, profile *icc.Profile) error {
+	_, err := deliverImage(context.Background(), img, path, nil, profile)
+	return err
 }
 
-func saveImage(img *image.RGBA, path string) error {
-	// Ensure directory exists
-	dir := filepath.Dir(path)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return err
+// deliverImage encodes img as PNG once and puts it to the file at path ("" =
+// none) and to extra (nil = none) as one composed sink, and returns the
+// content type. A .dzi path is written as a tile pyramid of PNG tiles
+// instead, and extra still receives the PNG.
+func deliverImage(ctx context.Context, img *image.RGBA, path string, extra sink.Sink, profile *icc.Profile) (string, error) {
+	if tiles.IsDZIPath(path) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", err
 		}
+		if err := tiles.WriteDZI(img, path, tiles.DefaultTileSize, tiles.DefaultOverlap); err != nil {
+			return "", err
+		}
+		path = ""
 	}
-
-	f, err := os.Create(path)
-	if err != nil {
-		return err
+	out := sink.Multi(sink.File(path), extra)
+	if out == nil {
+		return sink.TypePNG, nil
 	}
-	defer f.Close()
-
-	return png.Encode(f, img)
+	var buf bytes.Buffer
+	if err := icc.EncodePNG(&buf, img, profile); err != nil {
+		return "", err
+	}
+	return sink.TypePNG, out.Put(ctx, buf.Bytes(), sink.TypePNG)
 }
 
 // GenerateSimple generates a DNA visualization without stem separation.
@@ -512,3 +1337,32 @@ func GenerateSimple(ctx context.Context, inputPath, outputPath string, width int
 	config.SkipStems = true
 	return Generate(ctx, inputPath, outputPath, config)
 }
+
+// silentLane reports whether a lane never rises above -60 dBFS.
+func silentLane(segments []audio.VolumeSegment) bool {
+	for _, seg := range segments {
+		if seg.Peak > 0.001 {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeLanes scales the RMS of all lanes by one common factor, so a
+// quiet or silent lane stays visibly quiet next to the others.
+func normalizeLanes(stems []StemData) {
+	var maxRMS float64
+	for _, stem := range stems {
+		for _, seg := range stem.Segments {
+			maxRMS = max(maxRMS, seg.RMS)
+		}
+	}
+	if maxRMS == 0 {
+		return
+	}
+	for _, stem := range stems {
+		for i := range stem.Segments {
+			stem.Segments[i].RMS = min(stem.Segments[i].RMS/maxRMS, 1)
+		}
+	}
+}
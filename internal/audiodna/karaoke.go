@@ -0,0 +1,66 @@
+package audiodna
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// karaokeStripHeight is the height of the vocal balance strip above the stems.
+const karaokeStripHeight = 10
+
+// drawVocalBalance draws the vocals share as a strip starting at yOffset:
+// dim colors blend from the accompaniment color to the vocals color, and
+// a cappella and instrumental-only spans are drawn bright with their name.
+func drawVocalBalance(img *image.RGBA, vb *audio.VocalBalance, duration float64, yOffset, width int, t transform.Options, scale int) {
+	stripHeight := karaokeStripHeight * scale
+	if duration <= 0 {
+		return
+	}
+	vocals, accompaniment := StemColors["vocals"], StemColors["other"]
+	span := func(start, end float64) (int, int) {
+		x0 := int(t.MapTime(start/duration) * float64(width))
+		x1 := int(t.MapTime(end/duration) * float64(width))
+		if x1 < x0 {
+			x0, x1 = x1, x0
+		}
+		return x0, max(x1, x0+1)
+	}
+	fill := func(x0, x1 int, c color.RGBA) {
+		for x := x0; x < x1 && x < width; x++ {
+			for y := yOffset; y < yOffset+stripHeight; y++ {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+
+	fill(0, width, color.RGBA{R: 25, G: 25, B: 30, A: 255})
+	for i, b := range vb.Balance {
+		if b < 0 {
+			continue
+		}
+		c := color.RGBA{
+			R: uint8(float64(accompaniment.R) + (float64(vocals.R)-float64(accompaniment.R))*b),
+			G: uint8(float64(accompaniment.G) + (float64(vocals.G)-float64(accompaniment.G))*b),
+			B: uint8(float64(accompaniment.B) + (float64(vocals.B)-float64(accompaniment.B))*b),
+			A: 255,
+		}
+		x0, x1 := span(float64(i)*vb.Hop, float64(i+1)*vb.Hop)
+		fill(x0, x1, scaleColor(c, 0.35))
+	}
+
+	textColor := color.RGBA{R: 20, G: 20, B: 25, A: 255}
+	for _, s := range vb.Spans {
+		c, label := accompaniment, "instrumental"
+		if s.Kind == audio.SpanACappella {
+			c, label = vocals, "a cappella"
+		}
+		x0, x1 := span(s.Start, s.End)
+		fill(x0, x1, c)
+		if x1-x0 > (textWidth(label)+6)*scale {
+			drawTextScaled(img, label, x0+3*scale, yOffset+stripHeight/2-3*scale, textColor, scale)
+		}
+	}
+}
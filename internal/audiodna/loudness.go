@@ -0,0 +1,103 @@
+package audiodna
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/locale"
+)
+
+// measureLoudness measures BS.1770 loudness of the stereo mix, or of every
+// channel of a surround first track with the BS.1770 channel weights, which
+// a stereo downmix would lose.
+func measureLoudness(ctx context.Context, pcm *pcmCache, inputPath string) (*audio.LoudnessResult, error) {
+	// Float samples keep true peaks above 0 dBFS intact
+	waveformConfig := audio.DefaultWaveformConfig()
+	waveformConfig.Mono = false
+	waveformConfig.BitDepth = 32
+	if tracks, err := audio.ListTracks(inputPath); err == nil && len(tracks) > 0 && tracks[0].Channels > 2 {
+		waveformConfig.Track = tracks[0].Index
+		waveformConfig.Layout = tracks[0].Layout
+	}
+
+	waveform, err := pcm.waveform(ctx, inputPath, waveformConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract waveform for loudness: %w", err)
+	}
+	return audio.MeasureLoudness(waveform), nil
+}
+
+// measureDynamics extracts the stereo mix and computes its DR/PLR scores.
+func measureDynamics(ctx context.Context, pcm *pcmCache, inputPath string) (*audio.DynamicRange, error) {
+	waveformConfig := audio.DefaultWaveformConfig()
+	waveformConfig.Mono = false
+	waveformConfig.BitDepth = 32
+
+	waveform, err := pcm.waveform(ctx, inputPath, waveformConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract waveform for dynamic range: %w", err)
+	}
+	dr := audio.MeasureDynamicRange(waveform)
+	return &dr, nil
+}
+
+// drawViolations tints the time spans that violate a loudness target and
+// marks them with a solid bar along the bottom edge.
+func drawViolations(img *image.RGBA, violations []audio.LoudnessViolation, duration float64) {
+	if duration <= 0 {
+		return
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	marker := color.RGBA{R: 255, G: 60, B: 60, A: 255}
+
+	for _, v := range violations {
+		x0 := int(v.Start / duration * float64(w))
+		x1 := int(v.End / duration * float64(w))
+		if x1 <= x0 {
+			x1 = x0 + 1
+		}
+		for x := x0; x < x1 && x < w; x++ {
+			for y := 0; y < h; y++ {
+				c := img.RGBAAt(x, y)
+				img.SetRGBA(x, y, color.RGBA{
+					R: uint8(float64(c.R)*0.65 + float64(marker.R)*0.35),
+					G: uint8(float64(c.G) * 0.65),
+					B: uint8(float64(c.B) * 0.65),
+					A: 255,
+				})
+			}
+			for y := h - 3; y < h; y++ {
+				if y >= 0 {
+					img.SetRGBA(x, y, marker)
+				}
+			}
+		}
+	}
+}
+
+// complianceText formats a one-line compliance summary for the label bar.
+func complianceText(l locale.Locale, c *audio.ComplianceReport) string {
+	status := "fail"
+	if c.Pass {
+		status = "pass"
+	}
+	return strings.ToLower(fmt.Sprintf("%s %.1f lufs %.1f dbtp %s", c.Target.Name, c.Integrated, c.TruePeak, l.T(status)))
+}
+
+// avGapText formats an A/V duration mismatch for the label bar.
+func avGapText(l locale.Locale, gap *audio.AVGap) string {
+	if gap.Issue == audio.GapAudioShort {
+		return l.Sprintf("audio %.2fs short of video", -gap.Difference)
+	}
+	return l.Sprintf("audio %.2fs past video end", gap.Difference)
+}
+
+// dynamicsText formats a DR score for the label bar.
+func dynamicsText(label string, dr *audio.DynamicRange) string {
+	return fmt.Sprintf("%s dr%.0f", label, dr.DR)
+}
@@ -0,0 +1,50 @@
+package audiodna
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/pforret/videodna/internal/audio"
+)
+
+// Marker colors for noise findings along the top edge of the DNA.
+var (
+	humMarker   = color.RGBA{R: 255, G: 140, B: 40, A: 255}  // Orange
+	noiseMarker = color.RGBA{R: 200, G: 200, B: 120, A: 255} // Pale yellow
+)
+
+// measureNoise extracts the mono mix and analyzes hum and noise floor.
+func measureNoise(ctx context.Context, pcm *pcmCache, inputPath string) (*audio.NoiseReport, error) {
+	waveform, err := pcm.waveform(ctx, inputPath, audio.DefaultWaveformConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract waveform for noise analysis: %w", err)
+	}
+	return audio.AnalyzeNoise(waveform), nil
+}
+
+// drawNoise marks noisy quiet passages and hum spans with a 3px bar along the
+// top edge. Hum is drawn last so it stays visible where both apply.
+func drawNoise(img *image.RGBA, noise *audio.NoiseReport, duration float64) {
+	if duration <= 0 {
+		return
+	}
+	w := img.Bounds().Dx()
+	draw := func(spans []audio.NoiseSpan, c color.RGBA) {
+		for _, s := range spans {
+			x0 := int(s.Start / duration * float64(w))
+			x1 := int(s.End / duration * float64(w))
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			for x := x0; x < x1 && x < w; x++ {
+				for y := 0; y < 3; y++ {
+					img.SetRGBA(x, y, c)
+				}
+			}
+		}
+	}
+	draw(noise.Noisy, noiseMarker)
+	draw(noise.Hum, humMarker)
+}
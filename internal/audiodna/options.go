@@ -0,0 +1,217 @@
+package audiodna
+
+import (
+	"errors"
+
+	"github.com/pforret/videodna/internal/alttext"
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/events"
+	"github.com/pforret/videodna/internal/retry"
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// Validate checks the settings and their combinations, and reports every
+// violation at once (joined with errors.Join, one per line).
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, c.Locale.Errorf(format, args...))
+	}
+
+	stems := !c.SkipStems && !c.Tracks && !c.Channels
+	if stems {
+		if err := audio.CheckStemCount(c.StemConfig.Separator, c.StemConfig.NumStems); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	switch c.StemConfig.OOMFallback {
+	case "", audio.OOMFallbackAuto, audio.OOMFallbackSegment, audio.OOMFallbackCPU, audio.OOMFallbackOff:
+	default:
+		fail("unknown OOM fallback %q, use auto, segment, cpu, or off", c.StemConfig.OOMFallback)
+	}
+	for _, mode := range []struct {
+		name    string
+		enabled bool
+	}{{"karaoke", c.Karaoke}, {"podcast", c.Podcast}} {
+		if mode.enabled && (!stems || c.StemConfig.NumStems != 2) {
+			fail("%s needs 2-stem separation", mode.name)
+		}
+	}
+	if c.Width < 0 || c.Height < 0 || c.ResizeWidth < 0 || c.ResizeHeight < 0 || c.MaxDimension < 0 {
+		fail("width, height, resize and maximum dimension must not be negative")
+	}
+	switch c.BitDepth {
+	case 0, 16, 24, 32:
+	default:
+		fail("bit depth must be 16, 24, or 32, not %d", c.BitDepth)
+	}
+	if c.Overlap < 0 || c.Overlap > 0.9 {
+		fail("overlap must be between 0.0 and 0.9, not %g", c.Overlap)
+	}
+	if c.MFCC < 0 || c.MFCC > 40 {
+		fail("MFCC count must be between 0 and 40, not %d", c.MFCC)
+	}
+	if c.Scale < 0 || c.Scale > 3 {
+		fail("scale must be 1, 2, or 3, not %d", c.Scale)
+	}
+	if c.Retry.Attempts < 0 || c.Retry.Delay < 0 {
+		fail("retry attempts and delay must not be negative")
+	}
+	if err := c.Lanes.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.SegmentsPerSecond < 0 {
+		fail("segments per second must not be negative")
+	}
+	switch c.ColorScheme {
+	case "", SchemeDefault, SchemeColorblind, SchemeTol, SchemeMonochrome:
+	default:
+		fail("unknown palette %q, use default, colorblind, tol, or monochrome", c.ColorScheme)
+	}
+	switch c.Diarize.Diarizer {
+	case "", audio.DiarizerCluster, audio.DiarizerPyannote:
+	default:
+		fail("unknown diarizer %q, use cluster or pyannote", c.Diarize.Diarizer)
+	}
+	if c.EventsPath != "" {
+		if err := events.CheckPath(c.EventsPath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.AltTextPath != "" {
+		if err := alttext.CheckPath(c.AltTextPath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Builder assembles a Config step by step, starting from DefaultConfig.
+// Build validates the result, so library callers get the same checks as
+// the CLI:
+//
+//	config, err := audiodna.NewBuilder().Stems(2, audio.SeparatorDemucs).Karaoke().Build()
+type Builder struct {
+	config Config
+}
+
+// NewBuilder returns a builder with the default configuration.
+func NewBuilder() *Builder {
+	return &Builder{config: DefaultConfig()}
+}
+
+// Stems separates into n stems with sep.
+func (b *Builder) Stems(n int, sep audio.SeparatorType) *Builder {
+	b.config.SkipStems = false
+	b.config.StemConfig.NumStems = n
+	b.config.StemConfig.Separator = sep
+	return b
+}
+
+// NoStems draws the original audio only.
+func (b *Builder) NoStems() *Builder {
+	b.config.SkipStems = true
+	return b
+}
+
+// Device runs the separator on cpu, cuda or mps.
+func (b *Builder) Device(device string) *Builder {
+	b.config.StemConfig.Device = device
+	return b
+}
+
+// Resize scales the final image to width x height; a 0 keeps that side as
+// rendered.
+func (b *Builder) Resize(width, height int) *Builder {
+	b.config.ResizeWidth, b.config.ResizeHeight = width, height
+	return b
+}
+
+// BitDepth sets the PCM extraction depth (16, 24, or 32).
+func (b *Builder) BitDepth(bits int) *Builder {
+	b.config.BitDepth = bits
+	return b
+}
+
+// Overlap sets the volume window overlap (0.0 to 0.9).
+func (b *Builder) Overlap(overlap float64) *Builder {
+	b.config.Overlap = overlap
+	return b
+}
+
+// Palette selects the stem colors.
+func (b *Builder) Palette(scheme ColorScheme) *Builder {
+	b.config.ColorScheme = scheme
+	return b
+}
+
+// Scale draws labels and strips for HiDPI displays (1-3).
+func (b *Builder) Scale(scale int) *Builder {
+	b.config.Scale = scale
+	return b
+}
+
+// Transform reverses, flips or log-maps the time axis.
+func (b *Builder) Transform(t transform.Options) *Builder {
+	b.config.Transform = t
+	return b
+}
+
+// Loudness checks the mix against a loudness target.
+func (b *Builder) Loudness(target audio.LoudnessTarget) *Builder {
+	b.config.LoudnessTarget = &target
+	return b
+}
+
+// Karaoke shows the vocals/accompaniment balance (2 stems).
+func (b *Builder) Karaoke() *Builder {
+	b.config.Karaoke = true
+	return b
+}
+
+// Podcast flags likely intro, outro and mid-roll ads (2 stems).
+func (b *Builder) Podcast() *Builder {
+	b.config.Podcast = true
+	return b
+}
+
+// Report writes the JSON report to path.
+func (b *Builder) Report(path string) *Builder {
+	b.config.ReportPath = path
+	return b
+}
+
+// Events exports events to path (.edl, .ffmeta or .txt).
+func (b *Builder) Events(path string) *Builder {
+	b.config.EventsPath = path
+	return b
+}
+
+// AltText writes a text alternative of the image to path (.html or .json).
+func (b *Builder) AltText(path string) *Builder {
+	b.config.AltTextPath = path
+	return b
+}
+
+// Retry sets the retries of a crashing separator.
+func (b *Builder) Retry(policy retry.Policy) *Builder {
+	b.config.Retry = policy
+	return b
+}
+
+// Silent suppresses progress output.
+func (b *Builder) Silent() *Builder {
+	b.config.Silent = true
+	return b
+}
+
+// Configure changes any other setting.
+func (b *Builder) Configure(fn func(*Config)) *Builder {
+	fn(&b.config)
+	return b
+}
+
+// Build returns the configuration, or every violation found by Validate.
+func (b *Builder) Build() (Config, error) {
+	return b.config, b.config.Validate()
+}
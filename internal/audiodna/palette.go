@@ -0,0 +1,96 @@
+package audiodna
+
+import (
+	"image/color"
+)
+
+// Color-blind safe stem palettes, distinguishable with protanopia,
+// deuteranopia and tritanopia.
+var (
+	// okabeItoColors is the Okabe & Ito (2008) palette.
+	okabeItoColors = map[string]color.RGBA{
+		"vocals": {R: 230, G: 159, B: 0, A: 255},   // Orange
+		"drums":  {R: 86, G: 180, B: 233, A: 255},  // Sky blue
+		"bass":   {R: 0, G: 158, B: 115, A: 255},   // Bluish green
+		"other":  {R: 204, G: 121, B: 167, A: 255}, // Reddish purple
+		"piano":  {R: 240, G: 228, B: 66, A: 255},  // Yellow
+		"guitar": {R: 213, G: 94, B: 0, A: 255},    // Vermillion
+		"mixed":  {R: 200, G: 200, B: 200, A: 255}, // Gray
+	}
+
+	// tolBrightColors is Paul Tol's "bright" qualitative palette.
+	tolBrightColors = map[string]color.RGBA{
+		"vocals": {R: 238, G: 102, B: 119, A: 255}, // Red
+		"drums":  {R: 68, G: 119, B: 170, A: 255},  // Blue
+		"bass":   {R: 34, G: 136, B: 51, A: 255},   // Green
+		"other":  {R: 170, G: 51, B: 119, A: 255},  // Purple
+		"piano":  {R: 204, G: 187, B: 68, A: 255},  // Yellow
+		"guitar": {R: 102, G: 204, B: 238, A: 255}, // Cyan
+		"mixed":  {R: 187, G: 187, B: 187, A: 255}, // Gray
+	}
+)
+
+// stemColor returns the color of a stem in a color scheme. Monochrome uses
+// gray levels by stem index; pair it with fill patterns to tell stems apart.
+func stemColor(label string, index int, scheme ColorScheme) color.RGBA {
+	palette := StemColors
+	switch scheme {
+	case SchemeColorblind:
+		palette = okabeItoColors
+	case SchemeTol:
+		palette = tolBrightColors
+	case SchemeMonochrome:
+		level := uint8(230 - (index%4)*40)
+		return color.RGBA{R: level, G: level, B: level, A: 255}
+	}
+	c, ok := palette[label]
+	if !ok {
+		c = palette["mixed"]
+	}
+	return c
+}
+
+// laneOrder is the order palette colors are handed out to lanes that are
+// not stems (audio tracks, surround channels).
+var laneOrder = []string{"vocals", "drums", "bass", "other", "piano", "guitar"}
+
+// laneColor returns a distinct color for lane index in a color scheme.
+func laneColor(index int, scheme ColorScheme) color.RGBA {
+	return stemColor(laneOrder[index%len(laneOrder)], index, scheme)
+}
+
+// FillPattern is a texture drawn over a stem body so lanes stay
+// distinguishable in grayscale print.
+type FillPattern int
+
+const (
+	PatternSolid      FillPattern = iota
+	PatternHatch                  // Diagonal lines
+	PatternDots                   // Dot grid
+	PatternHorizontal             // Horizontal lines
+	PatternCrosshatch             // Crossed diagonals
+	PatternVertical               // Vertical lines
+)
+
+// stemPattern assigns patterns to stems by index.
+func stemPattern(index int) FillPattern {
+	return FillPattern(index % (int(PatternVertical) + 1))
+}
+
+// patternMask reports whether pixel (x, y) is part of the pattern texture.
+// Texture pixels are drawn darker than the body.
+func patternMask(p FillPattern, x, y int) bool {
+	switch p {
+	case PatternHatch:
+		return (x+y)%6 < 2
+	case PatternDots:
+		return x%4 == 0 && y%4 == 0
+	case PatternHorizontal:
+		return y%4 == 0
+	case PatternCrosshatch:
+		return (x+y)%6 == 0 || (x-y+6000)%6 == 0
+	case PatternVertical:
+		return x%4 == 0
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package audiodna
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pforret/videodna/internal/audio"
+)
+
+// pcmCache shares decoded PCM of the mix between the analyses of one run
+// (tempo, structure, noise, MFCC, ...), which would otherwise each start an
+// ffmpeg decode of the same file. Only the mix is kept: stems are decoded
+// once each and dropped after their volume segments are computed. A nil
+// cache decodes every request.
+type pcmCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[audio.WaveformConfig]*pcmEntry
+}
+
+type pcmEntry struct {
+	once     sync.Once
+	waveform *audio.WaveformData
+	err      error
+}
+
+// newPCMCache returns a cache for the mix at path.
+func newPCMCache(path string) *pcmCache {
+	return &pcmCache{path: path, entries: map[audio.WaveformConfig]*pcmEntry{}}
+}
+
+// waveform returns the decoded PCM of path, decoding the mix at most once per
+// configuration. Concurrent callers wait for the same decode. Callers must
+// not modify the returned samples.
+func (c *pcmCache) waveform(ctx context.Context, path string, config audio.WaveformConfig) (*audio.WaveformData, error) {
+	if c == nil || path != c.path {
+		return audio.ExtractWaveform(ctx, path, config)
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[config]
+	if !ok {
+		entry = &pcmEntry{}
+		c.entries[config] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.waveform, entry.err = audio.ExtractWaveform(ctx, path, config)
+	})
+	return entry.waveform, entry.err
+}
+
+// laneSource selects the audio of a lane cut from the input itself rather
+// than from a stem file: an audio track, and optionally one of its channels.
+type laneSource struct {
+	Track   int    // 1-based audio track (0 = default stream)
+	Channel string // ffmpeg channel name ("" = whole track)
+}
+
+// decodeStems decodes the waveforms of stems from one separation run with a
+// single ffmpeg process. When the merged decode fails, each stem is decoded
+// on its own so the error names the failing stem. With lanes, lane i is
+// decoded on its own from the track and channel of lanes[i].
+func decodeStems(ctx context.Context, pcm *pcmCache, paths, labels []string, lanes []laneSource, config audio.WaveformConfig) ([]*audio.WaveformData, error) {
+	if len(paths) > 1 && lanes == nil {
+		if waveforms, err := audio.ExtractWaveforms(ctx, paths, config); err == nil {
+			return waveforms, nil
+		} else if ctx.Err() != nil {
+			return nil, err
+		}
+	}
+	waveforms := make([]*audio.WaveformData, len(paths))
+	for i, path := range paths {
+		if lanes != nil {
+			config.Track, config.Channel = lanes[i].Track, lanes[i].Channel
+		}
+		waveform, err := pcm.waveform(ctx, path, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract waveform for %s: %w", labels[i], err)
+		}
+		waveforms[i] = waveform
+	}
+	return waveforms, nil
+}
@@ -0,0 +1,350 @@
+package audiodna
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/icc"
+)
+
+// Transition analysis parameters.
+const (
+	silenceLevel    = 0.00316 // -50 dBFS RMS counts as silence
+	silenceBlock    = 0.05    // Silence detection resolution in seconds
+	edgeSeconds     = 5.0     // Audio compared at the end/start of adjacent tracks
+	fadeWindow      = 15.0    // Search range for fade-outs/fade-ins in seconds
+	maxCrossfade    = 12.0    // Longest suggested crossfade in seconds
+	playlistHeight  = 80      // Height of the joined waveform
+	playlistLabelPx = 20      // Height of the transition label bar
+)
+
+// Transition describes how one track flows into the next.
+type Transition struct {
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Time        float64 `json:"time"`         // Boundary position in the joined timeline (seconds)
+	Gap         float64 `json:"gap"`          // Trailing plus leading silence (seconds)
+	EnergyDelta float64 `json:"energy_delta"` // Head minus tail RMS level (dB)
+	Timbre      float64 `json:"timbre"`       // MFCC similarity of tail and head (0.0 to 1.0)
+	Harmonic    float64 `json:"harmonic"`     // Chroma similarity of tail and head (0.0 to 1.0)
+	Score       float64 `json:"score"`        // Overall transition smoothness (0.0 to 1.0)
+	FadeOut     float64 `json:"fade_out"`     // Detected fade-out length of the first track (seconds)
+	FadeIn      float64 `json:"fade_in"`      // Detected fade-in length of the second track (seconds)
+	Crossfade   float64 `json:"crossfade"`    // Suggested crossfade duration (seconds)
+}
+
+// PlaylistResult contains the joined DNA and transition analysis.
+type PlaylistResult struct {
+	Image       *image.RGBA  `json:"-"`
+	Tracks      []string     `json:"tracks"`
+	Duration    float64      `json:"duration"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// WriteJSON writes the playlist analysis as indented JSON.
+func (r *PlaylistResult) WriteJSON(path string) error {
+	return writeJSON(path, r)
+}
+
+// trackEdges holds the audible start and end of a track. Only the edges are
+// kept so long playlists do not hold every decoded track in memory.
+type trackEdges struct {
+	head    *audio.WaveformData // First edgeSeconds after leading silence
+	tail    *audio.WaveformData // Last edgeSeconds before trailing silence
+	leading float64             // Leading silence in seconds
+	trail   float64             // Trailing silence in seconds
+	fadeIn  float64             // Detected fade-in length in seconds
+	fadeOut float64             // Detected fade-out length in seconds
+}
+
+// AnalyzePlaylist analyzes transitions between consecutive tracks and renders
+// them as one joined DNA with a colored marker per transition (green =
+// smooth, red = abrupt).
+func AnalyzePlaylist(ctx context.Context, paths []string, outputPath string, silent bool) (*PlaylistResult, error) {
+	if len(paths) < 2 {
+		return nil, fmt.Errorf("playlist needs at least two tracks")
+	}
+
+	tracks := make([]trackEdges, len(paths))
+	var segments []audio.VolumeSegment
+	var boundaries []float64
+	var duration float64
+	for i, path := range paths {
+		if !silent {
+			fmt.Printf("Analyzing %d/%d: %s\n", i+1, len(paths), path)
+		}
+		waveform, err := audio.ExtractWaveform(ctx, path, audio.DefaultWaveformConfig())
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract waveform for %s: %w", path, err)
+		}
+		tracks[i] = analyzeEdges(waveform)
+
+		// Volume at the default pixel rate; the joined image is one column per segment
+		for _, seg := range audio.ExtractVolume(waveform, int(math.Ceil(waveform.Duration*defaultFPS))) {
+			seg.TimeStart += duration
+			seg.TimeEnd += duration
+			segments = append(segments, seg)
+		}
+		duration += waveform.Duration
+		boundaries = append(boundaries, duration)
+	}
+
+	result := &PlaylistResult{
+		Tracks:   paths,
+		Duration: duration,
+	}
+	for i := 0; i+1 < len(tracks); i++ {
+		t := analyzeTransition(tracks[i], tracks[i+1])
+		t.From, t.To, t.Time = paths[i], paths[i+1], boundaries[i]
+		result.Transitions = append(result.Transitions, t)
+
+		if !silent {
+			fmt.Printf("  %d -> %d: score %.2f, gap %.1fs, energy %+.1f dB, crossfade %.1fs\n",
+				i+1, i+2, t.Score, t.Gap, t.EnergyDelta, t.Crossfade)
+		}
+	}
+
+	result.Image = renderPlaylist(segments, duration, result.Transitions)
+
+	if err := saveImage(result.Image, outputPath, icc.SRGB()); err != nil {
+		return nil, fmt.Errorf("failed to save image: %w", err)
+	}
+	return result, nil
+}
+
+// analyzeEdges extracts the audible head and tail of a track and detects
+// silence and fades at both ends.
+func analyzeEdges(waveform *audio.WaveformData) trackEdges {
+	lead, trail := silenceEdges(waveform)
+	return trackEdges{
+		head:    edgeWaveform(waveform, lead, edgeSeconds),
+		tail:    edgeWaveform(waveform, waveform.Duration-trail-edgeSeconds, edgeSeconds),
+		leading: lead,
+		trail:   trail,
+		fadeIn:  fadeLength(waveform, lead, false),
+		fadeOut: fadeLength(waveform, trail, true),
+	}
+}
+
+// analyzeTransition compares the audible end of a with the audible start of b.
+func analyzeTransition(a, b trackEdges) Transition {
+	tail, head := a.tail, b.head
+
+	t := Transition{
+		Gap:         a.trail + b.leading,
+		EnergyDelta: levelDB(head.Samples) - levelDB(tail.Samples),
+		Timbre:      (cosineSimilarity(meanVector(audio.MFCC(tail, 0.5, 13), 1), meanVector(audio.MFCC(head, 0.5, 13), 1)) + 1) / 2,
+		Harmonic:    cosineSimilarity(meanVector(audio.Chroma(tail, 0.5), 0), meanVector(audio.Chroma(head, 0.5), 0)),
+		FadeOut:     a.fadeOut,
+		FadeIn:      b.fadeIn,
+	}
+
+	energyScore := math.Max(0, 1-math.Abs(t.EnergyDelta)/12)
+	t.Score = 0.4*energyScore + 0.3*t.Timbre + 0.3*t.Harmonic
+
+	// Follow existing fades; otherwise blend longer the rougher the match
+	switch {
+	case t.FadeOut > 0 || t.FadeIn > 0:
+		t.Crossfade = math.Max(t.FadeOut, t.FadeIn)
+	case t.Score >= 0.8:
+		t.Crossfade = 1
+	default:
+		t.Crossfade = 1 + (0.8-t.Score)*10
+	}
+	t.Crossfade = math.Min(t.Crossfade, maxCrossfade)
+	return t
+}
+
+// silenceEdges returns the leading and trailing silence of a waveform in seconds.
+func silenceEdges(waveform *audio.WaveformData) (float64, float64) {
+	levels := blockLevels(waveform, silenceBlock)
+	lead := 0
+	for lead < len(levels) && levels[lead] < silenceLevel {
+		lead++
+	}
+	trail := 0
+	for trail < len(levels)-lead && levels[len(levels)-1-trail] < silenceLevel {
+		trail++
+	}
+	return float64(lead) * silenceBlock, float64(trail) * silenceBlock
+}
+
+// fadeLength detects a fade at the end (or start) of a track: the time
+// between the last (or first) second at full level and the silence edge.
+// Fades shorter than two seconds are ignored.
+func fadeLength(waveform *audio.WaveformData, silence float64, atEnd bool) float64 {
+	start := silence
+	if atEnd {
+		start = waveform.Duration - silence - fadeWindow
+	}
+	levels := blockLevels(edgeWaveform(waveform, start, fadeWindow), 1)
+	if len(levels) == 0 {
+		return 0
+	}
+
+	var loudest float64
+	for _, l := range levels {
+		loudest = math.Max(loudest, l)
+	}
+	full := loudest * 0.7 // Within 3 dB of the loudest second
+
+	fade := 0
+	if atEnd {
+		for i := len(levels) - 1; i >= 0 && levels[i] < full; i-- {
+			fade++
+		}
+	} else {
+		for i := 0; i < len(levels) && levels[i] < full; i++ {
+			fade++
+		}
+	}
+	if fade < 2 {
+		return 0
+	}
+	return float64(fade)
+}
+
+// edgeWaveform returns a copy of a mono sub-waveform from start for length
+// seconds, clamped to the waveform.
+func edgeWaveform(waveform *audio.WaveformData, start, length float64) *audio.WaveformData {
+	from := int(math.Max(start, 0) * float64(waveform.SampleRate))
+	to := from + int(length*float64(waveform.SampleRate))
+	if from > len(waveform.Samples) {
+		from = len(waveform.Samples)
+	}
+	if to > len(waveform.Samples) {
+		to = len(waveform.Samples)
+	}
+	samples := append([]float64(nil), waveform.Samples[from:to]...)
+	return &audio.WaveformData{
+		Samples:    samples,
+		SampleRate: waveform.SampleRate,
+		Duration:   float64(len(samples)) / float64(waveform.SampleRate),
+		Channels:   1,
+	}
+}
+
+// blockLevels returns the RMS of consecutive blocks of blockSeconds.
+func blockLevels(waveform *audio.WaveformData, blockSeconds float64) []float64 {
+	size := int(blockSeconds * float64(waveform.SampleRate))
+	if size < 1 {
+		return nil
+	}
+	var levels []float64
+	for start := 0; start+size <= len(waveform.Samples); start += size {
+		levels = append(levels, rms(waveform.Samples[start:start+size]))
+	}
+	return levels
+}
+
+func rms(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range samples {
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+// levelDB returns the RMS level of samples in dBFS (floored at -120).
+func levelDB(samples []float64) float64 {
+	r := rms(samples)
+	if r <= 1e-6 {
+		return -120
+	}
+	return 20 * math.Log10(r)
+}
+
+// meanVector averages feature vectors, dropping the first skip dimensions.
+func meanVector(vectors [][]float64, skip int) []float64 {
+	if len(vectors) == 0 {
+		return nil
+	}
+	mean := make([]float64, len(vectors[0])-skip)
+	for _, v := range vectors {
+		for k := range mean {
+			mean[k] += v[k+skip] / float64(len(vectors))
+		}
+	}
+	return mean
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// renderPlaylist draws the joined volume segments with a colored marker and
+// score label per transition.
+func renderPlaylist(segments []audio.VolumeSegment, duration float64, transitions []Transition) *image.RGBA {
+	width := len(segments)
+	if width < minOutputWidth {
+		width = minOutputWidth
+	}
+	height := playlistLabelPx + playlistHeight
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	bgColor := color.RGBA{R: 20, G: 20, B: 25, A: 255}
+	labelBg := color.RGBA{R: 25, G: 25, B: 30, A: 255}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if y < playlistLabelPx {
+				img.SetRGBA(x, y, labelBg)
+			} else {
+				img.SetRGBA(x, y, bgColor)
+			}
+		}
+	}
+
+	segments = audio.ResampleSegments(segments, width)
+	audio.NormalizeVolume(segments)
+	yMid := playlistLabelPx + playlistHeight/2
+	for x, seg := range segments {
+		half := int(seg.RMS*float64(playlistHeight)*0.8) / 2
+		for y := yMid - half; y <= yMid+half; y++ {
+			img.SetRGBA(x, y, StemColors["mixed"])
+		}
+	}
+
+	for _, t := range transitions {
+		x := int(t.Time / duration * float64(width))
+		c := scoreColor(t.Score)
+		for y := playlistLabelPx; y < height; y++ {
+			for dx := -1; dx <= 1; dx++ {
+				if x+dx >= 0 && x+dx < width {
+					img.SetRGBA(x+dx, y, c)
+				}
+			}
+		}
+		label := fmt.Sprintf("%.2f %.0fs", t.Score, t.Crossfade)
+		drawText(img, label, x-textWidth(label)/2, playlistLabelPx/2-3, c)
+	}
+	return img
+}
+
+// scoreColor maps a transition score to red (abrupt) through yellow to green
+// (smooth).
+func scoreColor(score float64) color.RGBA {
+	score = math.Max(0, math.Min(1, score))
+	if score < 0.5 {
+		return color.RGBA{R: 255, G: uint8(100 + 310*score), B: 100, A: 255}
+	}
+	return color.RGBA{R: uint8(255 - 310*(score-0.5)), G: 255, B: 100, A: 255}
+}
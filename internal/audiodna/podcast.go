@@ -0,0 +1,53 @@
+package audiodna
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/locale"
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// podcastStripHeight is the height of the intro/outro/ad strip above the stems.
+const podcastStripHeight = 12
+
+// podcastColors are the strip colors per podcast segment kind.
+var podcastColors = map[string]color.RGBA{
+	audio.PodcastIntro: {R: 80, G: 160, B: 100, A: 255}, // Green
+	audio.PodcastOutro: {R: 70, G: 110, B: 180, A: 255}, // Blue
+	audio.PodcastAd:    {R: 210, G: 90, B: 60, A: 255},  // Red
+}
+
+// drawPodcast draws the flagged segments as labeled spans in a strip
+// starting at yOffset; low-confidence segments are drawn dimmer.
+func drawPodcast(img *image.RGBA, segments []audio.PodcastSegment, duration float64, yOffset, width int, t transform.Options, l locale.Locale, scale int) {
+	stripHeight := podcastStripHeight * scale
+	if duration <= 0 {
+		return
+	}
+	bg := color.RGBA{R: 25, G: 25, B: 30, A: 255}
+	for x := 0; x < width; x++ {
+		for y := yOffset; y < yOffset+stripHeight; y++ {
+			img.SetRGBA(x, y, bg)
+		}
+	}
+
+	textColor := color.RGBA{R: 230, G: 230, B: 230, A: 255}
+	for _, s := range segments {
+		c := scaleColor(podcastColors[s.Kind], 0.5+0.5*s.Confidence)
+		x0 := int(t.MapTime(s.Start/duration) * float64(width))
+		x1 := int(t.MapTime(s.End/duration) * float64(width))
+		if x1 < x0 {
+			x0, x1 = x1, x0
+		}
+		for x := x0; x < x1 && x < width; x++ {
+			for y := yOffset; y < yOffset+stripHeight; y++ {
+				img.SetRGBA(x, y, c)
+			}
+		}
+		if label := l.T(s.Kind); x1-x0 > (textWidth(label)+6)*scale {
+			drawTextScaled(img, label, x0+3*scale, yOffset+stripHeight/2-3*scale, textColor, scale)
+		}
+	}
+}
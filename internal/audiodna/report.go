@@ -0,0 +1,164 @@
+package audiodna
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/checksum"
+	"github.com/pforret/videodna/internal/sink"
+	"github.com/pforret/videodna/internal/timing"
+)
+
+// Report is the JSON export of a generation run.
+type Report struct {
+	Input           string                  `json:"input"`
+	Duration        float64                 `json:"duration"`
+	SegmentDuration float64                 `json:"segment_duration"` // Seconds per segment
+	Stems           []StemReport            `json:"stems"`
+	Loudness        *audio.LoudnessResult   `json:"loudness,omitempty"`
+	Compliance      *audio.ComplianceReport `json:"compliance,omitempty"`
+	Dynamics        *audio.DynamicRange     `json:"dynamics,omitempty"` // Mix DR/PLR
+	Tempo           *audio.TempoResult      `json:"tempo,omitempty"`
+	Sections        []audio.Section         `json:"sections,omitempty"`
+	Features        *Features               `json:"features,omitempty"`
+	Speakers        []audio.SpeakerTurn     `json:"speakers,omitempty"`
+	Noise           *audio.NoiseReport      `json:"noise,omitempty"`
+	Silences        []Silence               `json:"silences,omitempty"`
+	Karaoke         *audio.VocalBalance     `json:"karaoke,omitempty"`
+	Podcast         []audio.PodcastSegment  `json:"podcast,omitempty"`
+	Content         *audio.ContentReport    `json:"content,omitempty"`
+	AVGap           *audio.AVGap            `json:"av_gap,omitempty"`
+	Checksums       *checksum.Sums          `json:"checksums,omitempty"` // Digests of the input file
+	Timings         *timing.Timings         `json:"timings,omitempty"`   // Seconds per stage of the run
+
+	// ColumnTimes maps the image columns of a content-warped time axis back
+	// to seconds (see Result.ColumnTimes).
+	ColumnTimes []float64 `json:"column_times,omitempty"`
+}
+
+// StemReport holds the per-segment volume fingerprint of one stem.
+type StemReport struct {
+	Label    string              `json:"label"`
+	RMS      []float64           `json:"rms"`
+	Peak     []float64           `json:"peak"`
+	TruePeak []float64           `json:"true_peak"`
+	Crest    []float64           `json:"crest_db"`
+	Dynamics *audio.DynamicRange `json:"dynamics,omitempty"`
+}
+
+// NewReport builds the JSON report for a result.
+func NewReport(inputPath string, result *Result) *Report {
+	report := &Report{
+		Input:      inputPath,
+		Duration:   result.Duration,
+		Loudness:   result.Loudness,
+		Compliance: result.Compliance,
+		Dynamics:   result.Dynamics,
+		Tempo:      result.Tempo,
+		Sections:   result.Sections,
+		Features:   result.Features,
+		Speakers:   result.Speakers,
+		Noise:      result.Noise,
+		Silences:   result.Silences,
+		Karaoke:    result.Karaoke,
+		Podcast:    result.Podcast,
+		Content:    result.Content,
+		AVGap:      result.AVGap,
+		Checksums:  result.Checksums,
+	}
+	if result.Timings.Total > 0 {
+		report.Timings = &result.Timings
+	}
+	report.ColumnTimes = result.ColumnTimes
+	for _, stem := range result.Stems {
+		sr := StemReport{Label: stem.Label, Dynamics: stem.Dynamics}
+		for _, seg := range stem.Segments {
+			sr.RMS = append(sr.RMS, seg.RMS)
+			sr.Peak = append(sr.Peak, seg.Peak)
+			sr.TruePeak = append(sr.TruePeak, seg.TruePeak)
+			sr.Crest = append(sr.Crest, seg.Crest)
+		}
+		report.Stems = append(report.Stems, sr)
+		if n := len(stem.Segments); n > 0 && report.SegmentDuration == 0 {
+			report.SegmentDuration = stem.Segments[n-1].TimeEnd / float64(n)
+		}
+	}
+	return report
+}
+
+// writeCSV writes one row per segment with the RMS and peak of each stem,
+// followed by the MFCCs of the mix when features were extracted.
+func writeCSV(path string, stems []StemData, features *Features) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	header := []string{"time_start", "time_end"}
+	for _, stem := range stems {
+		header = append(header, stem.Label+"_rms", stem.Label+"_peak")
+	}
+	numCoeffs := 0
+	if features != nil && len(features.MFCC) > 0 {
+		numCoeffs = len(features.MFCC[0])
+		for c := 0; c < numCoeffs; c++ {
+			header = append(header, "mfcc_"+strconv.Itoa(c))
+		}
+	}
+	w.Write(header)
+
+	if len(stems) > 0 {
+		for i, seg := range stems[0].Segments {
+			row := []string{formatFloat(seg.TimeStart), formatFloat(seg.TimeEnd)}
+			for _, stem := range stems {
+				if i < len(stem.Segments) {
+					row = append(row, formatFloat(stem.Segments[i].RMS), formatFloat(stem.Segments[i].Peak))
+				} else {
+					row = append(row, "", "")
+				}
+			}
+			for c := 0; c < numCoeffs; c++ {
+				if i < len(features.MFCC) {
+					row = append(row, formatFloat(features.MFCC[i][c]))
+				} else {
+					row = append(row, "")
+				}
+			}
+			w.Write(row)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+// writeJSON writes v as indented JSON to path.
+func writeJSON(path string, v interface{}) error {
+	return putJSON(context.Background(), sink.File(path), v)
+}
+
+// putJSON delivers v as indented JSON to s.
+func putJSON(ctx context.Context, s sink.Sink, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	if err := s.Put(ctx, append(data, '\n'), sink.TypeJSON); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package audiodna
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/pforret/videodna/internal/audio"
+)
+
+// speakerColors are assigned to speakers in order of first appearance.
+var speakerColors = []color.RGBA{
+	{R: 255, G: 100, B: 100, A: 255}, // Red
+	{R: 100, G: 200, B: 255, A: 255}, // Light Blue
+	{R: 255, G: 220, B: 100, A: 255}, // Yellow
+	{R: 100, G: 255, B: 150, A: 255}, // Green
+	{R: 200, G: 150, B: 255, A: 255}, // Purple
+	{R: 255, G: 180, B: 100, A: 255}, // Orange
+}
+
+// measureSpeakers diarizes the speech in path (the vocals stem or the mix).
+func measureSpeakers(ctx context.Context, pcm *pcmCache, path string, config audio.DiarizeConfig) ([]audio.SpeakerTurn, error) {
+	var waveform *audio.WaveformData
+	if config.Diarizer == audio.DiarizerCluster {
+		var err error
+		waveform, err = pcm.waveform(ctx, path, audio.DefaultWaveformConfig())
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract waveform for diarization: %w", err)
+		}
+	}
+	turns, err := audio.Diarize(ctx, path, waveform, config)
+	if err != nil {
+		return nil, fmt.Errorf("diarization failed: %w", err)
+	}
+	return turns, nil
+}
+
+// speakerColumns maps every column of a lane of the given width to a speaker
+// color, or nil where nobody speaks.
+func speakerColumns(turns []audio.SpeakerTurn, duration float64, width int) []*color.RGBA {
+	columns := make([]*color.RGBA, width)
+	if duration <= 0 {
+		return columns
+	}
+	index := map[string]int{}
+	for _, t := range turns {
+		idx, ok := index[t.Speaker]
+		if !ok {
+			idx = len(index)
+			index[t.Speaker] = idx
+		}
+		c := speakerColors[idx%len(speakerColors)]
+		for x := int(t.Start / duration * float64(width)); x < int(t.End/duration*float64(width)) && x < width; x++ {
+			columns[x] = &c
+		}
+	}
+	return columns
+}
+
+// speakerSummary formats the speaking time per speaker for progress output.
+func speakerSummary(turns []audio.SpeakerTurn) string {
+	var order []string
+	talk := map[string]float64{}
+	for _, t := range turns {
+		if _, ok := talk[t.Speaker]; !ok {
+			order = append(order, t.Speaker)
+		}
+		talk[t.Speaker] += t.End - t.Start
+	}
+	var parts []string
+	for _, s := range order {
+		parts = append(parts, fmt.Sprintf("%s %.0fs", s, talk[s]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,76 @@
+package audiodna
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// sectionStripHeight is the height of the structure strip above the stems.
+const sectionStripHeight = 12
+
+// sectionColors are assigned to section labels in order of appearance.
+var sectionColors = []color.RGBA{
+	{R: 70, G: 110, B: 180, A: 255},
+	{R: 190, G: 120, B: 60, A: 255},
+	{R: 80, G: 160, B: 100, A: 255},
+	{R: 170, G: 80, B: 140, A: 255},
+	{R: 160, G: 160, B: 70, A: 255},
+	{R: 80, G: 160, B: 170, A: 255},
+}
+
+// measureStructure extracts the mono mix and segments it into sections.
+func measureStructure(ctx context.Context, pcm *pcmCache, inputPath string) ([]audio.Section, error) {
+	waveform, err := pcm.waveform(ctx, inputPath, audio.DefaultWaveformConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract waveform for structure: %w", err)
+	}
+	return audio.SegmentStructure(waveform), nil
+}
+
+// drawSections draws sections as colored spans with their label in a strip
+// of sectionStripHeight (times the UI scale) starting at yOffset, following
+// the time transform applied to the waveform.
+func drawSections(img *image.RGBA, sections []audio.Section, duration float64, yOffset, width int, t transform.Options, scale int) {
+	stripHeight := sectionStripHeight * scale
+	if duration <= 0 {
+		return
+	}
+	colorIndex := map[string]int{}
+	textColor := color.RGBA{R: 230, G: 230, B: 230, A: 255}
+
+	for _, s := range sections {
+		idx, ok := colorIndex[s.Label]
+		if !ok {
+			idx = len(colorIndex)
+			colorIndex[s.Label] = idx
+		}
+		c := sectionColors[idx%len(sectionColors)]
+
+		x0 := int(t.MapTime(s.Start/duration) * float64(width))
+		x1 := int(t.MapTime(s.End/duration) * float64(width))
+		if x1 < x0 {
+			x0, x1 = x1, x0
+		}
+		for x := x0; x < x1 && x < width; x++ {
+			for y := yOffset; y < yOffset+stripHeight; y++ {
+				// Darken the first column to separate adjacent sections
+				if x < x0+scale {
+					img.SetRGBA(x, y, scaleColor(c, 0.5))
+				} else {
+					img.SetRGBA(x, y, c)
+				}
+			}
+		}
+
+		label := strings.ToLower(s.Label)
+		if x1-x0 > (textWidth(label)+6)*scale {
+			drawTextScaled(img, label, x0+3*scale, yOffset+stripHeight/2-3*scale, textColor, scale)
+		}
+	}
+}
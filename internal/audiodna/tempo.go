@@ -0,0 +1,54 @@
+package audiodna
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/pforret/videodna/internal/audio"
+)
+
+// measureTempo extracts the mono mix and estimates its tempo.
+func measureTempo(ctx context.Context, pcm *pcmCache, inputPath string) (*audio.TempoResult, error) {
+	waveform, err := pcm.waveform(ctx, inputPath, audio.DefaultWaveformConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract waveform for tempo: %w", err)
+	}
+	return audio.EstimateTempo(waveform), nil
+}
+
+// drawBeatGrid draws faint vertical lines per beat and brighter ones per bar.
+// Beats closer than 3px apart are skipped so the grid never floods the image.
+func drawBeatGrid(img *image.RGBA, tempo *audio.TempoResult, duration float64) {
+	interval := tempo.BeatInterval()
+	if interval <= 0 || duration <= 0 {
+		return
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	pxPerSecond := float64(w) / duration
+	showBeats := interval*pxPerSecond >= 3
+
+	beatColor := color.RGBA{R: 32, G: 32, B: 38, A: 255}
+	barColor := color.RGBA{R: 48, G: 48, B: 56, A: 255}
+
+	for beat := 0; ; beat++ {
+		t := tempo.Offset + float64(beat)*interval
+		if t >= duration {
+			break
+		}
+		isBar := tempo.BeatsPerBar > 0 && beat%tempo.BeatsPerBar == 0
+		if !isBar && !showBeats {
+			continue
+		}
+		c := beatColor
+		if isBar {
+			c = barColor
+		}
+		x := int(t * pxPerSecond)
+		for y := 0; y < h; y++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
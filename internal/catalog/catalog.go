@@ -0,0 +1,202 @@
+// Package catalog records generated DNA images in a SQLite database, so heavy
+// users can find which media has been processed, with which options, and
+// where the output went. It uses the sqlite3 command-line tool, keeping the
+// module free of Go dependencies.
+package catalog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// hashChunk is the size of the head and tail hashed by HashFile.
+const hashChunk = 1 << 20
+
+const schema = `CREATE TABLE IF NOT EXISTS dna (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	created TEXT NOT NULL,
+	tool    TEXT NOT NULL,
+	input   TEXT NOT NULL,
+	hash    TEXT NOT NULL,
+	options TEXT NOT NULL,
+	output  TEXT NOT NULL,
+	stats   TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS dna_input ON dna(input);
+CREATE INDEX IF NOT EXISTS dna_hash ON dna(hash);
+`
+
+// Entry is one generated DNA.
+type Entry struct {
+	ID      int64           `json:"id"`
+	Created string          `json:"created"` // UTC, RFC 3339
+	Tool    string          `json:"tool"`    // "videodna" or "audiodna"
+	Input   string          `json:"input"`   // Absolute input path
+	Hash    string          `json:"hash"`    // See HashFile
+	Options json.RawMessage `json:"options"` // Command-line arguments (JSON array)
+	Output  string          `json:"output"`  // Absolute output path
+	Stats   json.RawMessage `json:"stats"`   // Key stats (JSON object)
+}
+
+// Filter selects catalog entries; zero fields match everything.
+type Filter struct {
+	Input string    // Substring of the input path
+	Tool  string    // Exact tool name
+	Hash  string    // Hash prefix
+	Since time.Time // Created at or after
+	Limit int       // Maximum entries, newest first (0 = all)
+}
+
+// Record adds an entry for a generated DNA. Paths are made absolute, the
+// input is hashed and the creation time is set.
+func Record(dbPath, tool, input, output string, args []string, stats map[string]any) error {
+	hash, err := HashFile(input)
+	if err != nil {
+		return err
+	}
+	if abs, err := filepath.Abs(input); err == nil {
+		input = abs
+	}
+	if abs, err := filepath.Abs(output); err == nil {
+		output = abs
+	}
+	if args == nil {
+		args = []string{}
+	}
+	options, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	if stats == nil {
+		stats = map[string]any{}
+	}
+	statsJSON, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+
+	sql := schema + fmt.Sprintf(
+		"INSERT INTO dna (created, tool, input, hash, options, output, stats) VALUES (%s, %s, %s, %s, %s, %s, %s);\n",
+		quote(time.Now().UTC().Format(time.RFC3339)), quote(tool), quote(input), quote(hash),
+		quote(string(options)), quote(output), quote(string(statsJSON)))
+	_, err = run(dbPath, sql)
+	return err
+}
+
+// Query returns the entries matching filter, newest first.
+func Query(dbPath string, filter Filter) ([]Entry, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("catalog not found: %w", err)
+	}
+
+	var where []string
+	if filter.Input != "" {
+		where = append(where, "instr(input, "+quote(filter.Input)+") > 0")
+	}
+	if filter.Tool != "" {
+		where = append(where, "tool = "+quote(filter.Tool))
+	}
+	if filter.Hash != "" {
+		where = append(where, "substr(hash, 1, "+fmt.Sprint(len(filter.Hash))+") = "+quote(filter.Hash))
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "created >= "+quote(filter.Since.UTC().Format(time.RFC3339)))
+	}
+
+	sql := "SELECT id, created, tool, input, hash, options, output, stats FROM dna"
+	if len(where) > 0 {
+		sql += " WHERE " + strings.Join(where, " AND ")
+	}
+	sql += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		sql += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	out, err := run(dbPath, schema+sql+";\n", "-json")
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+
+	// options and stats come back as JSON text
+	var rows []struct {
+		Entry
+		Options string `json:"options"`
+		Stats   string `json:"stats"`
+	}
+	if err := json.Unmarshal(out, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog query output: %w", err)
+	}
+	entries := make([]Entry, len(rows))
+	for i, row := range rows {
+		entries[i] = row.Entry
+		entries[i].Options = json.RawMessage(row.Options)
+		entries[i].Stats = json.RawMessage(row.Stats)
+	}
+	return entries, nil
+}
+
+// HashFile returns a quick content hash: SHA-256 of the file size and its
+// first and last MiB. It identifies the same media under another name
+// without reading multi-gigabyte files completely.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash input: %w", err)
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to hash input: %w", err)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n", st.Size())
+	if _, err := io.CopyN(h, f, hashChunk); err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to hash input: %w", err)
+	}
+	if st.Size() > 2*hashChunk {
+		if _, err := f.Seek(-hashChunk, io.SeekEnd); err != nil {
+			return "", fmt.Errorf("failed to hash input: %w", err)
+		}
+		if _, err := io.Copy(h, f); err != nil {
+			return "", fmt.Errorf("failed to hash input: %w", err)
+		}
+	} else if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash input: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// run feeds SQL to the sqlite3 command-line tool.
+func run(dbPath, sql string, flags ...string) ([]byte, error) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return nil, fmt.Errorf("sqlite3 not found: install the SQLite command-line tool to use a catalog")
+	}
+	args := append([]string{"-bail"}, flags...)
+	cmd := exec.Command("sqlite3", append(args, dbPath)...)
+	cmd.Stdin = strings.NewReader(sql)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3 failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// quote returns s as an SQL string literal.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
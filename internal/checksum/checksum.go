@@ -0,0 +1,110 @@
+// Package checksum computes content digests of input media for provenance:
+// the digests are recorded with the DNA, and a run can refuse an input that
+// does not match a known digest, as forensic chain of custody requires.
+package checksum
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrMismatch is wrapped by the error of an input that fails verification.
+var ErrMismatch = errors.New("checksum mismatch")
+
+// Sums are the digests of an input file, lowercase hex.
+type Sums struct {
+	SHA256 string `json:"sha256"`
+	MD5    string `json:"md5,omitempty"` // For legacy systems
+}
+
+// Options select the checksums of a run. The zero value computes none.
+type Options struct {
+	Enabled bool   // Record the SHA-256 of the input
+	MD5     bool   // Also record the MD5
+	Verify  string // Refuse inputs that don't match this digest (see Parse); implies Enabled
+}
+
+// Run computes the checksums selected by o for the file at path and
+// verifies them. It returns nil sums when o selects none.
+func (o Options) Run(path string) (*Sums, error) {
+	if !o.Enabled && !o.MD5 && o.Verify == "" {
+		return nil, nil
+	}
+	algorithm, digest, err := Parse(o.Verify)
+	if err != nil {
+		return nil, err
+	}
+	sums, err := Compute(path, o.MD5 || algorithm == "md5")
+	if err != nil {
+		return nil, err
+	}
+	if o.Verify == "" {
+		return sums, nil
+	}
+	name, got := "SHA-256", sums.SHA256
+	if algorithm == "md5" {
+		name, got = "MD5", sums.MD5
+	}
+	if got != digest {
+		return nil, fmt.Errorf("%w: %s of %s is %s, expected %s", ErrMismatch, name, path, got, digest)
+	}
+	return sums, nil
+}
+
+// Compute reads the file at path once and returns its SHA-256, and its MD5
+// when withMD5 is set.
+func Compute(path string, withMD5 bool) (*Sums, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum input: %w", err)
+	}
+	defer f.Close()
+
+	sha := sha256.New()
+	var legacy hash.Hash
+	w := io.Writer(sha)
+	if withMD5 {
+		legacy = md5.New()
+		w = io.MultiWriter(sha, legacy)
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return nil, fmt.Errorf("failed to checksum input: %w", err)
+	}
+
+	sums := &Sums{SHA256: hex.EncodeToString(sha.Sum(nil))}
+	if legacy != nil {
+		sums.MD5 = hex.EncodeToString(legacy.Sum(nil))
+	}
+	return sums, nil
+}
+
+// Parse splits an expected digest into its algorithm ("sha256" or "md5")
+// and lowercase hex. It accepts "sha256:HEX", "md5:HEX" or bare hex, whose
+// length selects the algorithm. An empty digest returns empty strings.
+func Parse(s string) (algorithm, digest string, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", "", nil
+	}
+	if prefix, rest, ok := strings.Cut(s, ":"); ok {
+		algorithm, s = strings.ToLower(strings.ReplaceAll(prefix, "-", "")), rest
+	}
+	s = strings.ToLower(s)
+	if _, err := hex.DecodeString(s); err != nil {
+		return "", "", fmt.Errorf("invalid checksum %q: not hexadecimal", s)
+	}
+	switch {
+	case (algorithm == "" || algorithm == "sha256") && len(s) == 64:
+		return "sha256", s, nil
+	case (algorithm == "" || algorithm == "md5") && len(s) == 32:
+		return "md5", s, nil
+	}
+	return "", "", fmt.Errorf("invalid checksum %q: use a SHA-256 (64 hex digits) or MD5 (32 hex digits), optionally prefixed with sha256: or md5:", s)
+}
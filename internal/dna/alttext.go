@@ -0,0 +1,45 @@
+package dna
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"strings"
+
+	"github.com/pforret/videodna/internal/alttext"
+)
+
+// videoAltText describes the raw DNA, one column per frame (row with
+// vertical output), by the average color of alttext.Spans time spans.
+func videoAltText(raw image.Image, vertical bool, outputPath, inputPath, name string, duration float64) *alttext.Description {
+	length := raw.Bounds().Dx()
+	if vertical {
+		length = raw.Bounds().Dy()
+	}
+	spans := aggregateTime(raw, max(min(alttext.Spans, length), 1), vertical, false)
+
+	bounds := spans.Bounds()
+	n, across := bounds.Dx(), bounds.Dy()
+	if vertical {
+		n, across = across, n
+	}
+	colors := make([]color.RGBA, n)
+	for i := range colors {
+		var r, g, b uint32
+		for a := 0; a < across; a++ {
+			x, y := i, a
+			if vertical {
+				x, y = a, i
+			}
+			cr, cg, cb, _ := spans.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			r, g, b = r+cr>>8, g+cg>>8, b+cb>>8
+		}
+		count := uint32(max(across, 1))
+		colors[i] = color.RGBA{R: uint8(r / count), G: uint8(g / count), B: uint8(b / count), A: 255}
+	}
+
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	}
+	return alttext.Video(outputPath, name, duration, colors)
+}
@@ -0,0 +1,247 @@
+package dna
+
+import (
+	"fmt"
+
+	"github.com/pforret/videodna/internal/adjust"
+	"github.com/pforret/videodna/internal/checksum"
+	"github.com/pforret/videodna/internal/events"
+	"github.com/pforret/videodna/internal/hooks"
+	"github.com/pforret/videodna/internal/lanespec"
+	"github.com/pforret/videodna/internal/timing"
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// AnalysisConfig selects per-frame analysis passes run during decoding.
+// Each enabled pass renders a lane below the DNA and a section in the report.
+type AnalysisConfig struct {
+	Letterbox     bool    // Detect active picture area and aspect ratio changes
+	LogoPath      string  // Detect this logo/watermark image in the frame corners
+	LogoThreshold float64 // Match score (0-1) for logo presence (default 0.6)
+	Text          bool    // Detect credits and burned-in subtitles
+	Skin          bool    // Measure skin-tone pixel ratio (people-on-screen density)
+	Cuts          bool    // Detect hard scene cuts
+	LaneHeight    int     // Height per lane in pixels (default 32)
+	ReportPath    string  // Write JSON analysis report (empty = none)
+	EventsPath    string  // Export cuts and QC spans as .edl, .ffmeta or .txt chapters (empty = none)
+	SeekMapPath   string  // Write a pixel-to-time map as .html image map or .json (empty = none)
+	AltTextPath   string  // Write a text alternative (dominant colors over time) as .html or .json (empty = none)
+	XMP           bool    // Write an XMP sidecar (output with .xmp) with the layout and report
+	AutoLevels    string  // Stretch the DNA contrast: LevelsGlobal or LevelsRow (empty = off)
+	Linear        bool    // Average in linear light instead of gamma-encoded sRGB values
+
+	// Checksum records the SHA-256 (and MD5) of the input in the PNG layout
+	// metadata and the report, and refuses inputs not matching Verify.
+	Checksum checksum.Options
+
+	// TimecodeBase overrides the start timecode read from the container
+	// (HH:MM:SS:FF; 00:00:00:00 for zero-based timecode). TimecodeFormat
+	// selects drop-frame or non-drop-frame notation (TimecodeAuto, TimecodeNDF
+	// or TimecodeDF).
+	TimecodeBase   string
+	TimecodeFormat string
+
+	// Width sets the number of DNA columns (rows with vertical output),
+	// averaging adjacent frames: WidthAuto caps one column per frame at a
+	// size viewers handle, 0 keeps one column per frame. ColumnsPerSecond,
+	// when set, derives the width from the duration instead.
+	Width            int
+	ColumnsPerSecond float64
+
+	// AdaptiveColumns shares the columns by content: after decoding, a
+	// pass over the frame-to-frame change gives stretches around scene
+	// changes up to four times the columns of static shots, with tick
+	// marks at round times. Needs Width or ColumnsPerSecond.
+	AdaptiveColumns bool
+
+	// ICCProfile tags the PNG or TIFF with a color profile: srgb, rec709
+	// (gamma 2.4 of grading monitors), none, or the path of an .icc file
+	// ("" = srgb).
+	ICCProfile string
+
+	// ColorConvert converts frames to BT.709/sRGB from the color
+	// properties of the source: the BT.709 matrix for HD, BT.2020
+	// primaries, and tone mapping of HDR, which needs an ffmpeg built with
+	// zimg (false = as ffmpeg decodes, with the BT.601 matrix).
+	ColorConvert bool
+
+	// BitDepth 16 keeps the fraction of averaged colors in a 16-bit per
+	// channel PNG or TIFF, for numeric analysis (0 or 8 = 8-bit).
+	BitDepth int
+
+	// Colors quantizes the output to an indexed PNG of at most this many
+	// colors (2 to 256, 0 = truecolor), for small catalog thumbnails.
+	Colors int
+
+	// MaxDimension caps the time axis of the output in pixels (0 = no cap,
+	// DefaultOptions uses DefaultMaxDimension); longer DNAs average adjacent
+	// frames.
+	MaxDimension int
+
+	// Transform reverses, flips or log-maps the time axis of the rendered
+	// DNA and its lanes. It does not affect analysis or the report.
+	Transform transform.Options
+
+	// Zoom renders a time region again, expanded to full width, below the
+	// DNA and its lanes.
+	Zoom Zoom
+
+	// Scale renders labels, legend, separators and lanes at 2x/3x for HiDPI
+	// displays; the DNA itself is enlarged without interpolation.
+	Scale int
+
+	// Annotations are user-supplied timed labels, drawn as markers on the
+	// DNA with their text in a band below it.
+	Annotations []Annotation
+
+	// FingerprintPath writes the DNA as a multi-resolution fingerprint
+	// (FingerprintLevels columns each, default 64/256/1024/4096).
+	FingerprintPath   string
+	FingerprintLevels []int
+
+	// Hooks run user commands after probing (a failing hook aborts).
+	Hooks *hooks.Runner
+
+	// Series are user time series (telemetry, QoE scores, ...) drawn as
+	// lanes below the analysis lanes, aligned with the DNA.
+	Series []TimeSeries
+
+	// Lanes sets the order, height and visibility of the lanes; a LaneDNA
+	// entry sets the DNA thickness (nil = every lane in default order).
+	Lanes lanespec.Spec
+
+	// Adjust changes saturation, contrast and brightness of the rendered DNA
+	// for presentation; fingerprints, reports and alt text keep the measured
+	// colors.
+	Adjust adjust.Options
+
+	// HWAccel decodes on the GPU ("cuda" or "vaapi") and keeps scaling and
+	// pixel format conversion there. In average mode without analysis
+	// passes (and without Linear) the averaged dimension is also reduced on
+	// the GPU.
+	HWAccel string
+}
+
+// AnalysisReport collects results of the analysis passes.
+type AnalysisReport struct {
+	Input     string           `json:"input"`
+	Frames    int              `json:"frames"`
+	FPS       float64          `json:"fps"`
+	Timecode  string           `json:"timecode,omitempty"` // Start timecode; times stay in seconds from the start of the file
+	Letterbox *LetterboxReport `json:"letterbox,omitempty"`
+	Logo      *LogoReport      `json:"logo,omitempty"`
+	Text      *TextReport      `json:"text,omitempty"`
+	Skin      *SkinReport      `json:"skin,omitempty"`
+	Cuts      *CutReport       `json:"cuts,omitempty"`
+	Levels    *Levels          `json:"levels,omitempty"`    // Auto-levels applied to the DNA
+	Checksums *checksum.Sums   `json:"checksums,omitempty"` // Digests of the input file
+	Timings   *timing.Timings  `json:"timings,omitempty"`   // Seconds per stage of the run
+}
+
+// Span is a range of frames sharing a label. EndFrame is exclusive.
+type Span struct {
+	Label      string  `json:"label"`
+	Start      float64 `json:"start"` // Seconds
+	End        float64 `json:"end"`   // Seconds
+	StartFrame int     `json:"start_frame"`
+	EndFrame   int     `json:"end_frame"`
+
+	StartTimecode string `json:"start_timecode,omitempty"` // Editorial timecode of Start
+	EndTimecode   string `json:"end_timecode,omitempty"`
+}
+
+// frameAnalyzer computes a per-frame metric during decoding.
+type frameAnalyzer interface {
+	// analyze inspects one RGB24 frame.
+	analyze(frame []byte, width, height int)
+	// finish fills its report section and returns the lanes to render.
+	finish(fps float64, report *AnalysisReport) []Lane
+}
+
+// newAnalyzers returns the analyzers enabled in config.
+func newAnalyzers(config AnalysisConfig) ([]frameAnalyzer, error) {
+	var analyzers []frameAnalyzer
+	if config.Letterbox {
+		analyzers = append(analyzers, &letterboxAnalyzer{})
+	}
+	if config.LogoPath != "" {
+		logo, err := newLogoAnalyzer(config.LogoPath, config.LogoThreshold)
+		if err != nil {
+			return nil, err
+		}
+		analyzers = append(analyzers, logo)
+	}
+	if config.Text {
+		analyzers = append(analyzers, &textAnalyzer{})
+	}
+	if config.Skin {
+		analyzers = append(analyzers, &skinAnalyzer{})
+	}
+	if config.Cuts {
+		analyzers = append(analyzers, &cutAnalyzer{})
+	}
+	return analyzers, nil
+}
+
+// spansFrom groups consecutive frames with the same non-empty label into
+// spans. Runs shorter than minFrames are absorbed into an adjacent preceding
+// span (or dropped), which suppresses flicker from noisy per-frame decisions.
+func spansFrom(labels []string, minFrames int, fps float64) []Span {
+	var spans []Span
+	start := 0
+	for i := 1; i <= len(labels); i++ {
+		if i < len(labels) && labels[i] == labels[start] {
+			continue
+		}
+		label, end := labels[start], i
+		short := end-start < minFrames
+
+		if n := len(spans); n > 0 && spans[n-1].EndFrame == start && (short || spans[n-1].Label == label) {
+			spans[n-1].EndFrame = end
+		} else if label != "" && !short {
+			spans = append(spans, Span{Label: label, StartFrame: start, EndFrame: end})
+		}
+		start = i
+	}
+
+	for i := range spans {
+		if fps > 0 {
+			spans[i].Start = float64(spans[i].StartFrame) / fps
+			spans[i].End = float64(spans[i].EndFrame) / fps
+		}
+	}
+	return spans
+}
+
+// Events returns the detected events of a report for export: cuts, text
+// spans, logo dropouts and aspect ratio changes.
+func (r *AnalysisReport) Events() []events.Event {
+	var list []events.Event
+	if r.Cuts != nil {
+		for i, c := range r.Cuts.Cuts {
+			list = append(list, events.Event{Start: c.Time, End: c.Time, Kind: "cut", Label: fmt.Sprintf("cut %d", i+1)})
+		}
+	}
+	if r.Text != nil {
+		for _, s := range append(r.Text.Credits, r.Text.Subtitles...) {
+			list = append(list, events.Event{Start: s.Start, End: s.End, Kind: s.Label, Label: s.Label})
+		}
+	}
+	if r.Logo != nil {
+		for _, s := range r.Logo.Absent {
+			list = append(list, events.Event{Start: s.Start, End: s.End, Kind: "logo", Label: "logo absent"})
+		}
+	}
+	if r.Letterbox != nil && r.Letterbox.MixedAspect {
+		for _, a := range r.Letterbox.Segments {
+			list = append(list, events.Event{Start: a.Start, End: a.End, Kind: "aspect", Label: fmt.Sprintf("aspect %.2f", a.Aspect)})
+		}
+	}
+	return list
+}
+
+// FormatTimestamp formats seconds as HH:MM:SS.mmm.
+func FormatTimestamp(seconds float64) string {
+	ms := int(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
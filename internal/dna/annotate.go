@@ -0,0 +1,157 @@
+package dna
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pforret/videodna/internal/font"
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// Annotation band layout (in logical pixels, multiplied by the UI scale).
+const (
+	annotationRowHeight = 11 // One row of label text
+	annotationMaxRows   = 3  // Overlapping labels stack up to this many rows
+)
+
+// annotationColor is used for annotation markers and labels.
+var annotationColor = color.RGBA{R: 120, G: 220, B: 255, A: 255}
+
+// Annotation is a user-supplied label at a point in time.
+type Annotation struct {
+	Time     float64 `json:"time"`               // Seconds
+	Label    string  `json:"label"`              // Text shown next to the marker
+	Timecode string  `json:"timecode,omitempty"` // HH:MM:SS:FF from NLE markers; replaces Time once the frame rate is known
+}
+
+// ParseAnnotation parses "TIME=LABEL", e.g. "00:05:00=sponsor read".
+func ParseAnnotation(s string) (Annotation, error) {
+	at, label, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(label) == "" {
+		return Annotation{}, fmt.Errorf("invalid annotation %q, use TIME=LABEL (e.g. 00:05:00=\"sponsor read\")", s)
+	}
+	seconds, err := ParseTimestamp(at)
+	if err != nil {
+		return Annotation{}, err
+	}
+	return Annotation{Time: seconds, Label: strings.TrimSpace(label)}, nil
+}
+
+// loadAnnotationsJSON reads annotations from a JSON file: an array of
+// objects with "time" (seconds or a timestamp string like "00:05:00") and
+// "label".
+func loadAnnotationsJSON(path string) ([]Annotation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read annotations: %w", err)
+	}
+	var entries []struct {
+		Time  json.RawMessage `json:"time"`
+		Label string          `json:"label"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse annotations: %w", err)
+	}
+
+	annotations := make([]Annotation, 0, len(entries))
+	for i, e := range entries {
+		var seconds float64
+		var timestamp string
+		if err := json.Unmarshal(e.Time, &seconds); err != nil {
+			if err := json.Unmarshal(e.Time, &timestamp); err != nil {
+				return nil, fmt.Errorf("annotation %d: time must be seconds or a timestamp string", i+1)
+			}
+			if seconds, err = ParseTimestamp(timestamp); err != nil {
+				return nil, fmt.Errorf("annotation %d: %w", i+1, err)
+			}
+		}
+		if strings.TrimSpace(e.Label) == "" {
+			return nil, fmt.Errorf("annotation %d: missing label", i+1)
+		}
+		annotations = append(annotations, Annotation{Time: seconds, Label: e.Label})
+	}
+	return annotations, nil
+}
+
+// addAnnotations draws a marker line for each annotation across the DNA
+// strip src and appends a band below it with the labels. Labels that would
+// overlap move to the next row. Positions are fractions of the full duration
+// (time * fps / frames), mapped through the time transform. The markers are
+// also recorded in marks (nil = none).
+func addAnnotations(src image.Image, marks *image.Alpha, annotations []Annotation, fps float64, frames int, t transform.Options, scale int) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	rowHeight := annotationRowHeight * scale
+	bandHeight := annotationMaxRows*rowHeight + 2*scale
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h+bandHeight))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(x, y, src.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	bg := color.RGBA{R: 20, G: 20, B: 25, A: 255}
+	for y := h; y < h+bandHeight; y++ {
+		for x := 0; x < w; x++ {
+			dst.SetRGBA(x, y, bg)
+		}
+	}
+
+	// Marker positions, left to right (the time axis may be reversed)
+	type marker struct {
+		x     int
+		label string
+	}
+	var markers []marker
+	for _, a := range annotations {
+		if frames <= 0 || a.Time < 0 || a.Time*fps > float64(frames) {
+			continue
+		}
+		x := int(t.MapTime(a.Time*fps/float64(frames)) * float64(w))
+		markers = append(markers, marker{x: min(max(x, 0), w-1), label: a.Label})
+	}
+	sort.SliceStable(markers, func(i, j int) bool { return markers[i].x < markers[j].x })
+
+	// Place labels in the first row where they do not overlap
+	rowEnd := make([]int, annotationMaxRows)
+	for i := range rowEnd {
+		rowEnd[i] = -1
+	}
+	for _, m := range markers {
+		x := m.x
+
+		labelX := x + 3*scale
+		labelW := textWidth(m.label) * scale
+		if labelX+labelW > w {
+			labelX = max(x-3*scale-labelW, 0)
+		}
+		row := 0
+		for row < annotationMaxRows-1 && rowEnd[row] >= labelX {
+			row++
+		}
+		rowEnd[row] = labelX + labelW + 4*scale
+
+		// Marker across the strip and down to the label row
+		labelY := h + 2*scale + row*rowHeight
+		for y := 0; y < labelY+rowHeight-2*scale; y++ {
+			for dx := 0; dx < scale && x+dx < w; dx++ {
+				dst.SetRGBA(x+dx, y, annotationColor)
+				if marks != nil {
+					marks.SetAlpha(x+dx, y, color.Alpha{A: 255})
+				}
+			}
+		}
+		drawTextScaled(dst, m.label, labelX, labelY+2*scale, annotationColor, scale)
+	}
+	return dst
+}
+
+// textWidth returns the rendered width of text in pixels at scale 1.
+func textWidth(text string) int {
+	return font.Width(text)
+}
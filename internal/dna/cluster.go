@@ -0,0 +1,229 @@
+package dna
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pforret/videodna/internal/fingerprint"
+	"github.com/pforret/videodna/internal/progress"
+)
+
+// mediaExtensions are the video file extensions picked up by Cluster.
+var mediaExtensions = map[string]bool{
+	".mp4": true, ".m4v": true, ".mov": true, ".mkv": true, ".webm": true, ".avi": true,
+	".mxf": true, ".mpg": true, ".mpeg": true, ".ts": true, ".m2ts": true, ".wmv": true, ".flv": true,
+}
+
+// ClusterConfig configures near-duplicate clustering.
+type ClusterConfig struct {
+	Threshold   float64 // Similarity at which two files are near-duplicates (0-1)
+	Columns     int     // Fingerprint resolution compared
+	MaxDuration float64 // Maximum relative duration difference of duplicates
+	Jobs        int     // Files fingerprinted in parallel (ffmpeg processes are bounded by toolexec.SetLimits)
+	Silent      bool    // Suppress progress output
+
+	// Progress shows the files being fingerprinted on a terminal dashboard
+	// instead of a line per file (nil = lines).
+	Progress *progress.Dashboard
+}
+
+// DefaultClusterConfig returns default clustering configuration.
+func DefaultClusterConfig() ClusterConfig {
+	return ClusterConfig{
+		Threshold:   0.95,
+		Columns:     256,
+		MaxDuration: 0.05,
+		Jobs:        1,
+	}
+}
+
+// ClusterFile is one media file of a cluster.
+type ClusterFile struct {
+	Path     string  `json:"path"`
+	Width    int     `json:"width"`
+	Height   int     `json:"height"`
+	BitRate  int64   `json:"bit_rate"`
+	Size     int64   `json:"size"`
+	Duration float64 `json:"duration"`
+}
+
+// ClusterGroup is a set of near-duplicate files; Files[0] is the best copy.
+type ClusterGroup struct {
+	Best       string        `json:"best"`
+	Similarity float64       `json:"similarity"` // Lowest similarity that joined the group
+	Files      []ClusterFile `json:"files"`
+}
+
+// ClusterReport lists the near-duplicate groups found in a directory.
+type ClusterReport struct {
+	Directory string            `json:"directory"`
+	Files     int               `json:"files"`
+	Groups    []ClusterGroup    `json:"groups"`
+	Skipped   map[string]string `json:"skipped,omitempty"` // Path -> error
+}
+
+// WriteJSON writes the report as JSON.
+func (r *ClusterReport) WriteJSON(path string) error {
+	return writeJSON(path, r)
+}
+
+// Cluster fingerprints all video files below dir and groups near-duplicates
+// (re-encodes, different resolutions or bit rates of the same content). Each
+// group is sorted best copy first: highest resolution, then bit rate, then
+// file size. Files that cannot be decoded are listed as skipped.
+func Cluster(ctx context.Context, dir string, config ClusterConfig) (*ClusterReport, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && mediaExtensions[strings.ToLower(filepath.Ext(path))] {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+
+	report := &ClusterReport{Directory: dir, Skipped: map[string]string{}}
+	config.Progress.SetTotal(len(paths))
+	levels := []int{fingerprint.DefaultLevels[0], config.Columns}
+
+	// Fingerprint in parallel; results keep the directory order
+	type result struct {
+		file ClusterFile
+		fp   *fingerprint.Fingerprint
+		err  error
+	}
+	results := make([]result, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var printMu sync.Mutex
+	for w := 0; w < max(config.Jobs, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				path := paths[i]
+				if !config.Silent && config.Progress == nil {
+					printMu.Lock()
+					fmt.Printf("Fingerprinting %d/%d: %s\n", i+1, len(paths), path)
+					printMu.Unlock()
+				}
+				task := config.Progress.Begin(path)
+				fp, info, err := ComputeFingerprint(progress.NewContext(ctx, task), path, levels)
+				task.Done(err)
+				if err != nil {
+					results[i].err = err
+					continue
+				}
+				file := ClusterFile{Path: path, Width: info.Width, Height: info.Height, BitRate: info.BitRate, Duration: fp.Duration}
+				if st, err := os.Stat(path); err == nil {
+					file.Size = st.Size()
+				}
+				results[i] = result{file: file, fp: fp}
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var files []ClusterFile
+	var fps []*fingerprint.Fingerprint
+	for i, r := range results {
+		if r.err != nil {
+			report.Skipped[paths[i]] = r.err.Error()
+			continue
+		}
+		files = append(files, r.file)
+		fps = append(fps, r.fp)
+	}
+	report.Files = len(files)
+
+	// Single-linkage clustering with union-find; the coarse level rejects
+	// most pairs before the finer comparison
+	parent := make([]int, len(files))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	joined := make(map[int]float64)
+	for i := range files {
+		for j := i + 1; j < len(files); j++ {
+			da, db := files[i].Duration, files[j].Duration
+			if math.Abs(da-db) > config.MaxDuration*math.Max(da, db) {
+				continue
+			}
+			if s, err := fingerprint.Similarity(fps[i], fps[j], levels[0]); err != nil || s < config.Threshold {
+				continue
+			}
+			s, err := fingerprint.Similarity(fps[i], fps[j], config.Columns)
+			if err != nil || s < config.Threshold {
+				continue
+			}
+			ri, rj := find(i), find(j)
+			low := s
+			if v, ok := joined[ri]; ok {
+				low = math.Min(low, v)
+			}
+			if v, ok := joined[rj]; ok {
+				low = math.Min(low, v)
+			}
+			parent[rj] = ri
+			joined[ri] = low
+		}
+	}
+
+	members := make(map[int][]ClusterFile)
+	for i, f := range files {
+		root := find(i)
+		members[root] = append(members[root], f)
+	}
+	for root, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(a, b int) bool { return betterCopy(group[a], group[b]) })
+		report.Groups = append(report.Groups, ClusterGroup{
+			Best:       group[0].Path,
+			Similarity: math.Round(joined[root]*1e4) / 1e4,
+			Files:      group,
+		})
+	}
+	sort.Slice(report.Groups, func(a, b int) bool { return report.Groups[a].Best < report.Groups[b].Best })
+	return report, nil
+}
+
+// betterCopy reports whether a is a better copy to keep than b.
+func betterCopy(a, b ClusterFile) bool {
+	if pa, pb := a.Width*a.Height, b.Width*b.Height; pa != pb {
+		return pa > pb
+	}
+	if a.BitRate != b.BitRate {
+		return a.BitRate > b.BitRate
+	}
+	if a.Size != b.Size {
+		return a.Size > b.Size
+	}
+	return a.Path < b.Path
+}
@@ -15,6 +15,19 @@ func AverageColor(row []byte, width int) color.Color {
 	return color.RGBA{R: uint8(rSum / n), G: uint8(gSum / n), B: uint8(bSum / n), A: 255}
 }
 
+// AverageColor64 returns the average RGB color of a row at 16 bits per
+// channel, keeping the fraction AverageColor rounds away.
+func AverageColor64(row []byte, width int) color.RGBA64 {
+	var rSum, gSum, bSum uint64
+	for x := 0; x < width; x++ {
+		i := x * 3
+		rSum += uint64(row[i])
+		gSum += uint64(row[i+1])
+		bSum += uint64(row[i+2])
+	}
+	return average64(rSum, gSum, bSum, uint64(width))
+}
+
 // MinColor returns the minimum RGB values in a row.
 func MinColor(row []byte, width int) color.Color {
 	var rMin, gMin, bMin uint8 = 255, 255, 255
@@ -90,6 +103,25 @@ func AverageColorCol(buf []byte, col, width, height int) color.Color {
 	return color.RGBA{R: uint8(rSum / n), G: uint8(gSum / n), B: uint8(bSum / n), A: 255}
 }
 
+// AverageColorCol64 returns the average RGB color of a column at 16 bits per
+// channel.
+func AverageColorCol64(buf []byte, col, width, height int) color.RGBA64 {
+	var rSum, gSum, bSum uint64
+	for y := 0; y < height; y++ {
+		i := (y*width + col) * 3
+		rSum += uint64(buf[i])
+		gSum += uint64(buf[i+1])
+		bSum += uint64(buf[i+2])
+	}
+	return average64(rSum, gSum, bSum, uint64(height))
+}
+
+// average64 scales 8-bit channel sums of n pixels to rounded 16-bit means.
+func average64(rSum, gSum, bSum, n uint64) color.RGBA64 {
+	scale := func(sum uint64) uint16 { return uint16((sum*257 + n/2) / n) }
+	return color.RGBA64{R: scale(rSum), G: scale(gSum), B: scale(bSum), A: 0xffff}
+}
+
 // MinColorCol returns the minimum RGB values in a column.
 func MinColorCol(buf []byte, col, width, height int) color.Color {
 	var rMin, gMin, bMin uint8 = 255, 255, 255
@@ -0,0 +1,67 @@
+package dna
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pforret/videodna/internal/video"
+)
+
+// colorFilter returns the ffmpeg filter that turns decoded frames into
+// BT.709/sRGB rgb24 for the color properties of the source, and a short
+// description of the conversion. Without it ffmpeg converts YUV with the
+// BT.601 matrix and leaves BT.2020 primaries as they are, shifting the hues
+// of HD and UHD sources. HDR (PQ or HLG) sources are tone mapped, which
+// needs an ffmpeg built with zimg. An empty filter leaves frames as ffmpeg
+// decodes them.
+func colorFilter(info *video.Info) (filter, description string) {
+	inRange := "tv"
+	if info.ColorRange == "pc" {
+		inRange = "pc"
+	}
+	switch {
+	case info.ColorSpace == "gbr":
+		return "", "RGB source"
+	case info.ColorTransfer == "smpte2084" || info.ColorTransfer == "arib-std-b67":
+		return "zscale=t=linear:npl=100,format=gbrpf32le,zscale=p=bt709,tonemap=hable:desat=0,zscale=t=bt709,format=rgb24",
+			fmt.Sprintf("HDR (%s) tone mapped to BT.709", info.ColorTransfer)
+	case info.ColorPrimaries == "bt2020" || info.ColorSpace == "bt2020nc" || info.ColorSpace == "bt2020c":
+		return fmt.Sprintf("colorspace=all=bt709:iall=bt2020:irange=%s:range=%s,scale=in_color_matrix=bt709:in_range=%s,format=rgb24", inRange, inRange, inRange),
+			"BT.2020 converted to BT.709"
+	}
+
+	var matrix string
+	switch info.ColorSpace {
+	case "bt709", "fcc", "smpte240m":
+		matrix = info.ColorSpace
+	case "smpte170m", "bt470bg":
+		matrix = "bt601"
+	case "":
+		// Untagged: HD and larger is BT.709 by convention, SD is BT.601
+		matrix = "bt601"
+		if info.Height >= 720 {
+			matrix = "bt709"
+		}
+		description = fmt.Sprintf("untagged, assumed %s", matrix)
+	default:
+		return "", ""
+	}
+	if description == "" {
+		description = matrix
+	}
+	return fmt.Sprintf("scale=in_color_matrix=%s:in_range=%s,format=rgb24", matrix, inRange), description
+}
+
+// decodeError reports an ffmpeg run that decoded no frames, with its error
+// output. conversion is the description of the colorFilter applied ("" =
+// none); the HDR conversion fails on ffmpeg builds without zimg.
+func decodeError(err error, stderr, conversion string) error {
+	msg := strings.TrimSpace(stderr)
+	if msg == "" {
+		msg = err.Error()
+	}
+	if strings.HasPrefix(conversion, "HDR") {
+		return fmt.Errorf("HDR tone mapping failed, it needs an ffmpeg built with zimg (or leave out -color-convert): %s", msg)
+	}
+	return fmt.Errorf("ffmpeg decoded no frames: %s", msg)
+}
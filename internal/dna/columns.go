@@ -0,0 +1,117 @@
+package dna
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/pforret/videodna/internal/imagesize"
+)
+
+// WidthAuto sizes the time axis from the duration: one column per frame, at
+// most maxAutoWidth columns.
+const WidthAuto = -1
+
+// maxAutoWidth keeps long videos at a size viewers and PNG encoders handle.
+const maxAutoWidth = 8192
+
+// DefaultMaxDimension is the default cap of the time axis in pixels.
+const DefaultMaxDimension = imagesize.DefaultMaxDimension
+
+// timeColumns returns the length of the time axis for the width settings,
+// or 0 to keep one column per frame.
+func timeColumns(width int, columnsPerSecond float64, frames int, fps float64) int {
+	switch {
+	case columnsPerSecond > 0 && fps > 0:
+		return max(1, int(math.Ceil(float64(frames)/fps*columnsPerSecond)))
+	case width == WidthAuto:
+		return min(frames, maxAutoWidth)
+	case width > 0:
+		return width
+	}
+	return 0
+}
+
+// capColumns limits the time axis of frames columns (0 = one per frame) to
+// maxDimension pixels at the given UI scale. ok reports whether the
+// limit applied.
+func capColumns(columns, frames, maxDimension, scale int) (capped int, ok bool) {
+	length := columns
+	if length == 0 {
+		length = frames
+	}
+	limit := maxDimension / max(scale, 1)
+	if maxDimension <= 0 || length <= limit {
+		return columns, false
+	}
+	return max(limit, 1), true
+}
+
+// aggregateTime averages runs of adjacent columns (rows when vertical) into
+// n columns, so every frame contributes instead of a sampled few. A longer
+// axis repeats columns. 16-bit images stay 16-bit. With linear, colors are
+// averaged in linear light.
+func aggregateTime(src image.Image, n int, vertical, linear bool) image.Image {
+	bounds := src.Bounds()
+	length, across := bounds.Dx(), bounds.Dy()
+	if vertical {
+		length, across = across, length
+	}
+	at := func(t, a int) color.Color {
+		if vertical {
+			return src.At(bounds.Min.X+a, bounds.Min.Y+t)
+		}
+		return src.At(bounds.Min.X+t, bounds.Min.Y+a)
+	}
+
+	var toLinear *[0x10000]float64
+	if linear {
+		toLinear = srgbLinear16()
+	}
+
+	var dst draw.Image
+	if vertical {
+		dst = newLike(src, across, n)
+	} else {
+		dst = newLike(src, n, across)
+	}
+	for i := 0; i < n; i++ {
+		from := i * length / n
+		to := max((i+1)*length/n, from+1)
+		for a := 0; a < across; a++ {
+			var c color.RGBA64
+			if linear {
+				var r, g, b float64
+				for t := from; t < to; t++ {
+					cr, cg, cb, _ := at(t, a).RGBA()
+					r, g, b = r+toLinear[cr], g+toLinear[cg], b+toLinear[cb]
+				}
+				c = linearMean(r, g, b, to-from)
+			} else {
+				var r, g, b uint32
+				for t := from; t < to; t++ {
+					cr, cg, cb, _ := at(t, a).RGBA()
+					r, g, b = r+cr, g+cg, b+cb
+				}
+				count := uint32(to - from)
+				c = color.RGBA64{R: uint16(r / count), G: uint16(g / count), B: uint16(b / count), A: 0xffff}
+			}
+			if vertical {
+				dst.Set(a, i, c)
+			} else {
+				dst.Set(i, a, c)
+			}
+		}
+	}
+	return dst
+}
+
+// newLike returns an empty w x h image with the bit depth of src: 16-bit
+// for *image.RGBA64, 8-bit otherwise.
+func newLike(src image.Image, w, h int) draw.Image {
+	if _, ok := src.(*image.RGBA64); ok {
+		return image.NewRGBA64(image.Rect(0, 0, w, h))
+	}
+	return image.NewRGBA(image.Rect(0, 0, w, h))
+}
@@ -0,0 +1,95 @@
+package dna
+
+import (
+	"image/color"
+	"math"
+)
+
+// Cut detection parameters.
+const (
+	cutGrid      = 16   // Frames are compared as a cutGrid x cutGrid luma thumbnail
+	cutThreshold = 0.12 // Mean thumbnail change (fraction of full scale) that counts as a cut
+	cutContrast  = 3.0  // ...and at least this many times the recent average change
+	cutMinGap    = 0.5  // Seconds between cuts (flashes and strobes count once)
+	cutLaneScale = 0.3  // Change rendered as a full lane bar
+)
+
+// CutReport lists detected scene cuts.
+type CutReport struct {
+	Count int   `json:"count"`
+	Cuts  []Cut `json:"cuts"`
+}
+
+// Cut is a hard scene change at the start of Frame.
+type Cut struct {
+	Time  float64 `json:"time"` // Seconds
+	Frame int     `json:"frame"`
+	Score float64 `json:"score"` // Mean thumbnail change (0-1)
+
+	Timecode string `json:"timecode,omitempty"` // Editorial timecode of Time
+}
+
+// cutAnalyzer detects hard cuts from the change of a small luma thumbnail
+// between consecutive frames. Dissolves and fades are not reported.
+type cutAnalyzer struct {
+	prev    []float64
+	changes []float64
+}
+
+func (a *cutAnalyzer) analyze(frame []byte, width, height int) {
+	thumb := make([]float64, cutGrid*cutGrid)
+	for gy := 0; gy < cutGrid; gy++ {
+		for gx := 0; gx < cutGrid; gx++ {
+			// Sample the center pixel of each grid cell
+			x := (2*gx + 1) * width / (2 * cutGrid)
+			y := (2*gy + 1) * height / (2 * cutGrid)
+			i := (y*width + x) * 3
+			thumb[gy*cutGrid+gx] = (0.299*float64(frame[i]) + 0.587*float64(frame[i+1]) + 0.114*float64(frame[i+2])) / 255
+		}
+	}
+
+	change := 0.0
+	if a.prev != nil {
+		for i, v := range thumb {
+			change += math.Abs(v - a.prev[i])
+		}
+		change /= float64(len(thumb))
+	}
+	a.changes = append(a.changes, change)
+	a.prev = thumb
+}
+
+func (a *cutAnalyzer) finish(fps float64, report *AnalysisReport) []Lane {
+	lane := Lane{Label: "cuts", Color: color.RGBA{R: 230, G: 230, B: 230, A: 255}}
+	for _, c := range a.changes {
+		lane.Values = append(lane.Values, math.Min(c/cutLaneScale, 1))
+	}
+
+	minGap := int(cutMinGap * fps)
+	cr := &CutReport{Cuts: []Cut{}}
+	last := -minGap - 1
+	for i, c := range a.changes {
+		// Average change over the previous second as the local baseline
+		var recent float64
+		from := max(i-int(math.Max(fps, 1)), 1)
+		for _, r := range a.changes[from:max(i, from)] {
+			recent += r
+		}
+		if i > from {
+			recent /= float64(i - from)
+		}
+		if c < cutThreshold || c < cutContrast*recent || i-last <= minGap {
+			continue
+		}
+		cut := Cut{Frame: i, Score: c}
+		if fps > 0 {
+			cut.Time = float64(i) / fps
+		}
+		cr.Cuts = append(cr.Cuts, cut)
+		last = i
+	}
+	cr.Count = len(cr.Cuts)
+	report.Cuts = cr
+
+	return []Lane{lane}
+}
@@ -0,0 +1,68 @@
+package dna
+
+import (
+	"image/color"
+	"math"
+)
+
+// srgbLinear maps 8-bit sRGB values to linear light (0.0 to 1.0).
+var srgbLinear = func() [256]float64 {
+	var lut [256]float64
+	for i := range lut {
+		v := float64(i) / 255
+		if v <= 0.04045 {
+			lut[i] = v / 12.92
+		} else {
+			lut[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+	return lut
+}()
+
+// RGBToLab converts an sRGB color to CIE L*a*b* (D65 white point).
+func RGBToLab(r, g, b uint8) (l, a, bb float64) {
+	rl, gl, bl := srgbLinear[r], srgbLinear[g], srgbLinear[b]
+
+	x := (0.4124*rl + 0.3576*gl + 0.1805*bl) / 0.95047
+	y := 0.2126*rl + 0.7152*gl + 0.0722*bl
+	z := (0.0193*rl + 0.1192*gl + 0.9505*bl) / 1.08883
+
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+func labF(t float64) float64 {
+	if t > 216.0/24389.0 {
+		return math.Cbrt(t)
+	}
+	return (24389.0/27.0*t + 16) / 116
+}
+
+// DeltaE returns the CIE76 color difference between two sRGB pixels.
+func DeltaE(r1, g1, b1, r2, g2, b2 uint8) float64 {
+	if r1 == r2 && g1 == g2 && b1 == b2 {
+		return 0
+	}
+	l1, a1, bb1 := RGBToLab(r1, g1, b1)
+	l2, a2, bb2 := RGBToLab(r2, g2, b2)
+	dl, da, db := l1-l2, a1-a2, bb1-bb2
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+// heatColor maps a value (0.0 to 1.0) to a black-red-yellow-white heat scale.
+func heatColor(v float64) color.RGBA {
+	if v < 0 {
+		v = 0
+	}
+	if v > 1 {
+		v = 1
+	}
+	switch {
+	case v < 1.0/3:
+		return color.RGBA{R: uint8(v * 3 * 255), A: 255}
+	case v < 2.0/3:
+		return color.RGBA{R: 255, G: uint8((v - 1.0/3) * 3 * 255), A: 255}
+	default:
+		return color.RGBA{R: 255, G: 255, B: uint8((v - 2.0/3) * 3 * 255), A: 255}
+	}
+}
@@ -0,0 +1,27 @@
+package dna
+
+import (
+	"image"
+	"image/draw"
+)
+
+// overlayDeep returns final as a 16-bit image with the 16-bit DNA in the
+// area of marks, the mask finishImage records over the DNA. Pixels set in
+// marks (ticks and markers) keep their 8-bit color. The first and last scale
+// rows of deep are hidden by the border lines, as in final.
+func overlayDeep(final, deep image.Image, marks *image.Alpha, scale int) *image.RGBA64 {
+	bounds := final.Bounds()
+	dst := image.NewRGBA64(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), final, bounds.Min, draw.Src)
+
+	dnaRect := marks.Rect
+	offset := deep.Bounds().Min.Add(image.Pt(0, scale)).Sub(dnaRect.Min)
+	for y := dnaRect.Min.Y; y < dnaRect.Max.Y; y++ {
+		for x := dnaRect.Min.X; x < dnaRect.Max.X; x++ {
+			if marks.AlphaAt(x, y).A == 0 {
+				dst.Set(x, y, deep.At(x+offset.X, y+offset.Y))
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,442 @@
+package dna
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pforret/videodna/internal/hooks"
+	"github.com/pforret/videodna/internal/icc"
+	"github.com/pforret/videodna/internal/quantize"
+	"github.com/pforret/videodna/internal/resizespec"
+	"github.com/pforret/videodna/internal/toolexec"
+	"github.com/pforret/videodna/internal/transform"
+
+	"github.com/pforret/videodna/internal/video"
+)
+
+// DiffConfig configures difference DNA generation between two encodes.
+type DiffConfig struct {
+	ReferencePath string       // Reference (master) video to compare against
+	Offset        int          // Frame alignment: >0 skips input frames, <0 skips reference frames
+	MaxDeltaE     float64      // DeltaE mapped to full heat scale (default 20)
+	Vertical      bool         // Vertical output (width=video width, height=frames)
+	Resize        string       // Resize output: 'WxH' or 'input'
+	Silent        bool         // Suppress progress output
+	Timeout       int          // Timeout in seconds
+	Legend        LegendConfig // Legend bar configuration
+	QualityLanes  bool         // Render PSNR/SSIM lanes below the DNA
+	LaneHeight    int          // Height per lane in pixels (default 32)
+	ReportPath    string       // Write JSON quality report (empty = none)
+	SeekMapPath   string       // Write a pixel-to-time map as .html or .json (empty = none)
+	XMP           bool         // Write an XMP sidecar (output with .xmp) with the layout and report
+	ICCProfile    string       // Color profile of the PNG: srgb, rec709, none or an .icc path ("" = srgb)
+	ColorConvert  bool         // Convert both videos to BT.709/sRGB from their color properties (see AnalysisConfig)
+	Colors        int          // Quantize to an indexed PNG of at most this many colors (0 = truecolor)
+
+	Transform   transform.Options // Rendering-stage time axis transform
+	Zoom        Zoom              // Region rendered expanded below the DNA
+	Scale       int               // UI scale factor for HiDPI displays (default 1)
+	Annotations []Annotation      // Timed labels marked on the DNA
+
+	// TimecodeBase overrides the start timecode of the input (HH:MM:SS:FF),
+	// TimecodeFormat its notation (see AnalysisConfig).
+	TimecodeBase   string
+	TimecodeFormat string
+
+	// Hooks run user commands after probing (a failing hook aborts).
+	Hooks *hooks.Runner
+}
+
+// DiffReport summarizes a difference run for encode QC.
+type DiffReport struct {
+	Input     string         `json:"input"`
+	Reference string         `json:"reference"`
+	Offset    int            `json:"offset"`
+	Frames    int            `json:"frames"`
+	FPS       float64        `json:"fps"`
+	DeltaE    MetricSummary  `json:"delta_e"`
+	PSNR      MetricSummary  `json:"psnr"`
+	SSIM      MetricSummary  `json:"ssim"`
+	PerFrame  []FrameQuality `json:"per_frame"`
+}
+
+// FrameQuality holds quality metrics for a single compared frame.
+type FrameQuality struct {
+	Frame  int     `json:"frame"`
+	Time   float64 `json:"time"`
+	DeltaE float64 `json:"delta_e"`
+	PSNR   float64 `json:"psnr"`
+	SSIM   float64 `json:"ssim"`
+}
+
+// DefaultDiffConfig returns default difference configuration.
+func DefaultDiffConfig() DiffConfig {
+	return DiffConfig{
+		MaxDeltaE:    20,
+		Timeout:      60,
+		Legend:       DefaultLegendConfig(),
+		QualityLanes: true,
+		LaneHeight:   defaultLaneHeight,
+	}
+}
+
+// Validate checks the settings and their combinations, and reports every
+// violation at once, like Options.Validate.
+func (c DiffConfig) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, c.Legend.Locale.Errorf(format, args...))
+	}
+
+	if c.ReferencePath == "" {
+		fail("a reference video is required")
+	}
+	if _, err := resizespec.Parse(c.Resize); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Timeout <= 0 {
+		fail("timeout must be positive")
+	}
+	if c.Scale < 0 || c.Scale > 3 {
+		fail("scale must be 1, 2, or 3, not %d", c.Scale)
+	}
+	if c.Colors != 0 && (c.Colors < 2 || c.Colors > quantize.MaxColors) {
+		fail("colors must be between 2 and %d, not %d", quantize.MaxColors, c.Colors)
+	}
+	if c.Vertical && c.Zoom.Enabled() {
+		fail("zoom is not supported with vertical output")
+	}
+	if c.Vertical && len(c.Annotations) > 0 {
+		fail("annotations are not supported with vertical output")
+	}
+	if c.SeekMapPath != "" {
+		if err := CheckSeekMapPath(c.SeekMapPath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.TimecodeBase != "" && !isTimecode(c.TimecodeBase) {
+		fail("invalid timecode base %q, use HH:MM:SS:FF", c.TimecodeBase)
+	}
+	switch strings.ToLower(c.TimecodeFormat) {
+	case TimecodeAuto, "auto", TimecodeNDF, TimecodeDF:
+	default:
+		fail("unknown timecode format %q, use auto, ndf or df", c.TimecodeFormat)
+	}
+	return errors.Join(errs...)
+}
+
+// GenerateDiff decodes the input and reference videos in lockstep and creates
+// a DNA image of per-row (or per-column) color difference. Black means the
+// encodes match; red, yellow and white mark increasing deviation. Per-frame
+// PSNR and SSIM are computed alongside and returned in the report.
+func GenerateDiff(inputPath, outputPath string, config DiffConfig) (*DiffReport, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+	if config.MaxDeltaE <= 0 {
+		config.MaxDeltaE = 20
+	}
+	profile, err := icc.Resolve(config.ICCProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := video.GetFullInfo(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyTimecodeBase(info, config.TimecodeBase, config.TimecodeFormat); err != nil {
+		return nil, err
+	}
+	refInfo, err := video.GetFullInfo(config.ReferencePath)
+	if err != nil {
+		return nil, fmt.Errorf("reference: %w", err)
+	}
+
+	// Both streams are decoded at the input resolution
+	width, height := info.Width, info.Height
+	frameCount := info.FrameCount
+	if refInfo.FrameCount > 0 && refInfo.FrameCount < frameCount {
+		frameCount = refInfo.FrameCount
+	}
+
+	if frameCount == 0 || height == 0 {
+		return nil, fmt.Errorf("invalid video properties")
+	}
+
+	if !config.Silent {
+		fmt.Printf("Comparing video: %d frames, %dx%d pixels (reference %dx%d)\n",
+			frameCount, width, height, refInfo.Width, refInfo.Height)
+		if refInfo.FrameCount != info.FrameCount {
+			fmt.Printf("Warning: frame count differs (input %d, reference %d)\n", info.FrameCount, refInfo.FrameCount)
+		}
+	}
+
+	// Both videos are converted alike, or a conversion shows up as a difference
+	var srcFilter, refFilter, srcColor, refColor string
+	if config.ColorConvert {
+		srcFilter, srcColor = colorFilter(info)
+		refFilter, refColor = colorFilter(refInfo)
+		if !config.Silent && (srcColor != "" || refColor != "") {
+			described := func(s string) string {
+				if s == "" {
+					return "as decoded"
+				}
+				return s
+			}
+			fmt.Printf("Color: %s (reference %s)\n", described(srcColor), described(refColor))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Second)
+	defer cancel()
+
+	if err := config.Hooks.Run(ctx, hooks.Context{Point: hooks.AfterProbe, Input: inputPath, Output: outputPath, Info: hookInfo(info)}); err != nil {
+		return nil, err
+	}
+
+	// The two decoders share one process slot: acquiring a second while
+	// holding the first would deadlock with a limit of one process. Both are
+	// reaped on every return; cancelling first stops them instead of
+	// draining the rest of the input.
+	release, err := toolexec.Acquire(ctx, 2*toolexec.FFmpegMemory(width, height))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	src, err := startFrameSource(ctx, inputPath, srcFilter, width, height)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { cancel(); src.wait() }()
+	ref, err := startFrameSource(ctx, config.ReferencePath, refFilter, width, height)
+	if err != nil {
+		return nil, fmt.Errorf("reference: %w", err)
+	}
+	defer func() { cancel(); ref.wait() }()
+
+	// Apply frame alignment
+	if config.Offset > 0 {
+		err = src.skip(config.Offset)
+	} else if config.Offset < 0 {
+		err = ref.skip(-config.Offset)
+	}
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to align frames: %w", err)
+	}
+
+	maxFrames := frameCount + frameCount/10 + 10
+	var dnaImage *image.RGBA
+	if config.Vertical {
+		dnaImage = image.NewRGBA(image.Rect(0, 0, width, maxFrames))
+	} else {
+		dnaImage = image.NewRGBA(image.Rect(0, 0, maxFrames, height))
+	}
+
+	frameSize := width * height * 3
+	frameBuf := make([]byte, frameSize)
+	refBuf := make([]byte, frameSize)
+	startTime := time.Now()
+
+	var deltas, psnrs, ssims []float64
+	frameIdx := 0
+	for frameIdx < maxFrames {
+		if err := src.next(frameBuf); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to read frame: %w", err)
+		}
+		if err := ref.next(refBuf); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to read reference frame: %w", err)
+		}
+
+		frameDelta := 0.0
+		if config.Vertical {
+			for x := 0; x < width; x++ {
+				d := DeltaECol(frameBuf, refBuf, x, width, height)
+				frameDelta += d
+				dnaImage.SetRGBA(x, frameIdx, heatColor(d/config.MaxDeltaE))
+			}
+			frameDelta /= float64(width)
+		} else {
+			for y := 0; y < height; y++ {
+				rowStart := y * width * 3
+				d := DeltaERow(frameBuf[rowStart:rowStart+width*3], refBuf[rowStart:rowStart+width*3], width)
+				frameDelta += d
+				dnaImage.SetRGBA(frameIdx, y, heatColor(d/config.MaxDeltaE))
+			}
+			frameDelta /= float64(height)
+		}
+
+		deltas = append(deltas, frameDelta)
+		psnrs = append(psnrs, PSNR(frameBuf, refBuf))
+		ssims = append(ssims, SSIM(frameBuf, refBuf, width, height))
+
+		frameIdx++
+
+		if !config.Silent && frameIdx%100 == 0 {
+			fps := float64(frameIdx) / time.Since(startTime).Seconds()
+			pct := float64(frameIdx) * 100 / float64(frameCount)
+			fmt.Printf("Compared %d/%d frames (%.1f fps, %.0f%% done)\n", frameIdx, frameCount, fps, pct)
+		}
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timeout after %d seconds", config.Timeout)
+	}
+
+	// One stream may still be running if the other ended first
+	cancel()
+	srcErr, refErr := src.wait(), ref.wait()
+
+	if frameIdx == 0 {
+		if msg := src.stderr.String(); srcErr != nil && msg != "" {
+			return nil, decodeError(srcErr, msg, srcColor)
+		}
+		if msg := ref.stderr.String(); refErr != nil && msg != "" {
+			return nil, fmt.Errorf("reference: %w", decodeError(refErr, msg, refColor))
+		}
+		return nil, fmt.Errorf("no frames compared")
+	}
+
+	report := &DiffReport{
+		Input:     inputPath,
+		Reference: config.ReferencePath,
+		Offset:    config.Offset,
+		Frames:    frameIdx,
+		FPS:       info.FPS,
+		DeltaE:    summarize(deltas),
+		PSNR:      summarize(psnrs),
+		SSIM:      summarize(ssims),
+		PerFrame:  make([]FrameQuality, frameIdx),
+	}
+	for i := range report.PerFrame {
+		fq := FrameQuality{Frame: i, DeltaE: deltas[i], PSNR: psnrs[i], SSIM: ssims[i]}
+		if info.FPS > 0 {
+			fq.Time = float64(i) / info.FPS
+		}
+		report.PerFrame[i] = fq
+	}
+
+	if !config.Silent {
+		elapsed := time.Since(startTime).Seconds()
+		fmt.Printf("Done: %d frames in %.2fs\n", frameIdx, elapsed)
+		fmt.Printf("  deltaE mean %.2f, worst %.2f (frame %d)\n", report.DeltaE.Mean, report.DeltaE.Max, report.DeltaE.MaxFrame)
+		fmt.Printf("  PSNR   mean %.2f dB, worst %.2f dB (frame %d)\n", report.PSNR.Mean, report.PSNR.Min, report.PSNR.MinFrame)
+		fmt.Printf("  SSIM   mean %.4f, worst %.4f (frame %d)\n", report.SSIM.Mean, report.SSIM.Min, report.SSIM.MinFrame)
+	}
+
+	var finalImage image.Image
+	if config.Vertical {
+		finalImage = dnaImage.SubImage(image.Rect(0, 0, width, frameIdx))
+	} else {
+		finalImage = dnaImage.SubImage(image.Rect(0, 0, frameIdx, height))
+	}
+
+	if config.Legend.Enabled && config.Legend.Name == "" {
+		config.Legend.Name = fmt.Sprintf("diff %s - %s",
+			strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath)),
+			strings.TrimSuffix(filepath.Base(config.ReferencePath), filepath.Ext(config.ReferencePath)))
+	}
+
+	var lanes []Lane
+	if config.QualityLanes {
+		lanes = qualityLanes(psnrs, ssims)
+	}
+
+	finalImage, dnaRect, _, err := finishImage(finalImage, inputPath, info, lanes, renderOptions{
+		resize:      config.Resize,
+		legend:      config.Legend,
+		laneHeight:  config.LaneHeight,
+		vertical:    config.Vertical,
+		transform:   config.Transform,
+		zoom:        config.Zoom,
+		annotations: config.Annotations,
+		scale:       config.Scale,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	layout := &Layout{
+		Version:     LayoutVersion,
+		Kind:        "diff",
+		Source:      inputPath,
+		Duration:    float64(frameIdx) / info.FPS,
+		Frames:      frameIdx,
+		FPS:         info.FPS,
+		Vertical:    config.Vertical,
+		Transformed: !config.Transform.IsZero(),
+		Timecode:    info.Timecode,
+		DNA:         newRect(dnaRect),
+	}
+	if config.Colors > 0 {
+		finalImage = quantizeImage(finalImage, config.Colors, config.Silent)
+	}
+	if err := writePNG(finalImage, outputPath, layout, profile); err != nil {
+		return nil, err
+	}
+
+	if config.SeekMapPath != "" {
+		if err := writeSeekMap(config.SeekMapPath, newSeekMap(outputPath, finalImage.Bounds(), layout, config.Transform)); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.XMP {
+		if err := writeSidecar(outputPath, finalImage.Bounds(), layout, report, config.Silent); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.ReportPath != "" {
+		if err := writeJSON(config.ReportPath, report); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// qualityLanes builds PSNR and SSIM lanes. PSNR is mapped from 20-50 dB,
+// SSIM from 0.5-1.0, so that a full bar means a transparent encode.
+func qualityLanes(psnrs, ssims []float64) []Lane {
+	psnrLane := Lane{Label: "psnr", Color: color.RGBA{R: 100, G: 200, B: 255, A: 255}}
+	ssimLane := Lane{Label: "ssim", Color: color.RGBA{R: 100, G: 255, B: 150, A: 255}}
+	for i := range psnrs {
+		psnrLane.Values = append(psnrLane.Values, (psnrs[i]-20)/30)
+		ssimLane.Values = append(ssimLane.Values, (ssims[i]-0.5)/0.5)
+	}
+	return []Lane{psnrLane, ssimLane}
+}
+
+// DeltaERow returns the mean per-pixel color difference between two rows.
+func DeltaERow(row, refRow []byte, width int) float64 {
+	var sum float64
+	for x := 0; x < width; x++ {
+		i := x * 3
+		sum += DeltaE(row[i], row[i+1], row[i+2], refRow[i], refRow[i+1], refRow[i+2])
+	}
+	return sum / float64(width)
+}
+
+// DeltaECol returns the mean per-pixel color difference between two columns.
+func DeltaECol(buf, refBuf []byte, col, width, height int) float64 {
+	var sum float64
+	for y := 0; y < height; y++ {
+		i := (y*width + col) * 3
+		sum += DeltaE(buf[i], buf[i+1], buf[i+2], refBuf[i], refBuf[i+1], refBuf[i+2])
+	}
+	return sum / float64(height)
+}
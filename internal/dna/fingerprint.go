@@ -0,0 +1,147 @@
+package dna
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/pforret/videodna/internal/fingerprint"
+	"github.com/pforret/videodna/internal/progress"
+	"github.com/pforret/videodna/internal/toolexec"
+	"github.com/pforret/videodna/internal/video"
+)
+
+// fingerprintBands is the number of bands each DNA column (one frame) is
+// averaged into across the frame: 16 bands x RGB = 48 values per column.
+const fingerprintBands = 16
+
+// videoFingerprint builds a fingerprint pyramid from the raw DNA, where each
+// frame is a column (or a row with vertical output).
+func videoFingerprint(img image.Image, vertical bool, source string, duration float64, levels []int) *fingerprint.Fingerprint {
+	bounds := img.Bounds()
+	frames, across := bounds.Dx(), bounds.Dy()
+	if vertical {
+		frames, across = across, frames
+	}
+
+	features := make([]string, 0, fingerprintBands*3)
+	for b := 0; b < fingerprintBands; b++ {
+		features = append(features, fmt.Sprintf("r%d", b), fmt.Sprintf("g%d", b), fmt.Sprintf("b%d", b))
+	}
+
+	columns := make([][]float64, frames)
+	for f := 0; f < frames; f++ {
+		col := make([]float64, fingerprintBands*3)
+		for b := 0; b < fingerprintBands; b++ {
+			from := b * across / fingerprintBands
+			to := max((b+1)*across/fingerprintBands, from+1)
+			var r, g, bl float64
+			for i := from; i < to; i++ {
+				x, y := f, i
+				if vertical {
+					x, y = i, f
+				}
+				cr, cg, cb, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+				r, g, bl = r+float64(cr>>8), g+float64(cg>>8), bl+float64(cb>>8)
+			}
+			n := float64(to-from) * 255
+			col[b*3], col[b*3+1], col[b*3+2] = r/n, g/n, bl/n
+		}
+		columns[f] = col
+	}
+	return fingerprint.New("video", source, duration, features, columns, levels)
+}
+
+// Frame size decoded by ComputeFingerprint: rows are averaged across the
+// width anyway, so a small frame gives the same bands much faster.
+const (
+	fingerprintDecodeWidth  = 32
+	fingerprintDecodeHeight = 64
+)
+
+// ComputeFingerprint decodes a video at a small frame size and returns its
+// fingerprint pyramid without rendering a DNA image. Decoded frames are
+// reported to the progress task of ctx, if any.
+func ComputeFingerprint(ctx context.Context, inputPath string, levels []int) (*fingerprint.Fingerprint, *video.Info, error) {
+	info, inputArgs, err := probeInput(inputPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.FrameCount == 0 || info.FPS <= 0 {
+		return nil, nil, fmt.Errorf("invalid video properties")
+	}
+	task := progress.FromContext(ctx)
+	task.SetTotal(info.FrameCount)
+
+	w, h := fingerprintDecodeWidth, fingerprintDecodeHeight
+	args := append(withVideoFilter(inputArgs, fmt.Sprintf("scale=%d:%d", w, h)),
+		"-an", "-sn",
+		"-f", "rawvideo",
+		"-pix_fmt", "rgb24",
+		"-v", "error",
+		"pipe:1")
+	cmd := toolexec.Command(ctx, "ffmpeg", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+	release, err := toolexec.Acquire(ctx, toolexec.FFmpegMemory(w, h))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	// Kill and reap ffmpeg when returning before the final Wait
+	waited := false
+	defer func() {
+		if !waited {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	}()
+
+	// One column per frame, each row averaged across the frame
+	var columns [][]color.RGBA
+	frame := make([]byte, w*h*3)
+	reader := bufio.NewReaderSize(stdout, len(frame))
+	for {
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, nil, fmt.Errorf("failed to read frame: %w", err)
+		}
+		col := make([]color.RGBA, h)
+		for y := 0; y < h; y++ {
+			var r, g, b int
+			for x := 0; x < w; x++ {
+				i := (y*w + x) * 3
+				r, g, b = r+int(frame[i]), g+int(frame[i+1]), b+int(frame[i+2])
+			}
+			col[y] = color.RGBA{R: uint8(r / w), G: uint8(g / w), B: uint8(b / w), A: 255}
+		}
+		columns = append(columns, col)
+		task.Add(1)
+	}
+	waited = true
+	if err := cmd.Wait(); err != nil {
+		return nil, nil, fmt.Errorf("ffmpeg failed: %w", err)
+	}
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("no frames decoded from %s", inputPath)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, len(columns), h))
+	for x, col := range columns {
+		for y, c := range col {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	duration := float64(len(columns)) / info.FPS
+	return videoFingerprint(img, false, inputPath, duration, levels), info, nil
+}
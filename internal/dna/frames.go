@@ -0,0 +1,86 @@
+package dna
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/pforret/videodna/internal/toolexec"
+)
+
+// frameSource streams raw RGB24 frames from an ffmpeg process.
+type frameSource struct {
+	cmd       *exec.Cmd
+	reader    *bufio.Reader
+	frameSize int
+	stderr    bytes.Buffer // ffmpeg error output
+
+	waited  sync.Once
+	waitErr error
+}
+
+// startFrameSource starts ffmpeg decoding inputPath scaled to width x height,
+// after the filter (see colorFilter; "" = none). The caller holds the
+// process slot (see toolexec.Acquire).
+func startFrameSource(ctx context.Context, inputPath, filter string, width, height int) (*frameSource, error) {
+	scale := fmt.Sprintf("scale=%d:%d", width, height)
+	if filter != "" {
+		scale = filter + "," + scale
+	}
+	cmd := toolexec.Command(ctx, "ffmpeg",
+		"-i", inputPath,
+		"-vf", scale,
+		"-f", "rawvideo",
+		"-pix_fmt", "rgb24",
+		"-v", "error",
+		"pipe:1")
+	s := &frameSource{cmd: cmd, frameSize: width * height * 3}
+	cmd.Stderr = &s.stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	s.reader = bufio.NewReaderSize(stdout, s.frameSize)
+	return s, nil
+}
+
+// next reads the next frame into buf. It returns io.EOF when the stream ends.
+func (s *frameSource) next(buf []byte) error {
+	_, err := io.ReadFull(s.reader, buf[:s.frameSize])
+	if err == io.ErrUnexpectedEOF {
+		return io.EOF
+	}
+	return err
+}
+
+// skip discards n frames.
+func (s *frameSource) skip(n int) error {
+	for i := 0; i < n; i++ {
+		if _, err := s.reader.Discard(s.frameSize); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return io.EOF
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// wait drains remaining output and waits for ffmpeg to exit. Later calls
+// return the first result, so callers can defer it.
+func (s *frameSource) wait() error {
+	s.waited.Do(func() {
+		io.Copy(io.Discard, s.reader)
+		s.waitErr = s.cmd.Wait()
+	})
+	return s.waitErr
+}
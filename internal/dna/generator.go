@@ -2,27 +2,44 @@ package dna
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"image"
 	"image/color"
-	"image/png"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/pforret/videodna/internal/alttext"
+	"github.com/pforret/videodna/internal/fingerprint"
+	"github.com/pforret/videodna/internal/font"
+	"github.com/pforret/videodna/internal/hooks"
+	"github.com/pforret/videodna/internal/icc"
+	"github.com/pforret/videodna/internal/locale"
+	"github.com/pforret/videodna/internal/progress"
+	"github.com/pforret/videodna/internal/quantize"
+	"github.com/pforret/videodna/internal/resizespec"
+	"github.com/pforret/videodna/internal/sink"
+	"github.com/pforret/videodna/internal/tiff"
+	"github.com/pforret/videodna/internal/tiles"
+	"github.com/pforret/videodna/internal/timeline"
+	"github.com/pforret/videodna/internal/timing"
+	"github.com/pforret/videodna/internal/toolexec"
+	"github.com/pforret/videodna/internal/transform"
 	"github.com/pforret/videodna/internal/video"
 )
 
 // LegendConfig configures the top legend bar.
 type LegendConfig struct {
-	Enabled bool   // Show legend
-	Height  int    // Height in pixels (default 24)
-	Name    string // Display name (default: basename of input file)
+	Enabled bool          // Show legend
+	Height  int           // Height in pixels (default 24)
+	Name    string        // Display name (default: basename of input file)
+	Timings bool          // Show the run time and its slowest stage
+	Locale  locale.Locale // Language of the legend, lane labels and settings errors ("" = English)
 }
 
 // DefaultLegendConfig returns default legend configuration.
@@ -34,6 +51,48 @@ func DefaultLegendConfig() LegendConfig {
 	}
 }
 
+// Config configures video DNA generation, like audiodna.Config does for
+// audio DNA. DefaultConfig returns the CLI defaults.
+type Config struct {
+	Mode     string         // Color mode: average, min, max, common
+	Vertical bool           // Vertical output (width=video width, height=frames)
+	Resize   string         // Resize output: 'WxH', '50%', '1920x', 'input' ... (see resizespec; "" = none)
+	Legend   LegendConfig   // Legend bar configuration
+	Timeout  int            // Timeout in seconds
+	Silent   bool           // Suppress progress output
+	Progress progress.Func  // Decode progress every 100 frames with throughput and ETA (nil = none; independent of Silent)
+	Analysis AnalysisConfig // Analysis lanes, exports and rendering options
+
+	// Sinks also receive the outputs, wherever they go (see package sink):
+	// the image encoded once with the output file (TIFF for .tif/.tiff, else
+	// PNG with its layout chunk), and the JSON report (nil = none).
+	ImageSink  sink.Sink
+	ReportSink sink.Sink
+}
+
+// Result is the outcome of GenerateWithConfig, so callers need not read the
+// image or the report back from disk.
+type Result struct {
+	Image   image.Image     // Final image as written: DNA, lanes, legend
+	Frames  int             // Frames decoded (the DNA length before columns and resize)
+	Info    *video.Info     // Probed properties of the input
+	Layout  *Layout         // Position of the DNA in Image and its time axis, as embedded in the PNG
+	Report  *AnalysisReport // Detected events (cuts, letterbox, text, logo, skin), as written to ReportPath
+	Timings timing.Timings  // Seconds per stage of the run
+}
+
+// DefaultConfig returns the CLI defaults.
+func DefaultConfig() Config {
+	return Config{
+		Mode:    "average",
+		Timeout: 60,
+		Legend:  DefaultLegendConfig(),
+		Analysis: AnalysisConfig{
+			MaxDimension: DefaultMaxDimension,
+		},
+	}
+}
+
 // Generate creates a video DNA image from the input video.
 func Generate(inputPath, outputPath, mode string, vertical bool, resize string, silent bool, timeout int) error {
 	return GenerateWithLegend(inputPath, outputPath, mode, vertical, resize, silent, timeout, LegendConfig{})
@@ -41,52 +100,166 @@ func Generate(inputPath, outputPath, mode string, vertical bool, resize string,
 
 // GenerateWithLegend creates a video DNA image with optional legend.
 func GenerateWithLegend(inputPath, outputPath, mode string, vertical bool, resize string, silent bool, timeout int, legend LegendConfig) error {
-	info, err := video.GetFullInfo(inputPath)
+	return GenerateWithAnalysis(inputPath, outputPath, mode, vertical, resize, silent, timeout, legend, AnalysisConfig{})
+}
+
+// GenerateWithAnalysis creates a video DNA image with optional legend and
+// per-frame analysis lanes.
+func GenerateWithAnalysis(inputPath, outputPath, mode string, vertical bool, resize string, silent bool, timeout int, legend LegendConfig, analysis AnalysisConfig) error {
+	_, err := GenerateWithConfig(context.Background(), inputPath, outputPath, Config{
+		Mode:     mode,
+		Vertical: vertical,
+		Resize:   resize,
+		Legend:   legend,
+		Timeout:  timeout,
+		Silent:   silent,
+		Analysis: analysis,
+	})
+	return err
+}
+
+// GenerateWithConfig creates a video DNA image from the input video and
+// returns it with the probed properties, the detected events and the
+// timings. An empty outputPath writes no image, for callers that encode or
+// upload Result.Image themselves. ctx bounds the whole run: hooks, decoding
+// (also bounded by config.Timeout) and uploads to the sinks. It does not
+// validate config; GenerateOptions does.
+func GenerateWithConfig(ctx context.Context, inputPath, outputPath string, config Config) (*Result, error) {
+	clock := timing.Start()
+	var timings timing.Timings
+
+	// Checksum before any work, so a mismatched input is refused
+	sums, err := config.Analysis.Checksum.Run(inputPath)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if sums != nil && !config.Silent {
+		fmt.Printf("SHA-256: %s\n", sums.SHA256)
 	}
 
+	info, inputArgs, err := probeInput(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyTimecodeBase(info, config.Analysis.TimecodeBase, config.Analysis.TimecodeFormat); err != nil {
+		return nil, err
+	}
+	timings.Probe = clock.Lap()
+
 	width, height, frameCount := info.Width, info.Height, info.FrameCount
 
 	if frameCount == 0 || height == 0 {
-		return fmt.Errorf("invalid video properties")
+		return nil, fmt.Errorf("invalid video properties")
 	}
 
-	if !silent {
+	analyzers, err := newAnalyzers(config.Analysis)
+	if err != nil {
+		return nil, err
+	}
+
+	if !config.Silent {
 		fmt.Printf("Processing video: %d frames, %dx%d pixels\n", frameCount, width, height)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	decodeCtx, cancel := context.WithTimeout(ctx, time.Duration(config.Timeout)*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ffmpeg",
-		"-i", inputPath,
+	if err := config.Analysis.Hooks.Run(decodeCtx, hooks.Context{Point: hooks.AfterProbe, Input: inputPath, Output: outputPath, Info: hookInfo(info)}); err != nil {
+		return nil, err
+	}
+
+	profile, err := icc.Resolve(config.Analysis.ICCProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Timelines scale their clips in their own filter graph
+	var filters []string
+	var colorConversion string
+	if config.Analysis.ColorConvert && !timeline.IsTimelinePath(inputPath) {
+		filter, description := colorFilter(info)
+		if filter != "" {
+			filters = append(filters, filter)
+		}
+		if description != "" && !config.Silent {
+			fmt.Printf("Color: %s\n", description)
+		}
+		colorConversion = description
+	}
+
+	if config.Analysis.HWAccel != "" {
+		if timeline.IsTimelinePath(inputPath) {
+			return nil, fmt.Errorf("hwaccel is not supported with a timeline input")
+		}
+		// The GPU scaler averages gamma-encoded values
+		reduce := config.Mode == "average" && len(analyzers) == 0 && !config.Analysis.Linear
+		var filter string
+		inputArgs, filter, width, height, err = hwaccelArgs(config.Analysis.HWAccel, inputArgs, width, height, config.Vertical, reduce)
+		if err != nil {
+			return nil, err
+		}
+		filters = append([]string{filter}, filters...)
+		if !config.Silent {
+			fmt.Printf("GPU decoding (%s), frames transferred at %dx%d\n", config.Analysis.HWAccel, width, height)
+		}
+	}
+	if len(filters) > 0 {
+		inputArgs = withVideoFilter(inputArgs, strings.Join(filters, ","))
+	}
+
+	args := append(inputArgs,
 		"-f", "rawvideo",
 		"-pix_fmt", "rgb24",
 		"-v", "error",
 		"pipe:1")
+	cmd := toolexec.Command(decodeCtx, "ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return fmt.Errorf("failed to create pipe: %w", err)
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+
+	release, err := toolexec.Acquire(decodeCtx, toolexec.FFmpegMemory(width, height))
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start ffmpeg: %w", err)
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	// Heatmap modes have one row (column when vertical) per bin
+	dnaWidth, dnaHeight := width, height
+	var heat *heatmap
+	if bins := heatmapBins(config.Mode); bins > 0 {
+		heat = newHeatmap(config.Mode, config.Vertical)
+		if config.Vertical {
+			dnaWidth = bins
+		} else {
+			dnaHeight = bins
+		}
 	}
 
 	maxFrames := frameCount + frameCount/10 + 10
 	var dnaImage *image.RGBA
-	if vertical {
-		dnaImage = image.NewRGBA(image.Rect(0, 0, width, maxFrames))
+	if config.Vertical {
+		dnaImage = image.NewRGBA(image.Rect(0, 0, dnaWidth, maxFrames))
 	} else {
-		dnaImage = image.NewRGBA(image.Rect(0, 0, maxFrames, height))
+		dnaImage = image.NewRGBA(image.Rect(0, 0, maxFrames, dnaHeight))
+	}
+	var deepImage *image.RGBA64 // 16-bit DNA keeping the fraction of averages
+	if config.Analysis.BitDepth == 16 {
+		deepImage = image.NewRGBA64(dnaImage.Bounds())
 	}
 
 	frameSize := width * height * 3
 	reader := bufio.NewReaderSize(stdout, frameSize)
 	frameBuf := make([]byte, frameSize)
 	startTime := time.Now()
+	meter := progress.NewMeter("decode", "frames", float64(frameCount))
 
 	frameIdx := 0
 	for {
@@ -95,15 +268,32 @@ func GenerateWithLegend(inputPath, outputPath, mode string, vertical bool, resiz
 			if err == io.EOF || err == io.ErrUnexpectedEOF {
 				break
 			}
-			return fmt.Errorf("failed to read frame: %w", err)
+			return nil, fmt.Errorf("failed to read frame: %w", err)
 		}
 
-		if vertical {
+		if heat != nil {
+			for i, c := range heat.column(frameBuf) {
+				x, y := frameIdx, i
+				if config.Vertical {
+					x, y = i, frameIdx
+				}
+				dnaImage.SetRGBA(x, y, c)
+				if deepImage != nil {
+					deepImage.Set(x, y, c)
+				}
+			}
+		} else if config.Vertical {
 			for x := 0; x < width; x++ {
 				var c color.Color
-				switch mode {
+				switch config.Mode {
 				case "average":
-					c = AverageColorCol(frameBuf, x, width, height)
+					if config.Analysis.Linear {
+						c = AverageColorColLinear(frameBuf, x, width, height)
+					} else if deepImage != nil {
+						c = AverageColorCol64(frameBuf, x, width, height)
+					} else {
+						c = AverageColorCol(frameBuf, x, width, height)
+					}
 				case "min":
 					c = MinColorCol(frameBuf, x, width, height)
 				case "max":
@@ -112,6 +302,9 @@ func GenerateWithLegend(inputPath, outputPath, mode string, vertical bool, resiz
 					c = MostCommonColorCol(frameBuf, x, width, height)
 				}
 				dnaImage.Set(x, frameIdx, c)
+				if deepImage != nil {
+					deepImage.Set(x, frameIdx, c)
+				}
 			}
 		} else {
 			for y := 0; y < height; y++ {
@@ -119,9 +312,15 @@ func GenerateWithLegend(inputPath, outputPath, mode string, vertical bool, resiz
 				row := frameBuf[rowStart : rowStart+width*3]
 
 				var c color.Color
-				switch mode {
+				switch config.Mode {
 				case "average":
-					c = AverageColor(row, width)
+					if config.Analysis.Linear {
+						c = AverageColorLinear(row, width)
+					} else if deepImage != nil {
+						c = AverageColor64(row, width)
+					} else {
+						c = AverageColor(row, width)
+					}
 				case "min":
 					c = MinColor(row, width)
 				case "max":
@@ -130,26 +329,49 @@ func GenerateWithLegend(inputPath, outputPath, mode string, vertical bool, resiz
 					c = MostCommonColor(row, width)
 				}
 				dnaImage.Set(frameIdx, y, c)
+				if deepImage != nil {
+					deepImage.Set(frameIdx, y, c)
+				}
 			}
 		}
 
+		for _, a := range analyzers {
+			a.analyze(frameBuf, width, height)
+		}
+
 		frameIdx++
 
-		if !silent && frameIdx%100 == 0 {
-			fps := float64(frameIdx) / time.Since(startTime).Seconds()
-			pct := float64(frameIdx) * 100 / float64(frameCount)
-			fmt.Printf("Processed %d/%d frames (%.1f fps, %.0f%% done)\n", frameIdx, frameCount, fps, pct)
+		if frameIdx%100 == 0 && (!config.Silent || config.Progress != nil) {
+			e := meter.Update(float64(frameIdx))
+			if config.Progress != nil {
+				config.Progress(e)
+			}
+			if !config.Silent {
+				eta := progress.FormatETA(e)
+				if eta != "" {
+					eta = ", " + eta
+				}
+				fmt.Printf("Processed %d/%d frames (%.1f fps, %.0f%% done%s)\n", frameIdx, frameCount, e.Rate, e.Percent, eta)
+			}
 		}
 	}
 
-	if err := cmd.Wait(); err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return fmt.Errorf("timeout after %d seconds", timeout)
+	err = cmd.Wait()
+	release()
+	if err != nil {
+		if decodeCtx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timeout after %d seconds", config.Timeout)
 		}
+		if frameIdx == 0 {
+			return nil, decodeError(err, stderr.String(), colorConversion)
+		}
+	}
+	if config.Progress != nil {
+		config.Progress(meter.Finish(float64(frameIdx)))
 	}
 
 	elapsed := time.Since(startTime).Seconds()
-	if !silent && elapsed > 0 {
+	if !config.Silent && elapsed > 0 {
 		fps := float64(frameIdx) / elapsed
 		totalPixels := float64(frameIdx) * float64(width) * float64(height)
 		pps := totalPixels / elapsed / 1e6
@@ -157,36 +379,371 @@ func GenerateWithLegend(inputPath, outputPath, mode string, vertical bool, resiz
 	}
 
 	var finalImage image.Image
-	if vertical {
-		finalImage = dnaImage.SubImage(image.Rect(0, 0, width, frameIdx))
+	if config.Vertical {
+		finalImage = dnaImage.SubImage(image.Rect(0, 0, dnaWidth, frameIdx))
 	} else {
-		finalImage = dnaImage.SubImage(image.Rect(0, 0, frameIdx, height))
+		finalImage = dnaImage.SubImage(image.Rect(0, 0, frameIdx, dnaHeight))
 	}
 
-	// Handle resize
-	if resize != "" {
-		var targetW, targetH int
-		if resize == "input" {
-			targetW, targetH = width, height
-		} else {
-			parts := strings.Split(strings.ToLower(resize), "x")
-			if len(parts) != 2 {
-				return fmt.Errorf("invalid resize format, use WxH or 'input'")
+	report := &AnalysisReport{Input: inputPath, Frames: frameIdx, FPS: info.FPS, Checksums: sums}
+	var lanes []Lane
+	for _, a := range analyzers {
+		lanes = append(lanes, a.finish(info.FPS, report)...)
+	}
+	lanes = append(lanes, seriesLanes(config.Analysis.Series, frameIdx, float64(frameIdx)/info.FPS)...)
+	lanes = arrangeLanes(lanes, config.Analysis.Lanes)
+
+	if !config.Silent && report.Letterbox != nil && report.Letterbox.MixedAspect {
+		fmt.Printf("Warning: mixed aspect ratios detected (%d segments)\n", len(report.Letterbox.Segments))
+	}
+	if !config.Silent && report.Logo != nil {
+		fmt.Printf("Logo present in %.1f%% of frames (%d absent spans)\n", report.Logo.PresentRatio*100, len(report.Logo.Absent))
+	}
+	report.applyTimecode(info.Timecode, info.FPS)
+	if !config.Silent && report.Text != nil {
+		for _, span := range append(report.Text.Credits, report.Text.Subtitles...) {
+			if span.StartTimecode != "" {
+				fmt.Printf("Text %s: %s - %s\n", span.Label, span.StartTimecode, span.EndTimecode)
+			} else {
+				fmt.Printf("Text %s: %s - %s\n", span.Label, FormatTimestamp(span.Start), FormatTimestamp(span.End))
 			}
-			targetW, err = strconv.Atoi(parts[0])
-			if err != nil {
-				return fmt.Errorf("invalid resize width: %w", err)
+		}
+	}
+	if !config.Silent && report.Cuts != nil {
+		fmt.Printf("Detected %d scene cuts\n", report.Cuts.Count)
+	}
+
+	if config.Analysis.Transform.CompressTail || config.Analysis.AdaptiveColumns {
+		activity := frameActivity(finalImage.(*image.RGBA), config.Vertical)
+		if config.Analysis.Transform.CompressTail {
+			config.Analysis.Transform.Tail = transform.TailStart(activity)
+			if !config.Silent && config.Analysis.Transform.Tail > 0 {
+				fmt.Printf("Static tail from %s compressed\n", FormatTimestamp(config.Analysis.Transform.Tail*float64(frameIdx)/info.FPS))
 			}
-			targetH, err = strconv.Atoi(parts[1])
-			if err != nil {
-				return fmt.Errorf("invalid resize height: %w", err)
+		}
+		if config.Analysis.AdaptiveColumns {
+			// Scene changes spread their share over two seconds around them
+			config.Analysis.Transform.Warp = transform.ActivityWarp(activity, int(2*info.FPS))
+		}
+	}
+
+	if config.Analysis.FingerprintPath != "" {
+		fp := videoFingerprint(finalImage, config.Vertical, inputPath, float64(frameIdx)/info.FPS, config.Analysis.FingerprintLevels)
+		if err := fingerprint.Write(config.Analysis.FingerprintPath, fp); err != nil {
+			return nil, err
+		}
+	}
+
+	var alt *alttext.Description
+	if config.Analysis.AltTextPath != "" {
+		alt = videoAltText(finalImage, config.Vertical, outputPath, inputPath, config.Legend.Name, float64(frameIdx)/info.FPS)
+	}
+
+	// Auto-levels are measured on the raw DNA, after the fingerprint, which
+	// stays comparable with unstretched DNA, and applied once rendered
+	if config.Analysis.AutoLevels != "" {
+		report.Levels = computeLevels(finalImage, config.Vertical, config.Analysis.AutoLevels)
+		if !config.Silent {
+			fmt.Printf("Auto-levels (%s): %s\n", report.Levels.Mode, report.Levels)
+		}
+	}
+
+	timings.Decode = clock.Lap()
+	columns := timeColumns(config.Analysis.Width, config.Analysis.ColumnsPerSecond, frameIdx, info.FPS)
+	if capped, ok := capColumns(columns, frameIdx, config.Analysis.MaxDimension, config.Analysis.Scale); ok {
+		fmt.Fprintf(os.Stderr, "Warning: the DNA exceeds the maximum dimension of %d pixels, averaging frames into %d columns (raise -max-dimension, 0 = no limit)\n",
+			config.Analysis.MaxDimension, capped)
+		columns = capped
+	}
+	var took string
+	if config.Legend.Timings {
+		sofar := timings
+		sofar.Total = clock.Total()
+		took = sofar.SummaryIn(config.Legend.Locale)
+	}
+
+	render := renderOptions{
+		took:         took,
+		columns:      columns,
+		resize:       config.Resize,
+		maxDimension: config.Analysis.MaxDimension,
+		legend:       config.Legend,
+		laneHeight:   config.Analysis.LaneHeight,
+		dnaHeight:    dnaLaneHeight(config.Analysis.Lanes),
+		vertical:     config.Vertical,
+		transform:    config.Analysis.Transform,
+		zoom:         config.Analysis.Zoom,
+		annotations:  config.Analysis.Annotations,
+		scale:        config.Analysis.Scale,
+		linear:       config.Analysis.Linear,
+		deep:         deepImage != nil,
+	}
+	finalImage, dnaRect, marks, err := finishImage(finalImage, inputPath, info, lanes, render)
+	if err != nil {
+		return nil, err
+	}
+	if deepImage != nil {
+		// Shape the 16-bit DNA the same way and put it in place of the
+		// 8-bit one
+		var deepDNA image.Image
+		if config.Vertical {
+			deepDNA = deepImage.SubImage(image.Rect(0, 0, dnaWidth, frameIdx))
+		} else {
+			deepDNA = deepImage.SubImage(image.Rect(0, 0, frameIdx, dnaHeight))
+		}
+		if deepDNA, err = shapeDNA(deepDNA, info, render); err != nil {
+			return nil, err
+		}
+		finalImage = overlayDeep(finalImage, deepDNA, marks, max(config.Analysis.Scale, 1))
+	}
+	if levels := report.Levels; levels != nil {
+		if config.Analysis.Transform.Flip {
+			levels = levels.flipped()
+		}
+		levels.apply(finalImage, dnaRect, config.Vertical)
+	}
+	config.Analysis.Adjust.Apply(finalImage, dnaRect)
+	if config.Analysis.Colors > 0 {
+		finalImage = quantizeImage(finalImage, config.Analysis.Colors, config.Silent)
+	}
+	timings.Render = clock.Lap()
+
+	layout := &Layout{
+		Version:     LayoutVersion,
+		Kind:        "video",
+		Source:      inputPath,
+		Duration:    float64(frameIdx) / info.FPS,
+		Frames:      frameIdx,
+		FPS:         info.FPS,
+		Mode:        config.Mode,
+		Vertical:    config.Vertical,
+		Transformed: !config.Analysis.Transform.IsZero(),
+		Timecode:    info.Timecode,
+		Linear:      config.Analysis.Linear,
+		Adjusted:    !config.Analysis.Adjust.IsZero(),
+		DNA:         newRect(dnaRect),
+		Checksums:   sums,
+	}
+	if report.Levels != nil {
+		layout.Version, layout.Levels = levelsLayoutVersion, report.Levels
+	}
+	// config.Timeout bounds decoding only; a slow upload must not hit it
+	if config.ImageSink != nil || config.ReportSink != nil {
+		if err := config.Analysis.Hooks.Run(ctx, hooks.Context{Point: hooks.BeforeUpload, Input: inputPath, Output: outputPath, Info: hookInfo(info)}); err != nil {
+			return nil, err
+		}
+	}
+	if err := deliverImage(ctx, finalImage, outputPath, config.ImageSink, layout, profile); err != nil {
+		return nil, err
+	}
+
+	if config.Analysis.SeekMapPath != "" {
+		if err := writeSeekMap(config.Analysis.SeekMapPath, newSeekMap(outputPath, finalImage.Bounds(), layout, config.Analysis.Transform)); err != nil {
+			return nil, err
+		}
+	}
+
+	if alt != nil {
+		if err := alttext.Write(config.Analysis.AltTextPath, alt); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Analysis.EventsPath != "" {
+		if err := writeEvents(config.Analysis.EventsPath, report.Events(), info); err != nil {
+			return nil, err
+		}
+	}
+
+	timings.Encode = clock.Lap()
+	timings.Total = clock.Total()
+	report.Timings = &timings
+	if !config.Silent {
+		fmt.Printf("Timings: probe %.1fs, decode %.1fs, render %.1fs, encode %.1fs\n",
+			timings.Probe, timings.Decode, timings.Render, timings.Encode)
+	}
+
+	if config.Analysis.XMP {
+		if err := writeSidecar(outputPath, finalImage.Bounds(), layout, report, config.Silent); err != nil {
+			return nil, err
+		}
+	}
+	if reports := sink.Multi(sink.File(config.Analysis.ReportPath), config.ReportSink); reports != nil {
+		if err := putJSON(ctx, reports, report); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Result{
+		Image:   finalImage,
+		Frames:  frameIdx,
+		Info:    info,
+		Layout:  layout,
+		Report:  report,
+		Timings: timings,
+	}, nil
+}
+
+// probeInput returns the video properties and ffmpeg input arguments for a
+// video file, or for an edited sequence (.otio, .fcpxml): the used range of
+// each clip is decoded in order at the size and frame rate of the first clip.
+func probeInput(inputPath string) (*video.Info, []string, error) {
+	if !timeline.IsTimelinePath(inputPath) {
+		info, err := video.GetFullInfo(inputPath)
+		return info, []string{"-i", inputPath}, err
+	}
+
+	tl, err := timeline.Load(inputPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := video.GetFullInfo(tl.FirstMedia())
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.FPS <= 0 {
+		return nil, nil, fmt.Errorf("unknown frame rate of %s", tl.FirstMedia())
+	}
+	info.Duration = tl.Duration()
+	info.Timecode = "" // Belongs to the first clip, not the sequence
+	info.FrameCount = int(info.Duration*info.FPS + 0.5)
+	return info, tl.FFmpegInputArgs(info.Width, info.Height, info.FPS), nil
+}
+
+// withVideoFilter adds filter to the ffmpeg input arguments of probeInput.
+// A timeline already maps the output of a filter graph, which -vf cannot be
+// applied to, so the filter is chained to the end of that graph instead.
+func withVideoFilter(inputArgs []string, filter string) []string {
+	args := slices.Clone(inputArgs)
+	graph := slices.Index(args, "-filter_complex")
+	out := slices.Index(args, "-map")
+	if graph < 0 || graph+1 >= len(args) || out < 0 || out+1 >= len(args) {
+		return append(args, "-vf", filter)
+	}
+	label := args[out+1]
+	args[graph+1] = strings.TrimSuffix(args[graph+1], label) + "," + filter + label
+	return args
+}
+
+// hookInfo returns the video properties passed to hooks.
+func hookInfo(info *video.Info) map[string]any {
+	return map[string]any{
+		"width":    info.Width,
+		"height":   info.Height,
+		"frames":   info.FrameCount,
+		"fps":      info.FPS,
+		"duration": info.Duration,
+		"codec":    info.Codec,
+		"bit_rate": info.BitRate,
+	}
+}
+
+// renderOptions collects the rendering-stage settings applied by finishImage.
+type renderOptions struct {
+	took         string            // Run time shown in the legend ("" = none)
+	columns      int               // Time axis length after averaging frames (0 = one per frame)
+	resize       string            // A resizespec argument: 'WxH', percentages or 'input'
+	maxDimension int               // Cap of each side of the resized DNA in pixels, before scale (0 = none)
+	legend       LegendConfig      // Legend bar configuration
+	laneHeight   int               // Height per lane in logical pixels
+	dnaHeight    int               // DNA thickness across the time axis in logical pixels (0 = as decoded)
+	vertical     bool              // Time runs top to bottom
+	transform    transform.Options // Time axis transform
+	zoom         Zoom              // Expanded region below the DNA
+	annotations  []Annotation      // Timed labels marked on the DNA
+	scale        int               // UI scale factor for HiDPI displays (0 or 1 = none)
+	linear       bool              // Average frames into columns in linear light
+	deep         bool              // Record the marks drawn over the DNA (see overlayDeep)
+}
+
+// finishImage applies time axis transforms, resize, border lines, metric
+// lanes, zoom strip and legend to a raw DNA image. It also returns where the
+// DNA (inside the border lines) ended up in the final image and, with
+// opts.deep, a mask over that area of the ticks and markers drawn on it.
+func finishImage(img image.Image, inputPath string, info *video.Info, lanes []Lane, opts renderOptions) (image.Image, image.Rectangle, *image.Alpha, error) {
+	legend, vertical, t, zoom := opts.legend, opts.vertical, opts.transform, opts.zoom
+	scale := opts.scale
+	if scale < 1 {
+		scale = 1
+	}
+	laneHeight := opts.laneHeight
+	if laneHeight <= 0 {
+		laneHeight = defaultLaneHeight
+	}
+
+	// Crop the zoom region before any transform; raw columns are frames
+	var zoomImg image.Image
+	var zoomFrom, zoomTo float64
+	if zoom.Enabled() {
+		if vertical {
+			return nil, image.Rectangle{}, nil, fmt.Errorf("zoom is not supported with vertical output")
+		}
+		var err error
+		zoomImg, zoomFrom, zoomTo, err = cropZoom(img, zoom, info.FPS)
+		if err != nil {
+			return nil, image.Rectangle{}, nil, err
+		}
+	}
+
+	// Raw columns are frames; annotations are placed by frame position
+	frames := img.Bounds().Dx()
+	if len(opts.annotations) > 0 && vertical {
+		return nil, image.Rectangle{}, nil, fmt.Errorf("annotations are not supported with vertical output")
+	}
+	annotations, err := resolveAnnotations(opts.annotations, info.FPS, info.Timecode)
+	if err != nil {
+		return nil, image.Rectangle{}, nil, err
+	}
+
+	if !t.IsZero() {
+		lanes = transformLanes(lanes, t)
+		if zoomImg != nil {
+			zoomImg = t.Apply(zoomImg, true)
+			zoomFrom, zoomTo = t.MapTime(zoomFrom), t.MapTime(zoomTo)
+			if zoomTo < zoomFrom {
+				zoomFrom, zoomTo = zoomTo, zoomFrom
 			}
 		}
-		finalImage = resizeImage(finalImage, targetW, targetH)
+	}
+
+	img, err = shapeDNA(img, info, opts)
+	if err != nil {
+		return nil, image.Rectangle{}, nil, err
 	}
 
 	// Add light gray border lines at top and bottom to make letterboxing visible
-	finalImage = addBorderLines(finalImage)
+	img = addBorderLines(img, scale)
+	stripW, stripH := img.Bounds().Dx(), img.Bounds().Dy()
+	dnaRect := image.Rect(0, scale, stripW, stripH-scale)
+	var marks *image.Alpha
+	if opts.deep {
+		marks = image.NewAlpha(dnaRect)
+	}
+
+	// Ticks at round times show how the content warps the axis
+	if t.Warped() && info.FPS > 0 {
+		ticks := t.Ticks(float64(frames) / info.FPS)
+		transform.DrawTicks(img.(*image.RGBA), dnaRect, ticks, !vertical, scale)
+		if marks != nil {
+			transform.DrawTicks(marks, dnaRect, ticks, !vertical, scale)
+		}
+	}
+
+	if len(annotations) > 0 {
+		img = addAnnotations(img, marks, annotations, info.FPS, frames, t, scale)
+	}
+
+	lanes = append([]Lane(nil), lanes...)
+	for i := range lanes {
+		lanes[i].Label = legend.Locale.T(lanes[i].Label)
+	}
+	img = addLanes(img, lanes, laneHeight*scale, vertical, scale)
+
+	// Zoomed region at the same size as the full strip
+	if zoomImg != nil {
+		zoomImg = addBorderLines(resizeImage(zoomImg, stripW, stripH), scale)
+		img = addZoom(img, marks, zoomImg, stripH, int(zoomFrom*float64(stripW)), int(zoomTo*float64(stripW)), scale)
+	}
 
 	// Add legend if enabled
 	if legend.Enabled {
@@ -198,29 +755,138 @@ func GenerateWithLegend(inputPath, outputPath, mode string, vertical bool, resiz
 		if name == "" {
 			name = strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
 		}
-		finalImage = addLegend(finalImage, legendHeight, name, info)
+		img = addLegend(img, legendHeight*scale, name, info, opts.took, legend.Locale, scale)
+		dnaRect = dnaRect.Add(image.Pt(0, legendHeight*scale))
+		if marks != nil {
+			marks.Rect = dnaRect
+		}
 	}
 
-	outFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+	return img, dnaRect, marks, nil
+}
+
+// shapeDNA applies the time axis transform, column averaging, resize and
+// HiDPI scaling of finishImage to a raw DNA image. 16-bit images stay
+// 16-bit.
+func shapeDNA(img image.Image, info *video.Info, opts renderOptions) (image.Image, error) {
+	if t := opts.transform; !t.IsZero() {
+		if deep, ok := img.(*image.RGBA64); ok {
+			img = t.Apply64(deep, !opts.vertical)
+		} else {
+			img = t.Apply(img, !opts.vertical)
+		}
 	}
-	defer outFile.Close()
 
-	if err := png.Encode(outFile, finalImage); err != nil {
-		return fmt.Errorf("failed to encode PNG: %w", err)
+	// Average frames into the requested number of columns
+	if opts.columns > 0 {
+		img = aggregateTime(img, opts.columns, opts.vertical, opts.linear)
+	}
+
+	// Handle resize
+	if opts.resize != "" {
+		spec, err := resizespec.Parse(opts.resize)
+		if err != nil {
+			return nil, err
+		}
+		if spec.Input {
+			spec = resizespec.Spec{
+				Width:  resizespec.Dimension{Pixels: info.Width},
+				Height: resizespec.Dimension{Pixels: info.Height},
+			}
+		}
+		w, h := img.Bounds().Dx(), img.Bounds().Dy()
+		limit := 0
+		if opts.maxDimension > 0 {
+			limit = max(opts.maxDimension/max(opts.scale, 1), 1)
+		}
+		targetW, targetH := spec.SizeWithin(w, h, limit)
+		if wantW, wantH := spec.Size(w, h); wantW != targetW || wantH != targetH {
+			fmt.Fprintf(os.Stderr, "Warning: resizing to %dx%d exceeds the maximum dimension of %d pixels, resizing to %dx%d (raise -max-dimension, 0 = no limit)\n",
+				wantW, wantH, opts.maxDimension, targetW, targetH)
+		}
+		img = resizeImage(img, targetW, targetH)
+	} else if opts.dnaHeight > 0 {
+		if opts.vertical {
+			img = resizeImage(img, opts.dnaHeight, img.Bounds().Dy())
+		} else {
+			img = resizeImage(img, img.Bounds().Dx(), opts.dnaHeight)
+		}
+	}
+
+	// HiDPI: enlarge the DNA without interpolation so the time resolution
+	// stays the same and UI elements are drawn at the scaled size
+	if opts.scale > 1 {
+		img = scaleNearest(img, opts.scale)
+	}
+	return img, nil
+}
+
+// quantizeImage reduces img to an indexed image of at most colors colors.
+func quantizeImage(img image.Image, colors int, silent bool) image.Image {
+	paletted := quantize.Paletted(img, colors)
+	if !silent {
+		fmt.Printf("Palette: %d colors\n", len(paletted.Palette))
+	}
+	return paletted
+}
+
+// writePNG writes img to outputPath through deliverImage, without extra sinks.
+func writePNG(img image.Image, outputPath string, layout *Layout, profile *icc.Profile) error {
+	return deliverImage(context.Background(), img, outputPath, nil, layout, profile)
+}
+
+// deliverImage encodes img once and puts it to the file at outputPath ("" =
+// none) and to extra (nil = none) as one composed sink. A .dzi output is
+// written as a tile pyramid instead, and extra then receives a PNG.
+func deliverImage(ctx context.Context, img image.Image, outputPath string, extra sink.Sink, layout *Layout, profile *icc.Profile) error {
+	if tiles.IsDZIPath(outputPath) {
+		if err := tiles.WriteDZI(img, outputPath, tiles.DefaultTileSize, tiles.DefaultOverlap); err != nil {
+			return err
+		}
+		outputPath = ""
+	}
+	out := sink.Multi(sink.File(outputPath), extra)
+	if out == nil {
+		return nil
+	}
+	data, contentType, err := encodeImage(img, outputPath, layout, profile)
+	if err != nil {
+		return err
 	}
+	return out.Put(ctx, data, contentType)
+}
 
-	return nil
+// encodeImage encodes img as TIFF when outputPath ends in .tif or .tiff, as
+// PNG otherwise, and returns the content type. A non-nil layout is embedded
+// in the PNG so the DNA can be read back later (see ReadFingerprintPNG).
+// Both are tagged with profile (nil = untagged); Deep Zoom tiles stay
+// untagged sRGB.
+func encodeImage(img image.Image, outputPath string, layout *Layout, profile *icc.Profile) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if tiff.IsTIFFPath(outputPath) {
+		var iccData []byte
+		if profile != nil {
+			iccData = profile.Data
+		}
+		if err := tiff.Encode(&buf, img, iccData); err != nil {
+			return nil, "", fmt.Errorf("failed to encode TIFF: %w", err)
+		}
+		return buf.Bytes(), sink.TypeTIFF, nil
+	}
+	if err := encodePNG(&buf, img, layout, profile); err != nil {
+		return nil, "", fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), sink.TypePNG, nil
 }
 
-// resizeImage scales an image to the target dimensions using bilinear interpolation.
+// resizeImage scales an image to the target dimensions using bilinear
+// interpolation. 16-bit images stay 16-bit.
 func resizeImage(src image.Image, targetW, targetH int) image.Image {
 	bounds := src.Bounds()
 	srcW := bounds.Dx()
 	srcH := bounds.Dy()
 
-	dst := image.NewRGBA(image.Rect(0, 0, targetW, targetH))
+	dst := newLike(src, targetW, targetH)
 
 	for y := 0; y < targetH; y++ {
 		for x := 0; x < targetW; x++ {
@@ -253,7 +919,7 @@ func resizeImage(src image.Image, targetW, targetH int) image.Image {
 			g := bilinear(g00, g10, g01, g11, xFrac, yFrac)
 			b := bilinear(b00, b10, b01, b11, xFrac, yFrac)
 
-			dst.Set(x, y, color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 255})
+			dst.Set(x, y, color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: 0xffff})
 		}
 	}
 
@@ -266,8 +932,8 @@ func bilinear(v00, v10, v01, v11 uint32, xFrac, yFrac float64) uint32 {
 	return uint32(v0*(1-yFrac) + v1*yFrac)
 }
 
-// addBorderLines adds light gray lines at top and bottom to make letterboxing visible
-func addBorderLines(src image.Image) image.Image {
+// addBorderLines draws thickness-pixel gray lines along the top and bottom.
+func addBorderLines(src image.Image, thickness int) image.Image {
 	bounds := src.Bounds()
 	w := bounds.Dx()
 	h := bounds.Dy()
@@ -284,15 +950,17 @@ func addBorderLines(src image.Image) image.Image {
 	// Draw light gray border lines
 	borderColor := color.RGBA{R: 80, G: 80, B: 80, A: 255}
 	for x := 0; x < w; x++ {
-		dst.Set(x, 0, borderColor)   // Top line
-		dst.Set(x, h-1, borderColor) // Bottom line
+		for t := 0; t < thickness && t < h; t++ {
+			dst.Set(x, t, borderColor)     // Top line
+			dst.Set(x, h-1-t, borderColor) // Bottom line
+		}
 	}
 
 	return dst
 }
 
 // addLegend adds a legend bar at the top of the image
-func addLegend(src image.Image, legendHeight int, name string, info *video.Info) *image.RGBA {
+func addLegend(src image.Image, legendHeight int, name string, info *video.Info, took string, l locale.Locale, scale int) *image.RGBA {
 	bounds := src.Bounds()
 	w := bounds.Dx()
 	h := bounds.Dy()
@@ -319,7 +987,7 @@ func addLegend(src image.Image, legendHeight int, name string, info *video.Info)
 
 	// Build legend text
 	textColor := color.RGBA{R: 200, G: 200, B: 200, A: 255}
-	yText := (legendHeight - 7) / 2 // Center 7px tall font
+	yText := (legendHeight - 7*scale) / 2 // Center 7px tall font
 
 	// Format: name | duration | fps | frames | codec | resolution
 	var parts []string
@@ -336,11 +1004,15 @@ func addLegend(src image.Image, legendHeight int, name string, info *video.Info)
 	}
 
 	if info.FPS > 0 {
-		parts = append(parts, fmt.Sprintf("%.1ffps", info.FPS))
+		parts = append(parts, l.Sprintf("%.1ffps", info.FPS))
+	}
+
+	if info.Timecode != "" {
+		parts = append(parts, "tc "+info.Timecode)
 	}
 
 	if info.FrameCount > 0 {
-		parts = append(parts, fmt.Sprintf("%df", info.FrameCount))
+		parts = append(parts, l.Sprintf("%df", info.FrameCount))
 	}
 
 	if info.Codec != "" {
@@ -351,75 +1023,35 @@ func addLegend(src image.Image, legendHeight int, name string, info *video.Info)
 		parts = append(parts, fmt.Sprintf("%dx%d", info.Width, info.Height))
 	}
 
+	if took != "" {
+		parts = append(parts, took)
+	}
+
 	legendText := strings.Join(parts, " | ")
-	drawText(dst, legendText, 8, yText, textColor)
+	drawTextScaled(dst, legendText, 8*scale, yText, textColor, scale)
 
 	return dst
 }
 
 // drawText draws text using a simple bitmap font
 func drawText(img *image.RGBA, text string, x, y int, c color.RGBA) {
-	for _, ch := range strings.ToLower(text) {
-		pattern, ok := bitmapFont[byte(ch)]
-		if !ok {
-			x += 4 // space for unknown chars
-			continue
-		}
+	drawTextScaled(img, text, x, y, c, 1)
+}
 
-		for dy, row := range pattern {
-			for dx, pixel := range row {
-				if pixel == '#' {
-					img.SetRGBA(x+dx, y+dy, c)
-				}
-			}
-		}
-		x += len(pattern[0]) + 1 // char width + spacing
-	}
+// drawTextScaled draws text with every font pixel enlarged to scale x scale.
+func drawTextScaled(img *image.RGBA, text string, x, y int, c color.RGBA, scale int) {
+	font.Draw(img, text, x, y, c, scale)
 }
 
-// bitmapFont is a simple 5x7 bitmap font
-var bitmapFont = map[byte][]string{
-	'a': {"..#..", ".#.#.", "#...#", "#####", "#...#", "#...#", "#...#"},
-	'b': {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
-	'c': {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
-	'd': {"####.", "#...#", "#...#", "#...#", "#...#", "#...#", "####."},
-	'e': {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
-	'f': {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
-	'g': {".###.", "#....", "#....", "#.###", "#...#", "#...#", ".###."},
-	'h': {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
-	'i': {".###.", "..#..", "..#..", "..#..", "..#..", "..#..", ".###."},
-	'j': {"..###", "...#.", "...#.", "...#.", "#..#.", "#..#.", ".##.."},
-	'k': {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
-	'l': {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
-	'm': {"#...#", "##.##", "#.#.#", "#...#", "#...#", "#...#", "#...#"},
-	'n': {"#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#", "#...#"},
-	'o': {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
-	'p': {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
-	'q': {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
-	'r': {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
-	's': {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
-	't': {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
-	'u': {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
-	'v': {"#...#", "#...#", "#...#", "#...#", ".#.#.", ".#.#.", "..#.."},
-	'w': {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "##.##", "#...#"},
-	'x': {"#...#", ".#.#.", "..#..", "..#..", "..#..", ".#.#.", "#...#"},
-	'y': {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
-	'z': {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
-	'0': {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
-	'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
-	'2': {".###.", "#...#", "....#", "..##.", ".#...", "#....", "#####"},
-	'3': {".###.", "#...#", "....#", "..##.", "....#", "#...#", ".###."},
-	'4': {"#...#", "#...#", "#...#", "#####", "....#", "....#", "....#"},
-	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
-	'6': {".###.", "#....", "####.", "#...#", "#...#", "#...#", ".###."},
-	'7': {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#...."},
-	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
-	'9': {".###.", "#...#", "#...#", ".####", "....#", "....#", ".###."},
-	'.': {".....", ".....", ".....", ".....", ".....", "..#..", "..#.."},
-	'|': {"..#..", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
-	'-': {".....", ".....", ".....", "#####", ".....", ".....", "....."},
-	'_': {".....", ".....", ".....", ".....", ".....", ".....", "#####"},
-	' ': {".....", ".....", ".....", ".....", ".....", ".....", "....."},
-	'(': {"...#.", "..#..", ".#...", ".#...", ".#...", "..#..", "...#."},
-	')': {".#...", "..#..", "...#.", "...#.", "...#.", "..#..", ".#..."},
+// scaleNearest enlarges an image by an integer factor without interpolation.
+func scaleNearest(src image.Image, factor int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dst := newLike(src, w*factor, h*factor)
+	for y := 0; y < h*factor; y++ {
+		for x := 0; x < w*factor; x++ {
+			dst.Set(x, y, src.At(bounds.Min.X+x/factor, bounds.Min.Y+y/factor))
+		}
+	}
+	return dst
 }
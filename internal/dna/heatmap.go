@@ -0,0 +1,123 @@
+package dna
+
+import (
+	"image/color"
+	"math"
+)
+
+// Heatmap modes: instead of one color per row of the frame, each column
+// (row when vertical) is the distribution of the frame's pixels over hue or
+// luma bins, brighter where more pixels fall.
+const (
+	ModeHue  = "hue"
+	ModeLuma = "luma"
+)
+
+const (
+	hueBins  = 180 // 2 degrees each
+	lumaBins = 256
+
+	// heatmapMinChroma is the chroma (max-min channel, 0-255) below which
+	// a pixel is gray and counts in no hue bin.
+	heatmapMinChroma = 24
+
+	// heatmapGamma maps the share of the frame's pixels in a bin (0-1) to
+	// its brightness, share^gamma; below 1 it lifts small populations so
+	// they stay visible.
+	heatmapGamma = 0.5
+)
+
+// heatmapBins returns the bin count of a heatmap mode, 0 for color modes.
+func heatmapBins(mode string) int {
+	switch mode {
+	case ModeHue:
+		return hueBins
+	case ModeLuma:
+		return lumaBins
+	}
+	return 0
+}
+
+// heatmap turns RGB24 frames into bin distributions.
+type heatmap struct {
+	mode     string
+	vertical bool
+	counts   []int
+}
+
+func newHeatmap(mode string, vertical bool) *heatmap {
+	return &heatmap{mode: mode, vertical: vertical, counts: make([]int, heatmapBins(mode))}
+}
+
+// column returns the colors of one frame, in DNA order across the time
+// axis: hue from red at the top (left when vertical) around the color
+// wheel; luma bright at the top (right when vertical).
+func (h *heatmap) column(frame []byte) []color.RGBA {
+	clear(h.counts)
+	bins := len(h.counts)
+	for i := 0; i+2 < len(frame); i += 3 {
+		r, g, b := int(frame[i]), int(frame[i+1]), int(frame[i+2])
+		if h.mode == ModeLuma {
+			h.counts[(54*r+183*g+19*b)>>8]++
+			continue
+		}
+		if bin, ok := hueBin(r, g, b, bins); ok {
+			h.counts[bin]++
+		}
+	}
+
+	// Intensity is the share of all pixels of the frame (grays included)
+	// through heatmapGamma, so columns compare across frames
+	total := len(frame) / 3
+	out := make([]color.RGBA, bins)
+	for bin, n := range h.counts {
+		v := 0.0
+		if total > 0 {
+			v = math.Pow(float64(n)/float64(total), heatmapGamma)
+		}
+		pos := bin
+		var c color.RGBA
+		if h.mode == ModeLuma {
+			c = heatColor(v)
+			if !h.vertical {
+				pos = bins - 1 - bin
+			}
+		} else {
+			c = hueColor((float64(bin)+0.5)*360/float64(bins), v)
+		}
+		out[pos] = c
+	}
+	return out
+}
+
+// hueBin returns the hue bin of an 8-bit color, false for grays.
+func hueBin(r, g, b, bins int) (int, bool) {
+	hi, lo := max(r, g, b), min(r, g, b)
+	chroma := hi - lo
+	if chroma < heatmapMinChroma {
+		return 0, false
+	}
+	var h float64
+	switch hi {
+	case r:
+		h = float64(g-b) / float64(chroma)
+		if h < 0 {
+			h += 6
+		}
+	case g:
+		h = float64(b-r)/float64(chroma) + 2
+	default:
+		h = float64(r-g)/float64(chroma) + 4
+	}
+	return min(int(h/6*float64(bins)), bins-1), true
+}
+
+// hueColor returns the fully saturated color of hue (degrees) at
+// brightness v (0-1).
+func hueColor(hue, v float64) color.RGBA {
+	channel := func(n float64) uint8 {
+		k := math.Mod(n+hue/60, 6)
+		return uint8(v*(1-max(min(k, 4-k, 1), 0))*255 + 0.5)
+	}
+	return color.RGBA{R: channel(5), G: channel(3), B: channel(1), A: 255}
+}
@@ -0,0 +1,47 @@
+package dna
+
+import (
+	"fmt"
+)
+
+// GPU decoders supported by AnalysisConfig.HWAccel.
+const (
+	HWAccelCUDA  = "cuda"
+	HWAccelVAAPI = "vaapi"
+)
+
+// hwReducedSize is the size the averaged dimension (the frame width, or the
+// height with vertical output) is scaled to on the GPU. Averages over 256
+// samples match the full row closely; transfers shrink 7.5x for 1080p.
+const hwReducedSize = 256
+
+// hwaccelArgs returns ffmpeg input arguments and a filter chain that decode
+// on the GPU and keep scaling and pixel format conversion there, so only
+// small NV12 frames are downloaded. With reduce, the averaged dimension is
+// scaled down; the returned size is the size of the piped frames.
+func hwaccelArgs(accel string, inputArgs []string, width, height int, vertical, reduce bool) ([]string, string, int, int, error) {
+	w, h := width, height
+	if reduce {
+		if vertical && h > hwReducedSize {
+			h = hwReducedSize
+		} else if !vertical && w > hwReducedSize {
+			w = hwReducedSize
+		}
+	}
+	// NV12 needs even dimensions
+	w, h = w&^1, h&^1
+
+	var in []string
+	var scale string
+	switch accel {
+	case HWAccelCUDA:
+		in = []string{"-hwaccel", "cuda", "-hwaccel_output_format", "cuda"}
+		scale = fmt.Sprintf("scale_cuda=w=%d:h=%d:format=nv12", w, h)
+	case HWAccelVAAPI:
+		in = []string{"-hwaccel", "vaapi", "-hwaccel_output_format", "vaapi"}
+		scale = fmt.Sprintf("scale_vaapi=w=%d:h=%d:format=nv12:mode=hq", w, h)
+	default:
+		return nil, "", 0, 0, fmt.Errorf("unknown hwaccel %q, use cuda or vaapi", accel)
+	}
+	return append(in, inputArgs...), scale + ",hwdownload,format=nv12", w, h, nil
+}
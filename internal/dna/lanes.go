@@ -0,0 +1,189 @@
+package dna
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"strings"
+
+	"github.com/pforret/videodna/internal/lanespec"
+	"github.com/pforret/videodna/internal/plot"
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// Lane is a per-frame metric rendered as a strip along the DNA time axis.
+type Lane struct {
+	Label  string
+	Values []float64    // One value per frame (0.0 to 1.0, NaN = no data)
+	Color  color.RGBA   // Bar color
+	Colors []color.RGBA // Optional per-frame bar colors (overrides Color)
+	Style  plot.Style   // plot.Bars (default) or plot.Waveform
+	Height int          // Lane thickness in logical pixels (0 = the configured lane height)
+}
+
+// defaultLaneHeight is the lane thickness in pixels when none is configured.
+const defaultLaneHeight = 32
+
+// LaneDNA is the lane spec name of the DNA itself. Its height sets the DNA
+// thickness across the time axis; it always comes first and cannot be
+// hidden.
+const LaneDNA = "dna"
+
+// arrangeLanes orders, sizes and hides lanes as spec says. Spec names that
+// match no lane are reported as warnings.
+func arrangeLanes(lanes []Lane, spec lanespec.Spec) []Lane {
+	if len(spec) == 0 {
+		return lanes
+	}
+	names := make([]string, len(lanes))
+	for i, lane := range lanes {
+		names[i] = lane.Label
+	}
+	order, heights, unknown := spec.Arrange(names)
+	for _, name := range unknown {
+		if !strings.EqualFold(name, LaneDNA) {
+			fmt.Fprintf(os.Stderr, "Warning: no lane named %q to arrange\n", name)
+		}
+	}
+	out := make([]Lane, len(order))
+	for i, idx := range order {
+		out[i] = lanes[idx]
+		if heights[i] > 0 {
+			out[i].Height = heights[i]
+		}
+	}
+	return out
+}
+
+// transformLanes remaps lane values along the time axis. Lanes are only
+// reversed or log-mapped; flipping does not apply to bar charts.
+func transformLanes(lanes []Lane, t transform.Options) []Lane {
+	out := make([]Lane, len(lanes))
+	for i, lane := range lanes {
+		out[i] = lane
+		out[i].Values = make([]float64, len(lane.Values))
+		for j, src := range t.ResampleIndex(len(lane.Values)) {
+			out[i].Values[j] = lane.Values[src]
+		}
+		if lane.Colors != nil {
+			out[i].Colors = make([]color.RGBA, len(lane.Colors))
+			for j, src := range t.ResampleIndex(len(lane.Colors)) {
+				out[i].Colors[j] = lane.Colors[src]
+			}
+		}
+	}
+	return out
+}
+
+// dnaLaneHeight returns the DNA thickness set by spec (0 = as decoded).
+func dnaLaneHeight(spec lanespec.Spec) int {
+	lane, _ := spec.Lookup(LaneDNA)
+	return lane.Height
+}
+
+// addLanes appends metric lanes below the DNA, or to the right of it in
+// vertical mode. Lane values are resampled to the DNA time axis length.
+// Lanes without a Height of their own are laneHeight pixels thick.
+func addLanes(src image.Image, lanes []Lane, laneHeight int, vertical bool, scale int) image.Image {
+	if len(lanes) == 0 {
+		return src
+	}
+	if laneHeight <= 0 {
+		laneHeight = defaultLaneHeight
+	}
+
+	heights := make([]int, len(lanes))
+	total := 0
+	for i, lane := range lanes {
+		heights[i] = laneHeight
+		if lane.Height > 0 {
+			heights[i] = lane.Height * scale
+		}
+		total += heights[i]
+	}
+
+	bounds := src.Bounds()
+	w := bounds.Dx()
+	h := bounds.Dy()
+
+	var dst *image.RGBA
+	if vertical {
+		dst = image.NewRGBA(image.Rect(0, 0, w+total, h))
+	} else {
+		dst = image.NewRGBA(image.Rect(0, 0, w, h+total))
+	}
+
+	// Copy original image
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, a := src.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)})
+		}
+	}
+
+	length := w
+	if vertical {
+		length = h
+	}
+
+	offset := 0
+	for i, lane := range lanes {
+		laneHeight := heights[i]
+		strip := renderLane(lane, length, laneHeight, !vertical, scale)
+		for t := 0; t < length; t++ {
+			for d := 0; d < laneHeight; d++ {
+				c := strip.RGBAAt(t, d)
+				if vertical {
+					// Bars grow left to right, time runs top to bottom
+					dst.SetRGBA(w+offset+(laneHeight-1-d), t, c)
+				} else {
+					dst.SetRGBA(t, h+offset+d, c)
+				}
+			}
+		}
+		offset += laneHeight
+	}
+
+	return dst
+}
+
+// renderLane draws a lane as a horizontal bar graph of the given size, with
+// separator and label drawn at the UI scale.
+func renderLane(lane Lane, length, height int, withLabel bool, scale int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, length, height))
+
+	bgColor := color.RGBA{R: 20, G: 20, B: 25, A: 255}
+	sepColor := color.RGBA{R: 50, G: 50, B: 55, A: 255}
+	for y := 0; y < height; y++ {
+		for x := 0; x < length; x++ {
+			img.SetRGBA(x, y, bgColor)
+		}
+	}
+	for x := 0; x < length; x++ {
+		for y := 0; y < scale; y++ {
+			img.SetRGBA(x, y, sepColor)
+		}
+	}
+
+	if n := len(lane.Values); n > 0 {
+		levels := make([]float64, length)
+		for x := range levels {
+			levels[x] = lane.Values[x*n/length]
+		}
+		paint := func(x, y int, intensity float64) color.RGBA {
+			c := lane.Color
+			if idx := x * n / length; idx < len(lane.Colors) {
+				c = lane.Colors[idx]
+			}
+			return plot.Shade(c, intensity)
+		}
+		plot.Draw(img, image.Rect(0, 2*scale, length, height), levels, lane.Style, paint)
+	}
+
+	if withLabel && lane.Label != "" {
+		drawTextScaled(img, lane.Label, 4*scale, 3*scale, color.RGBA{R: 200, G: 200, B: 200, A: 255}, scale)
+	}
+
+	return img
+}
@@ -0,0 +1,229 @@
+package dna
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pforret/videodna/internal/checksum"
+	"github.com/pforret/videodna/internal/fingerprint"
+	"github.com/pforret/videodna/internal/icc"
+)
+
+// LayoutVersion is the version of the layout metadata written to new PNGs.
+// PNGs with auto-levels are written as levelsLayoutVersion instead, so
+// older builds, which cannot undo the stretch, refuse to read them back.
+const LayoutVersion = 1
+
+// levelsLayoutVersion is the layout version that added Levels.
+const levelsLayoutVersion = 2
+
+// layoutKeyword is the PNG text chunk keyword holding the layout JSON.
+const layoutKeyword = "videodna"
+
+// Layout describes where the DNA sits in a rendered PNG and how it was made.
+// It is embedded as an iTXt chunk so the DNA can be re-extracted later.
+type Layout struct {
+	Version     int     `json:"version"`
+	Kind        string  `json:"kind"`   // "video" or "diff"
+	Source      string  `json:"source"` // Input file
+	Duration    float64 `json:"duration"`
+	Frames      int     `json:"frames"`
+	FPS         float64 `json:"fps"`
+	Mode        string  `json:"mode,omitempty"`
+	Vertical    bool    `json:"vertical"`              // Time runs top to bottom
+	Transformed bool    `json:"transformed,omitempty"` // Time axis is not linear
+	Timecode    string  `json:"timecode,omitempty"`    // Start timecode of the source (HH:MM:SS:FF)
+	Linear      bool    `json:"linear,omitempty"`      // Colors averaged in linear light
+	Adjusted    bool    `json:"adjusted,omitempty"`    // Presentation adjustments (saturation, ...) applied
+	DNA         Rect    `json:"dna"`                   // DNA area inside the border lines
+	Levels      *Levels `json:"levels,omitempty"`      // Contrast stretch of the DNA (nil = none)
+
+	Checksums *checksum.Sums `json:"checksums,omitempty"` // Digests of the source file
+}
+
+// Rect is a pixel rectangle in the final image.
+type Rect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+func newRect(r image.Rectangle) Rect {
+	return Rect{X: r.Min.X, Y: r.Min.Y, Width: r.Dx(), Height: r.Dy()}
+}
+
+func (r Rect) rectangle() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
+}
+
+// encodePNG writes img as PNG tagged with profile (nil = untagged), with
+// the layout in an iTXt chunk right after the IHDR chunk. A nil layout
+// writes no iTXt chunk.
+func encodePNG(w io.Writer, img image.Image, layout *Layout, profile *icc.Profile) error {
+	if layout == nil {
+		return icc.EncodePNG(w, img, profile)
+	}
+	text, err := json.Marshal(layout)
+	if err != nil {
+		return err
+	}
+
+	// iTXt: keyword, null, compression flag and method, empty language tag
+	// and translated keyword (each null terminated), UTF-8 text
+	data := append([]byte(layoutKeyword), 0, 0, 0, 0, 0)
+	data = append(data, text...)
+	return icc.EncodePNG(w, img, profile, icc.Chunk{Type: "iTXt", Data: data})
+}
+
+// readLayout returns the layout embedded in PNG data, or nil when the PNG
+// has none (images from older versions).
+func readLayout(data []byte) (*Layout, error) {
+	if len(data) < 8 || !bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		return nil, fmt.Errorf("not a PNG file")
+	}
+	for pos := 8; pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		kind := string(data[pos+4 : pos+8])
+		if length < 0 || pos+12+length > len(data) || kind == "IDAT" {
+			break
+		}
+		chunk := data[pos+8 : pos+8+length]
+		pos += 12 + length
+
+		keyword, rest, ok := bytes.Cut(chunk, []byte{0})
+		if !ok || string(keyword) != layoutKeyword {
+			continue
+		}
+		var text []byte
+		switch kind {
+		case "tEXt":
+			text = rest
+		case "iTXt":
+			// Skip compression flag and method, language tag, translated keyword
+			if len(rest) < 2 || rest[0] != 0 {
+				return nil, fmt.Errorf("compressed layout metadata is not supported")
+			}
+			parts := bytes.SplitN(rest[2:], []byte{0}, 3)
+			if len(parts) != 3 {
+				return nil, fmt.Errorf("invalid layout metadata")
+			}
+			text = parts[2]
+		default:
+			continue
+		}
+		var layout Layout
+		if err := json.Unmarshal(text, &layout); err != nil {
+			return nil, fmt.Errorf("invalid layout metadata: %w", err)
+		}
+		return &layout, nil
+	}
+	return nil, nil
+}
+
+// ReadFingerprintPNG re-extracts a fingerprint from a videodna PNG, using the
+// embedded layout. PNGs written before the layout was embedded are read as
+// horizontal DNA between the gray border lines, with an unknown duration.
+func ReadFingerprintPNG(path string, levels []int) (*fingerprint.Fingerprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DNA image: %w", err)
+	}
+	layout, err := readLayout(data)
+	if err != nil {
+		return nil, err
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode DNA image: %w", err)
+	}
+
+	if layout == nil {
+		rect, err := findBorderedStrip(img)
+		if err != nil {
+			return nil, err
+		}
+		layout = &Layout{Kind: "video", Source: filepath.Base(path), DNA: newRect(rect)}
+	}
+	if layout.Version > levelsLayoutVersion {
+		return nil, fmt.Errorf("layout version %d is newer than supported (%d)", layout.Version, levelsLayoutVersion)
+	}
+	if layout.Kind != "video" {
+		return nil, fmt.Errorf("%s DNA has no color fingerprint", layout.Kind)
+	}
+	if heatmapBins(layout.Mode) > 0 {
+		return nil, fmt.Errorf("%s heatmap DNA has no color fingerprint", layout.Mode)
+	}
+	if layout.Transformed {
+		return nil, fmt.Errorf("DNA was rendered with a time axis transform and cannot be re-extracted")
+	}
+	if layout.Adjusted {
+		return nil, fmt.Errorf("DNA was rendered with presentation adjustments and cannot be re-extracted")
+	}
+	rect := layout.DNA.rectangle()
+	if rect.Empty() || !rect.In(img.Bounds()) {
+		return nil, fmt.Errorf("DNA area %v is outside the %v image", rect, img.Bounds().Size())
+	}
+
+	sub := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			sub.Set(x, y, img.At(rect.Min.X+x, rect.Min.Y+y))
+		}
+	}
+	if layout.Levels != nil {
+		layout.Levels.invert(sub, sub.Bounds(), layout.Vertical)
+	}
+	return videoFingerprint(sub, layout.Vertical, layout.Source, layout.Duration, levels), nil
+}
+
+// findBorderedStrip locates the DNA of a PNG without layout metadata: the
+// rows between the first two runs of solid border-gray rows.
+func findBorderedStrip(img image.Image) (image.Rectangle, error) {
+	bounds := img.Bounds()
+	border := color.RGBA{R: 80, G: 80, B: 80, A: 255}
+	isBorder := func(y int) bool {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if color.RGBAModel.Convert(img.At(x, y)) != border {
+				return false
+			}
+		}
+		return true
+	}
+
+	var runs [][2]int // [first, last+1) rows of each border run
+	for y := bounds.Min.Y; y < bounds.Max.Y && len(runs) < 2; y++ {
+		if !isBorder(y) {
+			continue
+		}
+		if n := len(runs); n > 0 && runs[n-1][1] == y {
+			runs[n-1][1] = y + 1
+		} else {
+			runs = append(runs, [2]int{y, y + 1})
+		}
+	}
+	// The second run may still be growing when the loop stops
+	if len(runs) == 2 {
+		for runs[1][1] < bounds.Max.Y && isBorder(runs[1][1]) {
+			runs[1][1]++
+		}
+	}
+	if len(runs) < 2 || runs[1][0] <= runs[0][1] {
+		return image.Rectangle{}, fmt.Errorf("no layout metadata and no DNA border lines found")
+	}
+	return image.Rect(bounds.Min.X, runs[0][1], bounds.Max.X, runs[1][0]), nil
+}
+
+// IsPNGPath reports whether path is a PNG image.
+func IsPNGPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".png")
+}
@@ -0,0 +1,155 @@
+package dna
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// letterboxLimit is the mean luma below which a row or column counts as black
+// (same default as ffmpeg's cropdetect).
+const letterboxLimit = 24
+
+// LetterboxReport describes active picture area changes over time.
+type LetterboxReport struct {
+	MixedAspect bool         `json:"mixed_aspect"`
+	Segments    []AspectSpan `json:"segments"`
+}
+
+// AspectSpan is a run of frames with the same detected aspect ratio.
+type AspectSpan struct {
+	Span
+	Aspect float64 `json:"aspect"` // Mean active area aspect ratio
+	Crop   string  `json:"crop"`   // Active area as WxH+X+Y (first frame)
+}
+
+// aspectClass is a standard aspect ratio used to classify active areas.
+type aspectClass struct {
+	name  string
+	ratio float64
+	color color.RGBA
+}
+
+var aspectClasses = []aspectClass{
+	{"1.33", 4.0 / 3.0, color.RGBA{R: 255, G: 180, B: 100, A: 255}},
+	{"1.50", 1.5, color.RGBA{R: 255, G: 220, B: 100, A: 255}},
+	{"1.78", 16.0 / 9.0, color.RGBA{R: 100, G: 255, B: 150, A: 255}},
+	{"1.85", 1.85, color.RGBA{R: 100, G: 200, B: 255, A: 255}},
+	{"2.00", 2.0, color.RGBA{R: 150, G: 150, B: 255, A: 255}},
+	{"2.39", 2.39, color.RGBA{R: 200, G: 150, B: 255, A: 255}},
+	{"2.76", 2.76, color.RGBA{R: 255, G: 100, B: 100, A: 255}},
+}
+
+// nearestAspect returns the index of the closest standard aspect ratio.
+func nearestAspect(ratio float64) int {
+	best := 0
+	for i, c := range aspectClasses {
+		if math.Abs(c.ratio-ratio) < math.Abs(aspectClasses[best].ratio-ratio) {
+			best = i
+		}
+	}
+	return best
+}
+
+type cropRect struct {
+	x, y, w, h int
+}
+
+// letterboxAnalyzer tracks the active picture area per frame, cropdetect-style.
+type letterboxAnalyzer struct {
+	crops  []cropRect
+	width  int
+	height int
+}
+
+func (a *letterboxAnalyzer) analyze(frame []byte, width, height int) {
+	a.width, a.height = width, height
+
+	top := 0
+	for top < height && rowLuma(frame, top, width) < letterboxLimit {
+		top++
+	}
+	if top == height {
+		// Fully black frame (fade): keep previous area
+		if n := len(a.crops); n > 0 {
+			a.crops = append(a.crops, a.crops[n-1])
+		} else {
+			a.crops = append(a.crops, cropRect{w: width, h: height})
+		}
+		return
+	}
+	bottom := height - 1
+	for bottom > top && rowLuma(frame, bottom, width) < letterboxLimit {
+		bottom--
+	}
+	left := 0
+	for left < width && colLuma(frame, left, top, bottom, width) < letterboxLimit {
+		left++
+	}
+	right := width - 1
+	for right > left && colLuma(frame, right, top, bottom, width) < letterboxLimit {
+		right--
+	}
+
+	a.crops = append(a.crops, cropRect{x: left, y: top, w: right - left + 1, h: bottom - top + 1})
+}
+
+func (a *letterboxAnalyzer) finish(fps float64, report *AnalysisReport) []Lane {
+	lane := Lane{Label: "aspect"}
+	labels := make([]string, len(a.crops))
+	frameArea := float64(a.width * a.height)
+
+	for i, c := range a.crops {
+		class := aspectClasses[nearestAspect(float64(c.w)/float64(c.h))]
+		labels[i] = class.name
+		lane.Values = append(lane.Values, float64(c.w*c.h)/frameArea)
+		lane.Colors = append(lane.Colors, class.color)
+	}
+
+	// Ignore aspect flicker shorter than one second
+	minFrames := int(fps)
+	if minFrames < 1 {
+		minFrames = 1
+	}
+
+	lb := &LetterboxReport{}
+	seen := map[string]bool{}
+	for _, s := range spansFrom(labels, minFrames, fps) {
+		var sum float64
+		for _, c := range a.crops[s.StartFrame:s.EndFrame] {
+			sum += float64(c.w) / float64(c.h)
+		}
+		first := a.crops[s.StartFrame]
+		lb.Segments = append(lb.Segments, AspectSpan{
+			Span:   s,
+			Aspect: sum / float64(s.EndFrame-s.StartFrame),
+			Crop:   fmt.Sprintf("%dx%d+%d+%d", first.w, first.h, first.x, first.y),
+		})
+		seen[s.Label] = true
+	}
+	lb.MixedAspect = len(seen) > 1
+	report.Letterbox = lb
+
+	return []Lane{lane}
+}
+
+// rowLuma returns the mean luma of a frame row.
+func rowLuma(frame []byte, y, width int) float64 {
+	var sum float64
+	start := y * width * 3
+	for x := 0; x < width; x++ {
+		i := start + x*3
+		sum += luma(frame[i], frame[i+1], frame[i+2])
+	}
+	return sum / float64(width)
+}
+
+// colLuma returns the mean luma of a frame column between rows top and bottom.
+func colLuma(frame []byte, x, top, bottom, width int) float64 {
+	var sum float64
+	for y := top; y <= bottom; y++ {
+		i := (y*width + x) * 3
+		sum += luma(frame[i], frame[i+1], frame[i+2])
+	}
+	return sum / float64(bottom-top+1)
+}
@@ -0,0 +1,177 @@
+package dna
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"strconv"
+)
+
+// Auto-levels modes of AnalysisConfig.AutoLevels.
+const (
+	LevelsGlobal = "global" // One black and white point for the whole DNA
+	LevelsRow    = "row"    // One pair per DNA row (column with vertical output): each band of the frame
+)
+
+// levelsClip is the share of the darkest and brightest values ignored when
+// finding the black and white points, so a few specks (a flash, a black
+// frame) do not hold the stretch back.
+const levelsClip = 0.005
+
+// Levels records the contrast stretch applied to the DNA: every channel
+// value v became (v-Black)*255/(White-Black), clipped to 0-255. The same
+// stretch applies to R, G and B, so hues are kept. Global mode has one
+// pair; row mode one per DNA row (column with vertical output), in order
+// across the frame. Fingerprints are computed before the stretch, and
+// ReadFingerprintPNG undoes it, so they stay comparable with other DNA.
+type Levels struct {
+	Mode  string `json:"mode"`
+	Black []int  `json:"black"` // 0-255
+	White []int  `json:"white"`
+}
+
+// computeLevels finds the black and white points of the raw DNA, one frame
+// per column (row with vertical output).
+func computeLevels(img image.Image, vertical bool, mode string) *Levels {
+	bounds := img.Bounds()
+	bands := 1
+	if mode == LevelsRow {
+		bands = bounds.Dy()
+		if vertical {
+			bands = bounds.Dx()
+		}
+	}
+
+	hist := make([][256]int, bands)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			band := levelsBand(x-bounds.Min.X, y-bounds.Min.Y, bands, vertical)
+			r, g, b, _ := img.At(x, y).RGBA()
+			hist[band][r>>8]++
+			hist[band][g>>8]++
+			hist[band][b>>8]++
+		}
+	}
+
+	l := &Levels{Mode: mode, Black: make([]int, bands), White: make([]int, bands)}
+	for i, h := range hist {
+		total := 0
+		for _, n := range h {
+			total += n
+		}
+		clip := int(float64(total) * levelsClip)
+		lo, hi := 0, 255
+		for sum := 0; lo < 255; lo++ {
+			if sum += h[lo]; sum > clip {
+				break
+			}
+		}
+		for sum := 0; hi > 0; hi-- {
+			if sum += h[hi]; sum > clip {
+				break
+			}
+		}
+		if hi <= lo {
+			// Flat band: nothing to stretch
+			lo, hi = 0, 255
+		}
+		l.Black[i], l.White[i] = lo, hi
+	}
+	return l
+}
+
+// levelsBand returns the band of the pixel at x, y of the DNA.
+func levelsBand(x, y, bands int, vertical bool) int {
+	if bands == 1 {
+		return 0
+	}
+	if vertical {
+		return x
+	}
+	return y
+}
+
+// apply stretches the DNA area rect of img in place: 8-bit *image.RGBA or
+// 16-bit *image.RGBA64, time along x (y when vertical). Bands are mapped
+// proportionally, so it applies to rendered DNA of any size; every pixel
+// row (column) gets the stretch of one band and can be inverted.
+func (l *Levels) apply(img image.Image, rect image.Rectangle, vertical bool) {
+	l.transform(img, rect, vertical, func(v, lo, hi float64) float64 { return (v - lo) * 255 / (hi - lo) })
+}
+
+// invert undoes apply, as far as clipping and rounding allow.
+func (l *Levels) invert(img image.Image, rect image.Rectangle, vertical bool) {
+	l.transform(img, rect, vertical, func(v, lo, hi float64) float64 { return lo + v*(hi-lo)/255 })
+}
+
+// transform maps every channel value in rect of img (on a 0-255 scale)
+// with f and the black and white points of its band.
+func (l *Levels) transform(img image.Image, rect image.Rectangle, vertical bool, f func(v, lo, hi float64) float64) {
+	bounds := rect.Intersect(img.Bounds())
+	bands := len(l.Black)
+	if bands == 0 || len(l.White) != bands {
+		return
+	}
+	across := rect.Dy()
+	if vertical {
+		across = rect.Dx()
+	}
+	bandOf := func(x, y int) int {
+		if bands == 1 {
+			return 0
+		}
+		pos := y - rect.Min.Y
+		if vertical {
+			pos = x - rect.Min.X
+		}
+		return min(pos*bands/max(across, 1), bands-1)
+	}
+
+	switch img := img.(type) {
+	case *image.RGBA:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				band := bandOf(x, y)
+				lo, hi := float64(l.Black[band]), float64(l.White[band])
+				i := img.PixOffset(x, y)
+				for c := 0; c < 3; c++ {
+					img.Pix[i+c] = uint8(min(max(f(float64(img.Pix[i+c]), lo, hi), 0), 255) + 0.5)
+				}
+			}
+		}
+	case *image.RGBA64:
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				band := bandOf(x, y)
+				lo, hi := float64(l.Black[band]), float64(l.White[band])
+				i := img.PixOffset(x, y)
+				for c := 0; c < 3; c++ {
+					v := float64(uint16(img.Pix[i+2*c])<<8|uint16(img.Pix[i+2*c+1])) / 257
+					out := uint16(min(max(f(v, lo, hi), 0), 255)*257 + 0.5)
+					img.Pix[i+2*c], img.Pix[i+2*c+1] = uint8(out>>8), uint8(out)
+				}
+			}
+		}
+	}
+}
+
+// flipped returns the levels with the bands in reverse order, for DNA
+// rendered flipped across the frame.
+func (l *Levels) flipped() *Levels {
+	f := &Levels{Mode: l.Mode, Black: slices.Clone(l.Black), White: slices.Clone(l.White)}
+	slices.Reverse(f.Black)
+	slices.Reverse(f.White)
+	return f
+}
+
+// String summarizes the black and white points, as a range in row mode.
+func (l *Levels) String() string {
+	span := func(v []int) string {
+		lo, hi := slices.Min(v), slices.Max(v)
+		if lo == hi {
+			return strconv.Itoa(lo)
+		}
+		return fmt.Sprintf("%d-%d", lo, hi)
+	}
+	return fmt.Sprintf("black %s, white %s", span(l.Black), span(l.White))
+}
@@ -0,0 +1,66 @@
+package dna
+
+import (
+	"image/color"
+	"math"
+	"sync"
+)
+
+// encodeSRGB maps linear light (0-1) to a 16-bit sRGB value.
+func encodeSRGB(v float64) uint16 {
+	if v <= 0.0031308 {
+		v *= 12.92
+	} else {
+		v = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return uint16(min(max(v, 0), 1)*0xffff + 0.5)
+}
+
+// linearMean re-encodes channel sums of n pixels in linear light as a
+// 16-bit sRGB color.
+func linearMean(rSum, gSum, bSum float64, n int) color.RGBA64 {
+	f := float64(n)
+	return color.RGBA64{R: encodeSRGB(rSum / f), G: encodeSRGB(gSum / f), B: encodeSRGB(bSum / f), A: 0xffff}
+}
+
+// AverageColorLinear returns the average color of a row in linear light:
+// sRGB decoded, averaged and re-encoded. Averaging the encoded values
+// instead darkens mixes of bright and dark pixels.
+func AverageColorLinear(row []byte, width int) color.RGBA64 {
+	var rSum, gSum, bSum float64
+	for x := 0; x < width; x++ {
+		i := x * 3
+		rSum += srgbLinear[row[i]]
+		gSum += srgbLinear[row[i+1]]
+		bSum += srgbLinear[row[i+2]]
+	}
+	return linearMean(rSum, gSum, bSum, width)
+}
+
+// AverageColorColLinear returns the average color of a column in linear
+// light.
+func AverageColorColLinear(buf []byte, col, width, height int) color.RGBA64 {
+	var rSum, gSum, bSum float64
+	for y := 0; y < height; y++ {
+		i := (y*width + col) * 3
+		rSum += srgbLinear[buf[i]]
+		gSum += srgbLinear[buf[i+1]]
+		bSum += srgbLinear[buf[i+2]]
+	}
+	return linearMean(rSum, gSum, bSum, height)
+}
+
+// srgbLinear16 maps 16-bit sRGB values to linear light like srgbLinear,
+// built on first use by column averaging.
+var srgbLinear16 = sync.OnceValue(func() *[0x10000]float64 {
+	var t [0x10000]float64
+	for i := range t {
+		v := float64(i) / 0xffff
+		if v <= 0.04045 {
+			t[i] = v / 12.92
+		} else {
+			t[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+	return &t
+})
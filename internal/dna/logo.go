@@ -0,0 +1,264 @@
+package dna
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+)
+
+const (
+	defaultLogoThreshold = 0.6 // Minimum normalized cross-correlation for "present"
+	logoCornerFraction   = 0.3 // Corner search region as a fraction of frame size
+	logoSearchStep       = 4   // Coarse search step in pixels
+	logoJitter           = 2   // Per-frame search radius around the locked position
+)
+
+// LogoReport describes watermark presence over time.
+type LogoReport struct {
+	Path         string  `json:"path"`
+	Corner       string  `json:"corner,omitempty"`   // Corner where the logo was found
+	Position     string  `json:"position,omitempty"` // Top-left of the match as X,Y
+	PresentRatio float64 `json:"present_ratio"`      // Fraction of frames with the logo
+	Present      []Span  `json:"present"`
+	Absent       []Span  `json:"absent"`
+}
+
+// logoTemplate is a luma template with an alpha mask.
+type logoTemplate struct {
+	w, h   int
+	luma   []float64
+	mask   []bool
+	mean   float64
+	stddev float64
+	count  int
+}
+
+// loadLogoTemplate reads a PNG or JPEG logo. Pixels with alpha below 50%
+// are excluded from matching, so transparent logo backgrounds are ignored.
+func loadLogoTemplate(path string) (*logoTemplate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open logo: %w", err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode logo: %w", err)
+	}
+
+	b := img.Bounds()
+	t := &logoTemplate{w: b.Dx(), h: b.Dy()}
+	t.luma = make([]float64, t.w*t.h)
+	t.mask = make([]bool, t.w*t.h)
+
+	var sum, sumSq float64
+	for y := 0; y < t.h; y++ {
+		for x := 0; x < t.w; x++ {
+			r, g, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			if a < 0x8000 {
+				continue
+			}
+			v := luma(uint8(r>>8), uint8(g>>8), uint8(bl>>8))
+			i := y*t.w + x
+			t.luma[i] = v
+			t.mask[i] = true
+			sum += v
+			sumSq += v * v
+			t.count++
+		}
+	}
+	if t.count == 0 {
+		return nil, fmt.Errorf("logo has no opaque pixels")
+	}
+	t.mean = sum / float64(t.count)
+	t.stddev = math.Sqrt(sumSq/float64(t.count) - t.mean*t.mean)
+	if t.stddev == 0 {
+		return nil, fmt.Errorf("logo is a flat color, cannot match it")
+	}
+	return t, nil
+}
+
+// match returns the normalized cross-correlation of the template at (px, py).
+func (t *logoTemplate) match(frame []byte, width, px, py int) float64 {
+	var sum, sumSq, cross float64
+	for y := 0; y < t.h; y++ {
+		row := (py+y)*width + px
+		for x := 0; x < t.w; x++ {
+			ti := y*t.w + x
+			if !t.mask[ti] {
+				continue
+			}
+			i := (row + x) * 3
+			v := luma(frame[i], frame[i+1], frame[i+2])
+			sum += v
+			sumSq += v * v
+			cross += v * t.luma[ti]
+		}
+	}
+	n := float64(t.count)
+	mean := sum / n
+	variance := sumSq/n - mean*mean
+	if variance <= 0 {
+		return 0
+	}
+	return (cross/n - mean*t.mean) / (math.Sqrt(variance) * t.stddev)
+}
+
+// logoAnalyzer detects a watermark in the frame corners. It searches the
+// corners on sampled frames until the logo is found, then tracks it at
+// that position (broadcast bugs do not move).
+type logoAnalyzer struct {
+	path      string
+	template  *logoTemplate
+	threshold float64
+	scores    []float64
+	locked    bool
+	corner    string
+	lockX     int
+	lockY     int
+}
+
+func newLogoAnalyzer(path string, threshold float64) (*logoAnalyzer, error) {
+	t, err := loadLogoTemplate(path)
+	if err != nil {
+		return nil, err
+	}
+	if threshold <= 0 {
+		threshold = defaultLogoThreshold
+	}
+	return &logoAnalyzer{path: path, template: t, threshold: threshold}, nil
+}
+
+func (a *logoAnalyzer) analyze(frame []byte, width, height int) {
+	t := a.template
+	if t.w > width || t.h > height {
+		a.scores = append(a.scores, 0)
+		return
+	}
+
+	if !a.locked {
+		// Search corners about twice per second of 25fps video
+		score := 0.0
+		if len(a.scores)%12 == 0 {
+			score = a.search(frame, width, height)
+		}
+		a.scores = append(a.scores, score)
+		return
+	}
+
+	best := -1.0
+	for dy := -logoJitter; dy <= logoJitter; dy++ {
+		for dx := -logoJitter; dx <= logoJitter; dx++ {
+			x, y := a.lockX+dx, a.lockY+dy
+			if x < 0 || y < 0 || x+t.w > width || y+t.h > height {
+				continue
+			}
+			if s := t.match(frame, width, x, y); s > best {
+				best = s
+			}
+		}
+	}
+	a.scores = append(a.scores, best)
+}
+
+// search looks for the logo in all four corners and locks onto a match.
+func (a *logoAnalyzer) search(frame []byte, width, height int) float64 {
+	t := a.template
+	regionW := int(float64(width) * logoCornerFraction)
+	regionH := int(float64(height) * logoCornerFraction)
+	if regionW < t.w {
+		regionW = t.w
+	}
+	if regionH < t.h {
+		regionH = t.h
+	}
+
+	corners := []struct {
+		name   string
+		x0, y0 int
+	}{
+		{"top-left", 0, 0},
+		{"top-right", width - regionW, 0},
+		{"bottom-left", 0, height - regionH},
+		{"bottom-right", width - regionW, height - regionH},
+	}
+
+	best, bestX, bestY, bestCorner := -1.0, 0, 0, ""
+	for _, c := range corners {
+		for y := c.y0; y+t.h <= c.y0+regionH; y += logoSearchStep {
+			for x := c.x0; x+t.w <= c.x0+regionW; x += logoSearchStep {
+				if s := t.match(frame, width, x, y); s > best {
+					best, bestX, bestY, bestCorner = s, x, y, c.name
+				}
+			}
+		}
+	}
+
+	// Refine around the coarse match
+	cx, cy := bestX, bestY
+	for y := cy - logoSearchStep; y <= cy+logoSearchStep; y++ {
+		for x := cx - logoSearchStep; x <= cx+logoSearchStep; x++ {
+			if x < 0 || y < 0 || x+t.w > width || y+t.h > height {
+				continue
+			}
+			if s := t.match(frame, width, x, y); s > best {
+				best, bestX, bestY = s, x, y
+			}
+		}
+	}
+
+	if best >= a.threshold {
+		a.locked = true
+		a.corner = bestCorner
+		a.lockX, a.lockY = bestX, bestY
+	}
+	return best
+}
+
+func (a *logoAnalyzer) finish(fps float64, report *AnalysisReport) []Lane {
+	present := color.RGBA{R: 100, G: 255, B: 150, A: 255}
+	absent := color.RGBA{R: 255, G: 100, B: 100, A: 255}
+
+	lane := Lane{Label: "logo"}
+	presentLabels := make([]string, len(a.scores))
+	absentLabels := make([]string, len(a.scores))
+	count := 0
+	for i, s := range a.scores {
+		if s >= a.threshold {
+			presentLabels[i] = "present"
+			lane.Values = append(lane.Values, s)
+			lane.Colors = append(lane.Colors, present)
+			count++
+		} else {
+			absentLabels[i] = "absent"
+			lane.Values = append(lane.Values, 1)
+			lane.Colors = append(lane.Colors, absent)
+		}
+	}
+
+	minFrames := int(fps / 2)
+	if minFrames < 1 {
+		minFrames = 1
+	}
+
+	lr := &LogoReport{
+		Path:    a.path,
+		Present: spansFrom(presentLabels, minFrames, fps),
+		Absent:  spansFrom(absentLabels, minFrames, fps),
+	}
+	if a.locked {
+		lr.Corner = a.corner
+		lr.Position = fmt.Sprintf("%d,%d", a.lockX, a.lockY)
+	}
+	if len(a.scores) > 0 {
+		lr.PresentRatio = float64(count) / float64(len(a.scores))
+	}
+	report.Logo = lr
+
+	return []Lane{lane}
+}
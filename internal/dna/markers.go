@@ -0,0 +1,293 @@
+package dna
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pforret/videodna/internal/timecode"
+)
+
+// CSV header names (lowercase) recognized for marker time and label, in
+// order of preference. Covers Premiere Pro marker export and DaVinci Resolve
+// marker/edit index CSV.
+var (
+	markerTimeColumns  = []string{"in", "record in", "start", "timecode", "time", "source in"}
+	markerLabelColumns = []string{"marker name", "name", "label", "notes", "comments", "comment", "description"}
+)
+
+// LoadAnnotations reads annotations from a file. The format follows the
+// extension: .json (see loadAnnotationsJSON), .edl (CMX3600 events or
+// markers) or .csv/.txt (NLE marker export).
+func LoadAnnotations(path string) ([]Annotation, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".edl":
+		return loadEDL(path)
+	case ".csv", ".txt", ".tsv":
+		return loadMarkerCSV(path)
+	default:
+		return loadAnnotationsJSON(path)
+	}
+}
+
+// loadEDL reads markers from a CMX3600 EDL. Marker comments (Resolve
+// "|M:name" or Avid/Premiere "* LOC: TC COLOR name") are used when present,
+// otherwise every event becomes a marker at its record in point, labeled
+// with its clip name.
+func loadEDL(path string) ([]Annotation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EDL: %w", err)
+	}
+	defer f.Close()
+
+	var events, markers []Annotation
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		fields := strings.Fields(line)
+
+		switch {
+		case len(fields) >= 8 && isEventNumber(fields[0]):
+			// 001  AX  V  C  srcIn srcOut recIn recOut
+			recordIn := fields[len(fields)-2]
+			if !isTimecode(recordIn) {
+				continue
+			}
+			events = append(events, Annotation{Timecode: recordIn, Label: "event " + fields[0]})
+
+		case strings.HasPrefix(line, "* FROM CLIP NAME:") && len(events) > 0:
+			events[len(events)-1].Label = strings.TrimSpace(strings.TrimPrefix(line, "* FROM CLIP NAME:"))
+
+		case strings.Contains(line, "|M:") && len(events) > 0:
+			name := line[strings.Index(line, "|M:")+3:]
+			if i := strings.Index(name, " |"); i >= 0 {
+				name = name[:i]
+			}
+			markers = append(markers, Annotation{Timecode: events[len(events)-1].Timecode, Label: strings.TrimSpace(name)})
+
+		case len(fields) >= 3 && fields[0] == "*" && fields[1] == "LOC:" && isTimecode(fields[2]):
+			label := "marker"
+			if len(fields) > 4 {
+				label = strings.Join(fields[4:], " ")
+			}
+			markers = append(markers, Annotation{Timecode: fields[2], Label: label})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read EDL: %w", err)
+	}
+
+	if len(markers) == 0 {
+		markers = events
+	}
+	if len(markers) == 0 {
+		return nil, fmt.Errorf("no events or markers found in %s", path)
+	}
+	return markers, nil
+}
+
+// loadMarkerCSV reads a marker list exported by an NLE. Premiere exports
+// UTF-16 tab-separated text; Resolve exports comma-separated UTF-8.
+func loadMarkerCSV(path string) ([]Annotation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read marker CSV: %w", err)
+	}
+	data = decodeUTF16(data)
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // UTF-8 BOM
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	if firstLine, _, _ := bytes.Cut(data, []byte("\n")); bytes.Contains(firstLine, []byte("\t")) {
+		reader.Comma = '\t'
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse marker CSV: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("no markers found in %s", path)
+	}
+
+	header := map[string]int{}
+	for i, name := range records[0] {
+		header[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	timeCol := findColumn(header, markerTimeColumns)
+	if timeCol < 0 {
+		return nil, fmt.Errorf("marker CSV has no time column (expected one of: %s)", strings.Join(markerTimeColumns, ", "))
+	}
+	labelCols := make([]int, 0, len(markerLabelColumns))
+	for _, name := range markerLabelColumns {
+		if i, ok := header[name]; ok {
+			labelCols = append(labelCols, i)
+		}
+	}
+
+	var annotations []Annotation
+	for n, record := range records[1:] {
+		if timeCol >= len(record) || strings.TrimSpace(record[timeCol]) == "" {
+			continue
+		}
+		label := ""
+		for _, i := range labelCols {
+			if i < len(record) && strings.TrimSpace(record[i]) != "" {
+				label = strings.TrimSpace(record[i])
+				break
+			}
+		}
+		if label == "" {
+			label = fmt.Sprintf("marker %d", n+1)
+		}
+
+		value := strings.TrimSpace(record[timeCol])
+		if isTimecode(value) {
+			annotations = append(annotations, Annotation{Timecode: value, Label: label})
+			continue
+		}
+		seconds, err := ParseTimestamp(value)
+		if err != nil {
+			return nil, fmt.Errorf("marker %d: %w", n+1, err)
+		}
+		annotations = append(annotations, Annotation{Time: seconds, Label: label})
+	}
+	return annotations, nil
+}
+
+// findColumn returns the index of the first candidate present in header.
+func findColumn(header map[string]int, candidates []string) int {
+	for _, name := range candidates {
+		if i, ok := header[name]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// decodeUTF16 converts UTF-16 text with a byte order mark to UTF-8. Other
+// input is returned unchanged.
+func decodeUTF16(data []byte) []byte {
+	if len(data) < 2 {
+		return data
+	}
+	var bigEndian bool
+	switch {
+	case data[0] == 0xff && data[1] == 0xfe:
+	case data[0] == 0xfe && data[1] == 0xff:
+		bigEndian = true
+	default:
+		return data
+	}
+	units := make([]uint16, 0, len(data)/2)
+	for i := 2; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// isEventNumber reports whether s is an EDL event number (e.g. "001").
+func isEventNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil && len(s) >= 3
+}
+
+// isTimecode reports whether s looks like HH:MM:SS:FF (or HH:MM:SS;FF).
+func isTimecode(s string) bool {
+	_, err := parseTimecodeFields(s)
+	return err == nil
+}
+
+// parseTimecodeFields splits HH:MM:SS:FF (or HH:MM:SS;FF) into hours,
+// minutes, seconds and frames.
+func parseTimecodeFields(s string) ([4]int, error) {
+	var tc [4]int
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == ';' })
+	if len(parts) != 4 {
+		return tc, fmt.Errorf("invalid timecode %q, use HH:MM:SS:FF", s)
+	}
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 {
+			return tc, fmt.Errorf("invalid timecode %q, use HH:MM:SS:FF", s)
+		}
+		tc[i] = v
+	}
+	return tc, nil
+}
+
+// timecodeSeconds converts HH:MM:SS:FF (or drop-frame HH:MM:SS;FF) to
+// seconds at the given frame rate. Timecode counts whole frames at the
+// nominal rate (30 for 29.97), so the frame number is divided by the actual
+// rate.
+func timecodeSeconds(s string, fps float64) (float64, error) {
+	frames, _, err := timecode.Parse(s, fps)
+	if err != nil {
+		return 0, err
+	}
+	return float64(frames) / fps, nil
+}
+
+// stripStartHour removes the 01:00:00:00 sequence start most NLEs use when
+// every timecode is at or past one hour, so markers line up with the file.
+func stripStartHour(annotations []Annotation) []Annotation {
+	for _, a := range annotations {
+		if a.Timecode == "" {
+			continue
+		}
+		if tc, err := parseTimecodeFields(a.Timecode); err != nil || tc[0] < 1 {
+			return annotations
+		}
+	}
+	for i, a := range annotations {
+		if a.Timecode != "" {
+			tc, _ := parseTimecodeFields(a.Timecode)
+			annotations[i].Timecode = fmt.Sprintf("%02d:%02d:%02d:%02d", tc[0]-1, tc[1], tc[2], tc[3])
+		}
+	}
+	return annotations
+}
+
+// resolveAnnotations converts timecode annotations to seconds using the
+// video frame rate. Timecodes count from the start timecode of the video,
+// or without one from an NLE sequence start at 01:00:00:00 when every
+// marker is past it.
+func resolveAnnotations(annotations []Annotation, fps float64, start string) ([]Annotation, error) {
+	startSeconds := 0.0
+	if start != "" {
+		var err error
+		if startSeconds, err = timecodeSeconds(start, fps); err != nil {
+			return nil, err
+		}
+	} else {
+		annotations = stripStartHour(append([]Annotation(nil), annotations...))
+	}
+
+	resolved := make([]Annotation, len(annotations))
+	for i, a := range annotations {
+		resolved[i] = a
+		if a.Timecode == "" {
+			continue
+		}
+		if fps <= 0 {
+			return nil, fmt.Errorf("cannot place timecode marker %q: unknown frame rate", a.Label)
+		}
+		seconds, err := timecodeSeconds(a.Timecode, fps)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i].Time = seconds - startSeconds
+	}
+	return resolved, nil
+}
@@ -0,0 +1,358 @@
+package dna
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pforret/videodna/internal/alttext"
+	"github.com/pforret/videodna/internal/checksum"
+	"github.com/pforret/videodna/internal/events"
+	"github.com/pforret/videodna/internal/pipe"
+	"github.com/pforret/videodna/internal/quantize"
+	"github.com/pforret/videodna/internal/resizespec"
+	"github.com/pforret/videodna/internal/tiff"
+	"github.com/pforret/videodna/internal/tiles"
+	"github.com/pforret/videodna/internal/timeline"
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// Options collects the input, the output and the Config of
+// GenerateWithConfig in one value.
+type Options struct {
+	Input  string // Input video or .otio/.fcpxml timeline
+	Output string // Output PNG (.tif/.tiff = TIFF, .dzi = Deep Zoom tile pyramid, "" = none, Result.Image only)
+
+	// Reference selects a difference DNA against this video (GenerateDiff,
+	// see DiffConfig), which supports only part of Config ("" = none).
+	Reference string
+
+	Config
+}
+
+// DefaultOptions returns the CLI defaults for input and output.
+func DefaultOptions(input, output string) Options {
+	return Options{Input: input, Output: output, Config: DefaultConfig()}
+}
+
+// Validate checks the settings and their combinations, and reports every
+// violation at once (joined with errors.Join, one per line).
+func (o Options) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, o.Legend.Locale.Errorf(format, args...))
+	}
+	a := o.Analysis
+
+	if o.Input == "" {
+		fail("input is required")
+	}
+	switch o.Mode {
+	case "average", "min", "max", "common":
+	case ModeHue, ModeLuma:
+		if a.FingerprintPath != "" || a.AltTextPath != "" {
+			fail("%s heatmap mode has no colors for a fingerprint or alt text", o.Mode)
+		}
+	default:
+		fail("invalid mode %q, use average, min, max, common, hue or luma", o.Mode)
+	}
+	if spec, err := resizespec.Parse(o.Resize); err != nil {
+		errs = append(errs, err)
+	} else if limit := a.MaxDimension / max(a.Scale, 1); a.MaxDimension > 0 && max(spec.Width.Pixels, spec.Height.Pixels) > limit {
+		fail("resize %s exceeds the maximum dimension of %d pixels at scale %d (raise -max-dimension, 0 = no limit)", o.Resize, a.MaxDimension, max(a.Scale, 1))
+	}
+	if a.Width < WidthAuto {
+		fail("invalid width %d, use a column count or auto", a.Width)
+	}
+	if a.MaxDimension < 0 {
+		fail("maximum dimension must not be negative")
+	}
+	switch a.BitDepth {
+	case 0, 8:
+	case 16:
+		if tiles.IsDZIPath(o.Output) {
+			fail("16-bit depth is not supported with Deep Zoom output")
+		}
+	default:
+		fail("bit depth must be 8 or 16, not %d", a.BitDepth)
+	}
+	if a.Colors != 0 {
+		switch {
+		case a.Colors < 2 || a.Colors > quantize.MaxColors:
+			fail("colors must be between 2 and %d, not %d", quantize.MaxColors, a.Colors)
+		case a.BitDepth == 16:
+			fail("an indexed PNG cannot be 16-bit")
+		case tiles.IsDZIPath(o.Output) || tiff.IsTIFFPath(o.Output):
+			fail("colors needs a PNG output")
+		}
+	}
+	if a.XMP && (o.Output == "" || pipe.IsStdout(o.Output) || tiles.IsDZIPath(o.Output)) {
+		fail("an XMP sidecar needs a PNG or TIFF output file")
+	}
+	if _, _, err := checksum.Parse(a.Checksum.Verify); err != nil {
+		fail("invalid checksum %q, use a SHA-256 (64 hex digits) or MD5 (32 hex digits), optionally prefixed with sha256: or md5:", a.Checksum.Verify)
+	}
+	if a.ColumnsPerSecond < 0 {
+		fail("columns per second must not be negative")
+	}
+	if a.Width != 0 && a.ColumnsPerSecond > 0 {
+		fail("use either width or columns per second, not both")
+	}
+	if a.AdaptiveColumns && a.Width == 0 && a.ColumnsPerSecond <= 0 {
+		fail("adaptive columns need a width or columns per second")
+	}
+	if o.Timeout <= 0 {
+		fail("timeout must be positive")
+	}
+	if a.Scale < 0 || a.Scale > 3 {
+		fail("scale must be 1, 2, or 3, not %d", a.Scale)
+	}
+	if a.LogoThreshold < 0 || a.LogoThreshold > 1 {
+		fail("logo threshold must be between 0 and 1")
+	}
+	if o.Vertical && a.Zoom.Enabled() {
+		fail("zoom is not supported with vertical output")
+	}
+	if o.Vertical && len(a.Annotations) > 0 {
+		fail("annotations are not supported with vertical output")
+	}
+	switch a.HWAccel {
+	case "", HWAccelCUDA, HWAccelVAAPI:
+	default:
+		fail("unknown hwaccel %q, use cuda or vaapi", a.HWAccel)
+	}
+	for _, n := range a.FingerprintLevels {
+		if n <= 0 {
+			fail("fingerprint levels must be positive column counts")
+			break
+		}
+	}
+	if a.EventsPath != "" {
+		if err := events.CheckPath(a.EventsPath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if a.SeekMapPath != "" {
+		if err := CheckSeekMapPath(a.SeekMapPath); err != nil {
+			errs = append(errs, err)
+		}
+		if o.Output == "" {
+			fail("a seek map needs an output image")
+		}
+	}
+	if a.AltTextPath != "" {
+		if err := alttext.CheckPath(a.AltTextPath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	switch a.AutoLevels {
+	case "", LevelsGlobal, LevelsRow:
+	default:
+		fail("unknown auto-levels mode %q, use global or row", a.AutoLevels)
+	}
+	for _, series := range a.Series {
+		if err := series.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := a.Lanes.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if lane, ok := a.Lanes.Lookup(LaneDNA); ok {
+		if lane.Hidden {
+			fail("the DNA lane cannot be hidden")
+		}
+		if lane.Height > 0 && o.Resize != "" {
+			fail("a DNA lane height is not supported with resize")
+		}
+	}
+	if !a.Adjust.Valid() {
+		fail("saturation, vibrance and contrast must be between -1 and 3, brightness between -1 and 1")
+	}
+	if a.TimecodeBase != "" && !isTimecode(a.TimecodeBase) {
+		fail("invalid timecode base %q, use HH:MM:SS:FF", a.TimecodeBase)
+	}
+	switch strings.ToLower(a.TimecodeFormat) {
+	case TimecodeAuto, "auto", TimecodeNDF, TimecodeDF:
+	default:
+		fail("unknown timecode format %q, use auto, ndf or df", a.TimecodeFormat)
+	}
+	if o.Reference != "" {
+		for _, setting := range o.diffUnsupported() {
+			fail("%s: not supported with a reference video", setting)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// diffUnsupported returns the settings of o that a difference DNA
+// (DiffConfig) has no counterpart for.
+func (o Options) diffUnsupported() []string {
+	a := o.Analysis
+	var names []string
+	for _, setting := range []struct {
+		name string
+		set  bool
+	}{
+		{"timeline input", timeline.IsTimelinePath(o.Input)},
+		{"fingerprint", a.FingerprintPath != ""},
+		{"hwaccel", a.HWAccel != ""},
+		{"width", a.Width != 0},
+		{"columns per second", a.ColumnsPerSecond > 0},
+		{"adaptive columns", a.AdaptiveColumns},
+		{"16-bit depth", a.BitDepth == 16},
+		{"checksum", a.Checksum.Enabled || a.Checksum.MD5 || a.Checksum.Verify != ""},
+		{"events", a.EventsPath != ""},
+		{"alt text", a.AltTextPath != ""},
+		{"time series", len(a.Series) > 0},
+		{"tail compression", a.Transform.CompressTail},
+		{"lanes", len(a.Lanes) > 0},
+		{"presentation adjustments", !a.Adjust.IsZero()},
+		{"linear light", a.Linear},
+		{"auto-levels", a.AutoLevels != ""},
+		{"image and report sinks", o.ImageSink != nil || o.ReportSink != nil},
+	} {
+		if setting.set {
+			names = append(names, setting.name)
+		}
+	}
+	return names
+}
+
+// GenerateOptions validates opts and generates the video DNA under ctx.
+func GenerateOptions(ctx context.Context, opts Options) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+	return GenerateWithConfig(ctx, opts.Input, opts.Output, opts.Config)
+}
+
+// Builder assembles Options step by step, starting from DefaultOptions.
+// Build validates the result, so library callers get the same checks as
+// the CLI:
+//
+//	opts, err := dna.NewBuilder("in.mp4", "dna.png").Mode("max").Cuts().Events("cuts.edl").Build()
+type Builder struct {
+	opts Options
+}
+
+// NewBuilder returns a builder with the default options.
+func NewBuilder(input, output string) *Builder {
+	return &Builder{opts: DefaultOptions(input, output)}
+}
+
+// Mode sets the color mode (average, min, max, common).
+func (b *Builder) Mode(mode string) *Builder {
+	b.opts.Mode = mode
+	return b
+}
+
+// Vertical lets time run top to bottom.
+func (b *Builder) Vertical() *Builder {
+	b.opts.Vertical = true
+	return b
+}
+
+// Resize scales the DNA to 'WxH', a percentage ('50%', '100%x50%'), one
+// dimension ('1920x', 'x200') or the video size ('input').
+func (b *Builder) Resize(resize string) *Builder {
+	b.opts.Resize = resize
+	return b
+}
+
+// Width sets the number of DNA columns (WidthAuto = one per frame, capped).
+func (b *Builder) Width(width int) *Builder {
+	b.opts.Analysis.Width = width
+	return b
+}
+
+// Timeout sets the timeout in seconds.
+func (b *Builder) Timeout(seconds int) *Builder {
+	b.opts.Timeout = seconds
+	return b
+}
+
+// Silent suppresses progress output.
+func (b *Builder) Silent() *Builder {
+	b.opts.Silent = true
+	return b
+}
+
+// Legend shows name in the legend bar ("" = input file name).
+func (b *Builder) Legend(name string) *Builder {
+	b.opts.Legend.Enabled = true
+	b.opts.Legend.Name = name
+	return b
+}
+
+// NoLegend hides the legend bar.
+func (b *Builder) NoLegend() *Builder {
+	b.opts.Legend.Enabled = false
+	return b
+}
+
+// Letterbox adds the active picture area lane.
+func (b *Builder) Letterbox() *Builder {
+	b.opts.Analysis.Letterbox = true
+	return b
+}
+
+// Text adds the credits and subtitles lane.
+func (b *Builder) Text() *Builder {
+	b.opts.Analysis.Text = true
+	return b
+}
+
+// Cuts adds the scene cut lane.
+func (b *Builder) Cuts() *Builder {
+	b.opts.Analysis.Cuts = true
+	return b
+}
+
+// Report writes the JSON analysis report to path.
+func (b *Builder) Report(path string) *Builder {
+	b.opts.Analysis.ReportPath = path
+	return b
+}
+
+// Events exports events to path (.edl, .ffmeta or .txt).
+func (b *Builder) Events(path string) *Builder {
+	b.opts.Analysis.EventsPath = path
+	return b
+}
+
+// Transform reverses, flips or log-maps the time axis.
+func (b *Builder) Transform(t transform.Options) *Builder {
+	b.opts.Analysis.Transform = t
+	return b
+}
+
+// Zoom renders a time region expanded below the DNA.
+func (b *Builder) Zoom(zoom Zoom) *Builder {
+	b.opts.Analysis.Zoom = zoom
+	return b
+}
+
+// Scale draws the legend and lanes for HiDPI displays (1-3).
+func (b *Builder) Scale(scale int) *Builder {
+	b.opts.Analysis.Scale = scale
+	return b
+}
+
+// Annotate marks labeled points in time on the DNA.
+func (b *Builder) Annotate(annotations ...Annotation) *Builder {
+	b.opts.Analysis.Annotations = append(b.opts.Analysis.Annotations, annotations...)
+	return b
+}
+
+// Configure changes any other setting.
+func (b *Builder) Configure(fn func(*Options)) *Builder {
+	fn(&b.opts)
+	return b
+}
+
+// Build returns the options, or every violation found by Validate.
+func (b *Builder) Build() (Options, error) {
+	return b.opts, b.opts.Validate()
+}
@@ -0,0 +1,104 @@
+package dna
+
+import "math"
+
+// maxPSNR caps the PSNR of identical frames (which is infinite).
+const maxPSNR = 100.0
+
+// PSNR returns the peak signal-to-noise ratio in dB between two RGB24 frames.
+func PSNR(frame, ref []byte) float64 {
+	if len(frame) == 0 {
+		return maxPSNR
+	}
+	var sumSq uint64
+	for i := range frame {
+		d := int(frame[i]) - int(ref[i])
+		sumSq += uint64(d * d)
+	}
+	if sumSq == 0 {
+		return maxPSNR
+	}
+	mse := float64(sumSq) / float64(len(frame))
+	psnr := 10 * math.Log10(255*255/mse)
+	if psnr > maxPSNR {
+		psnr = maxPSNR
+	}
+	return psnr
+}
+
+// SSIM returns the mean structural similarity between two RGB24 frames,
+// computed on luma over non-overlapping 8x8 blocks.
+func SSIM(frame, ref []byte, width, height int) float64 {
+	const block = 8
+	const c1 = (0.01 * 255) * (0.01 * 255)
+	const c2 = (0.03 * 255) * (0.03 * 255)
+
+	var total float64
+	count := 0
+	for by := 0; by+block <= height; by += block {
+		for bx := 0; bx+block <= width; bx += block {
+			var sumA, sumB, sumAA, sumBB, sumAB float64
+			for y := by; y < by+block; y++ {
+				for x := bx; x < bx+block; x++ {
+					i := (y*width + x) * 3
+					a := luma(frame[i], frame[i+1], frame[i+2])
+					b := luma(ref[i], ref[i+1], ref[i+2])
+					sumA += a
+					sumB += b
+					sumAA += a * a
+					sumBB += b * b
+					sumAB += a * b
+				}
+			}
+			n := float64(block * block)
+			meanA, meanB := sumA/n, sumB/n
+			varA := sumAA/n - meanA*meanA
+			varB := sumBB/n - meanB*meanB
+			cov := sumAB/n - meanA*meanB
+
+			total += ((2*meanA*meanB + c1) * (2*cov + c2)) /
+				((meanA*meanA + meanB*meanB + c1) * (varA + varB + c2))
+			count++
+		}
+	}
+	if count == 0 {
+		return 1
+	}
+	return total / float64(count)
+}
+
+// luma returns BT.601 luma for an RGB pixel.
+func luma(r, g, b uint8) float64 {
+	return 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+}
+
+// MetricSummary holds aggregate statistics for a per-frame metric.
+type MetricSummary struct {
+	Mean     float64 `json:"mean"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	MinFrame int     `json:"min_frame"`
+	MaxFrame int     `json:"max_frame"`
+}
+
+// summarize computes mean/min/max of per-frame values.
+func summarize(values []float64) MetricSummary {
+	if len(values) == 0 {
+		return MetricSummary{}
+	}
+	s := MetricSummary{Min: values[0], Max: values[0]}
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if v < s.Min {
+			s.Min = v
+			s.MinFrame = i
+		}
+		if v > s.Max {
+			s.Max = v
+			s.MaxFrame = i
+		}
+	}
+	s.Mean = sum / float64(len(values))
+	return s
+}
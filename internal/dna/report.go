@@ -0,0 +1,26 @@
+package dna
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pforret/videodna/internal/sink"
+)
+
+// writeJSON writes v as indented JSON to path.
+func writeJSON(path string, v interface{}) error {
+	return putJSON(context.Background(), sink.File(path), v)
+}
+
+// putJSON delivers v as indented JSON to s.
+func putJSON(ctx context.Context, s sink.Sink, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	if err := s.Put(ctx, append(data, '\n'), sink.TypeJSON); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
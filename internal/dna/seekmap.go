@@ -0,0 +1,118 @@
+package dna
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// SeekMap maps every pixel along the time axis of a rendered DNA to the
+// video time it shows, so web frontends can seek on click without
+// re-deriving resize, legend and transform offsets.
+type SeekMap struct {
+	Image    string       `json:"image"` // Rendered image file name
+	Width    int          `json:"width"` // Image size in pixels
+	Height   int          `json:"height"`
+	DNA      Rect         `json:"dna"` // DNA area; positions are pixels along its time axis
+	Vertical bool         `json:"vertical"`
+	Duration float64      `json:"duration"`
+	FPS      float64      `json:"fps"`
+	Timecode string       `json:"timecode,omitempty"` // Start timecode of the source
+	Columns  []SeekColumn `json:"columns"`            // One per pixel along the time axis
+}
+
+// SeekColumn is the time range shown by one pixel column (row with
+// -vertical) of the DNA.
+type SeekColumn struct {
+	Pos      int     `json:"pos"`   // Image x (y with -vertical)
+	Start    float64 `json:"start"` // Seconds
+	End      float64 `json:"end"`   // Seconds
+	Frame    int     `json:"frame"` // First frame shown
+	Timecode string  `json:"timecode,omitempty"`
+}
+
+// CheckSeekMapPath reports an error if path is not a .html or .json seek map,
+// so callers can fail before a long analysis.
+func CheckSeekMapPath(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm", ".json":
+		return nil
+	}
+	return fmt.Errorf("unknown seek map format %q, use .html or .json", filepath.Ext(path))
+}
+
+// newSeekMap computes the seek map of a rendered DNA from its layout.
+func newSeekMap(imagePath string, bounds image.Rectangle, layout *Layout, t transform.Options) *SeekMap {
+	m := &SeekMap{
+		Image:    filepath.Base(imagePath),
+		Width:    bounds.Dx(),
+		Height:   bounds.Dy(),
+		DNA:      layout.DNA,
+		Vertical: layout.Vertical,
+		Duration: layout.Duration,
+		FPS:      layout.FPS,
+		Timecode: layout.Timecode,
+	}
+
+	n, origin := layout.DNA.Width, layout.DNA.X
+	if layout.Vertical {
+		n, origin = layout.DNA.Height, layout.DNA.Y
+	}
+	for i := 0; i < n; i++ {
+		// A reversed axis shows the times in descending order
+		t0 := t.SourceTime(float64(i)/float64(n)) * layout.Duration
+		t1 := t.SourceTime(float64(i+1)/float64(n)) * layout.Duration
+		if t1 < t0 {
+			t0, t1 = t1, t0
+		}
+		col := SeekColumn{Pos: origin + i, Start: t0, End: t1}
+		if layout.FPS > 0 {
+			col.Frame = min(int(math.Floor(t0*layout.FPS+1e-6)), max(layout.Frames-1, 0))
+		}
+		if layout.Timecode != "" {
+			col.Timecode = FormatTimecode(t0, layout.Timecode, layout.FPS)
+		}
+		m.Columns = append(m.Columns, col)
+	}
+	return m
+}
+
+// writeSeekMap writes a seek map as JSON, or as an HTML image map with one
+// area per column linking to a media fragment (#t=seconds).
+func writeSeekMap(path string, m *SeekMap) error {
+	if err := CheckSeekMapPath(path); err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return writeJSON(path, m)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<img src=\"%s\" width=\"%d\" height=\"%d\" usemap=\"#videodna\" alt=\"video DNA\">\n",
+		html.EscapeString(m.Image), m.Width, m.Height)
+	b.WriteString("<map name=\"videodna\">\n")
+	for _, c := range m.Columns {
+		x0, y0, x1, y1 := c.Pos, m.DNA.Y, c.Pos+1, m.DNA.Y+m.DNA.Height
+		if m.Vertical {
+			x0, y0, x1, y1 = m.DNA.X, c.Pos, m.DNA.X+m.DNA.Width, c.Pos+1
+		}
+		title := c.Timecode
+		if title == "" {
+			title = FormatTimestamp(c.Start)
+		}
+		fmt.Fprintf(&b, "  <area shape=\"rect\" coords=\"%d,%d,%d,%d\" href=\"#t=%.3f\" data-time=\"%.3f\" data-frame=\"%d\" title=\"%s\">\n",
+			x0, y0, x1, y1, c.Start, c.Start, c.Frame, title)
+	}
+	b.WriteString("</map>\n")
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write seek map: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package dna
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pforret/videodna/internal/plot"
+)
+
+// TimeSeries is a series of values from outside the video, such as
+// telemetry (bitrate, QoE scores, sensor readings), rendered as an extra
+// lane aligned with the DNA time axis.
+type TimeSeries struct {
+	Label  string
+	Values []float64  // Samples in any unit (NaN = missing)
+	Times  []float64  // Seconds of each sample from the start (nil = spread evenly over the video)
+	Min    float64    // Value drawn at the bottom of the lane
+	Max    float64    // Value drawn at the top (Min and Max both 0 = the range of Values)
+	Color  color.RGBA // Zero = the next of seriesColors
+	Style  plot.Style // plot.Bars (default) or plot.Waveform
+}
+
+// seriesColors are the default colors of time series lanes, in order.
+var seriesColors = []color.RGBA{
+	{R: 255, G: 180, B: 60, A: 255},
+	{R: 90, G: 200, B: 250, A: 255},
+	{R: 180, G: 130, B: 255, A: 255},
+	{R: 120, G: 230, B: 120, A: 255},
+	{R: 255, G: 110, B: 140, A: 255},
+}
+
+// Validate reports an error if the series cannot be drawn.
+func (s TimeSeries) Validate() error {
+	if len(s.Values) == 0 {
+		return fmt.Errorf("time series %q has no values", s.Label)
+	}
+	if s.Times != nil {
+		if len(s.Times) != len(s.Values) {
+			return fmt.Errorf("time series %q has %d times for %d values", s.Label, len(s.Times), len(s.Values))
+		}
+		for i := 1; i < len(s.Times); i++ {
+			if s.Times[i] < s.Times[i-1] {
+				return fmt.Errorf("time series %q: times must be ascending", s.Label)
+			}
+		}
+	}
+	if s.Min > s.Max {
+		return fmt.Errorf("time series %q: min %g is above max %g", s.Label, s.Min, s.Max)
+	}
+	if _, err := plot.ParseStyle(string(s.Style)); err != nil {
+		return fmt.Errorf("time series %q: %w", s.Label, err)
+	}
+	return nil
+}
+
+// levels maps the series to n columns over duration seconds, as levels of
+// 0-1: samples within a column are averaged, and a column without one
+// holds the previous sample. Columns before the first sample are NaN.
+func (s TimeSeries) levels(n int, duration float64) []float64 {
+	lo, hi := s.Min, s.Max
+	if lo == 0 && hi == 0 {
+		lo, hi = math.Inf(1), math.Inf(-1)
+		for _, v := range s.Values {
+			if !math.IsNaN(v) {
+				lo, hi = min(lo, v), max(hi, v)
+			}
+		}
+		// A series of non-negative values is drawn from zero
+		if lo > 0 {
+			lo = 0
+		}
+	}
+	if !(hi > lo) {
+		hi = lo + 1
+	}
+
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	for i, v := range s.Values {
+		if math.IsNaN(v) {
+			continue
+		}
+		var col int
+		if s.Times == nil || duration <= 0 {
+			col = i * n / len(s.Values)
+		} else {
+			col = int(s.Times[i] / duration * float64(n))
+		}
+		if col >= 0 && col < n {
+			sums[col] += v
+			counts[col]++
+		}
+	}
+
+	out := make([]float64, n)
+	last := math.NaN()
+	for i := range out {
+		if counts[i] > 0 {
+			last = (sums[i]/float64(counts[i]) - lo) / (hi - lo)
+		}
+		out[i] = last
+	}
+	return out
+}
+
+// seriesLanes renders time series as lanes of one value per frame.
+func seriesLanes(series []TimeSeries, frames int, duration float64) []Lane {
+	lanes := make([]Lane, 0, len(series))
+	for i, s := range series {
+		c := s.Color
+		if c == (color.RGBA{}) {
+			c = seriesColors[i%len(seriesColors)]
+		}
+		lanes = append(lanes, Lane{Label: s.Label, Values: s.levels(frames, duration), Color: c, Style: s.Style})
+	}
+	return lanes
+}
+
+// LoadTimeSeries reads time series from a .csv or .json file.
+//
+// A CSV file has a header row; the first column is the time of each row
+// (seconds or a timestamp like 00:01:30.5), every other column a series
+// labeled by its header. Empty cells are missing samples.
+//
+// A JSON file is an array of objects with "label", "values" and optional
+// "times" (seconds), "min", "max", "color" (#rrggbb) and "style" (bars or
+// waveform).
+func LoadTimeSeries(path string) ([]TimeSeries, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read time series: %w", err)
+	}
+	defer f.Close()
+
+	var series []TimeSeries
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		series, err = readSeriesCSV(f)
+	} else {
+		series, err = readSeriesJSON(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse time series %s: %w", filepath.Base(path), err)
+	}
+	for _, s := range series {
+		if err := s.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return series, nil
+}
+
+func readSeriesCSV(r io.Reader) ([]TimeSeries, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 || len(rows[0]) < 2 {
+		return nil, fmt.Errorf("need a header row and a time column followed by one or more value columns")
+	}
+
+	series := make([]TimeSeries, len(rows[0])-1)
+	for i := range series {
+		series[i].Label = strings.TrimSpace(rows[0][i+1])
+	}
+	for line, row := range rows[1:] {
+		t, err := ParseTimestamp(row[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line+2, err)
+		}
+		for i := range series {
+			v := math.NaN()
+			if i+1 < len(row) && strings.TrimSpace(row[i+1]) != "" {
+				if v, err = strconv.ParseFloat(strings.TrimSpace(row[i+1]), 64); err != nil {
+					return nil, fmt.Errorf("line %d: invalid %s value %q", line+2, series[i].Label, row[i+1])
+				}
+			}
+			series[i].Times = append(series[i].Times, t)
+			series[i].Values = append(series[i].Values, v)
+		}
+	}
+	return series, nil
+}
+
+func readSeriesJSON(r io.Reader) ([]TimeSeries, error) {
+	var entries []struct {
+		Label  string    `json:"label"`
+		Values []float64 `json:"values"`
+		Times  []float64 `json:"times"`
+		Min    float64   `json:"min"`
+		Max    float64   `json:"max"`
+		Color  string    `json:"color"`
+		Style  string    `json:"style"`
+	}
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	series := make([]TimeSeries, len(entries))
+	for i, e := range entries {
+		series[i] = TimeSeries{Label: e.Label, Values: e.Values, Times: e.Times, Min: e.Min, Max: e.Max, Style: plot.Style(e.Style)}
+		if e.Color != "" {
+			var c color.RGBA
+			if _, err := fmt.Sscanf(e.Color, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+				return nil, fmt.Errorf("%s: invalid color %q, use #rrggbb", e.Label, e.Color)
+			}
+			c.A = 255
+			series[i].Color = c
+		}
+	}
+	return series, nil
+}
@@ -0,0 +1,39 @@
+package dna
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pforret/videodna/internal/tiff"
+	"github.com/pforret/videodna/internal/xmp"
+)
+
+// writeSidecar writes the XMP sidecar of the image at outputPath with its
+// layout and the analysis report.
+func writeSidecar(outputPath string, bounds image.Rectangle, layout *Layout, report any, silent bool) error {
+	format := "image/png"
+	if tiff.IsTIFFPath(outputPath) {
+		format = "image/tiff"
+	}
+	path := xmp.SidecarPath(outputPath)
+	err := xmp.Write(path, xmp.Packet{
+		Title:       strings.TrimSuffix(filepath.Base(layout.Source), filepath.Ext(layout.Source)),
+		Source:      layout.Source,
+		Format:      format,
+		CreatorTool: "videodna",
+		Created:     time.Now(),
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Metadata:    map[string]any{"layout": layout, "analysis": report},
+	})
+	if err != nil {
+		return err
+	}
+	if !silent {
+		fmt.Printf("XMP sidecar: %s\n", path)
+	}
+	return nil
+}
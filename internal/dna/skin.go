@@ -0,0 +1,75 @@
+package dna
+
+import (
+	"image/color"
+	"math"
+)
+
+// skinLaneScale is the skin ratio rendered as a full lane bar. Even close-ups
+// rarely exceed a third of the frame, so the lane is scaled up accordingly.
+const skinLaneScale = 0.3
+
+// SkinReport summarizes the skin-tone ratio over time. It roughly indicates
+// people-on-screen density; it is a chroma heuristic, not face detection.
+type SkinReport struct {
+	Mean      float64   `json:"mean"`       // Mean fraction of skin-tone pixels
+	Max       float64   `json:"max"`        // Highest per-frame fraction
+	MaxFrame  int       `json:"max_frame"`  // Frame with the highest fraction
+	PerSecond []float64 `json:"per_second"` // Mean fraction per second of video
+}
+
+// skinAnalyzer measures the fraction of skin-tone pixels per frame using a
+// fixed YCbCr chroma range classifier.
+type skinAnalyzer struct {
+	ratios []float64
+}
+
+func (a *skinAnalyzer) analyze(frame []byte, width, height int) {
+	var skin, total int
+	// Sample every other pixel in both directions
+	for y := 0; y < height; y += 2 {
+		for x := 0; x < width; x += 2 {
+			i := (y*width + x) * 3
+			if isSkinTone(frame[i], frame[i+1], frame[i+2]) {
+				skin++
+			}
+			total++
+		}
+	}
+	ratio := 0.0
+	if total > 0 {
+		ratio = float64(skin) / float64(total)
+	}
+	a.ratios = append(a.ratios, ratio)
+}
+
+func (a *skinAnalyzer) finish(fps float64, report *AnalysisReport) []Lane {
+	lane := Lane{Label: "skin", Color: color.RGBA{R: 240, G: 170, B: 130, A: 255}}
+	for _, r := range a.ratios {
+		lane.Values = append(lane.Values, r/skinLaneScale)
+	}
+
+	summary := summarize(a.ratios)
+	sr := &SkinReport{Mean: summary.Mean, Max: summary.Max, MaxFrame: summary.MaxFrame}
+
+	perSecond := int(math.Round(fps))
+	if perSecond < 1 {
+		perSecond = 1
+	}
+	for start := 0; start < len(a.ratios); start += perSecond {
+		end := start + perSecond
+		if end > len(a.ratios) {
+			end = len(a.ratios)
+		}
+		sr.PerSecond = append(sr.PerSecond, summarize(a.ratios[start:end]).Mean)
+	}
+	report.Skin = sr
+
+	return []Lane{lane}
+}
+
+// isSkinTone classifies an RGB pixel by the classic Cb/Cr skin range.
+func isSkinTone(r, g, b uint8) bool {
+	y, cb, cr := color.RGBToYCbCr(r, g, b)
+	return y > 40 && cb >= 77 && cb <= 127 && cr >= 133 && cr <= 173
+}
@@ -0,0 +1,42 @@
+package dna
+
+import (
+	"image"
+)
+
+// frameActivity returns how much each frame of a raw DNA differs from the
+// one before: the mean absolute channel difference of its column (row when
+// vertical), 0-255. transform.TailStart looks for a static tail in it.
+func frameActivity(img *image.RGBA, vertical bool) []float64 {
+	bounds := img.Bounds()
+	frames, size := bounds.Dx(), bounds.Dy()
+	if vertical {
+		frames, size = size, frames
+	}
+	at := func(frame, i int) []uint8 {
+		if vertical {
+			return img.Pix[img.PixOffset(bounds.Min.X+i, bounds.Min.Y+frame):]
+		}
+		return img.Pix[img.PixOffset(bounds.Min.X+frame, bounds.Min.Y+i):]
+	}
+
+	activity := make([]float64, frames)
+	for f := 1; f < frames; f++ {
+		var sum int
+		for i := 0; i < size; i++ {
+			a, b := at(f, i), at(f-1, i)
+			for c := 0; c < 3; c++ {
+				if d := int(a[c]) - int(b[c]); d < 0 {
+					sum -= d
+				} else {
+					sum += d
+				}
+			}
+		}
+		activity[f] = float64(sum) / float64(3*size)
+	}
+	if frames > 1 {
+		activity[0] = activity[1]
+	}
+	return activity
+}
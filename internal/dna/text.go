@@ -0,0 +1,126 @@
+package dna
+
+import (
+	"image/color"
+	"math"
+)
+
+const (
+	textEdgeThreshold   = 80   // Luma step that counts as a sharp (glyph) edge
+	creditsEdgeDensity  = 0.02 // Sharp edge density for a credits frame
+	creditsMaxLuma      = 60   // Credits are light text on a dark background
+	subtitleEdgeDensity = 0.01 // Sharp edge density within the subtitle band
+	subtitleBandRatio   = 3.0  // Subtitle band must be this much denser than the rest
+	subtitleBand        = 0.25 // Subtitle band height as a fraction of the frame
+)
+
+// TextReport lists frames with text-like content.
+type TextReport struct {
+	Credits   []Span `json:"credits"`
+	Subtitles []Span `json:"subtitles"`
+}
+
+// textAnalyzer detects credits and burned-in subtitles using the density of
+// sharp horizontal luma edges, which glyph strokes produce far more of than
+// natural imagery.
+type textAnalyzer struct {
+	density []float64
+	labels  []string
+}
+
+func (a *textAnalyzer) analyze(frame []byte, width, height int) {
+	bandStart := height - int(float64(height)*subtitleBand)
+	var edges, bandEdges, samples, bandSamples, pixels int
+	var lumaSum float64
+
+	// Sample every other row to keep the pass cheap
+	for y := 0; y < height; y += 2 {
+		prev := -1.0
+		for x := 0; x < width; x++ {
+			i := (y*width + x) * 3
+			v := luma(frame[i], frame[i+1], frame[i+2])
+			lumaSum += v
+			pixels++
+			if prev >= 0 {
+				edge := math.Abs(v-prev) > textEdgeThreshold
+				if y >= bandStart {
+					bandSamples++
+					if edge {
+						bandEdges++
+					}
+				} else {
+					samples++
+					if edge {
+						edges++
+					}
+				}
+			}
+			prev = v
+		}
+	}
+
+	total := samples + bandSamples
+	if total == 0 {
+		a.density = append(a.density, 0)
+		a.labels = append(a.labels, "")
+		return
+	}
+
+	density := float64(edges+bandEdges) / float64(total)
+	meanLuma := lumaSum / float64(pixels)
+
+	var bandDensity, restDensity float64
+	if bandSamples > 0 {
+		bandDensity = float64(bandEdges) / float64(bandSamples)
+	}
+	if samples > 0 {
+		restDensity = float64(edges) / float64(samples)
+	}
+
+	label := ""
+	switch {
+	case density >= creditsEdgeDensity && meanLuma < creditsMaxLuma:
+		label = "credits"
+	case bandDensity >= subtitleEdgeDensity && bandDensity >= restDensity*subtitleBandRatio:
+		label = "subtitles"
+	}
+
+	a.density = append(a.density, density)
+	a.labels = append(a.labels, label)
+}
+
+func (a *textAnalyzer) finish(fps float64, report *AnalysisReport) []Lane {
+	creditsColor := color.RGBA{R: 255, G: 220, B: 100, A: 255}
+	subtitleColor := color.RGBA{R: 100, G: 200, B: 255, A: 255}
+	plainColor := color.RGBA{R: 90, G: 90, B: 95, A: 255}
+
+	lane := Lane{Label: "text"}
+	credits := make([]string, len(a.labels))
+	subtitles := make([]string, len(a.labels))
+	for i, label := range a.labels {
+		// Scale so that the credits threshold reaches half height
+		lane.Values = append(lane.Values, a.density[i]/(creditsEdgeDensity*2))
+		switch label {
+		case "credits":
+			credits[i] = label
+			lane.Colors = append(lane.Colors, creditsColor)
+		case "subtitles":
+			subtitles[i] = label
+			lane.Colors = append(lane.Colors, subtitleColor)
+		default:
+			lane.Colors = append(lane.Colors, plainColor)
+		}
+	}
+
+	// Subtitles stay up for at least half a second, credits for longer
+	minFrames := int(fps / 2)
+	if minFrames < 1 {
+		minFrames = 1
+	}
+	report.Text = &TextReport{
+		Credits:   spansFrom(credits, minFrames*4, fps),
+		Subtitles: spansFrom(subtitles, minFrames, fps),
+	}
+
+	return []Lane{lane}
+}
@@ -0,0 +1,120 @@
+package dna
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pforret/videodna/internal/events"
+	"github.com/pforret/videodna/internal/timecode"
+	"github.com/pforret/videodna/internal/video"
+)
+
+// Timecode notations of AnalysisConfig.TimecodeFormat.
+const (
+	TimecodeAuto = ""    // Drop-frame when the start timecode is written HH:MM:SS;FF
+	TimecodeNDF  = "ndf" // Non-drop-frame HH:MM:SS:FF
+	TimecodeDF   = "df"  // Drop-frame HH:MM:SS;FF (29.97 and 59.94 fps only)
+)
+
+// applyTimecodeBase sets the start timecode of a video: base overrides the
+// timecode read from the container ("" keeps it, 00:00:00:00 gives
+// zero-based timecode). format rewrites it in drop-frame or non-drop-frame
+// notation; drop-frame without a start timecode starts at 00:00:00;00.
+func applyTimecodeBase(info *video.Info, base, format string) error {
+	if base != "" {
+		if !isTimecode(base) {
+			return fmt.Errorf("invalid timecode base %q, use HH:MM:SS:FF", base)
+		}
+		info.Timecode = base
+	}
+
+	switch strings.ToLower(format) {
+	case TimecodeAuto, "auto":
+	case TimecodeNDF:
+		info.Timecode = timecode.Notation(info.Timecode, false)
+	case TimecodeDF:
+		if !timecode.IsDropFrameRate(info.FPS) {
+			return fmt.Errorf("drop-frame timecode needs 29.97 or 59.94 fps, video is %.3f fps", info.FPS)
+		}
+		if info.Timecode == "" {
+			info.Timecode = "00:00:00:00"
+		}
+		info.Timecode = timecode.Notation(info.Timecode, true)
+	default:
+		return fmt.Errorf("unknown timecode format %q, use ndf or df", format)
+	}
+
+	if info.Timecode != "" {
+		if info.FPS <= 0 {
+			return fmt.Errorf("cannot use start timecode %s: unknown frame rate", info.Timecode)
+		}
+		if _, _, err := timecode.Parse(info.Timecode, info.FPS); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatTimecode formats seconds from the start of a video as SMPTE
+// timecode counted from the start timecode, in drop-frame notation when the
+// start timecode uses it. Without a start timecode or frame rate it falls
+// back to FormatTimestamp.
+func FormatTimecode(seconds float64, start string, fps float64) string {
+	if fps <= 0 {
+		return FormatTimestamp(seconds)
+	}
+	startFrames, drop, err := timecode.Parse(start, fps)
+	if err != nil {
+		return FormatTimestamp(seconds)
+	}
+	return timecode.Format(startFrames+int(math.Round(seconds*fps)), fps, drop)
+}
+
+// applyTimecode adds editorial timecodes next to the seconds of every time
+// in the report.
+func (r *AnalysisReport) applyTimecode(start string, fps float64) {
+	if start == "" {
+		return
+	}
+	r.Timecode = start
+	spans := func(list []Span) {
+		for i := range list {
+			list[i].StartTimecode = FormatTimecode(list[i].Start, start, fps)
+			list[i].EndTimecode = FormatTimecode(list[i].End, start, fps)
+		}
+	}
+	if r.Letterbox != nil {
+		for i := range r.Letterbox.Segments {
+			s := &r.Letterbox.Segments[i].Span
+			s.StartTimecode = FormatTimecode(s.Start, start, fps)
+			s.EndTimecode = FormatTimecode(s.End, start, fps)
+		}
+	}
+	if r.Logo != nil {
+		spans(r.Logo.Present)
+		spans(r.Logo.Absent)
+	}
+	if r.Text != nil {
+		spans(r.Text.Credits)
+		spans(r.Text.Subtitles)
+	}
+	if r.Cuts != nil {
+		for i := range r.Cuts.Cuts {
+			r.Cuts.Cuts[i].Timecode = FormatTimecode(r.Cuts.Cuts[i].Time, start, fps)
+		}
+	}
+}
+
+// writeEvents exports events with the EDL timeline starting at the start
+// timecode of the video, when it has one.
+func writeEvents(path string, list []events.Event, info *video.Info) error {
+	if info.Timecode == "" {
+		return events.Write(path, list, info.Duration, info.FPS)
+	}
+	startFrame, drop, err := timecode.Parse(info.Timecode, info.FPS)
+	if err != nil {
+		return err
+	}
+	return events.WriteFrom(path, list, info.Duration, info.FPS, startFrame, drop)
+}
@@ -0,0 +1,140 @@
+package dna
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// zoomConnectorHeight is the height of the band with connector lines between
+// the full strip and the zoomed region.
+const zoomConnectorHeight = 24
+
+// zoomColor marks the zoomed region and its connector lines.
+var zoomColor = color.RGBA{R: 255, G: 200, B: 0, A: 255}
+
+// Zoom selects a time region rendered again, expanded to the full image
+// width, below the full-length DNA.
+type Zoom struct {
+	Start float64 // Seconds
+	End   float64 // Seconds
+}
+
+// Enabled reports whether a zoom region is set.
+func (z Zoom) Enabled() bool {
+	return z.End > z.Start
+}
+
+// ParseZoom parses a region like "00:10:00-00:12:30" or "90-120".
+func ParseZoom(s string) (Zoom, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return Zoom{}, fmt.Errorf("invalid zoom region %q, use START-END (e.g. 00:10:00-00:12:30)", s)
+	}
+	start, err := ParseTimestamp(parts[0])
+	if err != nil {
+		return Zoom{}, err
+	}
+	end, err := ParseTimestamp(parts[1])
+	if err != nil {
+		return Zoom{}, err
+	}
+	if end <= start {
+		return Zoom{}, fmt.Errorf("invalid zoom region %q: end must be after start", s)
+	}
+	return Zoom{Start: start, End: end}, nil
+}
+
+// ParseTimestamp parses HH:MM:SS(.mmm), MM:SS(.mmm) or plain seconds.
+func ParseTimestamp(s string) (float64, error) {
+	var seconds float64
+	for _, part := range strings.Split(strings.TrimSpace(s), ":") {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		seconds = seconds*60 + v
+	}
+	return seconds, nil
+}
+
+// cropZoom returns the raw DNA columns (one per frame) of the zoom region and
+// the region bounds as fractions of the full width.
+func cropZoom(img image.Image, zoom Zoom, fps float64) (image.Image, float64, float64, error) {
+	bounds := img.Bounds()
+	cols := bounds.Dx()
+	from := int(zoom.Start * fps)
+	to := int(zoom.End * fps)
+	if to > cols {
+		to = cols
+	}
+	if from >= to {
+		return nil, 0, 0, fmt.Errorf("zoom region %s - %s is outside the video", FormatTimestamp(zoom.Start), FormatTimestamp(zoom.End))
+	}
+
+	crop := image.NewRGBA(image.Rect(0, 0, to-from, bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := from; x < to; x++ {
+			crop.Set(x-from, y, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return crop, float64(from) / float64(cols), float64(to) / float64(cols), nil
+}
+
+// addZoom marks columns x0..x1 on the full strip (the top stripHeight rows of
+// src) and appends the zoomed strip below src, joined by connector lines
+// from the region edges to the zoom edges. The region edges are also recorded
+// in marks (nil = none).
+func addZoom(src image.Image, marks *image.Alpha, zoom image.Image, stripHeight, x0, x1, scale int) *image.RGBA {
+	connectorHeight := zoomConnectorHeight * scale
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	zoomBounds := zoom.Bounds()
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h+connectorHeight+zoomBounds.Dy()))
+	bg := color.RGBA{R: 20, G: 20, B: 25, A: 255}
+	for y := h; y < h+connectorHeight; y++ {
+		for x := 0; x < w; x++ {
+			dst.SetRGBA(x, y, bg)
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(x, y, src.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	for y := 0; y < zoomBounds.Dy(); y++ {
+		for x := 0; x < zoomBounds.Dx() && x < w; x++ {
+			dst.Set(x, h+connectorHeight+y, zoom.At(zoomBounds.Min.X+x, zoomBounds.Min.Y+y))
+		}
+	}
+
+	// Region edges on the full strip
+	if x1 >= w {
+		x1 = w - 1
+	}
+	for y := 0; y < stripHeight; y++ {
+		for t := 0; t < scale; t++ {
+			dst.SetRGBA(min(x0+t, w-1), y, zoomColor)
+			dst.SetRGBA(max(x1-t, 0), y, zoomColor)
+			if marks != nil {
+				marks.SetAlpha(min(x0+t, w-1), y, color.Alpha{A: 255})
+				marks.SetAlpha(max(x1-t, 0), y, color.Alpha{A: 255})
+			}
+		}
+	}
+
+	// Connector lines from the region edges down to the zoom edges
+	for y := 0; y < connectorHeight; y++ {
+		f := float64(y) / float64(connectorHeight-1)
+		left := int(float64(x0) * (1 - f))
+		right := int(float64(x1) + float64(w-1-x1)*f)
+		for t := 0; t < scale; t++ {
+			dst.SetRGBA(min(left+t, w-1), h+y, zoomColor)
+			dst.SetRGBA(max(right-t, 0), h+y, zoomColor)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,199 @@
+// Package events exports detected events (cuts, silences, QC flags, ...) as
+// EDL markers, FFmpeg metadata chapters or YouTube chapter text, so editors
+// can bring the analysis back into their tools.
+package events
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pforret/videodna/internal/timecode"
+)
+
+// Export settings.
+const (
+	DefaultFPS        = 25.0 // Timecode rate when the media has no frame rate (audio)
+	edlStartHour      = 1    // NLE timelines start at 01:00:00:00
+	youTubeMinChapter = 10.0 // YouTube ignores chapters shorter than 10 seconds
+)
+
+// Event is a detected point (End == Start) or span in seconds.
+type Event struct {
+	Start float64
+	End   float64
+	Kind  string // e.g. "cut", "silence", "loudness"
+	Label string
+}
+
+// CheckPath reports an error if path has no supported events extension, so
+// callers can fail before a long analysis.
+func CheckPath(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".edl", ".ffmeta", ".ffmetadata", ".txt":
+		return nil
+	}
+	return fmt.Errorf("unknown events format %q, use .edl, .ffmeta or .txt", filepath.Ext(path))
+}
+
+// Write exports events to path; the format follows the extension:
+//
+//	.edl                      CMX3600 with one marker per event
+//	.ffmeta, .ffmetadata      FFmpeg metadata chapters (ffmpeg -i in -i x.ffmeta -map_metadata 1)
+//	.txt                      YouTube chapter list for the video description
+//
+// fps sets the EDL timecode rate (0 = DefaultFPS); duration closes the last
+// chapter.
+func Write(path string, events []Event, duration, fps float64) error {
+	if fps <= 0 {
+		fps = DefaultFPS
+	}
+	return WriteFrom(path, events, duration, fps, edlStartHour*3600*timecode.Nominal(fps), false)
+}
+
+// WriteFrom is Write with the EDL timeline starting at startFrame (a frame
+// count, e.g. the start timecode of the source) instead of 01:00:00:00,
+// in drop-frame timecode with drop.
+func WriteFrom(path string, events []Event, duration, fps float64, startFrame int, drop bool) error {
+	if fps <= 0 {
+		fps = DefaultFPS
+	}
+	sorted := append([]Event(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	if err := CheckPath(path); err != nil {
+		return err
+	}
+	var content string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".edl":
+		content = formatEDL(sorted, fps, startFrame, drop)
+	case ".txt":
+		content = formatYouTube(sorted, duration)
+	default:
+		content = formatFFMetadata(sorted, duration)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write events: %w", err)
+	}
+	return nil
+}
+
+// formatEDL writes each event as a one-frame event with a Resolve-style
+// marker comment; spans carry their length in the |D: duration field.
+func formatEDL(events []Event, fps float64, startFrame int, drop bool) string {
+	var b strings.Builder
+	if drop {
+		b.WriteString("TITLE: DNA events\nFCM: DROP FRAME\n\n")
+	} else {
+		b.WriteString("TITLE: DNA events\nFCM: NON-DROP FRAME\n\n")
+	}
+	for i, e := range events {
+		in := recordTimecode(e.Start, fps, startFrame, drop)
+		out := recordTimecode(e.Start+1/fps, fps, startFrame, drop)
+		frames := max(int(math.Round((e.End-e.Start)*fps)), 1)
+		fmt.Fprintf(&b, "%03d  001      V     C        %s %s %s %s\n", i+1, in, out, in, out)
+		fmt.Fprintf(&b, " |C:%s |M:%s |D:%d\n\n", markerColor(e.Kind), eventTitle(e), frames)
+	}
+	return b.String()
+}
+
+// formatFFMetadata writes one chapter per event. Point events run until the
+// next event (or the end).
+func formatFFMetadata(events []Event, duration float64) string {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	for i, e := range events {
+		end := e.End
+		if end <= e.Start {
+			end = duration
+			if i+1 < len(events) {
+				end = events[i+1].Start
+			}
+		}
+		fmt.Fprintf(&b, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			int64(e.Start*1000), int64(math.Max(end, e.Start)*1000), escapeFFMetadata(eventTitle(e)))
+	}
+	return b.String()
+}
+
+// formatYouTube writes a chapter list following YouTube's rules: the first
+// chapter starts at 0:00 and chapters are at least 10 seconds apart.
+func formatYouTube(events []Event, duration float64) string {
+	var b strings.Builder
+	long := duration >= 3600
+	last := math.Inf(-1)
+	if len(events) == 0 || events[0].Start >= youTubeMinChapter {
+		fmt.Fprintf(&b, "%s Start\n", youTubeTime(0, long))
+		last = 0
+	}
+	for i, e := range events {
+		start := e.Start
+		if i == 0 && last < 0 {
+			start = 0 // Snap a first event within 10 seconds to 0:00
+		}
+		if start-last < youTubeMinChapter || (duration > 0 && duration-start < youTubeMinChapter) {
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s\n", youTubeTime(start, long), eventTitle(e))
+		last = start
+	}
+	return b.String()
+}
+
+// eventTitle returns the label, prefixed with the kind when it adds context.
+func eventTitle(e Event) string {
+	switch {
+	case e.Label == "":
+		return e.Kind
+	case e.Kind == "" || strings.Contains(strings.ToLower(e.Label), e.Kind):
+		return e.Label
+	default:
+		return e.Kind + ": " + e.Label
+	}
+}
+
+// markerColor maps event kinds to Resolve marker colors: QC problems red,
+// structure blue, everything else green.
+func markerColor(kind string) string {
+	switch kind {
+	case "loudness", "hum", "noise", "logo", "aspect":
+		return "ResolveColorRed"
+	case "section", "speaker", "cut":
+		return "ResolveColorBlue"
+	default:
+		return "ResolveColorGreen"
+	}
+}
+
+// recordTimecode formats seconds as HH:MM:SS:FF (HH:MM:SS;FF with drop) on
+// a timeline starting at startFrame.
+func recordTimecode(seconds, fps float64, startFrame int, drop bool) string {
+	return timecode.Format(int(seconds*fps+0.5)+startFrame, fps, drop)
+}
+
+// youTubeTime formats seconds as M:SS, or H:MM:SS for long videos.
+func youTubeTime(seconds float64, long bool) string {
+	s := int(seconds)
+	if long {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// escapeFFMetadata escapes the characters FFmpeg metadata treats specially.
+func escapeFFMetadata(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '=', ';', '#', '\\', '\n':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,231 @@
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Binary .vdna layout (little endian). The level table holds absolute,
+// 8-byte aligned offsets, so a memory-mapped file can be read in place.
+//
+//	magic     [4]byte  "VDNA"
+//	version   uint16   Fingerprint layout version
+//	codec     uint8    Value encoding (Codec)
+//	reserved  uint8
+//	duration  float64  Seconds
+//	features  uint16   Values per column
+//	levels    uint16   Number of levels
+//	kind      uint8 length + bytes
+//	source    uint16 length + bytes
+//	names     features x (uint8 length + bytes)
+//	(padding to 8 bytes)
+//	table     levels x (columns uint32, reserved uint32, offset uint64)
+//	data      per level: columns x features values, each level 8-byte aligned
+var magic = [4]byte{'V', 'D', 'N', 'A'}
+
+// Codec is the encoding of feature values in a .vdna file.
+type Codec uint8
+
+const (
+	CodecUint8   Codec = 1 // Values quantized to 0-255 (1 byte, ±0.002 error)
+	CodecUint16  Codec = 2 // Values quantized to 0-65535 (2 bytes)
+	CodecFloat32 Codec = 3 // Unquantized float32 (4 bytes)
+)
+
+// size returns the bytes per value.
+func (c Codec) size() int {
+	switch c {
+	case CodecUint8:
+		return 1
+	case CodecUint16:
+		return 2
+	case CodecFloat32:
+		return 4
+	}
+	return 0
+}
+
+// ParseCodec parses "uint8", "uint16" or "float32".
+func ParseCodec(s string) (Codec, error) {
+	switch s {
+	case "uint8":
+		return CodecUint8, nil
+	case "uint16":
+		return CodecUint16, nil
+	case "float32":
+		return CodecFloat32, nil
+	}
+	return 0, fmt.Errorf("unknown fingerprint codec %q, use uint8, uint16 or float32", s)
+}
+
+// MarshalBinary encodes the fingerprint as .vdna with the given codec.
+// Quantized codecs clamp values to 0.0-1.0.
+func (f *Fingerprint) MarshalBinary(codec Codec) ([]byte, error) {
+	if codec.size() == 0 {
+		return nil, fmt.Errorf("unknown fingerprint codec %d", codec)
+	}
+	if len(f.Features) > math.MaxUint16 || len(f.Levels) > math.MaxUint16 || len(f.Source) > math.MaxUint16 || len(f.Kind) > math.MaxUint8 {
+		return nil, fmt.Errorf("fingerprint too large for .vdna")
+	}
+
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	buf.Write(magic[:])
+	binary.Write(&buf, le, uint16(f.Version))
+	buf.WriteByte(byte(codec))
+	buf.WriteByte(0)
+	binary.Write(&buf, le, f.Duration)
+	binary.Write(&buf, le, uint16(len(f.Features)))
+	binary.Write(&buf, le, uint16(len(f.Levels)))
+	buf.WriteByte(byte(len(f.Kind)))
+	buf.WriteString(f.Kind)
+	binary.Write(&buf, le, uint16(len(f.Source)))
+	buf.WriteString(f.Source)
+	for _, name := range f.Features {
+		if len(name) > math.MaxUint8 {
+			return nil, fmt.Errorf("feature name too long: %q", name)
+		}
+		buf.WriteByte(byte(len(name)))
+		buf.WriteString(name)
+	}
+	pad(&buf)
+
+	// Level table, then the data of each level
+	offset := buf.Len() + 16*len(f.Levels)
+	for _, level := range f.Levels {
+		binary.Write(&buf, le, uint32(level.Columns))
+		binary.Write(&buf, le, uint32(0))
+		binary.Write(&buf, le, uint64(offset))
+		size := level.Columns * len(f.Features) * codec.size()
+		offset += (size + 7) &^ 7
+	}
+	for _, level := range f.Levels {
+		if len(level.Data) != level.Columns {
+			return nil, fmt.Errorf("level with %d columns has %d data columns", level.Columns, len(level.Data))
+		}
+		for _, col := range level.Data {
+			if len(col) != len(f.Features) {
+				return nil, fmt.Errorf("column has %d values, expected %d", len(col), len(f.Features))
+			}
+			for _, v := range col {
+				writeValue(&buf, codec, v)
+			}
+		}
+		pad(&buf)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode parses a .vdna file from memory (e.g. a memory-mapped file).
+func Decode(data []byte) (*Fingerprint, error) {
+	r := &reader{data: data}
+	if !bytes.Equal(r.bytes(4), magic[:]) {
+		return nil, fmt.Errorf("not a .vdna fingerprint")
+	}
+	f := &Fingerprint{Version: int(r.uint16())}
+	if f.Version > Version {
+		return nil, fmt.Errorf("fingerprint version %d is newer than supported (%d)", f.Version, Version)
+	}
+	codec := Codec(r.bytes(2)[0])
+	if codec.size() == 0 {
+		return nil, fmt.Errorf("unknown fingerprint codec %d", codec)
+	}
+	f.Duration = math.Float64frombits(r.uint64())
+	numFeatures := int(r.uint16())
+	numLevels := int(r.uint16())
+	f.Kind = string(r.bytes(int(r.uint8())))
+	f.Source = string(r.bytes(int(r.uint16())))
+	for i := 0; i < numFeatures; i++ {
+		f.Features = append(f.Features, string(r.bytes(int(r.uint8()))))
+	}
+	r.pos = (r.pos + 7) &^ 7
+
+	for i := 0; i < numLevels; i++ {
+		columns := int(r.uint32())
+		r.uint32()
+		offset := int(r.uint64())
+		if r.err != nil {
+			break
+		}
+		// Every column takes at least one byte, so a count beyond the file
+		// size is corrupt; checking first keeps size from overflowing.
+		if (numFeatures == 0 && columns > 0) || columns > len(data) {
+			return nil, fmt.Errorf("corrupt .vdna fingerprint: level %d has %d columns of %d features", i, columns, numFeatures)
+		}
+		size := columns * numFeatures * codec.size()
+		if offset < 0 || offset > len(data) || size > len(data)-offset {
+			return nil, fmt.Errorf("truncated .vdna fingerprint")
+		}
+		level := Level{Columns: columns, Data: make([][]float64, columns)}
+		values := data[offset : offset+size]
+		for c := range level.Data {
+			col := make([]float64, numFeatures)
+			for k := range col {
+				col[k] = readValue(values, codec, c*numFeatures+k)
+			}
+			level.Data[c] = col
+		}
+		f.Levels = append(f.Levels, level)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return f, nil
+}
+
+func writeValue(buf *bytes.Buffer, codec Codec, v float64) {
+	switch codec {
+	case CodecUint8:
+		buf.WriteByte(byte(math.Round(clamp01(v) * 255)))
+	case CodecUint16:
+		binary.Write(buf, binary.LittleEndian, uint16(math.Round(clamp01(v)*65535)))
+	case CodecFloat32:
+		binary.Write(buf, binary.LittleEndian, float32(v))
+	}
+}
+
+func readValue(values []byte, codec Codec, i int) float64 {
+	switch codec {
+	case CodecUint8:
+		return round4(float64(values[i]) / 255)
+	case CodecUint16:
+		return round4(float64(binary.LittleEndian.Uint16(values[2*i:])) / 65535)
+	default:
+		return round4(float64(math.Float32frombits(binary.LittleEndian.Uint32(values[4*i:]))))
+	}
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// pad aligns the buffer to 8 bytes.
+func pad(buf *bytes.Buffer) {
+	for buf.Len()%8 != 0 {
+		buf.WriteByte(0)
+	}
+}
+
+// reader reads little-endian fields and remembers the first overrun.
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || r.pos+n > len(r.data) {
+		r.err = fmt.Errorf("truncated .vdna fingerprint")
+		return make([]byte, n)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) uint8() uint8   { return r.bytes(1)[0] }
+func (r *reader) uint16() uint16 { return binary.LittleEndian.Uint16(r.bytes(2)) }
+func (r *reader) uint32() uint32 { return binary.LittleEndian.Uint32(r.bytes(4)) }
+func (r *reader) uint64() uint64 { return binary.LittleEndian.Uint64(r.bytes(8)) }
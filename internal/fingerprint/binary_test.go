@@ -0,0 +1,104 @@
+package fingerprint
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func testFingerprint() *Fingerprint {
+	columns := make([][]float64, 300)
+	for i := range columns {
+		columns[i] = []float64{float64(i) / 299, 1 - float64(i)/299, 0.5}
+	}
+	return New("video", "movie.mp4", 12.5, []string{"r", "g", "b"}, columns, []int{16, 64, 256})
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	fp := testFingerprint()
+	for _, codec := range []Codec{CodecUint8, CodecUint16, CodecFloat32} {
+		data, err := fp.MarshalBinary(codec)
+		if err != nil {
+			t.Fatalf("MarshalBinary(%d): %v", codec, err)
+		}
+		got, err := Decode(data)
+		if err != nil {
+			t.Fatalf("Decode(%d): %v", codec, err)
+		}
+		if got.Version != fp.Version || got.Kind != fp.Kind || got.Source != fp.Source || got.Duration != fp.Duration {
+			t.Errorf("codec %d: header %+v, want %+v", codec, got, fp)
+		}
+		if len(got.Features) != 3 || got.Features[2] != "b" {
+			t.Errorf("codec %d: features %v", codec, got.Features)
+		}
+		if len(got.Levels) != len(fp.Levels) {
+			t.Fatalf("codec %d: %d levels, want %d", codec, len(got.Levels), len(fp.Levels))
+		}
+		tolerance := map[Codec]float64{CodecUint8: 0.0025, CodecUint16: 0.0001, CodecFloat32: 0.0001}[codec]
+		for l, level := range fp.Levels {
+			if got.Levels[l].Columns != level.Columns {
+				t.Fatalf("codec %d level %d: %d columns, want %d", codec, l, got.Levels[l].Columns, level.Columns)
+			}
+			for c, col := range level.Data {
+				for k, v := range col {
+					if d := math.Abs(got.Levels[l].Data[c][k] - v); d > tolerance {
+						t.Fatalf("codec %d level %d [%d][%d] = %v, want %v", codec, l, c, k, got.Levels[l].Data[c][k], v)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	data, err := testFingerprint().MarshalBinary(CodecUint8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < len(data); n += 7 {
+		if _, err := Decode(data[:n]); err == nil {
+			t.Errorf("Decode of %d/%d bytes: no error", n, len(data))
+		}
+	}
+}
+
+// crafted returns a header with no features and one level table entry.
+func crafted(columns uint32, offset uint64) []byte {
+	le := binary.LittleEndian
+	data := append([]byte{}, magic[:]...)
+	data = le.AppendUint16(data, Version)
+	data = append(data, byte(CodecUint8), 0)
+	data = le.AppendUint64(data, math.Float64bits(1))
+	data = le.AppendUint16(data, 0) // features
+	data = le.AppendUint16(data, 1) // levels
+	data = append(data, 0)          // kind
+	data = le.AppendUint16(data, 0) // source
+	for len(data)%8 != 0 {
+		data = append(data, 0)
+	}
+	data = le.AppendUint32(data, columns)
+	data = le.AppendUint32(data, 0)
+	return le.AppendUint64(data, offset)
+}
+
+func TestDecodeCrafted(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns uint32
+		offset  uint64
+	}{
+		{"huge column count without features", 0xFFFFFFF0, 40},
+		{"column count beyond file size", 1000, 40},
+		{"offset near MaxInt", 0, math.MaxInt64 - 2},
+		{"offset beyond file", 0, 1 << 20},
+		{"negative offset", 0, math.MaxUint64},
+	}
+	for _, tt := range tests {
+		if _, err := Decode(crafted(tt.columns, tt.offset)); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+	if _, err := Decode(crafted(0, 40)); err != nil {
+		t.Errorf("empty level: %v", err)
+	}
+}
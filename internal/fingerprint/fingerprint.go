@@ -0,0 +1,198 @@
+// Package fingerprint stores DNA features at several temporal resolutions in
+// one file, so a similarity index can match coarse-to-fine without
+// re-analyzing the media.
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the fingerprint layout version written to new files.
+const Version = 1
+
+// DefaultLevels are the temporal resolutions (columns) of a pyramid.
+var DefaultLevels = []int{64, 256, 1024, 4096}
+
+// Fingerprint is a multi-resolution feature pyramid of one media file.
+type Fingerprint struct {
+	Version  int      `json:"version"`
+	Kind     string   `json:"kind"`     // "video" or "audio"
+	Source   string   `json:"source"`   // Input file
+	Duration float64  `json:"duration"` // Seconds
+	Features []string `json:"features"` // Name of each value in a column
+	Levels   []Level  `json:"levels"`   // Coarse to fine
+}
+
+// Level is the fingerprint at one temporal resolution.
+type Level struct {
+	Columns int         `json:"columns"`
+	Data    [][]float64 `json:"data"` // Data[column][feature], 0.0 to 1.0
+}
+
+// New builds a pyramid from full-resolution columns (one per frame or
+// segment). Levels finer than the input are skipped; the input resolution is
+// used when no level fits.
+func New(kind, source string, duration float64, features []string, columns [][]float64, levels []int) *Fingerprint {
+	if len(levels) == 0 {
+		levels = DefaultLevels
+	}
+	sizes := append([]int(nil), levels...)
+	sort.Ints(sizes)
+
+	fp := &Fingerprint{Version: Version, Kind: kind, Source: source, Duration: duration, Features: features}
+	for _, n := range sizes {
+		if n <= 0 || n > len(columns) || (len(fp.Levels) > 0 && fp.Levels[len(fp.Levels)-1].Columns == n) {
+			continue
+		}
+		fp.Levels = append(fp.Levels, Level{Columns: n, Data: Resample(columns, n)})
+	}
+	if len(fp.Levels) == 0 && len(columns) > 0 {
+		fp.Levels = append(fp.Levels, Level{Columns: len(columns), Data: Resample(columns, len(columns))})
+	}
+	return fp
+}
+
+// Level returns the coarsest level with at least columns columns, or the
+// finest level when none is that fine.
+func (f *Fingerprint) Level(columns int) *Level {
+	if len(f.Levels) == 0 {
+		return nil
+	}
+	for i := range f.Levels {
+		if f.Levels[i].Columns >= columns {
+			return &f.Levels[i]
+		}
+	}
+	return &f.Levels[len(f.Levels)-1]
+}
+
+// Resample averages columns into n columns; each output column covers an
+// equal share of the input (box filter).
+func Resample(columns [][]float64, n int) [][]float64 {
+	out := make([][]float64, n)
+	if len(columns) == 0 {
+		return out
+	}
+	width := len(columns[0])
+	for i := range out {
+		from := i * len(columns) / n
+		to := max((i+1)*len(columns)/n, from+1)
+		sum := make([]float64, width)
+		for _, col := range columns[from:to] {
+			for k, v := range col {
+				sum[k] += v
+			}
+		}
+		for k := range sum {
+			sum[k] = round4(sum[k] / float64(to-from))
+		}
+		out[i] = sum
+	}
+	return out
+}
+
+// Similarity scores two fingerprints from 0.0 (unrelated) to 1.0 (same
+// content) at about the given resolution, as 1 - the mean absolute feature
+// difference. The finer of the two levels is resampled to the coarser.
+func Similarity(a, b *Fingerprint, columns int) (float64, error) {
+	if strings.Join(a.Features, ",") != strings.Join(b.Features, ",") {
+		return 0, fmt.Errorf("fingerprints have different features (%s and %s)", a.Kind, b.Kind)
+	}
+	la, lb := a.Level(columns), b.Level(columns)
+	if la == nil || lb == nil {
+		return 0, fmt.Errorf("empty fingerprint")
+	}
+	n := min(la.Columns, lb.Columns)
+	da, db := Resample(la.Data, n), Resample(lb.Data, n)
+
+	var diff float64
+	var count int
+	for i := range da {
+		for k := range da[i] {
+			diff += math.Abs(da[i][k] - db[i][k])
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("empty fingerprint")
+	}
+	return 1 - diff/float64(count), nil
+}
+
+// DefaultCodec is the .vdna value encoding used by Write.
+const DefaultCodec = CodecUint8
+
+// IsBinaryPath reports whether path selects the binary .vdna format.
+func IsBinaryPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".vdna")
+}
+
+// Write saves the fingerprint as binary .vdna (DefaultCodec) when path ends
+// in .vdna, and as JSON otherwise.
+func Write(path string, f *Fingerprint) error {
+	return WriteCodec(path, f, DefaultCodec)
+}
+
+// WriteCodec is Write with an explicit .vdna codec (ignored for JSON).
+func WriteCodec(path string, f *Fingerprint, codec Codec) error {
+	var data []byte
+	var err error
+	if IsBinaryPath(path) {
+		data, err = f.MarshalBinary(codec)
+	} else {
+		data, err = json.Marshal(f)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode fingerprint: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write fingerprint: %w", err)
+	}
+	return nil
+}
+
+// Read loads a fingerprint written by Write; the format is detected from
+// the file content.
+func Read(path string) (*Fingerprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fingerprint: %w", err)
+	}
+	if bytes.HasPrefix(data, magic[:]) {
+		return Decode(data)
+	}
+	var f Fingerprint
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fingerprint: %w", err)
+	}
+	if f.Version > Version {
+		return nil, fmt.Errorf("fingerprint version %d is newer than supported (%d)", f.Version, Version)
+	}
+	return &f, nil
+}
+
+// ParseLevels parses a comma-separated list of column counts.
+func ParseLevels(s string) ([]int, error) {
+	var levels []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid fingerprint level %q, use positive column counts like 64,256,1024", part)
+		}
+		levels = append(levels, n)
+	}
+	return levels, nil
+}
+
+// round4 keeps JSON output compact; 1e-4 is far below quantization noise.
+func round4(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
@@ -0,0 +1,169 @@
+// Package font is the 5x7 bitmap font of the legends, labels and lanes
+// drawn on DNA images. It has lowercase glyphs only, so text is drawn
+// lowercase, including the Latin letters with diacritics used by the
+// French, German and Spanish translations. Text in other scripts is drawn
+// by ffmpeg with a font file (shape.go).
+package font
+
+import (
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Height is the glyph height in pixels at scale 1.
+const Height = 7
+
+// advance is the width of a glyph plus spacing, also skipped for runes
+// without a glyph.
+const advance = 6
+
+// Width returns the rendered width of text in pixels at scale 1.
+func Width(text string) int {
+	if needsShaping(text) {
+		if mask := shapedMask(text, 1); mask != nil {
+			return mask.Rect.Dx()
+		}
+	}
+	w := 0
+	for _, ch := range strings.ToLower(text) {
+		if pattern, ok := glyphs[ch]; ok {
+			w += len(pattern[0]) + 1
+		} else {
+			w += advance
+		}
+	}
+	return w
+}
+
+// Draw draws text with its top left corner at x, y, with every font pixel
+// enlarged to scale x scale. Text with letters the bitmap font lacks
+// (Arabic, Hebrew, CJK, ...) is drawn with a font file instead, see
+// SetFile.
+func Draw(img *image.RGBA, text string, x, y int, c color.RGBA, scale int) {
+	if needsShaping(text) {
+		if mask := shapedMask(text, scale); mask != nil {
+			drawMask(img, mask, x, y, c, scale)
+			return
+		}
+	}
+	for _, ch := range strings.ToLower(text) {
+		pattern, ok := glyphs[ch]
+		if !ok {
+			x += advance * scale // space for unknown chars
+			continue
+		}
+
+		for dy, row := range pattern {
+			for dx, pixel := range row {
+				if pixel == '#' {
+					for sy := 0; sy < scale; sy++ {
+						for sx := 0; sx < scale; sx++ {
+							img.SetRGBA(x+dx*scale+sx, y+dy*scale+sy, c)
+						}
+					}
+				}
+			}
+		}
+		x += (len(pattern[0]) + 1) * scale // char width + spacing
+	}
+}
+
+// glyphs maps runes to 5x7 patterns, "#" = ink.
+var glyphs = map[rune][]string{
+	'a': {"..#..", ".#.#.", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'b': {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+	'c': {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+	'd': {"####.", "#...#", "#...#", "#...#", "#...#", "#...#", "####."},
+	'e': {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'f': {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+	'g': {".###.", "#....", "#....", "#.###", "#...#", "#...#", ".###."},
+	'h': {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'i': {".###.", "..#..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'j': {"..###", "...#.", "...#.", "...#.", "#..#.", "#..#.", ".##.."},
+	'k': {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+	'l': {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+	'm': {"#...#", "##.##", "#.#.#", "#...#", "#...#", "#...#", "#...#"},
+	'n': {"#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#", "#...#"},
+	'o': {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'p': {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+	'q': {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
+	'r': {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+	's': {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
+	't': {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'u': {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'v': {"#...#", "#...#", "#...#", "#...#", ".#.#.", ".#.#.", "..#.."},
+	'w': {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "##.##", "#...#"},
+	'x': {"#...#", ".#.#.", "..#..", "..#..", "..#..", ".#.#.", "#...#"},
+	'y': {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+	'z': {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
+	'0': {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2': {".###.", "#...#", "....#", "..##.", ".#...", "#....", "#####"},
+	'3': {".###.", "#...#", "....#", "..##.", "....#", "#...#", ".###."},
+	'4': {"#...#", "#...#", "#...#", "#####", "....#", "....#", "....#"},
+	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6': {".###.", "#....", "####.", "#...#", "#...#", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#...."},
+	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9': {".###.", "#...#", "#...#", ".####", "....#", "....#", ".###."},
+	'.': {".....", ".....", ".....", ".....", ".....", "..#..", "..#.."},
+	':': {".....", "..#..", "..#..", ".....", "..#..", "..#..", "....."},
+	';': {".....", "..#..", "..#..", ".....", "..#..", "..#..", ".#..."},
+	'|': {"..#..", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'-': {".....", ".....", ".....", "#####", ".....", ".....", "....."},
+	'_': {".....", ".....", ".....", ".....", ".....", ".....", "#####"},
+	' ': {".....", ".....", ".....", ".....", ".....", ".....", "....."},
+	'(': {"...#.", "..#..", ".#...", ".#...", ".#...", "..#..", "...#."},
+	')': {".#...", "..#..", "...#.", "...#.", "...#.", "..#..", ".#..."},
+	',': {".....", ".....", ".....", ".....", "..#..", "..#..", ".#..."},
+	'/': {"....#", "....#", "...#.", "..#..", ".#...", "#....", "#...."},
+}
+
+// Marks drawn in the top row of accented letters.
+const (
+	acute      = "...#."
+	grave      = ".#..."
+	circumflex = ".###."
+	diaeresis  = ".#.#."
+	tilde      = ".##.#"
+)
+
+// accented lists the letters with diacritics: base letter and mark.
+var accented = map[rune]struct {
+	base rune
+	mark string
+}{
+	'á': {'a', acute}, 'à': {'a', grave}, 'â': {'a', circumflex}, 'ä': {'a', diaeresis},
+	'é': {'e', acute}, 'è': {'e', grave}, 'ê': {'e', circumflex}, 'ë': {'e', diaeresis},
+	'í': {'i', acute}, 'ì': {'i', grave}, 'î': {'i', circumflex}, 'ï': {'i', diaeresis},
+	'ó': {'o', acute}, 'ò': {'o', grave}, 'ô': {'o', circumflex}, 'ö': {'o', diaeresis},
+	'ú': {'u', acute}, 'ù': {'u', grave}, 'û': {'u', circumflex}, 'ü': {'u', diaeresis},
+	'ÿ': {'y', diaeresis}, 'ñ': {'n', tilde},
+}
+
+func init() {
+	for r, a := range accented {
+		glyphs[r] = append([]string{a.mark}, squeeze(glyphs[a.base])...)
+	}
+	// Cedilla below a squeezed c
+	glyphs['ç'] = append(squeeze(glyphs['c']), "..#..")
+	glyphs['ß'] = []string{".##..", "#..#.", "#..#.", "#.#..", "#..#.", "#..#.", "#.##."}
+	glyphs['¿'] = []string{"..#..", ".....", "..#..", ".#...", "#....", "#...#", ".###."}
+	glyphs['¡'] = []string{"..#..", ".....", "..#..", "..#..", "..#..", "..#..", "..#.."}
+	glyphs['\''] = []string{"..#..", "..#..", ".....", ".....", ".....", ".....", "....."}
+}
+
+// squeeze drops one row of a 7-row glyph, the last one repeating the row
+// below it, to make room for a mark.
+func squeeze(rows []string) []string {
+	drop := 1
+	for i := len(rows) - 2; i >= 0; i-- {
+		if rows[i] == rows[i+1] {
+			drop = i
+			break
+		}
+	}
+	out := append([]string{}, rows[:drop]...)
+	return append(out, rows[drop+1:]...)
+}
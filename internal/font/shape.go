@@ -0,0 +1,191 @@
+package font
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/pforret/videodna/internal/toolexec"
+	"github.com/pforret/videodna/internal/workdir"
+)
+
+// EnvFile names the environment variable with the default font file.
+const EnvFile = "VIDEODNA_FONT"
+
+// fontFile is the TrueType/OpenType font of text the bitmap font cannot
+// draw ("" = the first of systemFonts found).
+var fontFile = os.Getenv(EnvFile)
+
+// SetFile sets the font file of scripts without bitmap glyphs; it overrides
+// VIDEODNA_FONT. An empty path keeps the environment setting.
+func SetFile(path string) {
+	if path != "" {
+		fontFile = path
+	}
+}
+
+// workDir holds the temp files of shaped text (nil = the system temp
+// directory).
+var workDir *workdir.Manager
+
+// SetWorkdir puts the temp files of shaped text in the run directory of m.
+func SetWorkdir(m *workdir.Manager) {
+	workDir = m
+}
+
+// systemFonts are common fonts with wide coverage, tried in order when no
+// font file is set. Noto Sans CJK has CJK, DejaVu Sans has Arabic and
+// Hebrew; set a font covering all scripts of your names if you mix them.
+var systemFonts = []string{
+	"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/google-noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+	"/usr/share/fonts/dejavu/DejaVuSans.ttf",
+	"/System/Library/Fonts/Supplemental/Arial Unicode.ttf",
+	"/Library/Fonts/Arial Unicode.ttf",
+	`C:\Windows\Fonts\arialuni.ttf`,
+}
+
+// needsShaping reports whether text has letters without a bitmap glyph:
+// Arabic, Hebrew, CJK, Cyrillic, ... These are drawn with the font file.
+func needsShaping(text string) bool {
+	for _, ch := range strings.ToLower(text) {
+		if _, ok := glyphs[ch]; !ok && unicode.IsLetter(ch) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	shapedMu    sync.Mutex
+	shapedCache = map[string]*image.Alpha{}
+	warnOnce    sync.Once
+)
+
+// shapedMask returns the coverage mask of text drawn at scale, or nil when
+// it cannot be rendered; the bitmap font is used then, leaving gaps.
+func shapedMask(text string, scale int) *image.Alpha {
+	key := fmt.Sprintf("%d\x00%s", scale, text)
+	shapedMu.Lock()
+	defer shapedMu.Unlock()
+	if mask, ok := shapedCache[key]; ok {
+		return mask
+	}
+	mask, err := renderShaped(text, scale)
+	if err != nil {
+		warnOnce.Do(func() {
+			fmt.Fprintf(os.Stderr, "Warning: cannot draw %q, non-Latin text is left blank (set -font or $%s to a font with its script): %v\n", text, EnvFile, err)
+		})
+	}
+	shapedCache[key] = mask
+	return mask
+}
+
+// renderShaped draws text with ffmpeg's drawtext filter, which orders
+// right-to-left runs and shapes Arabic (fribidi/harfbuzz builds) and draws
+// any glyph of the font file, and returns the coverage mask cropped to the
+// text width. The text is 7 pixels tall per scale, like the bitmap font,
+// with room for ascenders and descenders above and below. The text goes
+// through a file, which needs no filtergraph escaping, and expansion=none
+// keeps "%{...}" in names and labels literal.
+func renderShaped(text string, scale int) (*image.Alpha, error) {
+	path := fontFile
+	if path == "" {
+		for _, candidate := range systemFonts {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+		if path == "" {
+			return nil, fmt.Errorf("no font file found")
+		}
+	}
+
+	dir, err := workDir.MkdirTemp("videodna-text-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	textPath := filepath.Join(dir, "text.txt")
+	if err := os.WriteFile(textPath, []byte(text), 0644); err != nil {
+		return nil, err
+	}
+
+	size := 10 * scale
+	w, h := min(size*(len([]rune(text))+1), 8192), shapedHeight*scale
+	filter := fmt.Sprintf("drawtext=fontfile=%s:textfile=%s:expansion=none:fontsize=%d:fontcolor=white:x=0:y=(h-%d)/2",
+		escapeFilterValue(path), escapeFilterValue(textPath), size, size)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cmd := toolexec.Command(ctx, "ffmpeg", "-v", "error", "-f", "lavfi", "-i", fmt.Sprintf("color=c=black:s=%dx%d", w, h),
+		"-vf", filter, "-frames:v", "1", "-f", "rawvideo", "-pix_fmt", "gray", "-")
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+		return nil, fmt.Errorf("ffmpeg drawtext: %s", strings.TrimSpace(string(exit.Stderr)))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg drawtext: %w", err)
+	}
+	if len(out) != w*h {
+		return nil, fmt.Errorf("ffmpeg drawtext: got %d bytes, expected %d", len(out), w*h)
+	}
+
+	// Crop to the last inked column
+	width := 0
+	for y := 0; y < h; y++ {
+		for x := width; x < w; x++ {
+			if out[y*w+x] != 0 {
+				width = x + 1
+			}
+		}
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, width, h))
+	for y := 0; y < h; y++ {
+		copy(mask.Pix[y*mask.Stride:], out[y*w:y*w+width])
+	}
+	return mask, nil
+}
+
+// shapedHeight is the mask height per scale, centered on the 7 pixel rows
+// of the bitmap font.
+const shapedHeight = 13
+
+// drawMask blends c into img through mask, centered on the bitmap text
+// rows starting at y, and returns the mask width.
+func drawMask(img *image.RGBA, mask *image.Alpha, x, y int, c color.RGBA, scale int) int {
+	top := y + (Height*scale-mask.Rect.Dy())/2
+	b := mask.Bounds()
+	for my := 0; my < b.Dy(); my++ {
+		for mx := 0; mx < b.Dx(); mx++ {
+			a := uint32(mask.AlphaAt(mx, my).A)
+			if a == 0 || !(image.Point{x + mx, top + my}.In(img.Rect)) {
+				continue
+			}
+			bg := img.RGBAAt(x+mx, top+my)
+			blend := func(fg, bg uint8) uint8 { return uint8((uint32(fg)*a + uint32(bg)*(255-a)) / 255) }
+			img.SetRGBA(x+mx, top+my, color.RGBA{R: blend(c.R, bg.R), G: blend(c.G, bg.G), B: blend(c.B, bg.B), A: 255})
+		}
+	}
+	return b.Dx()
+}
+
+// escapeFilterValue escapes a filter option value for a filtergraph: once
+// for the option parser, once for the graph parser.
+func escapeFilterValue(s string) string {
+	option := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(s)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`).Replace(option)
+}
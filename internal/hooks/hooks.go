@@ -0,0 +1,117 @@
+// Package hooks runs user commands at defined points of the DNA pipeline, so
+// custom logic (chat notifications, DAM ingestion, ...) can be added without
+// forking. Each hook receives a JSON Context on stdin.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Point is a pipeline point at which hooks run.
+type Point string
+
+const (
+	AfterProbe    Point = "after-probe"    // Input probed, before decoding; a failing hook aborts the run
+	AfterGenerate Point = "after-generate" // Output written
+	BeforeUpload  Point = "before-upload"  // Output rendered, before delivery to Config.ImageSink/ReportSink; a failing hook aborts the run
+	OnError       Point = "on-error"       // Run failed; Context.Error holds the message
+)
+
+// Points lists the supported hook points.
+var Points = []Point{AfterProbe, AfterGenerate, BeforeUpload, OnError}
+
+// Context is the JSON document passed to hooks.
+type Context struct {
+	Point   Point          `json:"point"`
+	Tool    string         `json:"tool"` // "videodna" or "audiodna"
+	Input   string         `json:"input"`
+	Output  string         `json:"output,omitempty"`
+	Info    map[string]any `json:"info,omitempty"` // Probed media properties
+	Error   string         `json:"error,omitempty"`
+	Elapsed float64        `json:"elapsed,omitempty"` // Seconds since the start (after-generate, on-error)
+}
+
+// Hook is custom logic run at a pipeline point. Command implements it for
+// external programs; Go callers can register their own implementations.
+type Hook interface {
+	Run(ctx context.Context, c Context) error
+}
+
+// Command is a hook that runs a shell command with the Context as JSON on
+// stdin and VIDEODNA_HOOK set to the point name. Its output is passed through
+// to stderr so it does not mix with progress output.
+type Command string
+
+// Run implements Hook.
+func (cmd Command) Run(ctx context.Context, c Context) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	proc := exec.CommandContext(ctx, shell, flag, string(cmd))
+	proc.Stdin = bytes.NewReader(data)
+	proc.Stdout = os.Stderr
+	proc.Stderr = os.Stderr
+	proc.Env = append(os.Environ(), "VIDEODNA_HOOK="+string(c.Point))
+	if err := proc.Run(); err != nil {
+		return fmt.Errorf("%s hook %q failed: %w", c.Point, string(cmd), err)
+	}
+	return nil
+}
+
+// Runner holds the hooks of one run. A nil Runner runs nothing.
+type Runner struct {
+	tool  string
+	hooks map[Point][]Hook
+}
+
+// NewRunner returns an empty runner for a tool.
+func NewRunner(tool string) *Runner {
+	return &Runner{tool: tool, hooks: map[Point][]Hook{}}
+}
+
+// Add registers a hook at a point; hooks run in the order they are added.
+func (r *Runner) Add(point Point, hook Hook) {
+	r.hooks[point] = append(r.hooks[point], hook)
+}
+
+// AddSpec registers a Command from a "point=command" specification.
+func (r *Runner) AddSpec(spec string) error {
+	name, command, ok := strings.Cut(spec, "=")
+	if !ok || strings.TrimSpace(command) == "" {
+		return fmt.Errorf("invalid hook %q, use POINT=COMMAND", spec)
+	}
+	point := Point(strings.TrimSpace(name))
+	for _, p := range Points {
+		if p == point {
+			r.Add(point, Command(command))
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown hook point %q, use after-probe, after-generate, before-upload or on-error", name)
+}
+
+// Run calls the hooks registered at c.Point and stops at the first error.
+func (r *Runner) Run(ctx context.Context, c Context) error {
+	if r == nil {
+		return nil
+	}
+	c.Tool = r.tool
+	for _, hook := range r.hooks[c.Point] {
+		if err := hook.Run(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Package icc builds and loads ICC color profiles and tags PNG output with
+// them, so color managed viewers show the DNA colors as intended.
+package icc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Built-in profile names accepted by Resolve.
+const (
+	SRGBName   = "srgb"   // sRGB, what browsers and image viewers assume
+	Rec709Name = "rec709" // BT.709 primaries with the BT.1886 gamma 2.4 of grading monitors
+	NoneName   = "none"   // No profile
+)
+
+// Profile is an ICC profile to embed in output images.
+type Profile struct {
+	Name string // Short name, stored in the PNG iCCP chunk
+	Data []byte // Profile bytes
+}
+
+// Resolve returns the profile for a built-in name or the path of an .icc
+// or .icm file. "" is sRGB; "none" returns nil.
+func Resolve(spec string) (*Profile, error) {
+	switch strings.ToLower(spec) {
+	case "", SRGBName:
+		return SRGB(), nil
+	case Rec709Name:
+		return Rec709(), nil
+	case NoneName:
+		return nil, nil
+	}
+	return Load(spec)
+}
+
+// Load reads an ICC profile file.
+func Load(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ICC profile: %w", err)
+	}
+	if len(data) < 132 || string(data[36:40]) != "acsp" || binary.BigEndian.Uint32(data) != uint32(len(data)) {
+		return nil, fmt.Errorf("%s is not an ICC profile", path)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return &Profile{Name: name, Data: data}, nil
+}
+
+// SRGB returns an sRGB display profile with the piecewise sRGB tone curve.
+func SRGB() *Profile {
+	curve := make([]uint16, 1024)
+	for i := range curve {
+		v := float64(i) / float64(len(curve)-1)
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		curve[i] = uint16(math.Round(v * 65535))
+	}
+	return &Profile{Name: "sRGB", Data: build("sRGB IEC61966-2.1", curve)}
+}
+
+// Rec709 returns a BT.709 display profile with a pure gamma 2.4 tone curve
+// (BT.1886 with a zero black level), matching a reference grading monitor.
+func Rec709() *Profile {
+	return &Profile{Name: "Rec709", Data: build("Rec. ITU-R BT.709 (gamma 2.4)", []uint16{uint16(math.Round(2.4 * 256))})}
+}
+
+// build returns an ICC v2 RGB display profile with BT.709 primaries, a D65
+// white point adapted to the D50 connection space, and curve as the tone
+// curve of all channels: a single u8Fixed8 gamma or a table.
+func build(description string, curve []uint16) []byte {
+	type tag struct {
+		sig  string
+		data []byte
+	}
+	d50 := [3]float64{0.9642, 1.0, 0.8249}
+	trc := tagData("curv", uint32(len(curve)), curve)
+	tags := []tag{
+		{"desc", textDescription(description)},
+		{"cprt", tagData("text", []byte("No copyright, use freely\x00"))},
+		{"wtpt", xyz(d50)},
+		// Bradford adapted colorants of the BT.709/sRGB primaries
+		{"rXYZ", xyz([3]float64{0.4360747, 0.2225045, 0.0139322})},
+		{"gXYZ", xyz([3]float64{0.3850649, 0.7168786, 0.0971045})},
+		{"bXYZ", xyz([3]float64{0.1430804, 0.0606169, 0.7141733})},
+		{"rTRC", trc},
+		{"gTRC", trc},
+		{"bTRC", trc},
+	}
+
+	// Header, tag table, then the tag data at 4 byte boundaries. The tone
+	// curves share one copy.
+	offset := 128 + 4 + 12*len(tags)
+	var table, body bytes.Buffer
+	binary.Write(&table, binary.BigEndian, uint32(len(tags)))
+	shared := map[*byte]int{}
+	for _, t := range tags {
+		at, ok := shared[&t.data[0]]
+		if !ok {
+			at = offset + body.Len()
+			shared[&t.data[0]] = at
+			body.Write(t.data)
+			for body.Len()%4 != 0 {
+				body.WriteByte(0)
+			}
+		}
+		table.WriteString(t.sig)
+		binary.Write(&table, binary.BigEndian, [2]uint32{uint32(at), uint32(len(t.data))})
+	}
+
+	size := offset + body.Len()
+	header := make([]byte, 128)
+	be := binary.BigEndian
+	be.PutUint32(header[0:], uint32(size))
+	be.PutUint32(header[8:], 0x02100000) // Version 2.1
+	copy(header[12:], "mntrRGB XYZ ")
+	for i, v := range []uint16{2024, 1, 1} { // Creation date, fixed for reproducible output
+		be.PutUint16(header[24+2*i:], v)
+	}
+	copy(header[36:], "acsp")
+	copy(header[68:], xyz(d50)[8:])
+
+	out := make([]byte, 0, size)
+	out = append(out, header...)
+	out = append(out, table.Bytes()...)
+	return append(out, body.Bytes()...)
+}
+
+// tagData returns a tag of the given type: signature, reserved bytes, then
+// the big-endian values.
+func tagData(typ string, values ...any) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(typ)
+	buf.Write([]byte{0, 0, 0, 0})
+	for _, v := range values {
+		binary.Write(&buf, binary.BigEndian, v)
+	}
+	return buf.Bytes()
+}
+
+// xyz returns an XYZType tag of one s15Fixed16 XYZ value.
+func xyz(v [3]float64) []byte {
+	var fixed [3]int32
+	for i := range v {
+		fixed[i] = int32(math.Round(v[i] * 65536))
+	}
+	return tagData("XYZ ", fixed)
+}
+
+// textDescription returns a v2 textDescriptionType tag with an ASCII
+// description and empty Unicode and ScriptCode parts.
+func textDescription(s string) []byte {
+	ascii := append([]byte(s), 0)
+	return tagData("desc", uint32(len(ascii)), ascii, uint32(0), uint32(0), uint16(0), uint8(0), [67]byte{})
+}
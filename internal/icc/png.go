@@ -0,0 +1,64 @@
+package icc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+)
+
+// Chunk is an extra PNG chunk, e.g. a text chunk.
+type Chunk struct {
+	Type string
+	Data []byte
+}
+
+// EncodePNG writes img as PNG with the profile in an iCCP chunk and the
+// extra chunks right after the IHDR chunk. A nil profile writes no iCCP.
+func EncodePNG(w io.Writer, img image.Image, p *Profile, chunks ...Chunk) error {
+	if p == nil && len(chunks) == 0 {
+		return png.Encode(w, img)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	if p != nil {
+		// iCCP: profile name, null, compression method 0, zlib data
+		var data bytes.Buffer
+		data.WriteString(p.Name)
+		data.Write([]byte{0, 0})
+		zw := zlib.NewWriter(&data)
+		zw.Write(p.Data)
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		chunks = append([]Chunk{{"iCCP", data.Bytes()}}, chunks...)
+	}
+
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4 // Signature + IHDR chunk
+	encoded := buf.Bytes()
+	if _, err := w.Write(encoded[:ihdrEnd]); err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		if err := writeChunk(w, c.Type, c.Data); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(encoded[ihdrEnd:])
+	return err
+}
+
+func writeChunk(w io.Writer, kind string, data []byte) error {
+	chunk := make([]byte, 0, len(data)+12)
+	chunk = binary.BigEndian.AppendUint32(chunk, uint32(len(data)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, data...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	_, err := w.Write(chunk)
+	return err
+}
@@ -0,0 +1,8 @@
+// Package imagesize holds the output image size limits shared by videodna
+// and audiodna.
+package imagesize
+
+// DefaultMaxDimension is the default cap of the time axis in pixels; PNG
+// encoders and image viewers choke on larger images (an hour at 24 columns
+// per second is 86400 pixels).
+const DefaultMaxDimension = 65535
@@ -0,0 +1,124 @@
+// Package lanespec parses layout specs that set the order, height and
+// visibility of the lanes of a DNA image: video DNA, audio stems and
+// metric lanes.
+package lanespec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Lane configures one lane, matched by name (case-insensitive) to the lane
+// label: a stem ("vocals"), an analysis lane ("cuts") or a time series.
+type Lane struct {
+	Name   string `json:"name"`
+	Height int    `json:"height"` // Pixels (0 = the default lane height)
+	Hidden bool   `json:"hidden"` // Leave the lane out of the image
+}
+
+// Spec lists lanes in the order they are drawn. Lanes it does not name
+// follow in their default order, at the default height.
+type Spec []Lane
+
+// Parse reads a spec from a .json file (an array of lanes) or from a
+// comma-separated list of name[:height] entries, where a leading "-" hides
+// the lane: "vocals:120,drums,-bass".
+func Parse(s string) (Spec, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if strings.EqualFold(filepath.Ext(s), ".json") {
+		data, err := os.ReadFile(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read lane spec: %w", err)
+		}
+		var spec Spec
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return nil, fmt.Errorf("failed to parse lane spec %s: %w", filepath.Base(s), err)
+		}
+		return spec, spec.Validate()
+	}
+
+	var spec Spec
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		var lane Lane
+		if rest, ok := strings.CutPrefix(entry, "-"); ok {
+			lane.Hidden = true
+			entry = rest
+		}
+		name, height, ok := strings.Cut(entry, ":")
+		lane.Name = strings.TrimSpace(name)
+		if ok {
+			h, err := strconv.Atoi(strings.TrimSpace(height))
+			if err != nil {
+				return nil, fmt.Errorf("invalid height %q of lane %q", height, lane.Name)
+			}
+			lane.Height = h
+		}
+		spec = append(spec, lane)
+	}
+	return spec, spec.Validate()
+}
+
+// Validate reports an error for unnamed lanes and negative heights.
+func (s Spec) Validate() error {
+	for _, lane := range s {
+		if lane.Name == "" {
+			return fmt.Errorf("lane spec entry without a name")
+		}
+		if lane.Height < 0 {
+			return fmt.Errorf("height of lane %q must not be negative", lane.Name)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the entry for name.
+func (s Spec) Lookup(name string) (Lane, bool) {
+	for _, lane := range s {
+		if strings.EqualFold(lane.Name, name) {
+			return lane, true
+		}
+	}
+	return Lane{}, false
+}
+
+// Arrange applies the spec to lanes with the given names. It returns the
+// indexes of the visible lanes in drawing order with their heights (0 =
+// default), and the spec names that matched no lane. An entry matches the
+// first lane of its name not matched before, so repeated names address
+// lanes of the same label in turn.
+func (s Spec) Arrange(names []string) (order, heights []int, unknown []string) {
+	used := make([]bool, len(names))
+	for _, lane := range s {
+		idx := -1
+		for i, name := range names {
+			if !used[i] && strings.EqualFold(name, lane.Name) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			unknown = append(unknown, lane.Name)
+			continue
+		}
+		used[idx] = true
+		if !lane.Hidden {
+			order = append(order, idx)
+			heights = append(heights, lane.Height)
+		}
+	}
+	for i := range names {
+		if !used[i] {
+			order = append(order, i)
+			heights = append(heights, 0)
+		}
+	}
+	return order, heights, unknown
+}
@@ -0,0 +1,74 @@
+// Package locale translates the user-visible text of videodna and audiodna:
+// legend fields, lane and stem labels drawn on the images, and settings
+// errors. Messages are keyed by their English text (format strings for
+// messages with values), so a message without a translation stays English.
+package locale
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Locale is a language of the translations.
+type Locale string
+
+// Supported locales.
+const (
+	English Locale = "en"
+	French  Locale = "fr"
+	German  Locale = "de"
+	Spanish Locale = "es"
+)
+
+// Locales lists the supported locales.
+var Locales = []Locale{English, French, German, Spanish}
+
+// EnvLocale names the environment variable with the default locale.
+const EnvLocale = "VIDEODNA_LOCALE"
+
+// Parse returns the locale of a language tag such as "fr", "de-CH" or
+// "es_ES.UTF-8"; the region and encoding are ignored. An empty tag is
+// English.
+func Parse(tag string) (Locale, error) {
+	if tag == "" {
+		return English, nil
+	}
+	lang, _, _ := strings.Cut(strings.ToLower(tag), ".")
+	lang, _, _ = strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+	for _, l := range Locales {
+		if Locale(lang) == l {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported locale %q, use en, fr, de or es", tag)
+}
+
+// Default returns the locale set in $VIDEODNA_LOCALE, or English when it
+// is unset or unsupported.
+func Default() Locale {
+	l, err := Parse(os.Getenv(EnvLocale))
+	if err != nil {
+		return English
+	}
+	return l
+}
+
+// T translates an English message into l.
+func (l Locale) T(msg string) string {
+	if t, ok := messages[msg][l]; ok {
+		return t
+	}
+	return msg
+}
+
+// Sprintf formats the translation of an English format string.
+func (l Locale) Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(l.T(format), args...)
+}
+
+// Errorf returns an error with the translation of an English format
+// string; %w wraps as in fmt.Errorf.
+func (l Locale) Errorf(format string, args ...any) error {
+	return fmt.Errorf(l.T(format), args...)
+}
@@ -0,0 +1,127 @@
+package locale
+
+// messages maps English messages to their translations. Image text is
+// drawn lowercase by the bitmap font.
+var messages = map[string]map[Locale]string{
+	// CLI
+	"Error": {French: "Erreur", German: "Fehler", Spanish: "Error"},
+
+	// Video legend and lanes
+	"%.1ffps": {French: "%.1f i/s", German: "%.1f B/s", Spanish: "%.1f fps"},
+	"%df":     {French: "%d images", German: "%d Bilder", Spanish: "%d fotogramas"},
+	"cuts":    {French: "coupes", German: "Schnitte", Spanish: "cortes"},
+	"skin":    {French: "peau", German: "Haut", Spanish: "piel"},
+	"text":    {French: "texte", German: "Text", Spanish: "texto"},
+	"aspect":  {French: "format", German: "Format", Spanish: "aspecto"},
+
+	// Run time (legend and label bar)
+	"took %.1fs":            {French: "durée %.1fs", German: "Dauer %.1fs", Spanish: "duración %.1fs"},
+	"took %.1fs (%s %.1fs)": {French: "durée %.1fs (%s %.1fs)", German: "Dauer %.1fs (%s %.1fs)", Spanish: "duración %.1fs (%s %.1fs)"},
+	"probe":                 {French: "analyse", German: "Analyse", Spanish: "análisis"},
+	"decode":                {French: "décodage", German: "Dekodierung", Spanish: "decodificación"},
+	"separation":            {French: "séparation", German: "Trennung", Spanish: "separación"},
+	"waveform":              {French: "forme d'onde", German: "Wellenform", Spanish: "forma de onda"},
+	"render":                {French: "rendu", German: "Rendern", Spanish: "renderizado"},
+	"encode":                {French: "encodage", German: "Kodierung", Spanish: "codificación"},
+
+	// Audio stems and label bar
+	"vocals":                     {French: "voix", German: "Gesang", Spanish: "voz"},
+	"drums":                      {French: "batterie", German: "Schlagzeug", Spanish: "batería"},
+	"bass":                       {French: "basse", German: "Bass", Spanish: "bajo"},
+	"other":                      {French: "autres", German: "Andere", Spanish: "otros"},
+	"piano":                      {French: "piano", German: "Klavier", Spanish: "piano"},
+	"guitar":                     {French: "guitare", German: "Gitarre", Spanish: "guitarra"},
+	"mixed":                      {French: "mixage", German: "Mischung", Spanish: "mezcla"},
+	"mix":                        {French: "mix", German: "Mix", Spanish: "mezcla"},
+	"pass":                       {French: "conforme", German: "bestanden", Spanish: "conforme"},
+	"fail":                       {French: "non conforme", German: "nicht bestanden", Spanish: "no conforme"},
+	"audio %.2fs short of video": {French: "audio %.2fs plus court que la vidéo", German: "Audio %.2fs kürzer als Video", Spanish: "audio %.2fs más corto que el vídeo"},
+	"audio %.2fs past video end": {French: "audio %.2fs après la fin de la vidéo", German: "Audio %.2fs über Videoende", Spanish: "audio %.2fs tras el final del vídeo"},
+	"outro":                      {Spanish: "cierre"},
+	"ad":                         {French: "pub", German: "Werbung", Spanish: "anuncio"},
+
+	// Settings errors
+	"input is required": {French: "l'entrée est obligatoire", German: "Eingabe ist erforderlich", Spanish: "la entrada es obligatoria"},
+	"timeout must be positive": {
+		French: "le délai d'expiration doit être positif", German: "das Zeitlimit muss positiv sein", Spanish: "el tiempo límite debe ser positivo"},
+	"invalid mode %q, use average, min, max, common, hue or luma": {
+		French: "mode %q invalide, utilisez average, min, max, common, hue ou luma", German: "ungültiger Modus %q, verwenden Sie average, min, max, common, hue oder luma", Spanish: "modo %q no válido, use average, min, max, common, hue o luma"},
+	"%s heatmap mode has no colors for a fingerprint or alt text": {
+		French: "le mode carte de chaleur %s n'a pas de couleurs pour une empreinte ou un texte alternatif", German: "der Heatmap-Modus %s hat keine Farben für einen Fingerprint oder Alternativtext", Spanish: "el modo de mapa de calor %s no tiene colores para una huella o un texto alternativo"},
+	"invalid width %d, use a column count or auto": {
+		French: "largeur %d invalide, utilisez un nombre de colonnes ou auto", German: "ungültige Breite %d, verwenden Sie eine Spaltenzahl oder auto", Spanish: "ancho %d no válido, use un número de columnas o auto"},
+	"maximum dimension must not be negative": {
+		French: "la dimension maximale ne doit pas être négative", German: "die maximale Größe darf nicht negativ sein", Spanish: "la dimensión máxima no puede ser negativa"},
+	"resize %s exceeds the maximum dimension of %d pixels at scale %d (raise -max-dimension, 0 = no limit)": {
+		French: "le redimensionnement %s dépasse la dimension maximale de %d pixels à l'échelle %d (augmentez -max-dimension, 0 = sans limite)", German: "die Größenänderung %s überschreitet die maximale Größe von %d Pixeln bei Skalierung %d (-max-dimension erhöhen, 0 = keine Grenze)", Spanish: "el redimensionado %s supera la dimensión máxima de %d píxeles a escala %d (aumente -max-dimension, 0 = sin límite)"},
+	"invalid checksum %q, use a SHA-256 (64 hex digits) or MD5 (32 hex digits), optionally prefixed with sha256: or md5:": {
+		French: "somme de contrôle %q invalide, utilisez un SHA-256 (64 chiffres hexadécimaux) ou un MD5 (32 chiffres hexadécimaux), éventuellement préfixé par sha256: ou md5:", German: "ungültige Prüfsumme %q, SHA-256 (64 Hex-Ziffern) oder MD5 (32 Hex-Ziffern) verwenden, optional mit dem Präfix sha256: oder md5:", Spanish: "suma de comprobación %q no válida, use un SHA-256 (64 dígitos hexadecimales) o un MD5 (32 dígitos hexadecimales), opcionalmente con el prefijo sha256: o md5:"},
+	"16-bit depth is not supported with Deep Zoom output": {
+		French: "la profondeur 16 bits n'est pas prise en charge avec la sortie Deep Zoom", German: "16 Bit Farbtiefe wird mit Deep-Zoom-Ausgabe nicht unterstützt", Spanish: "la profundidad de 16 bits no es compatible con la salida Deep Zoom"},
+	"bit depth must be 8 or 16, not %d": {
+		French: "la profondeur doit être 8 ou 16 bits, pas %d", German: "die Bittiefe muss 8 oder 16 sein, nicht %d", Spanish: "la profundidad debe ser 8 o 16, no %d"},
+	"bit depth must be 16, 24, or 32, not %d": {
+		French: "la profondeur doit être 16, 24 ou 32 bits, pas %d", German: "die Bittiefe muss 16, 24 oder 32 sein, nicht %d", Spanish: "la profundidad debe ser 16, 24 o 32, no %d"},
+	"colors must be between 2 and %d, not %d": {
+		French: "le nombre de couleurs doit être entre 2 et %d, pas %d", German: "die Farbanzahl muss zwischen 2 und %d liegen, nicht %d", Spanish: "el número de colores debe estar entre 2 y %d, no %d"},
+	"an indexed PNG cannot be 16-bit": {
+		French: "un PNG indexé ne peut pas être en 16 bits", German: "ein indiziertes PNG kann nicht 16 Bit haben", Spanish: "un PNG indexado no puede ser de 16 bits"},
+	"colors needs a PNG output": {
+		French: "colors nécessite une sortie PNG", German: "colors erfordert eine PNG-Ausgabe", Spanish: "colors requiere una salida PNG"},
+	"an XMP sidecar needs a PNG or TIFF output file": {
+		French: "un fichier XMP annexe nécessite une sortie PNG ou TIFF", German: "eine XMP-Sidecar-Datei erfordert eine PNG- oder TIFF-Ausgabedatei", Spanish: "un archivo XMP adjunto requiere una salida PNG o TIFF"},
+	"a seek map needs an output image": {
+		French: "une carte de navigation nécessite une image de sortie", German: "eine Sprungkarte erfordert ein Ausgabebild", Spanish: "un mapa de navegación requiere una imagen de salida"},
+	"columns per second must not be negative": {
+		French: "le nombre de colonnes par seconde ne doit pas être négatif", German: "Spalten pro Sekunde dürfen nicht negativ sein", Spanish: "las columnas por segundo no pueden ser negativas"},
+	"use either width or columns per second, not both": {
+		French: "utilisez soit la largeur, soit les colonnes par seconde, pas les deux", German: "verwenden Sie entweder Breite oder Spalten pro Sekunde, nicht beides", Spanish: "use el ancho o las columnas por segundo, no ambos"},
+	"scale must be 1, 2, or 3, not %d": {
+		French: "l'échelle doit être 1, 2 ou 3, pas %d", German: "der Maßstab muss 1, 2 oder 3 sein, nicht %d", Spanish: "la escala debe ser 1, 2 o 3, no %d"},
+	"logo threshold must be between 0 and 1": {
+		French: "le seuil du logo doit être entre 0 et 1", German: "die Logo-Schwelle muss zwischen 0 und 1 liegen", Spanish: "el umbral del logo debe estar entre 0 y 1"},
+	"zoom is not supported with vertical output": {
+		French: "le zoom n'est pas pris en charge en sortie verticale", German: "Zoom wird bei vertikaler Ausgabe nicht unterstützt", Spanish: "el zoom no es compatible con la salida vertical"},
+	"annotations are not supported with vertical output": {
+		French: "les annotations ne sont pas prises en charge en sortie verticale", German: "Anmerkungen werden bei vertikaler Ausgabe nicht unterstützt", Spanish: "las anotaciones no son compatibles con la salida vertical"},
+	"unknown hwaccel %q, use cuda or vaapi": {
+		French: "hwaccel %q inconnu, utilisez cuda ou vaapi", German: "unbekanntes hwaccel %q, verwenden Sie cuda oder vaapi", Spanish: "hwaccel %q desconocido, use cuda o vaapi"},
+	"fingerprint levels must be positive column counts": {
+		French: "les niveaux d'empreinte doivent être des nombres de colonnes positifs", German: "Fingerprint-Stufen müssen positive Spaltenzahlen sein", Spanish: "los niveles de huella deben ser números de columnas positivos"},
+	"unknown auto-levels mode %q, use global or row": {
+		French: "mode d'auto-niveaux %q inconnu, utilisez global ou row", German: "unbekannter Auto-Tonwert-Modus %q, verwenden Sie global oder row", Spanish: "modo de niveles automáticos %q desconocido, use global o row"},
+	"adaptive columns need a width or columns per second": {
+		French: "les colonnes adaptatives nécessitent une largeur ou des colonnes par seconde", German: "adaptive Spalten benötigen eine Breite oder Spalten pro Sekunde", Spanish: "las columnas adaptativas necesitan un ancho o columnas por segundo"},
+	"the DNA lane cannot be hidden": {
+		French: "la piste de l'ADN ne peut pas être masquée", German: "die DNA-Spur kann nicht ausgeblendet werden", Spanish: "la pista del ADN no se puede ocultar"},
+	"a DNA lane height is not supported with resize": {
+		French: "une hauteur de piste ADN n'est pas prise en charge avec le redimensionnement", German: "eine DNA-Spurhöhe wird mit Größenänderung nicht unterstützt", Spanish: "una altura de pista de ADN no es compatible con el redimensionado"},
+	"saturation, vibrance and contrast must be between -1 and 3, brightness between -1 and 1": {
+		French: "la saturation, la vibrance et le contraste doivent être entre -1 et 3, la luminosité entre -1 et 1", German: "Sättigung, Dynamik und Kontrast müssen zwischen -1 und 3 liegen, die Helligkeit zwischen -1 und 1", Spanish: "la saturación, la intensidad y el contraste deben estar entre -1 y 3, el brillo entre -1 y 1"},
+	"invalid timecode base %q, use HH:MM:SS:FF": {
+		French: "timecode de départ %q invalide, utilisez HH:MM:SS:FF", German: "ungültiger Start-Timecode %q, verwenden Sie HH:MM:SS:FF", Spanish: "código de tiempo inicial %q no válido, use HH:MM:SS:FF"},
+	"unknown timecode format %q, use auto, ndf or df": {
+		French: "format de timecode %q inconnu, utilisez auto, ndf ou df", German: "unbekanntes Timecode-Format %q, verwenden Sie auto, ndf oder df", Spanish: "formato de código de tiempo %q desconocido, use auto, ndf o df"},
+	"unknown OOM fallback %q, use auto, segment, cpu, or off": {
+		French: "repli OOM %q inconnu, utilisez auto, segment, cpu ou off", German: "unbekannter OOM-Fallback %q, verwenden Sie auto, segment, cpu oder off", Spanish: "alternativa OOM %q desconocida, use auto, segment, cpu u off"},
+	"%s: not supported with a reference video": {
+		French: "%s : non pris en charge avec une vidéo de référence", German: "%s: mit einem Referenzvideo nicht unterstützt", Spanish: "%s: no se admite con un vídeo de referencia"},
+	"a reference video is required": {
+		French: "une vidéo de référence est requise", German: "ein Referenzvideo ist erforderlich", Spanish: "se requiere un vídeo de referencia"},
+	"%s needs 2-stem separation": {
+		French: "%s nécessite une séparation en 2 pistes", German: "%s erfordert eine Trennung in 2 Stems", Spanish: "%s requiere separación en 2 pistas"},
+	"width, height, resize and maximum dimension must not be negative": {
+		French: "largeur, hauteur, redimensionnement et dimension maximale ne doivent pas être négatifs", German: "Breite, Höhe, Größe und maximale Größe dürfen nicht negativ sein", Spanish: "el ancho, el alto, el tamaño y la dimensión máxima no pueden ser negativos"},
+	"overlap must be between 0.0 and 0.9, not %g": {
+		French: "le chevauchement doit être entre 0.0 et 0.9, pas %g", German: "die Überlappung muss zwischen 0.0 und 0.9 liegen, nicht %g", Spanish: "el solapamiento debe estar entre 0.0 y 0.9, no %g"},
+	"MFCC count must be between 0 and 40, not %d": {
+		French: "le nombre de MFCC doit être entre 0 et 40, pas %d", German: "die MFCC-Anzahl muss zwischen 0 und 40 liegen, nicht %d", Spanish: "el número de MFCC debe estar entre 0 y 40, no %d"},
+	"retry attempts and delay must not be negative": {
+		French: "le nombre d'essais et le délai ne doivent pas être négatifs", German: "Wiederholungen und Wartezeit dürfen nicht negativ sein", Spanish: "los reintentos y la espera no pueden ser negativos"},
+	"segments per second must not be negative": {
+		French: "le nombre de segments par seconde ne doit pas être négatif", German: "Segmente pro Sekunde dürfen nicht negativ sein", Spanish: "los segmentos por segundo no pueden ser negativos"},
+	"unknown palette %q, use default, colorblind, tol, or monochrome": {
+		French: "palette %q inconnue, utilisez default, colorblind, tol ou monochrome", German: "unbekannte Palette %q, verwenden Sie default, colorblind, tol oder monochrome", Spanish: "paleta %q desconocida, use default, colorblind, tol o monochrome"},
+	"unknown diarizer %q, use cluster or pyannote": {
+		French: "diariseur %q inconnu, utilisez cluster ou pyannote", German: "unbekannter Diarizer %q, verwenden Sie cluster oder pyannote", Spanish: "diarizador %q desconocido, use cluster o pyannote"},
+}
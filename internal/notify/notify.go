@@ -0,0 +1,147 @@
+// Package notify posts a completion message to a chat or HTTP webhook when
+// a long job finishes or fails. Slack and Discord webhook URLs get their
+// native message format; any other URL receives a JSON document.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pforret/videodna/internal/hooks"
+	"github.com/pforret/videodna/internal/retry"
+)
+
+// thumbnailWidth is the maximum width of the attached thumbnail.
+const thumbnailWidth = 640
+
+// Message is a job summary.
+type Message struct {
+	Title     string // e.g. "videodna finished"
+	Text      string // Summary lines
+	Failed    bool
+	Thumbnail []byte // PNG (nil = none)
+}
+
+// Send posts the message to url. Discord and generic webhooks include the
+// thumbnail; Slack incoming webhooks only accept text.
+func Send(ctx context.Context, url string, m Message) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var body []byte
+	contentType := "application/json"
+	switch {
+	case strings.Contains(url, "hooks.slack.com"):
+		body, _ = json.Marshal(map[string]string{"text": "*" + m.Title + "*\n" + m.Text})
+	case strings.Contains(url, "discord.com/api/webhooks"), strings.Contains(url, "discordapp.com/api/webhooks"):
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		payload, _ := json.Marshal(map[string]string{"content": "**" + m.Title + "**\n" + m.Text})
+		w.WriteField("payload_json", string(payload))
+		if m.Thumbnail != nil {
+			part, err := w.CreateFormFile("files[0]", "thumbnail.png")
+			if err != nil {
+				return err
+			}
+			part.Write(m.Thumbnail)
+		}
+		w.Close()
+		body, contentType = buf.Bytes(), w.FormDataContentType()
+	default:
+		body, _ = json.Marshal(struct {
+			Title     string `json:"title"`
+			Text      string `json:"text"`
+			Failed    bool   `json:"failed"`
+			Thumbnail []byte `json:"thumbnail,omitempty"` // Base64 PNG
+		}{m.Title, m.Text, m.Failed, m.Thumbnail})
+	}
+
+	// Network errors and server errors are retried, rejections are not
+	policy := retry.DefaultPolicy()
+	policy.Delay, policy.MaxDelay = time.Second, 5*time.Second
+	return policy.Do(ctx, "notification", func(int) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return retry.Permanent(fmt.Errorf("invalid notify URL: %w", err))
+		}
+		req.Header.Set("Content-Type", contentType)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send notification: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return retry.HTTPStatus(resp, fmt.Errorf("notification rejected: %s", resp.Status))
+		}
+		return nil
+	})
+}
+
+// Thumbnail returns a PNG of the image at path scaled down to at most
+// thumbnailWidth pixels wide, or nil when it cannot be read.
+func Thumbnail(path string) []byte {
+	if !strings.EqualFold(filepath.Ext(path), ".png") {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	src, err := png.Decode(f)
+	if err != nil {
+		return nil
+	}
+
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > thumbnailWidth {
+		w, h = thumbnailWidth, max(1, h*thumbnailWidth/w)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dst.Set(x, y, src.At(bounds.Min.X+x*bounds.Dx()/w, bounds.Min.Y+y*bounds.Dy()/h))
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// Webhook is a hook that sends a notification after generation and on
+// errors. Delivery failures are printed as warnings, never fail the run.
+type Webhook string
+
+// Run implements hooks.Hook.
+func (url Webhook) Run(ctx context.Context, c hooks.Context) error {
+	m := Message{Title: c.Tool + " finished", Text: "Input: " + c.Input + "\nOutput: " + c.Output}
+	if c.Elapsed > 0 {
+		m.Text += fmt.Sprintf("\nTook %s", time.Duration(c.Elapsed*float64(time.Second)).Round(time.Second))
+	}
+	switch c.Point {
+	case hooks.AfterGenerate:
+		m.Thumbnail = Thumbnail(c.Output)
+	case hooks.OnError:
+		m.Title, m.Failed = c.Tool+" failed", true
+		m.Text += "\nError: " + c.Error
+	default:
+		return nil
+	}
+	if err := Send(ctx, string(url), m); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// Package openapi describes HTTP endpoints as an OpenAPI 3 document. Schemas
+// are derived from the Go request and response types by reflection, so the
+// spec served to client generators cannot drift from the handlers.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI version of the documents.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                  `json:"openapi"`
+	Info       Info                    `json:"info"`
+	Paths      map[string]PathItem     `json:"paths"`
+	Components Components              `json:"components"`
+	types      map[reflect.Type]string // Struct types registered as component schemas
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lowercase HTTP methods to their operations.
+type PathItem map[string]*Operation
+
+// Operation is one endpoint method.
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a query or path parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // query or path
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body of an operation.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is one response of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the named schemas.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema is a JSON schema in the OpenAPI dialect.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+// New returns an empty document.
+func New(title, version, description string) *Document {
+	return &Document{
+		OpenAPI:    Version,
+		Info:       Info{Title: title, Version: version, Description: description},
+		Paths:      map[string]PathItem{},
+		Components: Components{Schemas: map[string]*Schema{}},
+		types:      map[reflect.Type]string{},
+	}
+}
+
+// Add registers op for method and path.
+func (d *Document) Add(method, path string, op *Operation) {
+	item := d.Paths[path]
+	if item == nil {
+		item = PathItem{}
+		d.Paths[path] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+// JSON returns the JSON content of the type of v, e.g. for a request body
+// or response. Struct types become component schemas named after the type.
+func (d *Document) JSON(v any) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: d.Schema(reflect.TypeOf(v))}}
+}
+
+// Schema returns the schema of t, following the encoding/json rules: json
+// tags name the properties, omitempty fields are optional and embedded
+// structs are flattened.
+func (d *Document) Schema(t reflect.Type) *Schema {
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == reflect.TypeOf(time.Duration(0)):
+		return &Schema{Type: "integer", Format: "int64", Description: "Nanoseconds"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := d.Schema(t.Elem())
+		if s.Ref != "" {
+			return s // $ref siblings are ignored in OpenAPI 3.0
+		}
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"} // Base64
+		}
+		return &Schema{Type: "array", Items: d.Schema(t.Elem()), Nullable: true} // nil encodes as null
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.Schema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		return d.structRef(t)
+	}
+	return &Schema{} // Any value
+}
+
+// structRef registers t as a component schema and returns a reference to it.
+func (d *Document) structRef(t reflect.Type) *Schema {
+	name, ok := d.types[t]
+	if !ok {
+		name = t.Name()
+		if name == "" {
+			return d.structSchema(t) // Anonymous struct, inlined
+		}
+		if _, taken := d.Components.Schemas[name]; taken {
+			name = path.Base(t.PkgPath()) + "." + name // Same name in another package
+		}
+		d.types[t] = name
+		d.Components.Schemas[name] = &Schema{} // Placeholder for recursive types
+		d.Components.Schemas[name] = d.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				walk(f.Type)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			s.Properties[name] = d.Schema(f.Type)
+			if !strings.Contains(opts, "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+	}
+	walk(t)
+	return s
+}
+
+// ServeHTTP serves the document as JSON.
+func (d *Document) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(d)
+}
@@ -0,0 +1,42 @@
+// Package pipe lets "-" stand for standard output as the output path, so the
+// tools compose with ImageMagick and other programs without temp files.
+package pipe
+
+import (
+	"io"
+	"os"
+)
+
+// Stdout is the output path meaning standard output.
+const Stdout = "-"
+
+// stdout is the real standard output, kept when LogsToStderr redirects
+// os.Stdout.
+var stdout = os.Stdout
+
+// IsStdout reports whether path means standard output.
+func IsStdout(path string) bool {
+	return path == Stdout
+}
+
+// LogsToStderr points os.Stdout at stderr, so progress messages printed
+// with fmt.Printf do not end up in the image written to standard output.
+// Call it before anything is printed.
+func LogsToStderr() {
+	os.Stdout = os.Stderr
+}
+
+// Create creates the file at path for writing, or returns standard output
+// for "-". Closing standard output is a no-op.
+func Create(path string) (io.WriteCloser, error) {
+	if IsStdout(path) {
+		return nopCloser{stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,141 @@
+// Package plot draws series of levels along a DNA time axis, shared by the
+// metric lanes of video DNA, user time series (telemetry such as bitrate or
+// QoE scores) and the stem waveforms of audio DNA.
+package plot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Style is how levels are drawn.
+type Style string
+
+const (
+	Bars     Style = "bars"     // Bars growing from the bottom (default)
+	Waveform Style = "waveform" // Bars mirrored around the middle, shaded towards the edges, like audio stems
+)
+
+// ParseStyle returns the style named s ("" = Bars).
+func ParseStyle(s string) (Style, error) {
+	switch Style(s) {
+	case "", Bars:
+		return Bars, nil
+	case Waveform:
+		return Waveform, nil
+	}
+	return "", fmt.Errorf("unknown lane style %q, use bars or waveform", s)
+}
+
+// Paint returns the color of the pixel at x, y of a level; intensity is 1
+// except towards the edges of a waveform.
+type Paint func(x, y int, intensity float64) color.RGBA
+
+// Solid paints c, darkened by the intensity.
+func Solid(c color.RGBA) Paint {
+	return func(x, y int, intensity float64) color.RGBA { return Shade(c, intensity) }
+}
+
+// Shade scales the channels of c by intensity (0-1).
+func Shade(c color.RGBA, intensity float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(c.R) * intensity),
+		G: uint8(float64(c.G) * intensity),
+		B: uint8(float64(c.B) * intensity),
+		A: c.A,
+	}
+}
+
+// Blend mixes c over dst with opacity alpha (0-1).
+func Blend(dst, c color.RGBA, alpha float64) color.RGBA {
+	mix := func(a, b uint8) uint8 { return uint8(float64(a)*(1-alpha) + float64(b)*alpha + 0.5) }
+	return color.RGBA{R: mix(dst.R, c.R), G: mix(dst.G, c.G), B: mix(dst.B, c.B), A: 255}
+}
+
+// Draw draws one level per pixel column of rect: a bar of level (0-1)
+// times the rect height from the bottom, or a waveform of level times 80% of
+// the height centered on the middle, at least one pixel tall (levels up to
+// 1.25 fill the height, leaving headroom for peaks). Levels are clipped;
+// NaN levels (no data) are left empty. Edge pixels the level covers only
+// partly are blended over the image by their coverage, so lanes stay
+// smooth when the image is scaled down.
+func Draw(img *image.RGBA, rect image.Rectangle, levels []float64, style Style, paint Paint) {
+	height := rect.Dy()
+	for i, v := range levels {
+		x := rect.Min.X + i
+		if x >= rect.Max.X {
+			break
+		}
+		if math.IsNaN(v) {
+			continue
+		}
+		if style != Waveform {
+			top := float64(rect.Max.Y) - min(max(v, 0), 1)*float64(height)
+			fill(img, x, top, float64(rect.Max.Y), rect, func(y int) color.RGBA { return paint(x, y, 1) })
+			continue
+		}
+
+		mid := rect.Min.Y + height/2
+		span := max(min(max(v, 0), 1.25)*float64(height)*0.8, 1)
+		half := int(span) / 2
+		shade := func(y int) color.RGBA {
+			dist := y - mid
+			if dist < 0 {
+				dist = -dist
+			}
+			return paint(x, y, 1-float64(dist)/float64(half+1)*0.3)
+		}
+		for y := mid - half; y <= mid+half; y++ {
+			if y >= rect.Min.Y && y < rect.Max.Y {
+				img.SetRGBA(x, y, shade(y))
+			}
+		}
+		// The rest of the span is shared by the pixels above and below
+		if edge := (span - float64(2*half)) / 2; edge > 0 {
+			for _, y := range []int{mid - half - 1, mid + half + 1} {
+				if y >= rect.Min.Y && y < rect.Max.Y {
+					img.SetRGBA(x, y, Blend(img.RGBAAt(x, y), shade(y), min(edge, 1)))
+				}
+			}
+		}
+	}
+}
+
+// DrawSpan fills the span between a low and a high signed level per pixel
+// column of rect, on the scale of a Waveform: 1 is 40% of the height above
+// the middle, -1 as far below (clipped to ±1.25), at least one pixel tall.
+// Columns where high is below low or either is NaN are left empty; edge
+// pixels are blended by coverage as in Draw.
+func DrawSpan(img *image.RGBA, rect image.Rectangle, lows, highs []float64, paint Paint) {
+	height := rect.Dy()
+	mid := float64(rect.Min.Y + height/2)
+	offset := func(v float64) float64 {
+		return min(max(v, -1.25), 1.25) * float64(height) * 0.4
+	}
+	for i := 0; i < len(lows) && i < len(highs); i++ {
+		x := rect.Min.X + i
+		if x >= rect.Max.X {
+			break
+		}
+		lo, hi := lows[i], highs[i]
+		if math.IsNaN(lo) || math.IsNaN(hi) || hi < lo {
+			continue
+		}
+		fill(img, x, mid-offset(hi), mid-offset(lo)+1, rect, func(y int) color.RGBA { return paint(x, y, 1) })
+	}
+}
+
+// fill paints column x from y = top to bottom (fractional pixels) within
+// rect, blending partly covered pixels over the image by their coverage.
+func fill(img *image.RGBA, x int, top, bottom float64, rect image.Rectangle, shade func(y int) color.RGBA) {
+	for y := max(int(math.Floor(top)), rect.Min.Y); y < min(int(math.Ceil(bottom)), rect.Max.Y); y++ {
+		coverage := min(float64(y+1), bottom) - max(float64(y), top)
+		if coverage >= 1 {
+			img.SetRGBA(x, y, shade(y))
+		} else if coverage > 0 {
+			img.SetRGBA(x, y, Blend(img.RGBAAt(x, y), shade(y), coverage))
+		}
+	}
+}
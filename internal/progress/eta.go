@@ -0,0 +1,102 @@
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event is a progress update of one stage of a run, passed to Func
+// callbacks and printed as a JSON line with -progress-json.
+type Event struct {
+	Stage   string  `json:"stage"` // "decode" (video frames) or "separation" (seconds of audio)
+	Unit    string  `json:"unit"`  // "frames" or "seconds"
+	Done    float64 `json:"done"`
+	Total   float64 `json:"total"`   // 0 = unknown
+	Percent float64 `json:"percent"` // 0-100 (0 when the total is unknown)
+	Rate    float64 `json:"rate"`    // Units per second over the last RateWindow
+	Elapsed float64 `json:"elapsed"` // Seconds since the stage started
+	ETA     float64 `json:"eta"`     // Seconds left at Rate (-1 = unknown)
+}
+
+// Func receives progress events. It is called from the goroutine doing the
+// work, so it should return quickly.
+type Func func(Event)
+
+// RateWindow is how far back the rolling throughput of a Meter looks, so
+// the ETA follows speed changes (a slow start, a cached second half)
+// instead of the average since the start.
+const RateWindow = 10 * time.Second
+
+// Meter measures the throughput and ETA of one stage.
+type Meter struct {
+	stage, unit string
+	total       float64
+	start       time.Time
+	samples     []sample // Within RateWindow, oldest first
+}
+
+type sample struct {
+	at   time.Time
+	done float64
+}
+
+// NewMeter starts measuring a stage of total units (0 = unknown).
+func NewMeter(stage, unit string, total float64) *Meter {
+	now := time.Now()
+	return &Meter{stage: stage, unit: unit, total: total, start: now, samples: []sample{{now, 0}}}
+}
+
+// Update records that done units are complete and returns the event.
+func (m *Meter) Update(done float64) Event {
+	now := time.Now()
+	m.samples = append(m.samples, sample{now, done})
+	// Keep the newest sample older than the window as the rate baseline
+	for len(m.samples) > 2 && now.Sub(m.samples[1].at) >= RateWindow {
+		m.samples = m.samples[1:]
+	}
+
+	e := Event{Stage: m.stage, Unit: m.unit, Done: done, Total: m.total, Elapsed: now.Sub(m.start).Seconds(), ETA: -1}
+	first := m.samples[0]
+	if secs := now.Sub(first.at).Seconds(); secs > 0 {
+		e.Rate = (done - first.done) / secs
+	}
+	if m.total > 0 {
+		e.Percent = min(done/m.total*100, 100)
+		switch {
+		case done >= m.total:
+			e.ETA = 0
+		case e.Rate > 0:
+			e.ETA = (m.total - done) / e.Rate
+		}
+	}
+	return e
+}
+
+// Finish records the end of the stage, with done as the actual total (frame
+// counts from the container are estimates), and returns the last event.
+func (m *Meter) Finish(done float64) Event {
+	m.total = done
+	return m.Update(done)
+}
+
+// FormatETA formats the ETA of e as "ETA 1m05s", or "" when unknown.
+func FormatETA(e Event) string {
+	if e.ETA < 0 {
+		return ""
+	}
+	return "ETA " + formatDuration(time.Duration(e.ETA*float64(time.Second)))
+}
+
+// JSONLines returns a Func writing each event as one JSON line to w, for
+// -progress-json. It is safe for concurrent stages.
+func JSONLines(w io.Writer) Func {
+	var mu sync.Mutex
+	return func(e Event) {
+		data, _ := json.Marshal(e)
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write(append(data, '\n'))
+	}
+}
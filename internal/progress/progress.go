@@ -0,0 +1,277 @@
+// Package progress reports how far a run is: progress events with rolling
+// throughput and ETA per stage (eta.go), and a live terminal dashboard for
+// batch runs with one progress bar per running file, throughput, ETA and
+// failures, redrawn in place instead of interleaved lines from concurrent
+// jobs.
+package progress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dashboard tracks the files of a batch and redraws their progress.
+type Dashboard struct {
+	mu       sync.Mutex
+	out      io.Writer
+	title    string
+	total    int // Files in the batch (0 = unknown, e.g. a job stream)
+	start    time.Time
+	running  []*Task
+	done     int
+	failures []string // "name: error", oldest first
+	frames   int64    // Frames decoded by all tasks, for throughput
+	lines    int      // Lines drawn by the last redraw
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// refresh is the redraw interval.
+const refresh = 200 * time.Millisecond
+
+// maxFailures is the number of failures listed; older ones are counted.
+const maxFailures = 5
+
+// New returns a dashboard of total files (0 = unknown) drawing to out.
+// Call Start to begin redrawing and Stop when the batch ends.
+func New(out io.Writer, title string, total int) *Dashboard {
+	return &Dashboard{out: out, title: title, total: total, start: time.Now()}
+}
+
+// SetTotal sets the number of files once known, e.g. after a directory scan.
+func (d *Dashboard) SetTotal(files int) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.total = files
+	d.mu.Unlock()
+}
+
+// Start redraws the dashboard until Stop.
+func (d *Dashboard) Start() {
+	if d == nil {
+		return
+	}
+	d.stop, d.stopped = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(d.stopped)
+		ticker := time.NewTicker(refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+				d.draw()
+			}
+		}
+	}()
+}
+
+// Stop ends redrawing and leaves the final state on screen.
+func (d *Dashboard) Stop() {
+	if d == nil || d.stop == nil {
+		return
+	}
+	close(d.stop)
+	<-d.stopped
+	d.draw()
+}
+
+// Begin adds a running file and returns its task. A nil dashboard returns
+// a nil task, whose methods do nothing.
+func (d *Dashboard) Begin(name string) *Task {
+	if d == nil {
+		return nil
+	}
+	t := &Task{d: d, name: name}
+	d.mu.Lock()
+	d.running = append(d.running, t)
+	d.mu.Unlock()
+	return t
+}
+
+// Task is the progress of one file.
+type Task struct {
+	d     *Dashboard
+	name  string
+	total int // Expected frames (0 = unknown)
+	count int // Frames decoded so far
+}
+
+// SetTotal sets the expected frame count of the file.
+func (t *Task) SetTotal(frames int) {
+	if t == nil {
+		return
+	}
+	t.d.mu.Lock()
+	t.total = frames
+	t.d.mu.Unlock()
+}
+
+// Add counts decoded frames.
+func (t *Task) Add(frames int) {
+	if t == nil {
+		return
+	}
+	t.d.mu.Lock()
+	t.count += frames
+	t.d.frames += int64(frames)
+	t.d.mu.Unlock()
+}
+
+// Done removes the file from the running ones, listing it as failed when
+// err is not nil.
+func (t *Task) Done(err error) {
+	if t == nil {
+		return
+	}
+	d := t.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, r := range d.running {
+		if r == t {
+			d.running = append(d.running[:i], d.running[i+1:]...)
+			break
+		}
+	}
+	d.done++
+	if err != nil {
+		d.failures = append(d.failures, fmt.Sprintf("%s: %v", t.name, err))
+	}
+}
+
+// fraction returns the decoded share of the file (0 when unknown).
+func (t *Task) fraction() float64 {
+	if t.total <= 0 {
+		return 0
+	}
+	return min(float64(t.count)/float64(t.total), 1)
+}
+
+// draw redraws the dashboard over the previous one.
+func (d *Dashboard) draw() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	width := 80
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n >= 40 {
+		width = n
+	}
+	elapsed := time.Since(d.start)
+	var lines []string
+
+	// Header: files, failures, elapsed and ETA
+	header := fmt.Sprintf("%s  %d", d.title, d.done)
+	if d.total > 0 {
+		header += fmt.Sprintf("/%d", d.total)
+	}
+	header += " files"
+	if len(d.failures) > 0 {
+		header += fmt.Sprintf("  %d failed", len(d.failures))
+	}
+	header += "  elapsed " + formatDuration(elapsed)
+	if d.total > 0 {
+		progress := float64(d.done)
+		for _, t := range d.running {
+			progress += t.fraction()
+		}
+		if f := progress / float64(d.total); f > 0 && f < 1 {
+			header += "  ETA " + formatDuration(time.Duration(float64(elapsed)*(1-f)/f))
+		}
+	}
+	lines = append(lines, header)
+
+	// Throughput
+	if secs := elapsed.Seconds(); secs > 0 {
+		lines = append(lines, fmt.Sprintf("%.1f files/min, %.0f frames/s, %d running",
+			float64(d.done)/secs*60, float64(d.frames)/secs, len(d.running)))
+	}
+
+	// One bar per running file
+	barWidth := 20
+	for _, t := range d.running {
+		f := t.fraction()
+		filled := int(f * float64(barWidth))
+		bar := "[" + strings.Repeat("#", filled) + strings.Repeat(".", barWidth-filled) + "]"
+		status := fmt.Sprintf("%3.0f%%", f*100)
+		if t.total <= 0 {
+			status = "  ? "
+		}
+		prefix := fmt.Sprintf("  %s %s  ", bar, status)
+		lines = append(lines, prefix+shorten(t.name, width-len(prefix)-1))
+	}
+
+	// Latest failures
+	if len(d.failures) > 0 {
+		lines = append(lines, "failed:")
+		from := max(len(d.failures)-maxFailures, 0)
+		if from > 0 {
+			lines = append(lines, fmt.Sprintf("  ... %d earlier", from))
+		}
+		for _, f := range d.failures[from:] {
+			lines = append(lines, "  "+shorten(f, width-3))
+		}
+	}
+
+	var b strings.Builder
+	if d.lines > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", d.lines) // Back to the top of the last draw
+	}
+	for _, line := range lines {
+		b.WriteString("\r\x1b[2K" + line + "\n")
+	}
+	b.WriteString("\x1b[J") // Clear lines left from a taller draw
+	io.WriteString(d.out, b.String())
+	d.lines = len(lines)
+}
+
+// shorten cuts s to n runes, keeping its end (file names) after "...".
+func shorten(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n || n < 4 {
+		return s
+	}
+	return "..." + string(r[len(r)-n+3:])
+}
+
+// formatDuration formats d as 1h02m03s, 2m03s or 3s.
+func formatDuration(d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	switch {
+	case s >= 3600:
+		return fmt.Sprintf("%dh%02dm%02ds", s/3600, s/60%60, s%60)
+	case s >= 60:
+		return fmt.Sprintf("%dm%02ds", s/60, s%60)
+	}
+	return fmt.Sprintf("%ds", s)
+}
+
+// IsTerminal reports whether f is a terminal, where the dashboard can
+// redraw in place.
+func IsTerminal(f *os.File) bool {
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying t, so library code decoding a
+// file can report its frames.
+func NewContext(ctx context.Context, t *Task) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the task of ctx, or nil when there is none.
+func FromContext(ctx context.Context) *Task {
+	t, _ := ctx.Value(contextKey{}).(*Task)
+	return t
+}
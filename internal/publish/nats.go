@@ -0,0 +1,102 @@
+package publish
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pforret/videodna/internal/retry"
+)
+
+// natsPort is the default NATS client port.
+const natsPort = "4222"
+
+// nats publishes to a NATS subject with the core text protocol: one
+// connection per event, confirmed by a PING/PONG round trip.
+type nats struct {
+	addr       string
+	subject    string
+	user, pass string
+	tls        bool
+}
+
+func newNATS(spec string) (*nats, error) {
+	u, err := url.Parse(spec)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS URL %q, use nats://HOST[:PORT]/SUBJECT", spec)
+	}
+	subject := strings.TrimPrefix(u.Path, "/")
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n/") {
+		return nil, fmt.Errorf("invalid NATS subject %q in %s", subject, spec)
+	}
+	n := &nats{addr: u.Host, subject: subject, tls: u.Scheme == "tls"}
+	if u.Port() == "" {
+		n.addr = net.JoinHostPort(u.Hostname(), natsPort)
+	}
+	if u.User != nil {
+		n.user = u.User.Username()
+		n.pass, _ = u.User.Password()
+	}
+	return n, nil
+}
+
+// Publish implements Publisher.
+func (n *nats) Publish(ctx context.Context, e Event) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(10 * time.Second)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	conn.SetDeadline(deadline)
+
+	// The server greets with INFO; TLS starts after it
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("failed to publish to NATS: no INFO from %s", n.addr)
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info)
+	if n.tls || info.TLSRequired {
+		host, _, _ := net.SplitHostPort(n.addr)
+		tc := tls.Client(conn, &tls.Config{ServerName: host})
+		conn, r = tc, bufio.NewReader(tc)
+		defer tc.Close()
+	}
+
+	connect := map[string]any{"verbose": false, "pedantic": false, "lang": "go", "name": "videodna"}
+	if n.user != "" {
+		connect["user"], connect["pass"] = n.user, n.pass
+	}
+	options, _ := json.Marshal(connect)
+	data := marshal(e)
+	msg := fmt.Sprintf("CONNECT %s\r\nPUB %s %d\r\n%s\r\nPING\r\n", options, n.subject, len(data), data)
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to publish to NATS: %w", err)
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return retry.Permanent(fmt.Errorf("NATS rejected the event: %s", line))
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Package publish emits a completion event to a message bus (GCP Pub/Sub,
+// AWS SNS or NATS) when a DNA is written, so downstream indexers react
+// without polling the output directory. The target is a URL:
+//
+//	pubsub://PROJECT/TOPIC                  GCP Pub/Sub
+//	sns://arn:aws:sns:REGION:ACCOUNT:TOPIC  AWS SNS
+//	nats://[USER:PASS@]HOST[:PORT]/SUBJECT  NATS (tls:// for TLS)
+package publish
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/png" // Output dimensions
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pforret/videodna/internal/hooks"
+	"github.com/pforret/videodna/internal/retry"
+)
+
+// EnvURL names the environment variable with the publish URL of the HTTP
+// function.
+const EnvURL = "VIDEODNA_PUBLISH_URL"
+
+// Event announces a finished DNA.
+type Event struct {
+	Tool        string `json:"tool"`             // "videodna" or "audiodna"
+	AssetID     string `json:"asset_id"`         // Input file name without extension
+	Tenant      string `json:"tenant,omitempty"` // HTTP function tenant
+	Input       string `json:"input"`
+	OutputURL   string `json:"output_url,omitempty"`  // file:// URL of the output ("" = returned inline)
+	Fingerprint string `json:"fingerprint,omitempty"` // SHA-256 of the output image, hex
+	Stats       Stats  `json:"stats"`
+	Time        string `json:"time"` // RFC 3339
+}
+
+// Stats describe the output and the run.
+type Stats struct {
+	Width    int     `json:"width,omitempty"`
+	Height   int     `json:"height,omitempty"`
+	Bytes    int64   `json:"bytes,omitempty"`    // Output size
+	Duration float64 `json:"duration,omitempty"` // Media seconds
+	Elapsed  float64 `json:"elapsed,omitempty"`  // Seconds the run took
+}
+
+// Publisher sends events to one topic or subject.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Open returns the publisher of a publish URL.
+func Open(url string) (Publisher, error) {
+	switch {
+	case strings.HasPrefix(url, "pubsub://"):
+		return newPubSub(strings.TrimPrefix(url, "pubsub://"))
+	case strings.HasPrefix(url, "sns://"):
+		return newSNS(strings.TrimPrefix(url, "sns://"))
+	case strings.HasPrefix(url, "nats://"), strings.HasPrefix(url, "tls://"):
+		return newNATS(url)
+	}
+	return nil, fmt.Errorf("unknown publish URL %q, use pubsub://PROJECT/TOPIC, sns://TOPIC_ARN or nats://HOST/SUBJECT", url)
+}
+
+// NewEvent returns the event of a tool run from input, without output
+// details.
+func NewEvent(tool, input string) Event {
+	base := filepath.Base(input)
+	if i := strings.IndexAny(input, "?#"); i >= 0 && strings.Contains(input, "://") {
+		base = filepath.Base(input[:i])
+	}
+	return Event{
+		Tool:    tool,
+		AssetID: strings.TrimSuffix(base, filepath.Ext(base)),
+		Input:   input,
+		Time:    time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// Fingerprint returns the hex SHA-256 of r.
+func Fingerprint(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Describe fills the output URL, fingerprint and image stats of e from
+// the output file. Outputs that cannot be read (stdout, tile pyramids) keep
+// them empty.
+func (e *Event) Describe(output string) {
+	f, err := os.Open(output)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil || st.IsDir() {
+		return
+	}
+	e.Stats.Bytes = st.Size()
+	if abs, err := filepath.Abs(output); err == nil {
+		e.OutputURL = "file://" + filepath.ToSlash(abs)
+	}
+	if cfg, _, err := image.DecodeConfig(f); err == nil {
+		e.Stats.Width, e.Stats.Height = cfg.Width, cfg.Height
+	}
+	if _, err := f.Seek(0, io.SeekStart); err == nil {
+		e.Fingerprint, _ = Fingerprint(f)
+	}
+}
+
+// Send publishes e, retrying network and server errors.
+func Send(ctx context.Context, p Publisher, e Event) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	policy := retry.DefaultPolicy()
+	policy.Delay, policy.MaxDelay = time.Second, 5*time.Second
+	return policy.Do(ctx, "event publish", func(int) error {
+		return p.Publish(ctx, e)
+	})
+}
+
+// marshal returns the JSON message body of e.
+func marshal(e Event) []byte {
+	data, _ := json.Marshal(e)
+	return data
+}
+
+// Hook is a hook that publishes an event after generation. Delivery
+// failures are printed as warnings, never fail the run.
+type Hook struct {
+	Publisher Publisher
+}
+
+// Run implements hooks.Hook.
+func (h Hook) Run(ctx context.Context, c hooks.Context) error {
+	if c.Point != hooks.AfterGenerate {
+		return nil
+	}
+	e := NewEvent(c.Tool, c.Input)
+	e.Stats.Elapsed = c.Elapsed
+	e.Describe(c.Output)
+	if err := Send(ctx, h.Publisher, e); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pforret/videodna/internal/retry"
+)
+
+// GCP settings. PUBSUB_EMULATOR_HOST points at the Pub/Sub emulator, which
+// needs no token; otherwise GOOGLE_OAUTH_ACCESS_TOKEN or the metadata
+// server of the instance provides one.
+const (
+	pubSubEndpoint   = "https://pubsub.googleapis.com"
+	envPubSubEmu     = "PUBSUB_EMULATOR_HOST"
+	envGoogleToken   = "GOOGLE_OAUTH_ACCESS_TOKEN"
+	metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+// pubSub publishes to a GCP Pub/Sub topic over the REST API.
+type pubSub struct {
+	topic string // projects/PROJECT/topics/TOPIC
+}
+
+func newPubSub(spec string) (*pubSub, error) {
+	project, topic, ok := strings.Cut(spec, "/")
+	if !ok || project == "" || topic == "" || strings.Contains(topic, "/") {
+		return nil, fmt.Errorf("invalid Pub/Sub URL pubsub://%s, use pubsub://PROJECT/TOPIC", spec)
+	}
+	return &pubSub{topic: "projects/" + project + "/topics/" + topic}, nil
+}
+
+// Publish implements Publisher. Tool and asset_id are also message
+// attributes, so subscriptions can filter on them.
+func (p *pubSub) Publish(ctx context.Context, e Event) error {
+	endpoint, token := pubSubEndpoint, ""
+	if host := os.Getenv(envPubSubEmu); host != "" {
+		endpoint = "http://" + host
+	} else {
+		var err error
+		if token, err = googleToken(ctx); err != nil {
+			return err
+		}
+	}
+
+	body, _ := json.Marshal(map[string]any{"messages": []any{map[string]any{
+		"data":       marshal(e), // []byte encodes as base64
+		"attributes": map[string]string{"tool": e.Tool, "asset_id": e.AssetID},
+	}}})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/v1/"+p.topic+":publish", bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return do(req, "Pub/Sub")
+}
+
+// googleToken returns an OAuth access token for Pub/Sub.
+func googleToken(ctx context.Context) (string, error) {
+	if token := os.Getenv(envGoogleToken); token != "" {
+		return token, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataTokenURL, nil)
+	if err != nil {
+		return "", retry.Permanent(err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", retry.Permanent(fmt.Errorf("no Google credentials: set %s or run on GCP (%w)", envGoogleToken, err))
+	}
+	defer resp.Body.Close()
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server token request failed: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("invalid metadata server token response")
+	}
+	return token.AccessToken, nil
+}
+
+// do sends req; the error of a failed response is classified by
+// retry.HTTPStatus.
+func do(req *http.Request, service string) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return retry.HTTPStatus(resp, fmt.Errorf("%s rejected the event: %s %s", service, resp.Status, strings.TrimSpace(string(detail))))
+	}
+	return nil
+}
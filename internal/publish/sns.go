@@ -0,0 +1,62 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pforret/videodna/internal/awssig"
+	"github.com/pforret/videodna/internal/retry"
+)
+
+// AWS settings. Credentials come from the standard environment variables
+// (see awssig.FromEnv); AWS_ENDPOINT_URL_SNS points at a local SNS (e.g.
+// LocalStack).
+const envSNSEndpoint = "AWS_ENDPOINT_URL_SNS"
+
+// sns publishes to an AWS SNS topic over the query API, signed with
+// Signature Version 4.
+type sns struct {
+	arn    string
+	region string
+}
+
+func newSNS(arn string) (*sns, error) {
+	// arn:aws:sns:REGION:ACCOUNT:TOPIC
+	parts := strings.Split(arn, ":")
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "sns" || parts[3] == "" || parts[5] == "" {
+		return nil, fmt.Errorf("invalid SNS URL sns://%s, use sns://arn:aws:sns:REGION:ACCOUNT:TOPIC", arn)
+	}
+	return &sns{arn: arn, region: parts[3]}, nil
+}
+
+// Publish implements Publisher.
+func (s *sns) Publish(ctx context.Context, e Event) error {
+	creds, err := awssig.FromEnv()
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	endpoint := os.Getenv(envSNSEndpoint)
+	if endpoint == "" {
+		endpoint = "https://sns." + s.region + ".amazonaws.com"
+	}
+
+	form := url.Values{
+		"Action":   {"Publish"},
+		"Version":  {"2010-03-31"},
+		"TopicArn": {s.arn},
+		"Message":  {string(marshal(e))},
+	}
+	body := form.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", strings.NewReader(body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	awssig.Sign(req, []byte(body), creds, s.region, "sns", time.Now())
+	return do(req, "SNS")
+}
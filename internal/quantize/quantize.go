@@ -0,0 +1,176 @@
+// Package quantize reduces images to a palette of at most 256 colors, for
+// indexed PNGs a fraction of the size of truecolor ones.
+package quantize
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// MaxColors is the largest palette of an indexed PNG.
+const MaxColors = 256
+
+// Paletted returns img reduced to at most n colors (2 to MaxColors) chosen
+// by median cut. Images with n colors or fewer keep their exact colors.
+func Paletted(img image.Image, n int) *image.Paletted {
+	n = min(max(n, 2), MaxColors)
+	bounds := img.Bounds()
+	palette := MedianCut(img, n)
+	dst := image.NewPaletted(image.Rect(0, 0, bounds.Dx(), bounds.Dy()), palette)
+
+	index := make(map[uint32]uint8)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := dst.Pix[(y-bounds.Min.Y)*dst.Stride:]
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := key(img.At(x, y))
+			i, ok := index[c]
+			if !ok {
+				i = uint8(palette.Index(unkey(c)))
+				index[c] = i
+			}
+			row[x-bounds.Min.X] = i
+		}
+	}
+	return dst
+}
+
+// MedianCut returns a palette of at most n colors for img: the color space
+// is split at the median of the widest channel until there are n boxes,
+// each contributing the average of its pixels.
+func MedianCut(img image.Image, n int) color.Palette {
+	counts := make(map[uint32]int)
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			counts[key(img.At(x, y))]++
+		}
+	}
+	colors := make([]entry, 0, len(counts))
+	for c, count := range counts {
+		colors = append(colors, entry{c, count})
+	}
+	// Sorted, so the palette does not depend on map order
+	sort.Slice(colors, func(i, j int) bool { return colors[i].color < colors[j].color })
+
+	if len(colors) <= n {
+		palette := make(color.Palette, len(colors))
+		for i, e := range colors {
+			palette[i] = unkey(e.color)
+		}
+		return palette
+	}
+
+	boxes := []box{newBox(colors)}
+	for len(boxes) < n {
+		// Split the box with the widest channel range, weighted by its pixels
+		best, bestScore := -1, 0
+		for i, b := range boxes {
+			if len(b.colors) < 2 {
+				continue
+			}
+			if score := b.spread() * b.pixels; score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+		low, high := boxes[best].split()
+		boxes[best] = low
+		boxes = append(boxes, high)
+	}
+
+	palette := make(color.Palette, len(boxes))
+	for i, b := range boxes {
+		palette[i] = b.average()
+	}
+	return palette
+}
+
+// entry is a distinct color and the number of pixels with it.
+type entry struct {
+	color uint32 // 0xRRGGBB
+	count int
+}
+
+func key(c color.Color) uint32 {
+	r, g, b, _ := c.RGBA()
+	return r>>8<<16 | g>>8<<8 | b>>8
+}
+
+func unkey(c uint32) color.RGBA {
+	return color.RGBA{R: uint8(c >> 16), G: uint8(c >> 8), B: uint8(c), A: 0xff}
+}
+
+func channel(c uint32, ch int) int {
+	return int(c>>(16-8*ch)) & 0xff
+}
+
+// box is a set of colors of the median cut.
+type box struct {
+	colors []entry
+	pixels int
+	lo, hi [3]int
+}
+
+func newBox(colors []entry) box {
+	b := box{colors: colors, lo: [3]int{255, 255, 255}}
+	for _, e := range colors {
+		b.pixels += e.count
+		for ch := 0; ch < 3; ch++ {
+			v := channel(e.color, ch)
+			b.lo[ch], b.hi[ch] = min(b.lo[ch], v), max(b.hi[ch], v)
+		}
+	}
+	return b
+}
+
+// widest returns the channel with the largest range.
+func (b box) widest() int {
+	widest := 0
+	for ch := 1; ch < 3; ch++ {
+		if b.hi[ch]-b.lo[ch] > b.hi[widest]-b.lo[widest] {
+			widest = ch
+		}
+	}
+	return widest
+}
+
+func (b box) spread() int {
+	ch := b.widest()
+	return b.hi[ch] - b.lo[ch] + 1
+}
+
+// split divides the box at the pixel median of its widest channel.
+func (b box) split() (box, box) {
+	ch := b.widest()
+	sort.Slice(b.colors, func(i, j int) bool {
+		return channel(b.colors[i].color, ch) < channel(b.colors[j].color, ch)
+	})
+	at, sum := 1, 0
+	for i, e := range b.colors[:len(b.colors)-1] {
+		sum += e.count
+		at = i + 1
+		if sum*2 >= b.pixels {
+			break
+		}
+	}
+	return newBox(b.colors[:at]), newBox(b.colors[at:])
+}
+
+func (b box) average() color.RGBA {
+	var sum [3]int
+	for _, e := range b.colors {
+		for ch := 0; ch < 3; ch++ {
+			sum[ch] += channel(e.color, ch) * e.count
+		}
+	}
+	half := b.pixels / 2
+	return color.RGBA{
+		R: uint8((sum[0] + half) / b.pixels),
+		G: uint8((sum[1] + half) / b.pixels),
+		B: uint8((sum[2] + half) / b.pixels),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,149 @@
+// Package resizespec parses the -resize argument of videodna and audiodna:
+// "WxH" in pixels, percentages of the rendered size ("50%", "100%x50%"), a
+// single dimension ("1920x", "x200") or "input" for the video size.
+package resizespec
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Dimension is the target size of one side of the image: pixels, or a
+// percentage of the rendered size. The zero Dimension keeps the side as
+// rendered.
+type Dimension struct {
+	Pixels  int
+	Percent float64
+}
+
+// IsZero reports whether the dimension keeps the side as rendered.
+func (d Dimension) IsZero() bool {
+	return d == Dimension{}
+}
+
+// Of returns the target size of a side rendered at size pixels. Huge
+// percentages saturate at math.MaxInt32 instead of overflowing.
+func (d Dimension) Of(size int) int {
+	switch {
+	case d.Pixels > 0:
+		return d.Pixels
+	case d.Percent > 0:
+		return max(int(min(math.Round(float64(size)*d.Percent/100), math.MaxInt32)), 1)
+	}
+	return size
+}
+
+// Spec is a parsed resize argument. The zero Spec does not resize.
+type Spec struct {
+	Input  bool      // Resize to the dimensions of the input video
+	Width  Dimension // Target width (zero = as rendered)
+	Height Dimension // Target height (zero = as rendered)
+}
+
+// IsZero reports whether the spec leaves the image as rendered.
+func (s Spec) IsZero() bool {
+	return s == Spec{}
+}
+
+// Percent reports whether a dimension is relative to the rendered size.
+func (s Spec) Percent() bool {
+	return s.Width.Percent > 0 || s.Height.Percent > 0
+}
+
+// Size returns the target size of an image rendered at width×height. An
+// Input spec returns the size unchanged; the caller substitutes the size
+// of the input video.
+func (s Spec) Size(width, height int) (int, int) {
+	return s.Width.Of(width), s.Height.Of(height)
+}
+
+// SizeWithin returns Size with each side capped at limit pixels (0 = no
+// cap).
+func (s Spec) SizeWithin(width, height, limit int) (int, int) {
+	w, h := s.Size(width, height)
+	if limit > 0 {
+		w, h = min(w, limit), min(h, limit)
+	}
+	return w, h
+}
+
+// Error reports an invalid resize argument.
+type Error struct {
+	Value  string // The argument as given
+	Field  string // "width", "height", or "" when the format is wrong
+	Reason string // What is wrong with it
+}
+
+func (e *Error) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("invalid resize %q: %s", e.Value, e.Reason)
+	}
+	return fmt.Sprintf("invalid resize %q: %s %s", e.Value, e.Field, e.Reason)
+}
+
+// Parse reads a resize argument: "WxH", "W%xH%", "N%" (both sides), "Wx"
+// or "xH" (the other side as rendered), or "input". Pixels and
+// percentages can be mixed; "" returns the zero Spec.
+func Parse(s string) (Spec, error) {
+	value := strings.TrimSpace(s)
+	if value == "" {
+		return Spec{}, nil
+	}
+	if strings.EqualFold(value, "input") {
+		return Spec{Input: true}, nil
+	}
+
+	w, h, ok := strings.Cut(strings.ToLower(value), "x")
+	if !ok {
+		if !strings.HasSuffix(value, "%") {
+			return Spec{}, &Error{Value: s, Reason: "use WxH, a percentage, Wx, xH or 'input'"}
+		}
+		d, err := parseDimension(s, "size", value)
+		if err != nil {
+			return Spec{}, err
+		}
+		return Spec{Width: d, Height: d}, nil
+	}
+	if w == "" && h == "" {
+		return Spec{}, &Error{Value: s, Reason: "set a width, a height or both"}
+	}
+
+	var spec Spec
+	var err error
+	if w != "" {
+		if spec.Width, err = parseDimension(s, "width", w); err != nil {
+			return Spec{}, err
+		}
+	}
+	if h != "" {
+		if spec.Height, err = parseDimension(s, "height", h); err != nil {
+			return Spec{}, err
+		}
+	}
+	return spec, nil
+}
+
+// parseDimension reads one side: pixels, or a percentage ending in "%".
+func parseDimension(value, field, side string) (Dimension, error) {
+	side = strings.TrimSpace(side)
+	if p, ok := strings.CutSuffix(side, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || math.IsNaN(percent) || math.IsInf(percent, 0) {
+			return Dimension{}, &Error{Value: value, Field: field, Reason: "is not a percentage"}
+		}
+		if percent <= 0 {
+			return Dimension{}, &Error{Value: value, Field: field, Reason: "must be above 0%"}
+		}
+		return Dimension{Percent: percent}, nil
+	}
+	pixels, err := strconv.Atoi(side)
+	if err != nil {
+		return Dimension{}, &Error{Value: value, Field: field, Reason: "is not a number of pixels"}
+	}
+	if pixels <= 0 {
+		return Dimension{}, &Error{Value: value, Field: field, Reason: "must be positive"}
+	}
+	return Dimension{Pixels: pixels}, nil
+}
@@ -0,0 +1,144 @@
+package resizespec
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Spec
+	}{
+		{"", Spec{}},
+		{"  ", Spec{}},
+		{"input", Spec{Input: true}},
+		{"INPUT", Spec{Input: true}},
+		{"1920x1080", Spec{Width: Dimension{Pixels: 1920}, Height: Dimension{Pixels: 1080}}},
+		{"1920X200", Spec{Width: Dimension{Pixels: 1920}, Height: Dimension{Pixels: 200}}},
+		{" 600 x 40 ", Spec{Width: Dimension{Pixels: 600}, Height: Dimension{Pixels: 40}}},
+		{"1920x", Spec{Width: Dimension{Pixels: 1920}}},
+		{"x200", Spec{Height: Dimension{Pixels: 200}}},
+		{"50%", Spec{Width: Dimension{Percent: 50}, Height: Dimension{Percent: 50}}},
+		{"100%x25%", Spec{Width: Dimension{Percent: 100}, Height: Dimension{Percent: 25}}},
+		{"12.5%x", Spec{Width: Dimension{Percent: 12.5}}},
+		{"1920x50%", Spec{Width: Dimension{Pixels: 1920}, Height: Dimension{Percent: 50}}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		in    string
+		field string
+	}{
+		{"1920", ""},
+		{"big", ""},
+		{"x", ""},
+		{"1920x1080x2", "height"},
+		{"0x200", "width"},
+		{"-5x200", "width"},
+		{"1920x0", "height"},
+		{"abcx200", "width"},
+		{"1920xabc", "height"},
+		{"1.5x200", "width"},
+		{"0%", "size"},
+		{"-10%", "size"},
+		{"%x200", "width"},
+		{"50%x0%", "height"},
+		{"NaN%", "size"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.in)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("Parse(%q) error = %v, want *Error", tt.in, err)
+			continue
+		}
+		if e.Value != tt.in || e.Field != tt.field {
+			t.Errorf("Parse(%q) error = %+v, want value %q and field %q", tt.in, e, tt.in, tt.field)
+		}
+		if e.Error() == "" || e.Reason == "" {
+			t.Errorf("Parse(%q) error has no reason", tt.in)
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	tests := []struct {
+		in           string
+		w, h         int
+		wantW, wantH int
+	}{
+		{"1920x1080", 7200, 480, 1920, 1080},
+		{"1920x", 7200, 480, 1920, 480},
+		{"x200", 7200, 480, 7200, 200},
+		{"50%", 7200, 480, 3600, 240},
+		{"100%x25%", 7200, 480, 7200, 120},
+		{"1%", 50, 30, 1, 1}, // Never below one pixel
+		{"33%x", 100, 10, 33, 10},
+		{"", 640, 360, 640, 360},
+		{"input", 640, 360, 640, 360}, // The caller substitutes the video size
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.in)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.in, err)
+		}
+		if w, h := spec.Size(tt.w, tt.h); w != tt.wantW || h != tt.wantH {
+			t.Errorf("Parse(%q).Size(%d, %d) = %dx%d, want %dx%d", tt.in, tt.w, tt.h, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestSizeWithin(t *testing.T) {
+	tests := []struct {
+		in           string
+		w, h, limit  int
+		wantW, wantH int
+	}{
+		{"400%", 20000, 100, 65535, 65535, 400},
+		{"100000x", 7200, 480, 65535, 65535, 480},
+		{"1000000000000%", 7200, 480, 65535, 65535, 65535}, // No overflow
+		{"1000000000000%", 7200, 480, 0, math.MaxInt32, math.MaxInt32},
+		{"50%", 7200, 480, 65535, 3600, 240},
+		{"400%", 20000, 100, 0, 80000, 400}, // 0 = no cap
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.in)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.in, err)
+		}
+		if w, h := spec.SizeWithin(tt.w, tt.h, tt.limit); w != tt.wantW || h != tt.wantH {
+			t.Errorf("Parse(%q).SizeWithin(%d, %d, %d) = %dx%d, want %dx%d", tt.in, tt.w, tt.h, tt.limit, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestSpecFlags(t *testing.T) {
+	if !(Spec{}).IsZero() {
+		t.Error("zero Spec is not IsZero")
+	}
+	for _, in := range []string{"input", "1920x", "50%"} {
+		spec, _ := Parse(in)
+		if spec.IsZero() {
+			t.Errorf("Parse(%q).IsZero() = true", in)
+		}
+	}
+	for in, want := range map[string]bool{"50%": true, "1920x50%": true, "1920x200": false, "input": false} {
+		spec, _ := Parse(in)
+		if got := spec.Percent(); got != want {
+			t.Errorf("Parse(%q).Percent() = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Package retry repeats operations that fail for transient reasons (network
+// hiccups, object storage timeouts, crashing separators) with exponential
+// backoff, so unattended batch jobs recover on their own.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Policy configures the retries of an operation.
+type Policy struct {
+	Attempts int           // Total attempts including the first (0 or 1 = no retry)
+	Delay    time.Duration // Wait before the first retry, doubled after each retry
+	MaxDelay time.Duration // Upper bound of the wait (0 = unbounded)
+	Silent   bool          // Don't log retries to stderr
+}
+
+// DefaultPolicy returns three attempts waiting 2s, then 4s.
+func DefaultPolicy() Policy {
+	return Policy{
+		Attempts: 3,
+		Delay:    2 * time.Second,
+		MaxDelay: 30 * time.Second,
+	}
+}
+
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Do returns it without retrying (e.g. HTTP 404, bad
+// arguments).
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// HTTPStatus classifies err, the failure of an HTTP request answered with
+// resp: server errors, 408 Request Timeout and 429 Too Many Requests are
+// retried, other statuses are Permanent.
+func HTTPStatus(resp *http.Response, err error) error {
+	switch code := resp.StatusCode; {
+	case code >= 500, code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+		return err
+	}
+	return Permanent(err)
+}
+
+// Do calls fn until it succeeds, returns a Permanent error, the attempts are
+// used up or ctx is done. fn receives the attempt number starting at 1, so
+// it can change strategy on later attempts. name describes the operation in
+// the retry log.
+func (p Policy) Do(ctx context.Context, name string, fn func(attempt int) error) error {
+	attempts := max(p.Attempts, 1)
+	delay := p.Delay
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil {
+			return nil
+		}
+		var perm permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if attempt >= attempts || ctx.Err() != nil {
+			return err
+		}
+		if !p.Silent {
+			fmt.Fprintf(os.Stderr, "Warning: %s failed (attempt %d of %d), retrying in %s: %v\n",
+				name, attempt, attempts, delay, err)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if p.MaxDelay > 0 && delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package signature signs fingerprint files with Ed25519 and verifies them,
+// so a fingerprint can later prove what content looked like at a point in
+// time. The signature is a JSON sidecar next to the file (fp.json.sig),
+// covering the SHA-256 of the file and the signing time.
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pforret/videodna/internal/checksum"
+)
+
+// Version is the signature sidecar version written by Sign.
+const Version = 1
+
+// Ext is appended to the signed file name to get the sidecar path.
+const Ext = ".sig"
+
+// Signature is the JSON sidecar of a signed file.
+type Signature struct {
+	Version   int    `json:"version"`
+	Algorithm string `json:"algorithm"` // "ed25519"
+	File      string `json:"file"`      // Base name of the signed file
+	SHA256    string `json:"sha256"`    // Digest of the signed file, hex
+	SignedAt  string `json:"signed_at"` // RFC 3339, UTC
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"` // Base64 Ed25519 signature of message()
+}
+
+// message returns the signed bytes: the digest and time, prefixed with the
+// format so signatures can't be replayed for another purpose.
+func (s *Signature) message() []byte {
+	return []byte(fmt.Sprintf("videodna-signature-v%d\n%s\n%s\n%s\n", s.Version, s.File, s.SHA256, s.SignedAt))
+}
+
+// Key returns the public key the sidecar was signed with.
+func (s *Signature) Key() (ed25519.PublicKey, error) {
+	public, err := base64.StdEncoding.DecodeString(s.PublicKey)
+	if err != nil || len(public) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key")
+	}
+	return public, nil
+}
+
+// KeyID returns a short identifier of a public key: the first 16 hex
+// digits of its SHA-256.
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// LoadPrivateKey reads an Ed25519 private key in PKCS #8 PEM, as written by
+// `openssl genpkey -algorithm ed25519`.
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key %s: %w", path, err)
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid private key %s: not an Ed25519 key", path)
+	}
+	return private, nil
+}
+
+// LoadPublicKey reads an Ed25519 public key in PKIX PEM, as written by
+// `openssl pkey -pubout`.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %s: %w", path, err)
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid public key %s: not an Ed25519 key", path)
+	}
+	return public, nil
+}
+
+func readPEM(path, kind string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != kind {
+		return nil, fmt.Errorf("invalid key %s: no %s PEM block", path, kind)
+	}
+	return block.Bytes, nil
+}
+
+// Sign signs the file at path with key and writes the sidecar to
+// path + Ext, which it returns.
+func Sign(path string, key ed25519.PrivateKey) (string, error) {
+	digest, err := fileDigest(path)
+	if err != nil {
+		return "", err
+	}
+	s := &Signature{
+		Version:   Version,
+		Algorithm: "ed25519",
+		File:      filepath.Base(path),
+		SHA256:    digest,
+		SignedAt:  time.Now().UTC().Format(time.RFC3339),
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+	s.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, s.message()))
+
+	data, _ := json.MarshalIndent(s, "", "  ")
+	sigPath := path + Ext
+	if err := os.WriteFile(sigPath, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write signature: %w", err)
+	}
+	return sigPath, nil
+}
+
+// SignFingerprint signs the fingerprint at path like Sign and, unless
+// silent, prints the sidecar path and key ID.
+func SignFingerprint(path string, key ed25519.PrivateKey, silent bool) error {
+	sigPath, err := Sign(path, key)
+	if err != nil {
+		return err
+	}
+	if !silent {
+		fmt.Printf("Signed fingerprint: %s (key %s)\n", sigPath, KeyID(key.Public().(ed25519.PublicKey)))
+	}
+	return nil
+}
+
+// Verify checks the file at path against its sidecar at sigPath ("" =
+// path + Ext), which must name the file. With a trusted key, the signature
+// must be made by that key; without one, the key in the sidecar is used,
+// which proves the file is unchanged but not who signed it.
+func Verify(path, sigPath string, trusted ed25519.PublicKey) (*Signature, error) {
+	if sigPath == "" {
+		sigPath = path + Ext
+	}
+	data, err := os.ReadFile(sigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+	var s Signature
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid signature %s: %w", sigPath, err)
+	}
+	if s.Algorithm != "ed25519" || s.Version < 1 || s.Version > Version {
+		return nil, fmt.Errorf("unsupported signature %s: %s version %d", sigPath, s.Algorithm, s.Version)
+	}
+	public, err := s.Key()
+	if err != nil {
+		return nil, fmt.Errorf("%w in %s", err, sigPath)
+	}
+	if trusted != nil && !trusted.Equal(public) {
+		return nil, fmt.Errorf("signed by key %s, not the trusted key %s", KeyID(public), KeyID(trusted))
+	}
+	sig, err := base64.StdEncoding.DecodeString(s.Signature)
+	if err != nil || !ed25519.Verify(public, s.message(), sig) {
+		return nil, fmt.Errorf("invalid signature in %s", sigPath)
+	}
+	if name := filepath.Base(path); s.File != name {
+		return nil, fmt.Errorf("%s signs %s, not %s", sigPath, s.File, name)
+	}
+
+	digest, err := fileDigest(path)
+	if err != nil {
+		return nil, err
+	}
+	if digest != s.SHA256 {
+		return nil, fmt.Errorf("%s was modified after signing: SHA-256 is %s, signed %s", path, digest, s.SHA256)
+	}
+	return &s, nil
+}
+
+func fileDigest(path string) (string, error) {
+	sums, err := checksum.Compute(path, false)
+	if err != nil {
+		return "", err
+	}
+	return sums.SHA256, nil
+}
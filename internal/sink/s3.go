@@ -0,0 +1,90 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pforret/videodna/internal/awssig"
+	"github.com/pforret/videodna/internal/retry"
+)
+
+// S3 settings. Credentials come from the standard environment variables
+// (see awssig.FromEnv). AWS_ENDPOINT_URL_S3 points at another S3-compatible
+// store (MinIO, R2, GCS interoperability), addressed path-style.
+const (
+	envRegion     = "AWS_REGION"
+	envDefRegion  = "AWS_DEFAULT_REGION"
+	envS3Endpoint = "AWS_ENDPOINT_URL_S3"
+	defaultRegion = "us-east-1"
+)
+
+// s3Client bounds each upload attempt, so a stalled connection is retried
+// instead of hanging the run.
+var s3Client = &http.Client{Timeout: 10 * time.Minute}
+
+// s3 uploads outputs to one object with PUT Object, signed with Signature
+// Version 4.
+type s3 struct {
+	bucket string
+	key    string
+}
+
+func newS3(spec string) (*s3, error) {
+	bucket, key, ok := strings.Cut(spec, "/")
+	if !ok || bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return nil, fmt.Errorf("invalid S3 URL s3://%s, use s3://BUCKET/KEY", spec)
+	}
+	return &s3{bucket: bucket, key: key}, nil
+}
+
+// Put implements Sink, retrying network and server errors.
+func (s *s3) Put(ctx context.Context, data []byte, contentType string) error {
+	creds, err := awssig.FromEnv()
+	if err != nil {
+		return err
+	}
+	region := os.Getenv(envRegion)
+	if region == "" {
+		region = os.Getenv(envDefRegion)
+	}
+	if region == "" {
+		region = defaultRegion
+	}
+	target := &url.URL{Scheme: "https", Host: s.bucket + ".s3." + region + ".amazonaws.com", Path: "/" + s.key}
+	if endpoint := os.Getenv(envS3Endpoint); endpoint != "" {
+		u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid %s %q", envS3Endpoint, endpoint)
+		}
+		target = u.JoinPath(s.bucket, s.key)
+	}
+
+	policy := retry.DefaultPolicy()
+	return policy.Do(ctx, "S3 upload", func(int) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), bytes.NewReader(data))
+		if err != nil {
+			return retry.Permanent(err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-Amz-Content-Sha256", awssig.PayloadHash(data))
+		awssig.Sign(req, data, creds, region, "s3", time.Now())
+
+		resp, err := s3Client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to upload to s3://%s/%s: %w", s.bucket, s.key, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return retry.HTTPStatus(resp, fmt.Errorf("S3 rejected s3://%s/%s: %s %s", s.bucket, s.key, resp.Status, strings.TrimSpace(string(detail))))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,131 @@
+// Package sink delivers the outputs of a run (the encoded image, the JSON
+// report) to files, writers or object storage. The generators hand each
+// output to a Sink without knowing where it goes, so destinations compose
+// without generator changes:
+//
+//	upload, _ := sink.Open("s3://assets/dna/movie.png")
+//	config.ImageSink = sink.Multi(sink.File("dna.png"), upload)
+//	config.ReportSink = sink.File("report.json")
+//
+// Open also takes a file path ("-" = stdout).
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pforret/videodna/internal/pipe"
+)
+
+// Content types of the outputs.
+const (
+	TypePNG  = "image/png"
+	TypeTIFF = "image/tiff"
+	TypeJSON = "application/json"
+)
+
+// Sink receives one output of a run.
+type Sink interface {
+	// Put stores data, encoded as contentType.
+	Put(ctx context.Context, data []byte, contentType string) error
+}
+
+// Open returns the sink of a destination URL: s3://BUCKET/KEY for
+// S3-compatible object storage, or a file path ("-" = stdout).
+func Open(url string) (Sink, error) {
+	if strings.HasPrefix(url, "s3://") {
+		return newS3(strings.TrimPrefix(url, "s3://"))
+	}
+	if strings.Contains(url, "://") {
+		return nil, fmt.Errorf("unknown output URL %q, use s3://BUCKET/KEY or a file path", url)
+	}
+	return File(url), nil
+}
+
+// File writes outputs to the file at path, creating its directory; "-"
+// writes to standard output. An empty path returns nil (no sink).
+func File(path string) Sink {
+	if path == "" {
+		return nil
+	}
+	return fileSink(path)
+}
+
+type fileSink string
+
+// Put implements Sink.
+func (f fileSink) Put(_ context.Context, data []byte, _ string) error {
+	path := string(f)
+	if !pipe.IsStdout(path) {
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create directory of %s: %w", path, err)
+			}
+		}
+	}
+	out, err := pipe.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := out.Write(data); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return out.Close()
+}
+
+// Writer writes outputs to w, e.g. an HTTP response or a buffer.
+func Writer(w io.Writer) Sink {
+	return writerSink{w}
+}
+
+type writerSink struct {
+	w io.Writer
+}
+
+// Put implements Sink.
+func (s writerSink) Put(_ context.Context, data []byte, _ string) error {
+	_, err := s.w.Write(data)
+	return err
+}
+
+// Multi delivers outputs to every sink in order, skipping nil sinks. A
+// failing sink does not stop the others; their errors are joined. It
+// returns nil when no sink is left, the sink itself when one is.
+func Multi(sinks ...Sink) Sink {
+	var flat multiSink
+	for _, s := range sinks {
+		switch s := s.(type) {
+		case nil:
+		case multiSink:
+			flat = append(flat, s...)
+		default:
+			flat = append(flat, s)
+		}
+	}
+	switch len(flat) {
+	case 0:
+		return nil
+	case 1:
+		return flat[0]
+	}
+	return flat
+}
+
+type multiSink []Sink
+
+// Put implements Sink.
+func (m multiSink) Put(ctx context.Context, data []byte, contentType string) error {
+	var errs []error
+	for _, s := range m {
+		if err := s.Put(ctx, data, contentType); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
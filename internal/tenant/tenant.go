@@ -0,0 +1,253 @@
+// Package tenant isolates the jobs of the teams sharing one deployment of
+// the HTTP function: each tenant is identified by its API key and gets its
+// own work directory and quotas on concurrent jobs and processed media
+// minutes per day.
+package tenant
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default is the tenant of requests that don't name one.
+const Default = "default"
+
+// Environment variables configuring the API keys of DefaultKeys and the
+// quotas of DefaultQuota.
+const (
+	EnvKeys          = "VIDEODNA_TENANT_KEYS"
+	EnvMaxJobs       = "VIDEODNA_TENANT_MAX_JOBS"
+	EnvMinutesPerDay = "VIDEODNA_TENANT_MINUTES_PER_DAY"
+)
+
+// Quota errors; Acquire and Job.Reserve wrap them with the tenant and limit.
+var (
+	ErrTooManyJobs = errors.New("too many concurrent jobs")
+	ErrDailyQuota  = errors.New("daily minutes quota used up")
+)
+
+// ErrUnauthorized is returned by Keys.Authenticate for a missing or unknown
+// API key.
+var ErrUnauthorized = errors.New("missing or unknown API key")
+
+// validID keeps tenant IDs usable as directory names.
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ID returns the tenant named by id (Default when empty), or an error when
+// id has characters other than lowercase letters, digits, _ and -.
+func ID(id string) (string, error) {
+	if id == "" {
+		return Default, nil
+	}
+	if !validID.MatchString(id) {
+		return "", fmt.Errorf("invalid tenant %q: use up to 63 lowercase letters, digits, _ and -", id)
+	}
+	return id, nil
+}
+
+// Keys maps API keys to the tenant they authenticate. Without keys every
+// request runs as Default.
+type Keys map[string]string
+
+// DefaultKeys returns the API keys of VIDEODNA_TENANT_KEYS, a comma
+// separated list of TENANT=KEY pairs.
+func DefaultKeys() (Keys, error) {
+	keys := Keys{}
+	for _, pair := range strings.Split(os.Getenv(EnvKeys), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, key, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid %s entry %q: use TENANT=KEY", EnvKeys, name)
+		}
+		id, err := ID(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvKeys, err)
+		}
+		keys[key] = id
+	}
+	return keys, nil
+}
+
+// Authenticate returns the tenant of an Authorization header value
+// ("Bearer KEY"), Default when there are no keys, or an error wrapping
+// ErrUnauthorized.
+func (k Keys) Authenticate(authorization string) (string, error) {
+	if len(k) == 0 {
+		return Default, nil
+	}
+	given, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || given == "" {
+		return "", ErrUnauthorized
+	}
+	// Compare with every key so the time taken reveals nothing
+	tenant := ""
+	for key, id := range k {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(given)) == 1 {
+			tenant = id
+		}
+	}
+	if tenant == "" {
+		return "", ErrUnauthorized
+	}
+	return tenant, nil
+}
+
+// Dir returns the work directory of tenant under base ("" = $TMPDIR or the
+// system temp directory).
+func Dir(base, tenant string) string {
+	if base == "" {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "tenants", tenant)
+}
+
+// Quota limits each tenant separately. Zero fields are unlimited.
+type Quota struct {
+	MaxJobs       int     // Jobs running at the same time
+	MinutesPerDay float64 // Media minutes processed per UTC day
+}
+
+// DefaultQuota returns the quota set by VIDEODNA_TENANT_MAX_JOBS and
+// VIDEODNA_TENANT_MINUTES_PER_DAY, unlimited when they are unset.
+func DefaultQuota() (Quota, error) {
+	var q Quota
+	if v := os.Getenv(EnvMaxJobs); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("invalid %s %q", EnvMaxJobs, v)
+		}
+		q.MaxJobs = n
+	}
+	if v := os.Getenv(EnvMinutesPerDay); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			return q, fmt.Errorf("invalid %s %q", EnvMinutesPerDay, v)
+		}
+		q.MinutesPerDay = n
+	}
+	return q, nil
+}
+
+// usage is what one tenant has running and has used today.
+type usage struct {
+	jobs     int
+	reserved float64 // Minutes of running jobs, settled when they end
+	day      string  // UTC date of minutes
+	minutes  float64
+}
+
+// Limiter enforces a Quota per tenant. Usage is kept in memory, so each
+// instance of a scaled-out deployment counts on its own.
+type Limiter struct {
+	quota Quota
+	now   func() time.Time
+
+	mu      sync.Mutex
+	tenants map[string]*usage
+}
+
+// NewLimiter returns a limiter enforcing q.
+func NewLimiter(q Quota) *Limiter {
+	return &Limiter{quota: q, now: time.Now, tenants: map[string]*usage{}}
+}
+
+// Job is a running job of a tenant, holding a job slot and the minutes it
+// reserved until Done.
+type Job struct {
+	l        *Limiter
+	tenant   string
+	reserved float64
+	once     sync.Once
+}
+
+// Acquire starts a job of tenant, or returns an error wrapping
+// ErrTooManyJobs or ErrDailyQuota. The job must end with Done.
+func (l *Limiter) Acquire(tenant string) (*Job, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	u := l.usage(tenant)
+	if l.quota.MaxJobs > 0 && u.jobs >= l.quota.MaxJobs {
+		return nil, fmt.Errorf("tenant %s: %w (limit %d)", tenant, ErrTooManyJobs, l.quota.MaxJobs)
+	}
+	if l.quota.MinutesPerDay > 0 && u.minutes+u.reserved >= l.quota.MinutesPerDay {
+		return nil, fmt.Errorf("tenant %s: %w (%.0f of %.0f minutes)", tenant, ErrDailyQuota, u.minutes+u.reserved, l.quota.MinutesPerDay)
+	}
+	u.jobs++
+	return &Job{l: l, tenant: tenant}, nil
+}
+
+// Reserve books the media minutes the job is about to process, or returns
+// an error wrapping ErrDailyQuota when they would exceed today's quota
+// together with the used and reserved minutes. Checking and booking happen
+// at once, so concurrent jobs cannot overrun the quota together.
+func (j *Job) Reserve(minutes float64) error {
+	l := j.l
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	u := l.usage(j.tenant)
+	if l.quota.MinutesPerDay > 0 && u.minutes+u.reserved+minutes > l.quota.MinutesPerDay {
+		return fmt.Errorf("tenant %s: %w (%.1f of %.1f minutes, %.1f more needed)",
+			j.tenant, ErrDailyQuota, u.minutes+u.reserved, l.quota.MinutesPerDay, minutes)
+	}
+	u.reserved += minutes
+	j.reserved += minutes
+	return nil
+}
+
+// Done ends the job: it frees the job slot and replaces the reserved
+// minutes by the minutes it used. A failed job passes the minutes it
+// processed before failing, usually its reservation. Later calls do nothing.
+func (j *Job) Done(minutes float64) {
+	j.once.Do(func() {
+		l := j.l
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		u := l.usage(j.tenant)
+		u.jobs = max(u.jobs-1, 0)
+		u.reserved = max(u.reserved-j.reserved, 0)
+		u.minutes += minutes
+	})
+}
+
+// Reserved returns the minutes reserved by the job.
+func (j *Job) Reserved() float64 {
+	return j.reserved
+}
+
+// Minutes returns the media minutes tenant processed today.
+func (l *Limiter) Minutes(tenant string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.usage(tenant).minutes
+}
+
+// ResetIn returns the time until the daily quotas reset at UTC midnight.
+func (l *Limiter) ResetIn() time.Duration {
+	now := l.now().UTC()
+	return now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+}
+
+// usage returns the usage of tenant, starting a new day when the UTC date
+// changed. l.mu must be held.
+func (l *Limiter) usage(tenant string) *usage {
+	u, ok := l.tenants[tenant]
+	if !ok {
+		u = &usage{}
+		l.tenants[tenant] = u
+	}
+	if day := l.now().UTC().Format(time.DateOnly); u.day != day {
+		u.day, u.minutes = day, 0
+	}
+	return u
+}
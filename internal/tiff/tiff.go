@@ -0,0 +1,137 @@
+// Package tiff writes baseline uncompressed RGB TIFF files, at 16 bits per
+// channel for 16-bit images, for tools that read DNA pixels as data.
+package tiff
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// IsTIFFPath reports whether path has a .tif or .tiff extension.
+func IsTIFFPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".tif" || ext == ".tiff"
+}
+
+// TIFF tag numbers and field types.
+const (
+	tagImageWidth      = 256
+	tagImageLength     = 257
+	tagBitsPerSample   = 258
+	tagCompression     = 259
+	tagPhotometric     = 262
+	tagStripOffsets    = 273
+	tagSamplesPerPixel = 277
+	tagRowsPerStrip    = 278
+	tagStripByteCounts = 279
+	tagXResolution     = 282
+	tagYResolution     = 283
+	tagPlanarConfig    = 284
+	tagResolutionUnit  = 296
+	tagICCProfile      = 34675
+
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeUndefined = 7
+)
+
+// Encode writes img as a single-strip RGB TIFF: 16 bits per channel when img
+// is *image.RGBA64 or *image.NRGBA64, 8 bits otherwise. Alpha is dropped. A
+// non-empty icc profile is embedded.
+func Encode(w io.Writer, img image.Image, icc []byte) error {
+	bits := 8
+	switch img.(type) {
+	case *image.RGBA64, *image.NRGBA64:
+		bits = 16
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	size := uint64(width) * uint64(height) * 3 * uint64(bits/8)
+	if size+uint64(len(icc)) > 1<<32-1-1024 {
+		return fmt.Errorf("image too large for TIFF (%dx%d)", width, height)
+	}
+
+	type entry struct {
+		tag, typ     uint16
+		count, value uint32
+	}
+	entries := 13
+	if len(icc) > 0 {
+		entries++
+	}
+	ifdSize := 2 + entries*12 + 4
+	extraOffset := uint32(8 + ifdSize)              // BitsPerSample, X and Y resolution, ICC profile
+	iccOffset := extraOffset + 6 + 8 + 8            // Word aligned
+	dataOffset := iccOffset + uint32(len(icc)+1)&^1 // Word aligned
+	ifd := []entry{
+		{tagImageWidth, typeLong, 1, uint32(width)},
+		{tagImageLength, typeLong, 1, uint32(height)},
+		{tagBitsPerSample, typeShort, 3, extraOffset},
+		{tagCompression, typeShort, 1, 1}, // None
+		{tagPhotometric, typeShort, 1, 2}, // RGB
+		{tagStripOffsets, typeLong, 1, dataOffset},
+		{tagSamplesPerPixel, typeShort, 1, 3},
+		{tagRowsPerStrip, typeLong, 1, uint32(height)},
+		{tagStripByteCounts, typeLong, 1, uint32(size)},
+		{tagXResolution, typeRational, 1, extraOffset + 6},
+		{tagYResolution, typeRational, 1, extraOffset + 14},
+		{tagPlanarConfig, typeShort, 1, 1},   // Chunky
+		{tagResolutionUnit, typeShort, 1, 2}, // Inch
+	}
+	if len(icc) > 0 {
+		ifd = append(ifd, entry{tagICCProfile, typeUndefined, uint32(len(icc)), iccOffset})
+	}
+
+	bw := bufio.NewWriter(w)
+	le := binary.LittleEndian
+	put := func(v any) { binary.Write(bw, le, v) }
+
+	bw.WriteString("II")
+	put(uint16(42))
+	put(uint32(8))
+	put(uint16(len(ifd)))
+	for _, e := range ifd {
+		put(e.tag)
+		put(e.typ)
+		put(e.count)
+		if e.typ == typeShort && e.count == 1 {
+			put(uint16(e.value)) // Left-justified in the value field
+			put(uint16(0))
+		} else {
+			put(e.value)
+		}
+	}
+	put(uint32(0)) // No next IFD
+	put([3]uint16{uint16(bits), uint16(bits), uint16(bits)})
+	put([2]uint32{72, 1})
+	put([2]uint32{72, 1})
+	bw.Write(icc)
+	if len(icc)%2 != 0 {
+		bw.WriteByte(0)
+	}
+
+	row := make([]byte, width*3*(bits/8))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			i := (x - bounds.Min.X) * 3 * (bits / 8)
+			if bits == 16 {
+				le.PutUint16(row[i:], uint16(r))
+				le.PutUint16(row[i+2:], uint16(g))
+				le.PutUint16(row[i+4:], uint16(b))
+			} else {
+				row[i], row[i+1], row[i+2] = uint8(r>>8), uint8(g>>8), uint8(b>>8)
+			}
+		}
+		if _, err := bw.Write(row); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
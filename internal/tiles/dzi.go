@@ -0,0 +1,159 @@
+// Package tiles writes Deep Zoom (DZI) tile pyramids, so very wide DNA images
+// can be panned and zoomed in a browser with OpenSeadragon.
+package tiles
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Default DZI tile layout (tile size plus overlap stays at 256px).
+const (
+	DefaultTileSize = 254
+	DefaultOverlap  = 1
+)
+
+// IsDZIPath reports whether an output path asks for a DZI pyramid.
+func IsDZIPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".dzi")
+}
+
+// WriteDZI writes img as a Deep Zoom pyramid: the descriptor at path
+// (e.g. dna.dzi), PNG tiles in <name>_files/<level>/<col>_<row>.png and an
+// OpenSeadragon viewer page <name>.html.
+// Level 0 is 1x1 pixel; the highest level is the full-resolution image.
+func WriteDZI(img image.Image, path string, tileSize, overlap int) error {
+	if tileSize <= 0 {
+		tileSize = DefaultTileSize
+	}
+	if overlap < 0 {
+		overlap = DefaultOverlap
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return fmt.Errorf("cannot tile an empty image")
+	}
+
+	maxLevel := 0
+	for size := max(w, h); size > 1; size = (size + 1) / 2 {
+		maxLevel++
+	}
+
+	tilesDir := strings.TrimSuffix(path, filepath.Ext(path)) + "_files"
+	level := toRGBA(img)
+	for l := maxLevel; l >= 0; l-- {
+		if err := writeLevel(level, filepath.Join(tilesDir, fmt.Sprint(l)), tileSize, overlap); err != nil {
+			return err
+		}
+		level = halve(level)
+	}
+
+	descriptor := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Image xmlns="http://schemas.microsoft.com/deepzoom/2008" Format="png" Overlap="%d" TileSize="%d">
+  <Size Width="%d" Height="%d"/>
+</Image>
+`, overlap, tileSize, w, h)
+	if err := os.WriteFile(path, []byte(descriptor), 0644); err != nil {
+		return fmt.Errorf("failed to write DZI descriptor: %w", err)
+	}
+
+	viewer := fmt.Sprintf(viewerHTML, filepath.Base(path))
+	if err := os.WriteFile(strings.TrimSuffix(path, filepath.Ext(path))+".html", []byte(viewer), 0644); err != nil {
+		return fmt.Errorf("failed to write DZI viewer: %w", err)
+	}
+	return nil
+}
+
+// viewerHTML is a minimal OpenSeadragon page for the pyramid. Serve the
+// directory over HTTP; browsers block tile loading from file:// URLs.
+const viewerHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<script src="https://cdn.jsdelivr.net/npm/openseadragon@4/build/openseadragon/openseadragon.min.js"></script>
+<style>html, body, #viewer { margin: 0; width: 100%%; height: 100%%; background: #141419; }</style>
+</head>
+<body>
+<div id="viewer"></div>
+<script>
+OpenSeadragon({ id: "viewer", tileSources: "%s", prefixUrl: "https://cdn.jsdelivr.net/npm/openseadragon@4/build/openseadragon/images/" });
+</script>
+</body>
+</html>
+`
+
+// writeLevel cuts one pyramid level into tiles.
+func writeLevel(img *image.RGBA, dir string, tileSize, overlap int) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create tile dir: %w", err)
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	for row := 0; row*tileSize < h; row++ {
+		for col := 0; col*tileSize < w; col++ {
+			x0 := max(col*tileSize-overlap, 0)
+			y0 := max(row*tileSize-overlap, 0)
+			x1 := min((col+1)*tileSize+overlap, w)
+			y1 := min((row+1)*tileSize+overlap, h)
+
+			tile := img.SubImage(image.Rect(x0, y0, x1, y1))
+			if err := writeTile(tile, filepath.Join(dir, fmt.Sprintf("%d_%d.png", col, row))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeTile(img image.Image, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create tile: %w", err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("failed to encode tile: %w", err)
+	}
+	return nil
+}
+
+// halve downsamples an image by two with a 2x2 box filter (odd edges are
+// averaged with themselves).
+func halve(src *image.RGBA) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := (w+1)/2, (h+1)/2
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var r, g, b, a int
+			for _, p := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				sx, sy := min(2*x+p[0], w-1), min(2*y+p[1], h-1)
+				c := src.RGBAAt(sx, sy)
+				r, g, b, a = r+int(c.R), g+int(c.G), b+int(c.B), a+int(c.A)
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / 4), G: uint8(g / 4), B: uint8(b / 4), A: uint8(a / 4)})
+		}
+	}
+	return dst
+}
+
+// toRGBA copies img into an RGBA image anchored at the origin.
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	if rgba, ok := img.(*image.RGBA); ok && bounds.Min == (image.Point{}) {
+		return rgba
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			dst.Set(x, y, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,93 @@
+// Package timecode converts between frame counts and SMPTE timecode,
+// including drop-frame timecode for 29.97 and 59.94 fps video.
+package timecode
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Nominal returns the timecode frame rate for fps: timecode counts whole
+// frames, so 29.97 fps video is labeled at 30 frames per second.
+func Nominal(fps float64) int {
+	return int(math.Round(fps))
+}
+
+// IsDropFrameRate reports whether fps is an NTSC rate (29.97 or 59.94) for
+// which drop-frame timecode is defined.
+func IsDropFrameRate(fps float64) bool {
+	nominal := Nominal(fps)
+	return (nominal == 30 || nominal == 60) && math.Abs(fps-float64(nominal)*1000/1001) < 0.01
+}
+
+// dropPerMinute returns the frame numbers skipped at the start of every
+// minute except each tenth: 2 at 29.97, 4 at 59.94.
+func dropPerMinute(nominal int) int {
+	return nominal / 15
+}
+
+// Parse converts HH:MM:SS:FF, or drop-frame HH:MM:SS;FF, to a frame count
+// from 00:00:00:00. It reports whether the timecode is drop-frame.
+func Parse(s string, fps float64) (frames int, drop bool, err error) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == ';' })
+	if len(parts) != 4 {
+		return 0, false, fmt.Errorf("invalid timecode %q, use HH:MM:SS:FF", s)
+	}
+	var tc [4]int
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 {
+			return 0, false, fmt.Errorf("invalid timecode %q, use HH:MM:SS:FF", s)
+		}
+		tc[i] = v
+	}
+
+	nominal := Nominal(fps)
+	drop = strings.Contains(s, ";")
+	frames = (tc[0]*3600+tc[1]*60+tc[2])*nominal + tc[3]
+	if drop {
+		if !IsDropFrameRate(fps) {
+			return 0, false, fmt.Errorf("drop-frame timecode %s needs 29.97 or 59.94 fps, not %.3f", s, fps)
+		}
+		minutes := tc[0]*60 + tc[1]
+		frames -= dropPerMinute(nominal) * (minutes - minutes/10)
+	}
+	return frames, drop, nil
+}
+
+// Format formats a frame count as HH:MM:SS:FF, or as drop-frame HH:MM:SS;FF
+// with drop. Timecode wraps at 24 hours.
+func Format(frames int, fps float64, drop bool) string {
+	nominal := Nominal(fps)
+	sep := ":"
+	if drop {
+		// Skip the dropped frame numbers: per 10 minutes 9 minutes drop
+		d := dropPerMinute(nominal)
+		perTenMinutes := nominal*600 - 9*d
+		perMinute := nominal*60 - d
+		tens, rest := frames/perTenMinutes, frames%perTenMinutes
+		frames += 9 * d * tens
+		if rest > d {
+			frames += d * ((rest - d) / perMinute)
+		}
+		sep = ";"
+	}
+	s := frames / nominal
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", s/3600%24, s/60%60, s%60, sep, frames%nominal)
+}
+
+// Notation rewrites a timecode label in drop-frame (HH:MM:SS;FF) or
+// non-drop-frame (HH:MM:SS:FF) notation, keeping its digits.
+func Notation(s string, drop bool) string {
+	i := strings.LastIndexAny(s, ":;")
+	if i < 0 {
+		return s
+	}
+	sep := ":"
+	if drop {
+		sep = ";"
+	}
+	return s[:i] + sep + s[i+1:]
+}
@@ -0,0 +1,150 @@
+package timeline
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type fcpAsset struct {
+	ID       string `xml:"id,attr"`
+	Name     string `xml:"name,attr"`
+	Src      string `xml:"src,attr"` // FCPXML up to 1.8
+	Start    string `xml:"start,attr"`
+	MediaRep []struct {
+		Kind string `xml:"kind,attr"`
+		Src  string `xml:"src,attr"`
+	} `xml:"media-rep"` // FCPXML 1.9+
+}
+
+// fcpItem is any spine element (asset-clip, clip, gap, transition, ...).
+type fcpItem struct {
+	XMLName  xml.Name
+	Ref      string `xml:"ref,attr"`
+	Name     string `xml:"name,attr"`
+	Offset   string `xml:"offset,attr"`
+	Start    string `xml:"start,attr"`
+	Duration string `xml:"duration,attr"`
+	Video    []struct {
+		Ref    string `xml:"ref,attr"`
+		Offset string `xml:"offset,attr"`
+		Start  string `xml:"start,attr"`
+	} `xml:"video"`
+}
+
+type fcpSpine struct {
+	Items []fcpItem `xml:",any"`
+}
+
+type fcpProject struct {
+	Name  string   `xml:"name,attr"`
+	Spine fcpSpine `xml:"sequence>spine"`
+}
+
+type fcpDocument struct {
+	Assets   []fcpAsset   `xml:"resources>asset"`
+	Projects []fcpProject `xml:"library>event>project"`
+	Events   []fcpProject `xml:"event>project"` // Project outside a library
+}
+
+// parseFCPXML reads the primary storyline (spine) of the first project.
+// Connected clips and transitions are ignored; compound and multicam clips
+// are rendered as gaps.
+func parseFCPXML(data []byte) (*Timeline, error) {
+	var doc fcpDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse FCPXML: %w", err)
+	}
+	projects := append(doc.Projects, doc.Events...)
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("FCPXML file has no project")
+	}
+
+	assets := map[string]fcpAsset{}
+	for _, a := range doc.Assets {
+		assets[a.ID] = a
+	}
+
+	project := projects[0]
+	tl := &Timeline{Name: project.Name}
+	for _, item := range project.Spine.Items {
+		duration, err := fcpTime(item.Duration)
+		if err != nil {
+			return nil, err
+		}
+		clip := Clip{Name: item.Name, Duration: duration}
+
+		ref, mediaStart, mediaOffset := item.Ref, "0s", "0s"
+		switch item.XMLName.Local {
+		case "asset-clip":
+		case "clip":
+			if len(item.Video) == 0 {
+				ref = ""
+				break
+			}
+			ref, mediaStart, mediaOffset = item.Video[0].Ref, item.Video[0].Start, item.Video[0].Offset
+		case "gap", "ref-clip", "mc-clip", "sync-clip", "title":
+			ref = ""
+		default:
+			continue // transition, audio, ...
+		}
+
+		if asset, ok := assets[ref]; ok {
+			// Source position = clip start within its media, relative to the
+			// first frame of the file (the asset start timecode)
+			start, err := fcpSum(item.Start, mediaStart, "-"+mediaOffset, "-"+asset.Start)
+			if err != nil {
+				return nil, err
+			}
+			clip.Start = max(start, 0)
+			clip.Path = mediaPath(asset.Src)
+			for _, rep := range asset.MediaRep {
+				if rep.Kind == "" || rep.Kind == "original-media" {
+					clip.Path = mediaPath(rep.Src)
+					break
+				}
+			}
+		}
+		tl.Clips = append(tl.Clips, clip)
+	}
+	return tl, nil
+}
+
+// fcpSum adds FCPXML times; a leading "-" subtracts.
+func fcpSum(times ...string) (float64, error) {
+	var sum float64
+	for _, t := range times {
+		sign := 1.0
+		if strings.HasPrefix(t, "-") {
+			sign, t = -1, t[1:]
+		}
+		v, err := fcpTime(t)
+		if err != nil {
+			return 0, err
+		}
+		sum += sign * v
+	}
+	return sum, nil
+}
+
+// fcpTime parses an FCPXML rational time like "1001/30000s" or "10s".
+// An empty string is zero.
+func fcpTime(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	num, den, rational := strings.Cut(strings.TrimSuffix(s, "s"), "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid FCPXML time %q", s)
+	}
+	if !rational {
+		return n, nil
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0, fmt.Errorf("invalid FCPXML time %q", s)
+	}
+	return n / d, nil
+}
@@ -0,0 +1,91 @@
+package timeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// otioTime is an OTIO RationalTime.
+type otioTime struct {
+	Value float64 `json:"value"`
+	Rate  float64 `json:"rate"`
+}
+
+func (t otioTime) seconds() float64 {
+	if t.Rate == 0 {
+		return 0
+	}
+	return t.Value / t.Rate
+}
+
+type otioRange struct {
+	StartTime otioTime `json:"start_time"`
+	Duration  otioTime `json:"duration"`
+}
+
+type otioMediaReference struct {
+	Schema    string `json:"OTIO_SCHEMA"`
+	TargetURL string `json:"target_url"`
+}
+
+// otioItem covers the fields of Timeline, Stack, Track, Clip, Gap and
+// Transition objects used here.
+type otioItem struct {
+	Schema          string                        `json:"OTIO_SCHEMA"`
+	Name            string                        `json:"name"`
+	Kind            string                        `json:"kind"`
+	Tracks          *otioItem                     `json:"tracks"`
+	Children        []otioItem                    `json:"children"`
+	SourceRange     *otioRange                    `json:"source_range"`
+	MediaReference  *otioMediaReference           `json:"media_reference"`  // Clip.1
+	MediaReferences map[string]otioMediaReference `json:"media_references"` // Clip.2
+	ActiveMediaKey  string                        `json:"active_media_reference_key"`
+}
+
+// parseOTIO reads the first video track of an OTIO timeline. Transitions
+// are ignored; clips without a source range are skipped.
+func parseOTIO(data []byte) (*Timeline, error) {
+	var root otioItem
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse OTIO: %w", err)
+	}
+	if !strings.HasPrefix(root.Schema, "Timeline.") || root.Tracks == nil {
+		return nil, fmt.Errorf("OTIO file is not a timeline (schema %q)", root.Schema)
+	}
+
+	for _, track := range root.Tracks.Children {
+		if !strings.HasPrefix(track.Schema, "Track.") || track.Kind != "Video" {
+			continue
+		}
+		tl := &Timeline{Name: root.Name}
+		for _, item := range track.Children {
+			if item.SourceRange == nil {
+				continue
+			}
+			clip := Clip{
+				Name:     item.Name,
+				Start:    item.SourceRange.StartTime.seconds(),
+				Duration: item.SourceRange.Duration.seconds(),
+			}
+			switch {
+			case strings.HasPrefix(item.Schema, "Gap."):
+				clip.Start = 0
+			case strings.HasPrefix(item.Schema, "Clip."):
+				ref := item.MediaReference
+				if r, ok := item.MediaReferences[item.ActiveMediaKey]; ok {
+					ref = &r
+				}
+				if ref == nil || ref.TargetURL == "" {
+					return nil, fmt.Errorf("OTIO clip %q has no media file", item.Name)
+				}
+				clip.Path = mediaPath(ref.TargetURL)
+			default:
+				continue
+			}
+			tl.Clips = append(tl.Clips, clip)
+		}
+		return tl, nil
+	}
+	return nil, fmt.Errorf("OTIO timeline has no video track")
+}
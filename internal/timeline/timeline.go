@@ -0,0 +1,132 @@
+// Package timeline reads edited sequences from OpenTimelineIO (.otio) and
+// Final Cut Pro XML (.fcpxml) files, so a DNA can be rendered from the cut
+// rather than from a single source file.
+package timeline
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Clip is a used range of a source file, in sequence order. A clip without a
+// Path is a gap (rendered black).
+type Clip struct {
+	Path     string  // Source media file (empty = gap)
+	Name     string  // Clip name from the timeline
+	Start    float64 // Source in point in seconds
+	Duration float64 // Used duration in seconds
+}
+
+// Timeline is the first video track of an edited sequence.
+type Timeline struct {
+	Name  string
+	Clips []Clip
+}
+
+// IsTimelinePath reports whether path is a supported timeline file.
+func IsTimelinePath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".otio", ".fcpxml":
+		return true
+	}
+	return false
+}
+
+// Load reads a timeline file. Relative media paths are resolved against the
+// directory of the timeline file.
+func Load(path string) (*Timeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timeline: %w", err)
+	}
+
+	var tl *Timeline
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".otio":
+		tl, err = parseOTIO(data)
+	case ".fcpxml":
+		tl, err = parseFCPXML(data)
+	default:
+		return nil, fmt.Errorf("unknown timeline format %q, use .otio or .fcpxml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	media := 0
+	for i, c := range tl.Clips {
+		if c.Path == "" {
+			continue
+		}
+		media++
+		if !filepath.IsAbs(c.Path) {
+			tl.Clips[i].Path = filepath.Join(filepath.Dir(path), c.Path)
+		}
+	}
+	if media == 0 {
+		return nil, fmt.Errorf("timeline %s has no video clips", path)
+	}
+	if tl.Name == "" {
+		tl.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return tl, nil
+}
+
+// Duration returns the total sequence length in seconds.
+func (t *Timeline) Duration() float64 {
+	var d float64
+	for _, c := range t.Clips {
+		d += c.Duration
+	}
+	return d
+}
+
+// FirstMedia returns the path of the first non-gap clip.
+func (t *Timeline) FirstMedia() string {
+	for _, c := range t.Clips {
+		if c.Path != "" {
+			return c.Path
+		}
+	}
+	return ""
+}
+
+// FFmpegInputArgs returns ffmpeg arguments that decode the used range of
+// every clip in order, scaled and padded to width x height at fps, and
+// concatenate them into a single video stream. Append output options.
+func (t *Timeline) FFmpegInputArgs(width, height int, fps float64) []string {
+	var args []string
+	var filter strings.Builder
+	for i, c := range t.Clips {
+		if c.Path == "" {
+			args = append(args, "-f", "lavfi", "-t", seconds(c.Duration),
+				"-i", fmt.Sprintf("color=c=black:s=%dx%d:r=%s", width, height, seconds(fps)))
+		} else {
+			args = append(args, "-ss", seconds(c.Start), "-t", seconds(c.Duration), "-i", c.Path)
+		}
+		fmt.Fprintf(&filter, "[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%s[v%d];",
+			i, width, height, width, height, seconds(fps), i)
+	}
+	for i := range t.Clips {
+		fmt.Fprintf(&filter, "[v%d]", i)
+	}
+	fmt.Fprintf(&filter, "concat=n=%d:v=1:a=0[out]", len(t.Clips))
+	return append(args, "-filter_complex", filter.String(), "-map", "[out]")
+}
+
+// mediaPath converts a file:// URL or plain path to a file path.
+func mediaPath(ref string) string {
+	if strings.HasPrefix(ref, "file:") {
+		if u, err := url.Parse(ref); err == nil {
+			return u.Path
+		}
+	}
+	return ref
+}
+
+func seconds(v float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.6f", v), "0"), ".")
+}
@@ -0,0 +1,76 @@
+// Package timing measures where the wall clock time of a generation run
+// goes, stage by stage.
+package timing
+
+import (
+	"time"
+
+	"github.com/pforret/videodna/internal/locale"
+)
+
+// Timings are the seconds spent in each stage of a run. Stages a generator
+// does not have stay zero.
+type Timings struct {
+	Probe      float64 `json:"probe"`
+	Decode     float64 `json:"decode"`               // Video frames and lanes, or mix PCM and its analyses
+	Separation float64 `json:"separation,omitempty"` // Stem separation
+	Waveform   float64 `json:"waveform,omitempty"`   // Stem waveforms and volume segments
+	Render     float64 `json:"render"`
+	Encode     float64 `json:"encode"` // Image encoding and exports
+	Total      float64 `json:"total"`
+}
+
+// Summary returns the total and the slowest stage, e.g.
+// "took 74.2s (separation 61.0s)".
+func (t Timings) Summary() string {
+	return t.SummaryIn(locale.English)
+}
+
+// SummaryIn returns the Summary translated into l.
+func (t Timings) SummaryIn(l locale.Locale) string {
+	name, slowest := "", 0.0
+	for _, stage := range []struct {
+		name    string
+		seconds float64
+	}{
+		{"probe", t.Probe},
+		{"decode", t.Decode},
+		{"separation", t.Separation},
+		{"waveform", t.Waveform},
+		{"render", t.Render},
+		{"encode", t.Encode},
+	} {
+		if stage.seconds > slowest {
+			name, slowest = stage.name, stage.seconds
+		}
+	}
+	if name == "" {
+		return l.Sprintf("took %.1fs", t.Total)
+	}
+	return l.Sprintf("took %.1fs (%s %.1fs)", t.Total, l.T(name), slowest)
+}
+
+// Clock measures consecutive stages of a run.
+type Clock struct {
+	start, last time.Time
+}
+
+// Start returns a clock started now.
+func Start() *Clock {
+	now := time.Now()
+	return &Clock{start: now, last: now}
+}
+
+// Lap returns the seconds since the previous lap (or the start) and starts
+// the next lap.
+func (c *Clock) Lap() float64 {
+	now := time.Now()
+	seconds := now.Sub(c.last).Seconds()
+	c.last = now
+	return seconds
+}
+
+// Total returns the seconds since the start.
+func (c *Clock) Total() float64 {
+	return time.Since(c.start).Seconds()
+}
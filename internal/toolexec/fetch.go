@@ -0,0 +1,289 @@
+package toolexec
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pforret/videodna/internal/retry"
+)
+
+// Static ffmpeg builds are fetched on first use when a pinned archive is
+// configured, at build time:
+//
+//	go build -ldflags "-X github.com/pforret/videodna/internal/toolexec.staticURL=https://.../ffmpeg-7.1-linux64.tar.xz \
+//	  -X github.com/pforret/videodna/internal/toolexec.staticSHA256=<sha256>" ./cmd/videodna
+//
+// or at run time with VIDEODNA_FFMPEG_URL and VIDEODNA_FFMPEG_SHA256. The
+// URL must name a fixed release, not a "latest" build, as the checksum pins
+// its content; there is no default. The archive (.zip, .tar.gz or .tar.xz)
+// must contain ffmpeg and ffprobe; it is verified against the checksum
+// before anything is extracted.
+var (
+	staticURL    string
+	staticSHA256 string
+)
+
+// Environment variables overriding the pinned static build.
+const (
+	EnvFFmpegURL    = "VIDEODNA_FFMPEG_URL"
+	EnvFFmpegSHA256 = "VIDEODNA_FFMPEG_SHA256"
+)
+
+// downloadClient fetches the static build; the timeout gives up on a
+// stalled server instead of hanging the run.
+var downloadClient = &http.Client{Timeout: 10 * time.Minute}
+
+var (
+	staticOnce sync.Once
+	staticDir  string
+	staticErr  error
+)
+
+// staticTools are the tools provided by the static build.
+var staticTools = map[string]bool{"ffmpeg": true, "ffprobe": true}
+
+// staticConfig returns the configured archive URL and checksum.
+func staticConfig() (url, sum string) {
+	url, sum = staticURL, staticSHA256
+	if v := os.Getenv(EnvFFmpegURL); v != "" {
+		url, sum = v, os.Getenv(EnvFFmpegSHA256)
+	}
+	return url, strings.ToLower(sum)
+}
+
+// staticPath returns the path of a fetched static tool, downloading the
+// archive into the user cache directory on first use. ok is false when name
+// is not a static tool or no build is configured.
+func staticPath(name string) (path string, ok bool, err error) {
+	url, sum := staticConfig()
+	if !staticTools[name] || url == "" {
+		return "", false, nil
+	}
+	staticOnce.Do(func() { staticDir, staticErr = fetchStatic(url, sum) })
+	if staticErr != nil {
+		return "", true, staticErr
+	}
+	return filepath.Join(staticDir, executable(name)), true, nil
+}
+
+// fetchStatic downloads, verifies and extracts the static build once; later
+// runs reuse the cache directory named after the checksum. The build is
+// downloaded and extracted in a temp directory next to it and renamed into
+// place when complete, so an interrupted or concurrent first run never
+// leaves a partial directory behind.
+func fetchStatic(url, sum string) (string, error) {
+	if len(sum) != 64 {
+		return "", fmt.Errorf("static ffmpeg %s has no valid SHA-256 checksum", url)
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no cache directory for static ffmpeg: %w", err)
+	}
+	parent := filepath.Join(cache, "videodna")
+	dir := filepath.Join(parent, "ffmpeg-"+sum[:16])
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", parent, err)
+	}
+	tmp, err := os.MkdirTemp(parent, "ffmpeg-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create download dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	fmt.Fprintf(os.Stderr, "Downloading static ffmpeg (first run): %s\n", url)
+	archive, err := os.Create(filepath.Join(tmp, "download"))
+	if err != nil {
+		return "", fmt.Errorf("failed to create download file: %w", err)
+	}
+	defer archive.Close()
+
+	// Network errors and server errors are retried; a checksum mismatch of a
+	// complete download is not
+	err = retry.DefaultPolicy().Do(context.Background(), "static ffmpeg download", func(int) error {
+		if err := archive.Truncate(0); err != nil {
+			return retry.Permanent(err)
+		}
+		if _, err := archive.Seek(0, io.SeekStart); err != nil {
+			return retry.Permanent(err)
+		}
+		resp, err := downloadClient.Get(url)
+		if err != nil {
+			return fmt.Errorf("failed to download static ffmpeg: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return retry.HTTPStatus(resp, fmt.Errorf("failed to download static ffmpeg: %s", resp.Status))
+		}
+		h := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(archive, h), resp.Body); err != nil {
+			return fmt.Errorf("failed to download static ffmpeg: %w", err)
+		}
+		if got := hex.EncodeToString(h.Sum(nil)); got != sum {
+			return retry.Permanent(fmt.Errorf("static ffmpeg checksum mismatch: got %s, expected %s", got, sum))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	bin := filepath.Join(tmp, "bin")
+	if err := os.Mkdir(bin, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", bin, err)
+	}
+	switch lower := strings.ToLower(url); {
+	case strings.HasSuffix(lower, ".zip"):
+		err = extractZip(archive.Name(), bin)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		err = extractTarGz(archive.Name(), bin)
+	case strings.HasSuffix(lower, ".tar.xz"):
+		err = extractTarXz(archive.Name(), bin)
+	default:
+		err = fmt.Errorf("unsupported static ffmpeg archive %s (use .zip, .tar.gz or .tar.xz)", url)
+	}
+	if err != nil {
+		return "", err
+	}
+	for tool := range staticTools {
+		if _, err := os.Stat(filepath.Join(bin, executable(tool))); err != nil {
+			return "", fmt.Errorf("static ffmpeg archive has no %s", tool)
+		}
+	}
+	if err := os.Rename(bin, dir); err != nil {
+		// Another run may have installed it first
+		if _, statErr := os.Stat(dir); statErr == nil {
+			return dir, nil
+		}
+		return "", fmt.Errorf("failed to install static ffmpeg: %w", err)
+	}
+	return dir, nil
+}
+
+// executable returns the file name of a tool on this platform.
+func executable(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// wanted returns the tool file name an archive entry provides, or "".
+func wanted(entry string) string {
+	base := filepath.Base(filepath.FromSlash(entry))
+	for tool := range staticTools {
+		if base == executable(tool) {
+			return base
+		}
+	}
+	return ""
+}
+
+// writeTool writes one extracted binary into dir.
+func writeTool(dir, name string, r io.Reader) error {
+	tmp := filepath.Join(dir, name+".tmp")
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	return os.Rename(tmp, filepath.Join(dir, name))
+}
+
+func extractZip(path, dir string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open static ffmpeg archive: %w", err)
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		name := wanted(f.Name)
+		if name == "" || f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		err = writeTool(dir, name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractTarGz(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open static ffmpeg archive: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to open static ffmpeg archive: %w", err)
+	}
+	return extractTar(tar.NewReader(gz), dir)
+}
+
+// extractTarXz uses the system xz, as the standard library has no decoder.
+func extractTarXz(path, dir string) error {
+	cmd := exec.Command("xz", "-dc", path)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("xz is needed to extract %s: %w", filepath.Base(path), err)
+	}
+	if err := extractTar(tar.NewReader(stdout), dir); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("xz failed: %w", err)
+	}
+	return nil
+}
+
+func extractTar(tr *tar.Reader, dir string) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read static ffmpeg archive: %w", err)
+		}
+		if name := wanted(hdr.Name); name != "" && hdr.Typeflag == tar.TypeReg {
+			if err := writeTool(dir, name, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package toolexec
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Memory estimates of one external process, used to keep batch runs within
+// the memory budget.
+const (
+	ffmpegBaseMemory  = 128 << 20 // Decoder and filter state
+	ffmpegFrameQueue  = 32        // Frames buffered across decoder threads
+	demucsMemory      = 4 << 30   // htdemucs on CPU with 7s segments
+	demucsCUDAMemory  = 2 << 30   // Host memory when the model runs on the GPU
+	spleeterMemory    = 2 << 30   // TensorFlow with the 4/5-stem model
+	defaultToolMemory = 256 << 20
+)
+
+// Environment variables read by SetLimitsFromEnv.
+const (
+	EnvMaxProcs  = "VIDEODNA_MAX_PROCS"
+	EnvMaxMemory = "VIDEODNA_MAX_MEMORY"
+)
+
+// limiter bounds the external processes running at once, by count and by
+// estimated memory. Slots are shared by all goroutines of the process, so a
+// batch can run many in-process workers without spawning one ffmpeg or
+// demucs per worker.
+type limiter struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	procs    int
+	memory   int64
+	maxProcs int   // 0 = unlimited
+	maxMem   int64 // 0 = unlimited
+}
+
+// global is nil until SetLimits is called: single runs are not limited.
+var global *limiter
+
+// SetLimits bounds the external processes started through this package:
+// at most maxProcs at once (0 = unlimited) with an estimated total memory of
+// maxMemory bytes (0 = unlimited). Call it once, before starting workers.
+func SetLimits(maxProcs int, maxMemory int64) {
+	l := &limiter{maxProcs: maxProcs, maxMem: maxMemory}
+	l.cond = sync.NewCond(&l.mu)
+	global = l
+}
+
+// SetLimitsFromEnv calls SetLimits with VIDEODNA_MAX_PROCS (default 0 =
+// unlimited) and VIDEODNA_MAX_MEMORY (default 75% of RAM), for services
+// configured through their environment.
+func SetLimitsFromEnv() error {
+	maxProcs := 0
+	if v := os.Getenv(EnvMaxProcs); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid %s %q", EnvMaxProcs, v)
+		}
+		maxProcs = n
+	}
+	budget := SystemMemory() * 3 / 4
+	if v := os.Getenv(EnvMaxMemory); v != "" {
+		var err error
+		if budget, err = ParseMemory(v); err != nil {
+			return fmt.Errorf("invalid %s: %w", EnvMaxMemory, err)
+		}
+	}
+	SetLimits(maxProcs, budget)
+	return nil
+}
+
+// Acquire waits for a process slot with the given memory estimate and
+// returns the function releasing it. A process estimated larger than the
+// whole budget runs alone. Without limits it returns immediately.
+func Acquire(ctx context.Context, memory int64) (release func(), err error) {
+	l := global
+	if l == nil {
+		return func() {}, nil
+	}
+
+	// Wake waiters when the context ends so they can give up
+	stop := context.AfterFunc(ctx, func() {
+		l.mu.Lock()
+		l.cond.Broadcast()
+		l.mu.Unlock()
+	})
+	defer stop()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for !l.fits(memory) {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("waiting for a process slot: %w", err)
+		}
+		l.cond.Wait()
+	}
+	l.procs++
+	l.memory += memory
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.procs--
+			l.memory -= memory
+			l.cond.Broadcast()
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+func (l *limiter) fits(memory int64) bool {
+	if l.maxProcs > 0 && l.procs >= l.maxProcs {
+		return false
+	}
+	if l.maxMem > 0 && l.procs > 0 && l.memory+memory > l.maxMem {
+		return false
+	}
+	return true
+}
+
+// FFmpegMemory estimates the memory of an ffmpeg decode of width x height
+// frames.
+func FFmpegMemory(width, height int) int64 {
+	return ffmpegBaseMemory + ffmpegFrameQueue*int64(width)*int64(height)*3
+}
+
+// ToolMemory estimates the memory of a stem separator or other tool run.
+func ToolMemory(name, device string) int64 {
+	switch name {
+	case "demucs":
+		if device == "cuda" {
+			return demucsCUDAMemory
+		}
+		return demucsMemory
+	case "spleeter":
+		return spleeterMemory
+	}
+	return defaultToolMemory
+}
+
+// SystemMemory returns the total physical memory in bytes, or 0 when it
+// cannot be determined (only Linux is supported).
+func SystemMemory() int64 {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, _ := strconv.ParseInt(fields[1], 10, 64)
+			return kb << 10
+		}
+	}
+	return 0
+}
+
+// ParseMemory parses a size like "8G", "512M" or a number of bytes.
+func ParseMemory(size string) (int64, error) {
+	s := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "B")
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(s, "G"):
+		mult, s = 1<<30, strings.TrimSuffix(s, "G")
+	case strings.HasSuffix(s, "M"):
+		mult, s = 1<<20, strings.TrimSuffix(s, "M")
+	case strings.HasSuffix(s, "K"):
+		mult, s = 1<<10, strings.TrimSuffix(s, "K")
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid memory size %q, use e.g. 8G or 512M", size)
+	}
+	return int64(v * float64(mult)), nil
+}
@@ -0,0 +1,133 @@
+// Package toolexec starts the external tools (ffmpeg, ffprobe, demucs,
+// spleeter). When ffmpeg is not installed, a pinned static build can be
+// fetched on first use. When a tool is still missing and a Docker image is
+// configured, it runs inside that image instead, with the working directory,
+// the temp directory and the directories of all path arguments mounted at
+// the same paths, so arguments and outputs work unchanged.
+package toolexec
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// EnvDockerImage names the environment variable with the default image.
+const EnvDockerImage = "VIDEODNA_DOCKER_IMAGE"
+
+// dockerImage is the fallback image ("" = never use Docker).
+var dockerImage = os.Getenv(EnvDockerImage)
+
+// SetDockerImage sets the image used for tools missing locally; it overrides
+// VIDEODNA_DOCKER_IMAGE. An empty image keeps the environment setting.
+func SetDockerImage(image string) {
+	if image != "" {
+		dockerImage = image
+	}
+}
+
+// Available returns nil when name can run: locally, from the static ffmpeg
+// build (see fetch.go) or through Docker.
+func Available(name string) error {
+	if _, err := exec.LookPath(name); err == nil {
+		return nil
+	}
+	if _, ok, err := staticPath(name); ok {
+		return err
+	}
+	if dockerImage == "" {
+		return fmt.Errorf("%s not found in PATH", name)
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		return fmt.Errorf("%s not found in PATH, and docker is not installed to run it from %s", name, dockerImage)
+	}
+	return nil
+}
+
+// Command returns the command running name with args: the local binary when
+// installed, then the static ffmpeg build when configured, otherwise
+// `docker run` of the configured image. A failed static download is
+// returned by Start/Run.
+func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	if _, err := exec.LookPath(name); err == nil {
+		return exec.CommandContext(ctx, name, args...)
+	}
+	if path, ok, err := staticPath(name); ok {
+		cmd := exec.CommandContext(ctx, path, args...)
+		if err != nil {
+			cmd.Err = err
+		}
+		return cmd
+	}
+	if dockerImage == "" {
+		return exec.CommandContext(ctx, name, args...)
+	}
+	return dockerCommand(ctx, name, args)
+}
+
+// dockerCommand returns `docker run` of name in a named container. Killing
+// the docker client leaves the container running, so cancelling ctx removes
+// the container by name before killing the client.
+func dockerCommand(ctx context.Context, name string, args []string) *exec.Cmd {
+	var id [8]byte
+	rand.Read(id[:])
+	container := fmt.Sprintf("videodna-%s-%x", name, id)
+	cmd := exec.CommandContext(ctx, "docker", dockerArgs(name, container, args)...)
+	cmd.Cancel = func() error {
+		exec.Command("docker", "rm", "-f", container).Run()
+		return cmd.Process.Kill()
+	}
+	return cmd
+}
+
+// dockerArgs builds the docker run arguments for name in container.
+func dockerArgs(name, container string, args []string) []string {
+	cwd, _ := os.Getwd()
+	mounts := map[string]bool{cwd: true, os.TempDir(): true}
+	for _, arg := range args {
+		if dir := argDir(arg); dir != "" {
+			mounts[dir] = true
+		}
+	}
+	dirs := make([]string, 0, len(mounts))
+	for dir := range mounts {
+		if dir != "" && dir != "/" {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+
+	out := []string{"run", "--rm", "-i", "--name", container}
+	if runtime.GOOS == "linux" {
+		// Outputs belong to the user, not root
+		out = append(out, "--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()))
+	}
+	for _, dir := range dirs {
+		out = append(out, "-v", dir+":"+dir)
+	}
+	out = append(out, "-w", cwd, "--entrypoint", name, dockerImage)
+	return append(out, args...)
+}
+
+// argDir returns the directory to mount for an absolute path argument: the
+// path itself when it is a directory, its parent otherwise. Relative paths
+// resolve under the working directory, which is always mounted; flags and
+// other values return "".
+func argDir(arg string) string {
+	if !filepath.IsAbs(arg) {
+		return ""
+	}
+	if st, err := os.Stat(arg); err == nil && st.IsDir() {
+		return filepath.Clean(arg)
+	}
+	dir := filepath.Dir(arg)
+	if st, err := os.Stat(dir); err == nil && st.IsDir() {
+		return dir
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package transform
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"slices"
+)
+
+const (
+	// tailThreshold is the share of the median activity below which the
+	// end of the media counts as a quiet or static tail.
+	tailThreshold = 0.2
+
+	// minTail is the shortest tail worth compressing, as a share of the
+	// duration.
+	minTail = 0.05
+
+	// maxTailShare is the largest share of the axis a compressed tail
+	// takes; shorter tails take a third of their length.
+	maxTailShare = 0.1
+)
+
+// TailStart finds a quiet or static tail (credits, outro silence) in
+// activity, one value per evenly spaced step of the media (frame change,
+// loudness), and returns where it starts as a time fraction for Tail. It
+// returns 0 when there is no tail: the longest run at the end whose
+// smoothed activity stays below tailThreshold of the median must cover at
+// least minTail of the duration.
+func TailStart(activity []float64) float64 {
+	n := len(activity)
+	if n == 0 {
+		return 0
+	}
+
+	// Average over 1% of the duration so single quiet steps do not count
+	window := max(n/100, 1)
+	smoothed := make([]float64, n)
+	var sum float64
+	for i, v := range activity {
+		sum += v
+		if i >= window {
+			sum -= activity[i-window]
+		}
+		smoothed[i] = sum / float64(min(i+1, window))
+	}
+
+	sorted := slices.Clone(smoothed)
+	slices.Sort(sorted)
+	median := sorted[n/2]
+	if median <= 0 {
+		return 0
+	}
+
+	start := n
+	for start > 0 && smoothed[start-1] < median*tailThreshold {
+		start--
+	}
+	if float64(n-start) < minTail*float64(n) {
+		return 0
+	}
+	return float64(start) / float64(n)
+}
+
+// tailShare returns the share of the axis taken by the compressed tail.
+func (o Options) tailShare() float64 {
+	return min((1-o.Tail)/3, maxTailShare)
+}
+
+// compressTail maps a time fraction to its position with the tail
+// compressed: the time before it is stretched linearly, the tail is
+// mapped logarithmically into the remaining share.
+func (o Options) compressTail(t float64) float64 {
+	share := o.tailShare()
+	if t <= o.Tail {
+		return t / o.Tail * (1 - share)
+	}
+	u := (t - o.Tail) / (1 - o.Tail)
+	return 1 - share + share*math.Log1p(u*(logBase-1))/math.Log(logBase)
+}
+
+// expandTail is the inverse of compressTail.
+func (o Options) expandTail(p float64) float64 {
+	share := o.tailShare()
+	if p <= 1-share {
+		return p / (1 - share) * o.Tail
+	}
+	u := (math.Pow(logBase, (p-1+share)/share) - 1) / (logBase - 1)
+	return o.Tail + u*(1-o.Tail)
+}
+
+// Ticks returns the positions (fractions of the transformed axis) of tick
+// marks at a round interval of the duration in seconds, about one to two
+// dozen of them, so a non-linear time axis shows how it is warped.
+func (o Options) Ticks(duration float64) []float64 {
+	if duration <= 0 {
+		return nil
+	}
+	step := 3600.0
+	for _, s := range []float64{1, 2, 5, 10, 15, 30, 60, 120, 300, 600, 900, 1800} {
+		if duration/s <= 20 {
+			step = s
+			break
+		}
+	}
+	var ticks []float64
+	for t := step; t < duration; t += step {
+		ticks = append(ticks, o.MapTime(t/duration))
+	}
+	return ticks
+}
+
+// DrawTicks marks tick positions (fractions of the time axis) along the
+// bottom edge of rect, or its right edge when time runs vertically, with
+// light lines 4 pixels long and 1 wide at the UI scale.
+func DrawTicks(img draw.Image, rect image.Rectangle, ticks []float64, timeAlongX bool, scale int) {
+	tickColor := color.RGBA{R: 230, G: 230, B: 230, A: 255}
+	for _, p := range ticks {
+		for d := 0; d < 4*scale; d++ {
+			for w := 0; w < scale; w++ {
+				var x, y int
+				if timeAlongX {
+					x, y = rect.Min.X+int(p*float64(rect.Dx()))+w, rect.Max.Y-1-d
+				} else {
+					x, y = rect.Max.X-1-d, rect.Min.Y+int(p*float64(rect.Dy()))+w
+				}
+				if image.Pt(x, y).In(rect) {
+					img.Set(x, y, tickColor)
+				}
+			}
+		}
+	}
+}
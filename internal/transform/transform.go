@@ -0,0 +1,161 @@
+// Package transform provides rendering-stage time axis transforms shared by
+// video and audio DNA.
+package transform
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// logBase controls how strongly log time expands the beginning: the first
+// tenth of the duration takes about a third of the output.
+const logBase = 10.0
+
+// Options selects time axis transforms. The zero value is the identity.
+type Options struct {
+	Reverse bool // Reverse the time axis (end first)
+	Flip    bool // Flip the axis perpendicular to time
+	LogTime bool // Map time logarithmically, expanding the beginning
+
+	// CompressTail asks the generator to find a quiet or static tail
+	// (credits, outro silence) and set Tail to compress it.
+	CompressTail bool
+
+	// Tail is where the compressed tail starts, as a time fraction (0 =
+	// none). The time before it fills most of the axis and the tail is
+	// mapped logarithmically into the rest; see TailStart.
+	Tail float64
+
+	// Warp maps time to position piecewise linearly: Warp[i] is the
+	// position of time i/(len(Warp)-1), from 0 to 1 (nil = linear). The
+	// generator sets it from the content; see ActivityWarp.
+	Warp []float64
+}
+
+// IsZero reports whether no transform is selected.
+func (o Options) IsZero() bool {
+	return !o.Reverse && !o.Flip && !o.LogTime && o.Tail == 0 && o.Warp == nil
+}
+
+// Warped reports whether the time axis follows the content (Tail or Warp),
+// which tick marks make visible.
+func (o Options) Warped() bool {
+	return o.Tail > 0 || o.Warp != nil
+}
+
+// MapTime converts a time fraction (0.0 to 1.0 of the duration) to its
+// position fraction along the transformed time axis.
+func (o Options) MapTime(t float64) float64 {
+	if o.Warp != nil {
+		t = o.warpTime(t)
+	}
+	if o.Tail > 0 {
+		t = o.compressTail(t)
+	}
+	if o.LogTime {
+		t = math.Log1p(t*(logBase-1)) / math.Log(logBase)
+	}
+	if o.Reverse {
+		t = 1 - t
+	}
+	return t
+}
+
+// SourceTime is the inverse of MapTime: it returns the time fraction shown
+// at a position fraction of the transformed axis.
+func (o Options) SourceTime(p float64) float64 {
+	if o.Reverse {
+		p = 1 - p
+	}
+	if o.LogTime {
+		p = (math.Pow(logBase, p) - 1) / (logBase - 1)
+	}
+	if o.Tail > 0 {
+		p = o.expandTail(p)
+	}
+	if o.Warp != nil {
+		p = o.unwarpTime(p)
+	}
+	return p
+}
+
+// Apply returns src with the transforms applied. timeAlongX tells whether
+// time runs horizontally (true) or vertically.
+func (o Options) Apply(src image.Image, timeAlongX bool) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	srcRGBA, ok := src.(*image.RGBA)
+	if !ok {
+		srcRGBA = image.NewRGBA(bounds)
+		draw.Draw(srcRGBA, bounds, src, bounds.Min, draw.Src)
+	}
+
+	source := o.sourcePoint(w, h, timeAlongX)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := source(x, y)
+			dst.SetRGBA(x, y, srcRGBA.RGBAAt(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// Apply64 is Apply for 16-bit images.
+func (o Options) Apply64(src *image.RGBA64, timeAlongX bool) *image.RGBA64 {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	source := o.sourcePoint(w, h, timeAlongX)
+	dst := image.NewRGBA64(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := source(x, y)
+			dst.SetRGBA64(x, y, src.RGBA64At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// sourcePoint returns a function mapping a pixel of the transformed w x h
+// image to the pixel of the source it shows.
+func (o Options) sourcePoint(w, h int, timeAlongX bool) func(x, y int) (int, int) {
+	timeLen, crossLen := w, h
+	if !timeAlongX {
+		timeLen, crossLen = h, w
+	}
+
+	// Source index per output index along each axis
+	timeIndex := o.ResampleIndex(timeLen)
+	crossIndex := make([]int, crossLen)
+	for i := range crossIndex {
+		crossIndex[i] = i
+		if o.Flip {
+			crossIndex[i] = crossLen - 1 - i
+		}
+	}
+
+	return func(x, y int) (int, int) {
+		if timeAlongX {
+			return timeIndex[x], crossIndex[y]
+		}
+		return crossIndex[x], timeIndex[y]
+	}
+}
+
+// ResampleIndex returns, for each of n output positions along the time
+// axis, the index of the source position (of n) it shows.
+func (o Options) ResampleIndex(n int) []int {
+	index := make([]int, n)
+	for i := range index {
+		src := int(o.SourceTime((float64(i)+0.5)/float64(n)) * float64(n))
+		if src >= n {
+			src = n - 1
+		}
+		if src < 0 {
+			src = 0
+		}
+		index[i] = src
+	}
+	return index
+}
@@ -0,0 +1,69 @@
+package transform
+
+import (
+	"sort"
+)
+
+// warpMaxBoost caps the extra share of the axis a step of high activity
+// gets: a scene change takes at most 1+warpMaxBoost times the columns of a
+// static shot of the same length.
+const warpMaxBoost = 3.0
+
+// ActivityWarp returns a Warp that shares the time axis by activity (frame
+// change, one value per evenly spaced step): each step gets 1 plus its
+// activity averaged over window steps around it, relative to the mean
+// (capped at warpMaxBoost), so stretches around scene changes get more
+// columns than static shots. It returns nil when there is no activity.
+func ActivityWarp(activity []float64, window int) []float64 {
+	n := len(activity)
+	if n == 0 {
+		return nil
+	}
+	window = max(window, 1)
+
+	// Centered moving average via prefix sums
+	prefix := make([]float64, n+1)
+	for i, v := range activity {
+		prefix[i+1] = prefix[i] + v
+	}
+	smoothed := make([]float64, n)
+	var mean float64
+	for i := range smoothed {
+		from, to := max(i-window/2, 0), min(i+window/2+1, n)
+		smoothed[i] = (prefix[to] - prefix[from]) / float64(to-from)
+		mean += smoothed[i] / float64(n)
+	}
+	if mean <= 0 {
+		return nil
+	}
+
+	warp := make([]float64, n+1)
+	for i, v := range smoothed {
+		warp[i+1] = warp[i] + 1 + min(v/mean, warpMaxBoost)
+	}
+	for i := range warp {
+		warp[i] /= warp[n]
+	}
+	return warp
+}
+
+// warpTime maps a time fraction through Warp.
+func (o Options) warpTime(t float64) float64 {
+	steps := float64(len(o.Warp) - 1)
+	i := min(max(int(t*steps), 0), len(o.Warp)-2)
+	frac := t*steps - float64(i)
+	return o.Warp[i] + (o.Warp[i+1]-o.Warp[i])*frac
+}
+
+// unwarpTime is the inverse of warpTime.
+func (o Options) unwarpTime(p float64) float64 {
+	steps := len(o.Warp) - 1
+	i := sort.SearchFloat64s(o.Warp, p) - 1
+	i = min(max(i, 0), steps-1)
+	span := o.Warp[i+1] - o.Warp[i]
+	frac := 0.0
+	if span > 0 {
+		frac = (p - o.Warp[i]) / span
+	}
+	return (float64(i) + frac) / float64(steps)
+}
@@ -1,25 +1,39 @@
 package video
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"strconv"
 	"strings"
+
+	"github.com/pforret/videodna/internal/toolexec"
 )
 
 type probeResult struct {
 	Streams []struct {
-		Width        int    `json:"width"`
-		Height       int    `json:"height"`
-		NbFrames     string `json:"nb_frames"`
-		CodecName    string `json:"codec_name"`
-		RFrameRate   string `json:"r_frame_rate"`
-		AvgFrameRate string `json:"avg_frame_rate"`
-		Duration     string `json:"duration"`
+		Width          int    `json:"width"`
+		Height         int    `json:"height"`
+		NbFrames       string `json:"nb_frames"`
+		CodecName      string `json:"codec_name"`
+		RFrameRate     string `json:"r_frame_rate"`
+		AvgFrameRate   string `json:"avg_frame_rate"`
+		Duration       string `json:"duration"`
+		BitRate        string `json:"bit_rate"`
+		ColorSpace     string `json:"color_space"`
+		ColorTransfer  string `json:"color_transfer"`
+		ColorPrimaries string `json:"color_primaries"`
+		ColorRange     string `json:"color_range"`
+		Tags           struct {
+			Timecode string `json:"timecode"`
+		} `json:"tags"`
 	} `json:"streams"`
 	Format struct {
 		Duration string `json:"duration"`
+		BitRate  string `json:"bit_rate"`
+		Tags     struct {
+			Timecode string `json:"timecode"`
+		} `json:"tags"`
 	} `json:"format"`
 }
 
@@ -31,6 +45,14 @@ type Info struct {
 	Duration   float64
 	FPS        float64
 	Codec      string
+	BitRate    int64  // Video stream bits per second (0 = unknown)
+	Timecode   string // Start timecode HH:MM:SS:FF from the stream or container ("" = none)
+
+	// Color properties as named by ffprobe ("" = untagged)
+	ColorSpace     string // YUV matrix: bt709, bt2020nc, smpte170m, bt470bg, gbr, ...
+	ColorTransfer  string // Transfer: bt709, smpte2084 (PQ), arib-std-b67 (HLG), ...
+	ColorPrimaries string // Primaries: bt709, bt2020, ...
+	ColorRange     string // tv (limited) or pc (full)
 }
 
 // GetInfo returns video width, height, and frame count using ffprobe.
@@ -44,11 +66,11 @@ func GetInfo(inputPath string) (width, height, frameCount int, err error) {
 
 // GetFullInfo returns complete video metadata using ffprobe.
 func GetFullInfo(inputPath string) (*Info, error) {
-	cmd := exec.Command("ffprobe",
+	cmd := toolexec.Command(context.Background(), "ffprobe",
 		"-v", "error",
 		"-select_streams", "v:0",
-		"-show_entries", "stream=width,height,nb_frames,codec_name,r_frame_rate,avg_frame_rate,duration",
-		"-show_entries", "format=duration",
+		"-show_entries", "stream=width,height,nb_frames,codec_name,r_frame_rate,avg_frame_rate,duration,bit_rate,color_space,color_transfer,color_primaries,color_range:stream_tags=timecode",
+		"-show_entries", "format=duration,bit_rate:format_tags=timecode",
 		"-of", "json",
 		inputPath)
 
@@ -68,9 +90,13 @@ func GetFullInfo(inputPath string) (*Info, error) {
 
 	s := probe.Streams[0]
 	info := &Info{
-		Width:  s.Width,
-		Height: s.Height,
-		Codec:  s.CodecName,
+		Width:          s.Width,
+		Height:         s.Height,
+		Codec:          s.CodecName,
+		ColorSpace:     known(s.ColorSpace),
+		ColorTransfer:  known(s.ColorTransfer),
+		ColorPrimaries: known(s.ColorPrimaries),
+		ColorRange:     known(s.ColorRange),
 	}
 
 	// Parse frame count
@@ -83,6 +109,19 @@ func GetFullInfo(inputPath string) (*Info, error) {
 		info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
 	}
 
+	// Parse bit rate (prefer stream, fallback to the container total)
+	if s.BitRate != "" {
+		info.BitRate, _ = strconv.ParseInt(s.BitRate, 10, 64)
+	} else if probe.Format.BitRate != "" {
+		info.BitRate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+	}
+
+	// Start timecode: QuickTime keeps it on the video stream, MXF on the container
+	info.Timecode = s.Tags.Timecode
+	if info.Timecode == "" {
+		info.Timecode = probe.Format.Tags.Timecode
+	}
+
 	// Parse FPS from r_frame_rate or avg_frame_rate (format: "num/den")
 	fpsStr := s.RFrameRate
 	if fpsStr == "" || fpsStr == "0/0" {
@@ -98,3 +137,12 @@ func GetFullInfo(inputPath string) (*Info, error) {
 
 	return info, nil
 }
+
+// known returns an ffprobe color property, or "" when it is "unknown" or
+// "unspecified".
+func known(v string) string {
+	if v == "unknown" || v == "unspecified" {
+		return ""
+	}
+	return v
+}
@@ -0,0 +1,23 @@
+package workdir
+
+import "fmt"
+
+// CheckSpace returns an error when dir has less than need bytes free, so a
+// run can fail fast instead of dying mid-way on a full disk. It passes when
+// the free space cannot be determined.
+func CheckSpace(dir string, need int64) error {
+	free, ok := freeSpace(dir)
+	if !ok || free >= need {
+		return nil
+	}
+	return fmt.Errorf("not enough disk space in %s: about %s needed, %s free (point -workdir or TMPDIR to a larger disk)",
+		dir, FormatSize(need), FormatSize(free))
+}
+
+// FormatSize formats a byte count as MB or GB.
+func FormatSize(bytes int64) string {
+	if bytes >= 1<<30 {
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+	}
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+}
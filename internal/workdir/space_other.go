@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd)
+
+package workdir
+
+// freeSpace is not implemented on this platform; the check is skipped.
+func freeSpace(dir string) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd
+
+package workdir
+
+import "syscall"
+
+// freeSpace returns the bytes available to unprivileged users in dir.
+func freeSpace(dir string) (int64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, false
+	}
+	return int64(uint64(st.Bavail) * uint64(st.Bsize)), true
+}
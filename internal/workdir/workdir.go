@@ -0,0 +1,118 @@
+// Package workdir keeps the temp files of a run (stems, downloaded media,
+// checkpoints) in a single per-run directory, so they can be accounted for
+// and are removed together when the run ends. This matters on serverless
+// platforms where /tmp is small and shared between invocations.
+package workdir
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Manager owns the temp directory of one run. A nil *Manager is valid and
+// falls back to plain temp files in the system temp directory, so library
+// code can call FromContext(ctx).MkdirTemp without checking for nil.
+type Manager struct {
+	base string // Parent directory (-workdir, else $TMPDIR)
+	dir  string // Per-run directory under base
+
+	mu      sync.Mutex
+	removed bool
+}
+
+// New creates a per-run directory under base ("" = $TMPDIR or the system
+// temp directory).
+func New(base string) (*Manager, error) {
+	if base == "" {
+		base = os.TempDir()
+	}
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create work dir: %w", err)
+	}
+	dir, err := os.MkdirTemp(base, "videodna-run-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create work dir: %w", err)
+	}
+	return &Manager{base: base, dir: dir}, nil
+}
+
+// Base returns the parent directory of the run directory. Files that must
+// outlive the run (resumable stems) belong here.
+func (m *Manager) Base() string {
+	if m == nil {
+		return os.TempDir()
+	}
+	return m.base
+}
+
+// Dir returns the run directory ("" for a nil manager).
+func (m *Manager) Dir() string {
+	if m == nil {
+		return ""
+	}
+	return m.dir
+}
+
+// MkdirTemp creates a new directory in the run directory, see os.MkdirTemp.
+func (m *Manager) MkdirTemp(pattern string) (string, error) {
+	return os.MkdirTemp(m.Dir(), pattern)
+}
+
+// CreateTemp creates a new file in the run directory, see os.CreateTemp.
+func (m *Manager) CreateTemp(pattern string) (*os.File, error) {
+	return os.CreateTemp(m.Dir(), pattern)
+}
+
+// Size returns the bytes currently used by the run directory.
+func (m *Manager) Size() int64 {
+	if m == nil {
+		return 0
+	}
+	var size int64
+	filepath.WalkDir(m.dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && !d.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// Cleanup removes the run directory and everything in it, and returns the
+// bytes it held. It is safe to call more than once.
+func (m *Manager) Cleanup() (int64, error) {
+	if m == nil {
+		return 0, nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.removed {
+		return 0, nil
+	}
+	size := m.Size()
+	if err := os.RemoveAll(m.dir); err != nil {
+		return size, fmt.Errorf("failed to remove work dir: %w", err)
+	}
+	m.removed = true
+	return size, nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying m.
+func NewContext(ctx context.Context, m *Manager) context.Context {
+	return context.WithValue(ctx, contextKey{}, m)
+}
+
+// FromContext returns the manager of ctx, or nil when there is none.
+func FromContext(ctx context.Context) *Manager {
+	m, _ := ctx.Value(contextKey{}).(*Manager)
+	return m
+}
@@ -0,0 +1,153 @@
+// Package xmp writes XMP sidecar files with the analysis metadata of a
+// generated DNA, which digital asset management systems ingest natively.
+package xmp
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Namespace is the XMP namespace of the analysis properties (prefix vdna).
+const Namespace = "https://github.com/pforret/videodna/ns/1.0/"
+
+// SidecarPath returns the sidecar of an image: its path with the extension
+// replaced by .xmp, as Adobe tools name them.
+func SidecarPath(imagePath string) string {
+	return strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + ".xmp"
+}
+
+// Packet is the metadata of one image.
+type Packet struct {
+	Title       string    // dc:title
+	Source      string    // dc:source, the analyzed media
+	Format      string    // dc:format MIME type, e.g. "image/png"
+	CreatorTool string    // xmp:CreatorTool, e.g. "videodna"
+	Created     time.Time // xmp:CreateDate
+	Width       int       // tiff:ImageWidth
+	Height      int       // tiff:ImageLength
+
+	// Metadata holds the analysis as any JSON-encodable value. Objects
+	// become XMP structs and arrays ordered lists, named by their JSON keys.
+	Metadata map[string]any
+}
+
+// Write writes p as an XMP sidecar to path.
+func Write(path string, p Packet) error {
+	data, err := Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write XMP sidecar: %w", err)
+	}
+	return nil
+}
+
+// Marshal returns p as an XMP packet.
+func Marshal(p Packet) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"\n")
+	b.WriteString("    xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n")
+	b.WriteString("    xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\"\n")
+	b.WriteString("    xmlns:tiff=\"http://ns.adobe.com/tiff/1.0/\"\n")
+	fmt.Fprintf(&b, "    xmlns:vdna=%q>\n", Namespace)
+
+	if p.Title != "" {
+		b.WriteString("   <dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">")
+		xml.EscapeText(&b, []byte(p.Title))
+		b.WriteString("</rdf:li></rdf:Alt></dc:title>\n")
+	}
+	simple := func(name, value string) {
+		fmt.Fprintf(&b, "   <%s>", name)
+		xml.EscapeText(&b, []byte(value))
+		fmt.Fprintf(&b, "</%s>\n", name)
+	}
+	if p.Source != "" {
+		simple("dc:source", p.Source)
+	}
+	if p.Format != "" {
+		simple("dc:format", p.Format)
+	}
+	if p.CreatorTool != "" {
+		simple("xmp:CreatorTool", p.CreatorTool)
+	}
+	if !p.Created.IsZero() {
+		simple("xmp:CreateDate", p.Created.Format(time.RFC3339))
+	}
+	if p.Width > 0 && p.Height > 0 {
+		simple("tiff:ImageWidth", strconv.Itoa(p.Width))
+		simple("tiff:ImageLength", strconv.Itoa(p.Height))
+	}
+
+	// Round trip through JSON, so the properties follow the json tags
+	raw, err := json.Marshal(p.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode XMP metadata: %w", err)
+	}
+	var tree map[string]any
+	if err := json.Unmarshal(raw, &tree); err != nil {
+		return nil, fmt.Errorf("failed to encode XMP metadata: %w", err)
+	}
+	writeFields(&b, tree, "   ")
+
+	b.WriteString("  </rdf:Description>\n")
+	b.WriteString(" </rdf:RDF>\n")
+	b.WriteString("</x:xmpmeta>\n")
+	b.WriteString("<?xpacket end=\"w\"?>\n")
+	return b.Bytes(), nil
+}
+
+// writeFields writes the members of an object as vdna properties, sorted by
+// name.
+func writeFields(b *bytes.Buffer, fields map[string]any, indent string) {
+	names := make([]string, 0, len(fields))
+	for name, v := range fields {
+		if v != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeValue(b, "vdna:"+name, fields[name], indent)
+	}
+}
+
+// writeValue writes one property: a simple value, a struct or an ordered
+// list (rdf:Seq).
+func writeValue(b *bytes.Buffer, name string, v any, indent string) {
+	switch v := v.(type) {
+	case map[string]any:
+		fmt.Fprintf(b, "%s<%s rdf:parseType=\"Resource\">\n", indent, name)
+		writeFields(b, v, indent+" ")
+		fmt.Fprintf(b, "%s</%s>\n", indent, name)
+	case []any:
+		fmt.Fprintf(b, "%s<%s>\n%s <rdf:Seq>\n", indent, name, indent)
+		for _, item := range v {
+			writeValue(b, "rdf:li", item, indent+"  ")
+		}
+		fmt.Fprintf(b, "%s </rdf:Seq>\n%s</%s>\n", indent, indent, name)
+	default:
+		fmt.Fprintf(b, "%s<%s>", indent, name)
+		switch v := v.(type) {
+		case string:
+			xml.EscapeText(b, []byte(v))
+		case float64:
+			b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		case nil:
+		default:
+			fmt.Fprint(b, v)
+		}
+		fmt.Fprintf(b, "</%s>\n", name)
+	}
+}
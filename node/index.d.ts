@@ -0,0 +1,266 @@
+// Type definitions of the videodna npm package. Options mirror the command
+// line flags in camelCase (noLegend for -no-legend); reports mirror the JSON
+// written with -json.
+
+/** Seconds spent in each stage of a run. */
+export interface Timings {
+  probe: number;
+  decode: number;
+  separation?: number;
+  waveform?: number;
+  render: number;
+  encode: number;
+  total: number;
+}
+
+/** A range of frames sharing a label; endFrame is exclusive. */
+export interface Span {
+  label: string;
+  start: number;
+  end: number;
+  start_frame: number;
+  end_frame: number;
+  start_timecode?: string;
+  end_timecode?: string;
+}
+
+export interface AspectSpan extends Span {
+  aspect: number;
+  crop: string;
+}
+
+export interface Cut {
+  time: number;
+  frame: number;
+  score: number;
+  timecode?: string;
+}
+
+/** Digests of the input file, lowercase hex. */
+export interface Checksums {
+  sha256: string;
+  md5?: string;
+}
+
+/** The analysis report of videodna. */
+export interface VideoReport {
+  input: string;
+  frames: number;
+  fps: number;
+  timecode?: string;
+  letterbox?: { mixed_aspect: boolean; segments: AspectSpan[] };
+  logo?: {
+    path: string;
+    corner?: string;
+    position?: string;
+    present_ratio: number;
+    present: Span[];
+    absent: Span[];
+  };
+  text?: { credits: Span[]; subtitles: Span[] };
+  skin?: { mean: number; max: number; max_frame: number; per_second: number[] };
+  cuts?: { count: number; cuts: Cut[] };
+  checksums?: Checksums;
+  timings?: Timings;
+}
+
+export interface StemReport {
+  label: string;
+  rms: number[];
+  peak: number[];
+  true_peak: number[];
+  crest_db: number[];
+  dynamics?: Record<string, unknown>;
+}
+
+/** The report of audiodna. Less common sections are left untyped. */
+export interface AudioReport {
+  input: string;
+  duration: number;
+  segment_duration: number;
+  stems: StemReport[];
+  loudness?: {
+    integrated_lufs: number;
+    true_peak_dbtp: number;
+    short_term_lufs: number[];
+    true_peaks_dbtp: number[];
+  };
+  compliance?: {
+    integrated_lufs: number;
+    true_peak_dbtp: number;
+    pass: boolean;
+    [key: string]: unknown;
+  };
+  tempo?: { bpm: number; offset: number; beats_per_bar: number; confidence: number };
+  sections?: { label: string; start: number; end: number }[];
+  silences?: { start: number; end: number }[];
+  /** Seconds at the left edge of each column, with adaptiveColumns. */
+  column_times?: number[];
+  checksums?: Checksums;
+  timings?: Timings;
+  [section: string]: unknown;
+}
+
+/** A progress update of a stage: video decode (frames) or stem separation (seconds of audio). */
+export interface ProgressEvent {
+  stage: 'decode' | 'separation';
+  unit: 'frames' | 'seconds';
+  done: number;
+  /** 0 when unknown. */
+  total: number;
+  percent: number;
+  /** Units per second over the last 10 seconds. */
+  rate: number;
+  elapsed: number;
+  /** Seconds left at the current rate, -1 when unknown. */
+  eta: number;
+}
+
+/** Options shared by both tools. */
+export interface CommonOptions {
+  /** Print progress to stderr of the tool (default: silent). */
+  verbose?: boolean;
+  /** Called with progress events and their ETA (runs the tool with -progress-json). */
+  onProgress?: (event: ProgressEvent) => void;
+  /** Keep the JSON report at this path (default: a temp file). */
+  json?: string;
+  timeout?: number;
+  scale?: 1 | 2 | 3;
+  maxDimension?: number;
+  icc?: 'srgb' | 'rec709' | 'none' | string;
+  xmp?: boolean;
+  /** Record the SHA-256 of the input in the report. */
+  checksum?: boolean;
+  md5?: boolean;
+  /** Fail unless the input matches: 'sha256:HEX', 'md5:HEX' or bare hex. */
+  verifyChecksum?: string;
+  /** Language of the labels and settings errors (default $VIDEODNA_LOCALE or 'en'). */
+  locale?: 'en' | 'fr' | 'de' | 'es';
+  timings?: boolean;
+  events?: string;
+  /** Write a text alternative of the image for screen readers: .html or .json. */
+  altText?: string;
+  fingerprint?: string;
+  catalog?: string;
+  dockerImage?: string;
+  notifyUrl?: string;
+  /** Also deliver the image (TIFF for a .tif output, else PNG) to 's3://BUCKET/KEY' (AWS_* environment credentials) or a file path. */
+  upload?: string;
+  /** Also deliver the JSON report to 's3://BUCKET/KEY' or a file path. */
+  uploadJson?: string;
+  reverse?: boolean;
+  flip?: boolean;
+  logTime?: boolean;
+  /** Compress a quiet or static tail (credits, outro silence), with tick marks. */
+  compressTail?: boolean;
+}
+
+export interface VideoOptions extends CommonOptions {
+  mode?: 'average' | 'min' | 'max' | 'common' | 'hue' | 'luma';
+  vertical?: boolean;
+  /** 'WxH', a percentage ('50%', '100%x50%'), one side ('1920x', 'x200') or 'input'. */
+  resize?: string;
+  /** Column count, or 'auto'. */
+  width?: number | 'auto';
+  columnsPerSecond?: number;
+  /** Share the columns by content: more around scene changes. Needs width or columnsPerSecond. */
+  adaptiveColumns?: boolean;
+  depth?: 8 | 16;
+  colors?: number;
+  name?: string;
+  noLegend?: boolean;
+  reference?: string;
+  offset?: number;
+  noLanes?: boolean;
+  hwaccel?: 'cuda' | 'vaapi';
+  letterbox?: boolean;
+  logo?: string;
+  logoThreshold?: number;
+  text?: boolean;
+  skin?: boolean;
+  cuts?: boolean;
+  annotations?: string;
+  /** Time series lanes aligned with the DNA: .csv or .json files. */
+  series?: string | string[];
+  seriesStyle?: 'bars' | 'waveform';
+  /** Lane order, heights and visibility: 'dna:200,cuts:64,-skin' or a .json file. */
+  lanes?: string;
+  seekMap?: string;
+  /** Average colors in linear light instead of gamma-encoded values. */
+  linear?: boolean;
+  /** Convert frames to BT.709/sRGB from the color properties of the source (HDR needs an ffmpeg with zimg). */
+  colorConvert?: boolean;
+  /** Presentation adjustments of the rendered DNA (0 = unchanged); exported data is unaffected. */
+  saturation?: number;
+  vibrance?: number;
+  contrast?: number;
+  brightness?: number;
+  /** Stretch the DNA contrast of dark films; fingerprints are unaffected. */
+  autoLevels?: 'global' | 'row';
+  timecodeBase?: string;
+  timecodeFormat?: 'auto' | 'ndf' | 'df';
+  zoom?: string;
+}
+
+export interface AudioOptions extends CommonOptions {
+  /** 'WxH', or one side: 'Wx' or 'xH'. */
+  resize?: string;
+  stems?: 2 | 4 | 5 | 6;
+  separator?: 'demucs' | 'spleeter';
+  model?: string;
+  device?: 'cpu' | 'cuda' | 'mps';
+  noStems?: boolean;
+  stemHeight?: number;
+  /** Stem order, heights and visibility: 'vocals:120,drums,-other' or a .json file. */
+  lanes?: string;
+  noLabels?: boolean;
+  noNormalize?: boolean;
+  workdir?: string;
+  retries?: number;
+  /** Check loudness compliance: 'ebu' (-23 LUFS) or 'atsc' (-24 LKFS). */
+  loudness?: 'ebu' | 'atsc';
+  targetLufs?: number;
+  targetTp?: number;
+  csv?: string;
+  bitDepth?: 16 | 24 | 32;
+  overlap?: number;
+  peakOutline?: boolean;
+  /** Min-max sample span drawn translucent behind the RMS body. */
+  envelope?: boolean;
+  dr?: boolean;
+  grid?: boolean;
+  structure?: boolean;
+  noise?: boolean;
+  palette?: string;
+  patterns?: boolean;
+  tracks?: boolean;
+  channels?: boolean;
+  classify?: boolean;
+  podcast?: boolean;
+  karaoke?: boolean;
+  segmentsPerSecond?: number;
+  /** Share the width by loudness; the report lists column_times. */
+  adaptiveColumns?: boolean;
+}
+
+export interface Result<R> {
+  output: string;
+  report: R;
+  /** The PNG, when output is '-'. */
+  image?: Buffer;
+}
+
+export class VideoDNAError extends Error {
+  /** Exit code of the tool (null when it could not start). */
+  code: number | null;
+  stderr: string;
+}
+
+/** Renders the video DNA of input to output ('-' = returned as result.image). */
+export function generateVideo(input: string, output: string, options?: VideoOptions): Promise<Result<VideoReport>>;
+
+/** Renders the audio DNA of input to output ('-' = returned as result.image). */
+export function generateAudio(input: string, output: string, options?: AudioOptions): Promise<Result<AudioReport>>;
+
+/** Turns options into command line flags. */
+export function flags(options: Record<string, unknown>): string[];
@@ -0,0 +1,141 @@
+'use strict';
+
+// Thin wrapper of the videodna and audiodna binaries: options are turned
+// into flags, the JSON report is read back, and output "-" returns the PNG
+// as a Buffer without touching the disk.
+
+const { spawn } = require('child_process');
+const fs = require('fs');
+const os = require('os');
+const path = require('path');
+
+class VideoDNAError extends Error {
+  constructor(message, code, stderr) {
+    super(message);
+    this.name = 'VideoDNAError';
+    this.code = code;
+    this.stderr = stderr;
+  }
+}
+
+// binary returns the path of a tool: $VIDEODNA_BIN / $AUDIODNA_BIN, else
+// the PATH.
+function binary(tool) {
+  return process.env[`${tool.toUpperCase()}_BIN`] || tool;
+}
+
+// flags turns { noLegend: true, resize: '1200x100' } into
+// ['-no-legend', '-resize', '1200x100']. False and undefined are left out,
+// arrays repeat the flag.
+function flags(options) {
+  const args = [];
+  for (const [key, value] of Object.entries(options || {})) {
+    if (value === undefined || value === null || value === false) {
+      continue;
+    }
+    const flag = '-' + key.replace(/[A-Z]/g, (c) => '-' + c.toLowerCase());
+    if (value === true) {
+      args.push(flag);
+    } else if (Array.isArray(value)) {
+      for (const v of value) {
+        args.push(flag, String(v));
+      }
+    } else {
+      args.push(flag, String(value));
+    }
+  }
+  return args;
+}
+
+function run(tool, input, output, options) {
+  const opts = Object.assign({}, options);
+  const keepReport = opts.json;
+  const dir = keepReport ? null : fs.mkdtempSync(path.join(os.tmpdir(), 'videodna-'));
+  const reportPath = keepReport || path.join(dir, 'report.json');
+  opts.json = reportPath;
+  if (!opts.verbose) {
+    opts.silent = true;
+  }
+  delete opts.verbose;
+  const onProgress = opts.onProgress;
+  delete opts.onProgress;
+  if (onProgress) {
+    opts.progressJson = true;
+  }
+
+  const args = ['-input', input, '-output', output, ...flags(opts)];
+  const cleanup = () => {
+    if (dir) {
+      fs.rmSync(dir, { recursive: true, force: true });
+    }
+  };
+
+  return new Promise((resolve, reject) => {
+    const child = spawn(binary(tool), args, { stdio: ['ignore', 'pipe', 'pipe'] });
+    const stdout = [];
+    let stderr = '';
+    let partial = '';
+    child.stdout.on('data', (chunk) => stdout.push(chunk));
+    child.stderr.on('data', (chunk) => {
+      if (!onProgress) {
+        stderr += chunk;
+        return;
+      }
+      // Progress events are JSON lines; everything else is kept for errors
+      const lines = (partial + chunk).split('\n');
+      partial = lines.pop();
+      for (const line of lines) {
+        let event;
+        if (line.startsWith('{"stage"')) {
+          try {
+            event = JSON.parse(line);
+          } catch (err) {
+            // Not an event after all
+          }
+        }
+        if (event) {
+          onProgress(event);
+          continue;
+        }
+        stderr += line + '\n';
+      }
+    });
+    child.on('error', (err) => {
+      cleanup();
+      reject(new VideoDNAError(`failed to start ${tool}: ${err.message}`, null, ''));
+    });
+    child.on('close', (code) => {
+      stderr += partial;
+      // audiodna exits with 2 on failed loudness compliance, with a report
+      if (code !== 0 && !(tool === 'audiodna' && code === 2)) {
+        cleanup();
+        const lines = stderr.split('\n').filter((l) => l.startsWith('Error: '));
+        const message = lines.map((l) => l.slice(7)).join('\n') || `${tool} exited with code ${code}`;
+        reject(new VideoDNAError(message, code, stderr));
+        return;
+      }
+      try {
+        const report = JSON.parse(fs.readFileSync(reportPath, 'utf8'));
+        const result = { output, report };
+        if (output === '-') {
+          result.image = Buffer.concat(stdout);
+        }
+        resolve(result);
+      } catch (err) {
+        reject(new VideoDNAError(`failed to read report: ${err.message}`, code, stderr));
+      } finally {
+        cleanup();
+      }
+    });
+  });
+}
+
+function generateVideo(input, output, options) {
+  return run('videodna', input, output, options);
+}
+
+function generateAudio(input, output, options) {
+  return run('audiodna', input, output, options);
+}
+
+module.exports = { generateVideo, generateAudio, VideoDNAError, flags };
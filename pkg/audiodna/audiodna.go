@@ -0,0 +1,131 @@
+// Package audiodna generates audio DNA images from Go programs: the
+// loudness of each stem (vocals, drums, bass, ...) over time, stacked in
+// lanes, with optional loudness compliance, structure, tempo and report,
+// as the audiodna command does.
+//
+//	config := audiodna.DefaultConfig()
+//	config.StemConfig.NumStems = 2
+//	config.ReportPath = "report.json"
+//	result, err := audiodna.Generate(ctx, "song.mp3", "dna.png", config)
+//
+// An empty output path skips writing the image; Result.Image holds it.
+// The types are aliases of the types the command uses. ffmpeg and ffprobe
+// must be installed, and demucs or spleeter for stem separation.
+package audiodna
+
+import (
+	"context"
+
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/audiodna"
+	"github.com/pforret/videodna/internal/checksum"
+	"github.com/pforret/videodna/internal/lanespec"
+	"github.com/pforret/videodna/internal/locale"
+	"github.com/pforret/videodna/internal/progress"
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// Config configures Generate; DefaultConfig returns the command defaults.
+type Config = audiodna.Config
+
+// Result holds the rendered image, the per-stem segments and the analyses
+// Config enabled.
+type Result = audiodna.Result
+
+// Report is the JSON report written to Config.ReportPath; NewReport builds
+// it from a Result.
+type Report = audiodna.Report
+
+// Builder assembles a Config step by step; Build validates it.
+type Builder = audiodna.Builder
+
+// StemData holds the volume segments of one stem.
+type StemData = audiodna.StemData
+
+// VolumeSegment is the RMS, peak and true peak of one time segment.
+type VolumeSegment = audio.VolumeSegment
+
+// ColorScheme selects the colors of the stems.
+type ColorScheme = audiodna.ColorScheme
+
+// Color schemes.
+const (
+	SchemeDefault    = audiodna.SchemeDefault    // Distinct colors per stem
+	SchemeMonochrome = audiodna.SchemeMonochrome // Grayscale
+	SchemeHeatmap    = audiodna.SchemeHeatmap    // Volume as heat colors
+	SchemeSpectrum   = audiodna.SchemeSpectrum   // Rainbow spectrum
+	SchemeColorblind = audiodna.SchemeColorblind // Okabe-Ito color-blind safe palette
+	SchemeTol        = audiodna.SchemeTol        // Paul Tol bright color-blind safe palette
+)
+
+// StemConfig configures stem separation.
+type StemConfig = audio.StemConfig
+
+// SeparatorType is a stem separation backend.
+type SeparatorType = audio.SeparatorType
+
+// Stem separators.
+const (
+	SeparatorDemucs   = audio.SeparatorDemucs
+	SeparatorSpleeter = audio.SeparatorSpleeter
+)
+
+// LoudnessTarget is a delivery loudness specification for
+// Config.LoudnessTarget.
+type LoudnessTarget = audio.LoudnessTarget
+
+// Standard delivery targets.
+var (
+	TargetEBU  = audio.TargetEBU
+	TargetATSC = audio.TargetATSC
+)
+
+// Transform reverses, flips, log-maps or compresses the time axis.
+type Transform = transform.Options
+
+// Checksum records and verifies digests of the input.
+type Checksum = checksum.Options
+
+// LaneSpec sets the order, height and visibility of the stem lanes.
+type LaneSpec = lanespec.Spec
+
+// Locale is the language of the labels and settings errors.
+type Locale = locale.Locale
+
+// ProgressFunc receives stem separation progress with throughput and ETA.
+type ProgressFunc = progress.Func
+
+// ProgressEvent is one progress update.
+type ProgressEvent = progress.Event
+
+// DefaultConfig returns the defaults of the audiodna command.
+func DefaultConfig() Config {
+	return audiodna.DefaultConfig()
+}
+
+// NewBuilder starts a Config from DefaultConfig.
+func NewBuilder() *Builder {
+	return audiodna.NewBuilder()
+}
+
+// Generate validates config and creates the audio DNA of inputPath at
+// outputPath ("" = no file). Cancelling ctx stops separation and decoding.
+func Generate(ctx context.Context, inputPath, outputPath string, config Config) (*Result, error) {
+	return audiodna.Generate(ctx, inputPath, outputPath, config)
+}
+
+// NewReport builds the JSON report of a Result.
+func NewReport(inputPath string, result *Result) *Report {
+	return audiodna.NewReport(inputPath, result)
+}
+
+// ParseLanes parses a lane spec such as "vocals:120,drums,-bass" or a
+// .json file.
+func ParseLanes(s string) (LaneSpec, error) {
+	return lanespec.Parse(s)
+}
+
+// ParseLocale returns the locale of a language tag such as "fr" or "de-CH".
+func ParseLocale(tag string) (Locale, error) {
+	return locale.Parse(tag)
+}
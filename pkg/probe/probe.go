@@ -0,0 +1,38 @@
+// Package probe reads the metadata of video and audio files with ffprobe,
+// as videodna and audiodna do before generating a DNA: dimensions, frame
+// count and color tags of the video, duration, sample rate and tracks of
+// the audio.
+//
+//	info, err := probe.Video("movie.mp4")
+//	fmt.Println(info.Width, info.Height, info.FrameCount, info.FPS)
+package probe
+
+import (
+	"github.com/pforret/videodna/internal/audio"
+	"github.com/pforret/videodna/internal/video"
+)
+
+// VideoInfo is the metadata of the first video stream.
+type VideoInfo = video.Info
+
+// AudioInfo is the metadata of the first audio stream, with the stream
+// durations of a video container.
+type AudioInfo = audio.Info
+
+// Track is one audio stream of a media file.
+type Track = audio.Track
+
+// Video returns the metadata of the first video stream of path.
+func Video(path string) (*VideoInfo, error) {
+	return video.GetFullInfo(path)
+}
+
+// Audio returns the metadata of the first audio stream of path.
+func Audio(path string) (*AudioInfo, error) {
+	return audio.GetInfo(path)
+}
+
+// Tracks returns the audio tracks of path in stream order.
+func Tracks(path string) ([]Track, error) {
+	return audio.ListTracks(path)
+}
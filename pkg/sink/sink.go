@@ -0,0 +1,50 @@
+// Package sink delivers the outputs of videodna.Generate and
+// audiodna.Generate to files, writers or S3-compatible object storage,
+// through Config.ImageSink (the image, encoded as the output file) and
+// Config.ReportSink (the JSON report). Sinks compose, so one run can keep a
+// local copy and upload:
+//
+//	upload, err := sink.Open("s3://assets/dna/movie.png")
+//	config.ImageSink = sink.Multi(sink.File("dna.png"), upload)
+//	config.ReportSink = sink.Writer(w)
+//
+// Implement Sink to send outputs anywhere else.
+package sink
+
+import (
+	"io"
+
+	"github.com/pforret/videodna/internal/sink"
+)
+
+// Sink receives one output of a run.
+type Sink = sink.Sink
+
+// Content types passed to Sink.Put.
+const (
+	TypePNG  = sink.TypePNG
+	TypeTIFF = sink.TypeTIFF
+	TypeJSON = sink.TypeJSON
+)
+
+// Open returns the sink of s3://BUCKET/KEY or of a file path ("-" =
+// stdout). S3 uploads read the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN, AWS_REGION and AWS_ENDPOINT_URL_S3 variables.
+func Open(url string) (Sink, error) {
+	return sink.Open(url)
+}
+
+// File writes outputs to path, creating its directory ("" = nil).
+func File(path string) Sink {
+	return sink.File(path)
+}
+
+// Writer writes outputs to w.
+func Writer(w io.Writer) Sink {
+	return sink.Writer(w)
+}
+
+// Multi delivers outputs to every non-nil sink, joining their errors.
+func Multi(sinks ...Sink) Sink {
+	return sink.Multi(sinks...)
+}
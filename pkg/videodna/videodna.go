@@ -0,0 +1,155 @@
+// Package videodna generates video DNA images from Go programs: one color
+// per row (column when vertical) of every frame, with optional analysis
+// lanes, legend, report and exports, as the videodna command does.
+//
+//	config := videodna.DefaultConfig()
+//	config.Mode = "max"
+//	config.Analysis.Cuts = true
+//	config.Analysis.ReportPath = "report.json"
+//	result, err := videodna.Generate(ctx, "movie.mp4", "dna.png", config)
+//
+// The types are aliases of the types the command uses, so a Config here is
+// the one the command builds from its flags. ffmpeg and ffprobe must be
+// installed, as for the command.
+package videodna
+
+import (
+	"context"
+
+	"github.com/pforret/videodna/internal/adjust"
+	"github.com/pforret/videodna/internal/checksum"
+	"github.com/pforret/videodna/internal/dna"
+	"github.com/pforret/videodna/internal/lanespec"
+	"github.com/pforret/videodna/internal/locale"
+	"github.com/pforret/videodna/internal/progress"
+	"github.com/pforret/videodna/internal/transform"
+)
+
+// Config configures Generate: color mode, orientation, resize, legend,
+// timeout, progress and the AnalysisConfig.
+type Config = dna.Config
+
+// LegendConfig configures the legend bar above the DNA.
+type LegendConfig = dna.LegendConfig
+
+// AnalysisConfig selects the analysis lanes (cuts, letterbox, text, ...),
+// report and export paths, and rendering options of the DNA.
+type AnalysisConfig = dna.AnalysisConfig
+
+// Result is the outcome of Generate: the image, frame count, probed
+// properties, layout, detected events and timings.
+type Result = dna.Result
+
+// AnalysisReport holds the detected events, as written to
+// AnalysisConfig.ReportPath.
+type AnalysisReport = dna.AnalysisReport
+
+// Layout locates the DNA in the image and describes its time axis.
+type Layout = dna.Layout
+
+// Options bundles the input, the output and a Config for GenerateOptions.
+type Options = dna.Options
+
+// Builder assembles Options step by step; Build validates them.
+type Builder = dna.Builder
+
+// Zoom selects a time region rendered again below the DNA.
+type Zoom = dna.Zoom
+
+// Annotation is a timed label marked on the DNA.
+type Annotation = dna.Annotation
+
+// TimeSeries is an external series of values drawn as a lane.
+type TimeSeries = dna.TimeSeries
+
+// Transform reverses, flips, log-maps or compresses the time axis.
+type Transform = transform.Options
+
+// Adjust sets saturation, vibrance, contrast and brightness of the DNA.
+type Adjust = adjust.Options
+
+// Checksum records and verifies digests of the input.
+type Checksum = checksum.Options
+
+// LaneSpec sets the order, height and visibility of lanes.
+type LaneSpec = lanespec.Spec
+
+// Locale is the language of the legend, lane labels and settings errors.
+type Locale = locale.Locale
+
+// ProgressFunc receives decode progress with throughput and ETA.
+type ProgressFunc = progress.Func
+
+// ProgressEvent is one progress update.
+type ProgressEvent = progress.Event
+
+// Values of Config and AnalysisConfig fields.
+const (
+	WidthAuto           = dna.WidthAuto           // AnalysisConfig.Width: one column per frame, capped
+	DefaultMaxDimension = dna.DefaultMaxDimension // Default AnalysisConfig.MaxDimension
+	LevelsGlobal        = dna.LevelsGlobal        // AnalysisConfig.AutoLevels: one black and white point
+	LevelsRow           = dna.LevelsRow           // AnalysisConfig.AutoLevels: one pair per DNA row
+)
+
+// DefaultConfig returns the defaults of the videodna command.
+func DefaultConfig() Config {
+	return dna.DefaultConfig()
+}
+
+// DefaultOptions returns the defaults of the videodna command for input
+// and output.
+func DefaultOptions(input, output string) Options {
+	return dna.DefaultOptions(input, output)
+}
+
+// NewBuilder starts Options for input and output from DefaultOptions.
+func NewBuilder(input, output string) *Builder {
+	return dna.NewBuilder(input, output)
+}
+
+// Generate validates config and creates the video DNA of inputPath at
+// outputPath (.png, .tif/.tiff or .dzi; "-" writes the PNG to stdout, ""
+// writes no image). The Result holds the image, the probed properties, the
+// detected events and the timings. Cancelling ctx stops decoding, hooks and
+// uploads to the sinks.
+func Generate(ctx context.Context, inputPath, outputPath string, config Config) (*Result, error) {
+	return GenerateOptions(ctx, Options{Input: inputPath, Output: outputPath, Config: config})
+}
+
+// GenerateOptions validates opts and creates the video DNA under ctx.
+// Invalid settings are reported together, one per line.
+func GenerateOptions(ctx context.Context, opts Options) (*Result, error) {
+	return dna.GenerateOptions(ctx, opts)
+}
+
+// ParseZoom parses a region like "00:10:00-00:12:30" or "90-120".
+func ParseZoom(s string) (Zoom, error) {
+	return dna.ParseZoom(s)
+}
+
+// ParseAnnotation parses "TIME=LABEL", e.g. "00:05:00=sponsor read".
+func ParseAnnotation(s string) (Annotation, error) {
+	return dna.ParseAnnotation(s)
+}
+
+// LoadAnnotations reads annotations from a .json, .edl (CMX3600) or
+// .csv/.txt (NLE marker export) file.
+func LoadAnnotations(path string) ([]Annotation, error) {
+	return dna.LoadAnnotations(path)
+}
+
+// LoadTimeSeries reads time series from a .csv or .json file.
+func LoadTimeSeries(path string) ([]TimeSeries, error) {
+	return dna.LoadTimeSeries(path)
+}
+
+// ParseLanes parses a lane spec such as "dna:200,cuts,-skin" or a .json
+// file.
+func ParseLanes(s string) (LaneSpec, error) {
+	return lanespec.Parse(s)
+}
+
+// ParseLocale returns the locale of a language tag such as "fr" or "de-CH".
+func ParseLocale(tag string) (Locale, error) {
+	return locale.Parse(tag)
+}
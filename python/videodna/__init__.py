@@ -0,0 +1,18 @@
+"""Video and audio DNA images from Python.
+
+A thin ctypes wrapper of libvideodna, the C shared library of the videodna
+engine (see cmd/libvideodna). Keyword arguments are named like the command
+line flags.
+"""
+
+from ._native import VideoDNAError, generate_audio, generate_video
+from ._results import AudioResult, VideoResult
+
+__all__ = [
+    "AudioResult",
+    "VideoDNAError",
+    "VideoResult",
+    "generate_audio",
+    "generate_video",
+]
+__version__ = "1.0.0"
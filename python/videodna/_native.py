@@ -0,0 +1,102 @@
+"""Loading of libvideodna and the generate calls."""
+
+import ctypes
+import ctypes.util
+import json
+import os
+import sys
+import tempfile
+from typing import Any, Optional
+
+from ._results import AudioResult, VideoResult
+
+
+class VideoDNAError(Exception):
+    """A generation failed; the message is the error of the engine."""
+
+
+def _library_name() -> str:
+    if sys.platform == "darwin":
+        return "libvideodna.dylib"
+    if sys.platform == "win32":
+        return "libvideodna.dll"
+    return "libvideodna.so"
+
+
+_lib: Optional[ctypes.CDLL] = None
+
+
+def _load() -> ctypes.CDLL:
+    """Load the library from $VIDEODNA_LIBRARY, the package, or the system."""
+    global _lib
+    if _lib is not None:
+        return _lib
+    path = os.environ.get("VIDEODNA_LIBRARY")
+    if not path:
+        bundled = os.path.join(os.path.dirname(__file__), _library_name())
+        path = bundled if os.path.exists(bundled) else ctypes.util.find_library("videodna")
+    if not path:
+        raise VideoDNAError(
+            "libvideodna not found: build it with "
+            "go build -buildmode=c-shared -o python/videodna/libvideodna.so ./cmd/libvideodna "
+            "or set VIDEODNA_LIBRARY"
+        )
+    lib = ctypes.CDLL(path)
+    for name in ("generate_video_dna", "generate_audio_dna"):
+        fn = getattr(lib, name)
+        fn.argtypes = [ctypes.c_char_p, ctypes.c_char_p, ctypes.c_char_p]
+        fn.restype = ctypes.c_void_p  # Not c_char_p: the pointer must be freed
+    lib.videodna_free.argtypes = [ctypes.c_void_p]
+    lib.videodna_free.restype = None
+    _lib = lib
+    return lib
+
+
+def _call(name: str, input: str, output: str, options: dict) -> dict:
+    """Run a generate function and return its JSON report."""
+    lib = _load()
+    keep = options.get("json")
+    report_path = keep
+    if not keep:
+        fd, report_path = tempfile.mkstemp(prefix="videodna-", suffix=".json")
+        os.close(fd)
+        options = dict(options, json=report_path)
+    try:
+        err = getattr(lib, name)(
+            os.fsencode(input),
+            os.fsencode(output),
+            json.dumps(options).encode(),
+        )
+        if err:
+            try:
+                message = ctypes.string_at(err).decode(errors="replace")
+            finally:
+                lib.videodna_free(err)
+            raise VideoDNAError(message)
+        with open(report_path, encoding="utf-8") as f:
+            return json.load(f)
+    finally:
+        if not keep and os.path.exists(report_path):
+            os.remove(report_path)
+
+
+def generate_video(input: str, output: str, **options: Any) -> VideoResult:
+    """Render the video DNA of input to output.
+
+    Options are named like the videodna flags, e.g. mode="average",
+    resize="1200x100", width="auto", cuts=True, no_legend=True,
+    verbose=True to print progress.
+    """
+    report = _call("generate_video_dna", input, output, options)
+    return VideoResult(output=output, report=report)
+
+
+def generate_audio(input: str, output: str, **options: Any) -> AudioResult:
+    """Render the audio DNA of input to output.
+
+    Options are named like the audiodna flags, e.g. stems=4,
+    separator="demucs", device="cuda", width=1200; stems=-1 skips stem
+    separation.
+    """
+    report = _call("generate_audio_dna", input, output, options)
+    return AudioResult(output=output, report=report)
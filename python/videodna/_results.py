@@ -0,0 +1,51 @@
+"""Typed results of the generate functions."""
+
+from dataclasses import dataclass, field
+from typing import Any, Dict, List, Optional
+
+
+@dataclass
+class VideoResult:
+    """A generated video DNA and its JSON analysis report."""
+
+    output: str
+    report: Dict[str, Any] = field(default_factory=dict)
+
+    @property
+    def frames(self) -> int:
+        return int(self.report.get("frames", 0))
+
+    @property
+    def fps(self) -> float:
+        return float(self.report.get("fps", 0.0))
+
+    @property
+    def duration(self) -> float:
+        return self.frames / self.fps if self.fps else 0.0
+
+    @property
+    def cuts(self) -> List[float]:
+        """Scene cut times in seconds (empty unless cuts=True)."""
+        cuts = self.report.get("cuts") or {}
+        return [float(c["time"]) for c in cuts.get("cuts") or []]
+
+
+@dataclass
+class AudioResult:
+    """A generated audio DNA and its JSON report."""
+
+    output: str
+    report: Dict[str, Any] = field(default_factory=dict)
+
+    @property
+    def duration(self) -> float:
+        return float(self.report.get("duration", 0.0))
+
+    @property
+    def stems(self) -> List[str]:
+        return [s["label"] for s in self.report.get("stems") or []]
+
+    @property
+    def integrated_lufs(self) -> Optional[float]:
+        loudness = self.report.get("loudness")
+        return float(loudness["integrated_lufs"]) if loudness else None